@@ -12,12 +12,16 @@ import (
 )
 
 const (
-	maxFileBytes      = 80 << 20 // 80 MiB
-	maxChunkBytes     = 1 << 20  // cap non-data chunk allocation (guards bogus sizes)
-	minBPM            = 70.0
-	maxBPM            = 180.0
-	envelopeHop       = 512
-	maxAnalyzeSamples = 44100 * 60 // analyze at most 60s of audio
+	maxFileBytes  = 80 << 20 // 80 MiB
+	maxChunkBytes = 1 << 20  // cap non-data chunk allocation (guards bogus sizes)
+	minBPM        = 70.0
+	maxBPM        = 180.0
+	envelopeHop   = 512
+	// Onsets stop at maxOnsets, so they are detected in the first minute only
+	// and onset counts stay comparable between short loops and full tracks.
+	maxOnsetSec = 60
+	// Audio longer than this also reports per-segment tempo and key.
+	minSegmentedSec = 60
 )
 
 // Onset marks a detected transient in the sample. Beat is relative to the start
@@ -27,6 +31,7 @@ type Onset struct {
 	Beat     float64 `json:"beat"`
 	Sec      float64 `json:"sec"`
 	Strength float64 `json:"strength"`
+	Class    string  `json:"class,omitempty" jsonschema:"description=Drum hit class from band energy and spectral shape: kick, snare, hat or other"`
 }
 
 type Result struct {
@@ -37,14 +42,17 @@ type Result struct {
 	Channels          int               `json:"channels"`
 	PeakLevel         float64           `json:"peak_level"`
 	RMSLevel          float64           `json:"rms_level"`
+	Loudness          *Loudness         `json:"loudness,omitempty"`
 	EstimatedBPM      float64           `json:"estimated_bpm"`
 	BPMConfidence     float64           `json:"bpm_confidence"`
 	BPMAlternatives   []TempoHypothesis `json:"bpm_alternatives,omitempty"`
+	BeatGrid          *BeatGrid         `json:"beat_grid,omitempty"`
 	OnsetCount        int               `json:"onset_count"`
 	Onsets            []Onset           `json:"onsets,omitempty"`
 	RhythmDensity     float64           `json:"rhythm_density,omitempty" jsonschema:"description=Onsets per bar at estimated BPM"`
 	RMSPerBeat        []float64         `json:"rms_per_beat,omitempty" jsonschema:"description=RMS level per beat for chop/energy decisions"`
 	BandBalance       *BandBalance      `json:"band_balance,omitempty"`
+	Spectrum          *Spectrum         `json:"spectrum,omitempty"`
 	SuggestedWarpMode string            `json:"suggested_warp_mode"`
 	Key               string            `json:"key,omitempty"`
 	Scale             string            `json:"scale,omitempty"`
@@ -53,58 +61,161 @@ type Result struct {
 	ChordProgression  []ChordSegment    `json:"chord_progression,omitempty"`
 	ChordSummary      string            `json:"chord_summary,omitempty"`
 	Sections          []Section         `json:"sections,omitempty"`
+	Segments          []Segment         `json:"segments,omitempty" jsonschema:"description=Audio over 60 s: stretches with a steady BPM and key (one per track in a DJ mix)"`
 	MatchAxes         []MatchAxis       `json:"match_axes,omitempty" jsonschema:"description=Three production axes to match when referencing this audio"`
 	BrightnessHz      float64           `json:"brightness_hz,omitempty"`
 	CrestFactorDB     float64           `json:"crest_factor_db,omitempty"`
 	StereoWidth       float64           `json:"stereo_width"`
 	LengthBarsAtBPM   float64           `json:"length_bars_at_project_tempo,omitempty"`
+	Streamed          bool              `json:"streamed,omitempty" jsonschema:"description=Whole file analyzed in chunks; onsets, chords, beat grid, texture and spectrum are skipped"`
 	Note              string            `json:"note"`
 }
 
-// AnalyzeFile analyzes a local WAV file already present on disk. It never
-// downloads or writes audio; callers must supply audio they have rights to use.
+// AnalyzeOptions selects optional, heavier analysis detail.
+type AnalyzeOptions struct {
+	ProjectTempo float64
+	// Spectrum adds a fractional-octave spectrum (SpectrumThirdOctave or
+	// SpectrumSixthOctave); empty keeps the response small.
+	Spectrum string
+	// Streaming analyzes the whole file in bounded memory (per-segment BPM
+	// and key, whole-file loudness) instead of decoding it for full detail.
+	// Files over maxFileBytes, or that decode to more than
+	// maxDecodedSeconds, always stream.
+	Streaming bool
+}
+
+// AnalyzeFile analyzes a local audio file (WAV, AIFF/AIFC, FLAC or MP3)
+// already present on disk. It never downloads or writes audio; callers must
+// supply audio they have rights to use.
 func AnalyzeFile(path string, projectTempo float64) (Result, error) {
-	abs, err := validateLocalAudioPath(path)
+	return AnalyzeFileWithOptions(path, AnalyzeOptions{ProjectTempo: projectTempo})
+}
+
+// AnalyzeFileWithOptions is AnalyzeFile with optional detail levels.
+func AnalyzeFileWithOptions(path string, opts AnalyzeOptions) (Result, error) {
+	resolution, err := normalizeSpectrumResolution(opts.Spectrum)
 	if err != nil {
 		return Result{}, err
 	}
+	f, abs, size, err := openLocalAudioFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer func() { _ = f.Close() }()
 
-	info, err := os.Stat(abs)
+	if opts.Streaming || size > maxFileBytes {
+		if resolution != "" {
+			return Result{}, errors.New("spectrum is not available with streaming analysis; analyze a shorter excerpt for it")
+		}
+		stream, err := openAudioStream(f)
+		if err != nil {
+			return Result{}, err
+		}
+		return streamedResult(stream, abs, opts.ProjectTempo, "")
+	}
+
+	stream, err := openAudioStream(io.LimitReader(f, maxFileBytes+1))
 	if err != nil {
-		return Result{}, fmt.Errorf("stat audio file: %w", err)
+		return Result{}, err
 	}
-	if info.IsDir() {
-		return Result{}, errors.New("path must be a file")
+	chans, rest, err := decodeHead(stream, maxDecodedSeconds*stream.sampleRate())
+	if err != nil {
+		return Result{}, err
 	}
-	if info.Size() <= 0 {
-		return Result{}, errors.New("audio file is empty")
+	if rest != nil {
+		// Too long to hold in memory: carry on from the decoded head as a
+		// stream rather than cut the file off or decode it twice.
+		if resolution != "" {
+			return Result{}, fmt.Errorf("spectrum is not available for audio longer than %d minutes; analyze a shorter excerpt for it", maxDecodedSeconds/60)
+		}
+		why := fmt.Sprintf("Longer than %d minutes, so analyzed as a stream. ", maxDecodedSeconds/60)
+		return streamedResult(&prefixedStream{audioStream: rest, head: chans}, abs, opts.ProjectTempo, why)
 	}
-	if info.Size() > maxFileBytes {
-		return Result{}, fmt.Errorf("audio file too large (%d bytes); max is %d", info.Size(), maxFileBytes)
+	audio, err := newDecodedAudio(stream.format(), chans, stream.sampleRate())
+	if err != nil {
+		return Result{}, err
 	}
-
-	f, err := os.Open(abs)
+	out, err := analyzeDecoded(audio, opts.ProjectTempo)
 	if err != nil {
-		return Result{}, fmt.Errorf("open audio file: %w", err)
+		return Result{}, err
 	}
-	defer func() { _ = f.Close() }()
+	if resolution != "" {
+		spectrum, err := analyzeSpectrum(audio.mono, audio.sampleRate, resolution, out.Sections)
+		if err != nil {
+			return Result{}, err
+		}
+		out.Spectrum = &spectrum
+	}
+	out.Path = abs
+	out.Note = "Local-file analysis only. No download, transcription, or melody/note extraction — use onsets, rms_per_beat, band_balance, sections, and match_axes for placement decisions."
+	return out, nil
+}
 
-	out, err := analyzeWAVStream(f, projectTempo)
+// streamedResult runs the streaming analysis for AnalyzeFileWithOptions; why
+// prefixes the note when streaming was not requested.
+func streamedResult(stream audioStream, abs string, projectTempo float64, why string) (Result, error) {
+	out, err := analyzeAudioStream(stream, projectTempo)
 	if err != nil {
 		return Result{}, err
 	}
 	out.Path = abs
-	out.Note = "Local-file analysis only. No download, transcription, or melody/note extraction — use onsets, rms_per_beat, band_balance, sections, and match_axes for placement decisions."
+	out.Note = why + "Streaming analysis of the whole file: levels and loudness cover every sample; BPM and key are estimated per 30 s window and merged into segments. Onsets, chords, beat grid, texture and spectrum are skipped — analyze an excerpt for those."
 	return out, nil
 }
 
-// analyzeWAVStream decodes WAV audio from r and computes sampling-oriented
-// metadata. Path and Note are left for the caller to fill in per source.
-func analyzeWAVStream(r io.Reader, projectTempo float64) (Result, error) {
-	audio, err := loadWAV(io.LimitReader(r, maxFileBytes+1))
+// openLocalAudio validates path and opens it for in-memory decoding,
+// rejecting files over maxFileBytes. It returns the cleaned absolute path.
+func openLocalAudio(path string) (*os.File, string, error) {
+	f, abs, size, err := openLocalAudioFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if size > maxFileBytes {
+		_ = f.Close()
+		return nil, "", fmt.Errorf("audio file too large (%d bytes); max is %d", size, maxFileBytes)
+	}
+	return f, abs, nil
+}
+
+// openLocalAudioFile validates path and opens it without a size cap, for
+// callers that stream.
+func openLocalAudioFile(path string) (*os.File, string, int64, error) {
+	abs, err := validateLocalAudioPath(path)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("stat audio file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, "", 0, errors.New("path must be a file")
+	}
+	if info.Size() <= 0 {
+		return nil, "", 0, errors.New("audio file is empty")
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("open audio file: %w", err)
+	}
+	return f, abs, info.Size(), nil
+}
+
+// analyzeStream decodes audio from r (format sniffed from its header) and
+// computes sampling-oriented metadata. Path and Note are left for the caller
+// to fill in per source.
+func analyzeStream(r io.Reader, projectTempo float64) (Result, error) {
+	audio, err := loadAudio(io.LimitReader(r, maxFileBytes+1))
 	if err != nil {
 		return Result{}, err
 	}
+	return analyzeDecoded(audio, projectTempo)
+}
+
+// analyzeDecoded runs the full analysis on already-decoded audio.
+func analyzeDecoded(audio decodedAudio, projectTempo float64) (Result, error) {
 	mono := audio.mono
 	sampleRate := audio.sampleRate
 	channels := audio.channels
@@ -114,12 +225,14 @@ func analyzeWAVStream(r io.Reader, projectTempo float64) (Result, error) {
 
 	duration := float64(len(mono)) / float64(sampleRate)
 	peak, rms := levels(mono)
-	analyze := mono
-	if len(analyze) > maxAnalyzeSamples {
-		analyze = analyze[:maxAnalyzeSamples]
-	}
-	bpm, confidence := estimateBPM(analyze, sampleRate)
-	onsetList := detectOnsets(analyze, sampleRate)
+	onsetSamples := mono
+	if limit := maxOnsetSec * sampleRate; len(onsetSamples) > limit {
+		onsetSamples = onsetSamples[:limit]
+	}
+	onsetSec := float64(len(onsetSamples)) / float64(sampleRate)
+	bpm, confidence := estimateBPM(mono, sampleRate)
+	onsetList := detectOnsets(onsetSamples, sampleRate)
+	classifyOnsets(onsetSamples, sampleRate, onsetList)
 	onsets := len(onsetList)
 	warpMode := "beats"
 	if duration >= 8 || onsets < 8 {
@@ -127,7 +240,7 @@ func analyzeWAVStream(r io.Reader, projectTempo float64) (Result, error) {
 	}
 
 	out := Result{
-		Format:            "wav",
+		Format:            audio.format,
 		DurationSec:       duration,
 		SampleRate:        sampleRate,
 		Channels:          channels,
@@ -139,7 +252,10 @@ func analyzeWAVStream(r io.Reader, projectTempo float64) (Result, error) {
 		OnsetCount:        onsets,
 		SuggestedWarpMode: warpMode,
 	}
-	if key, ok := estimateKey(analyze, sampleRate); ok {
+	if grid, ok := trackBeats(mono, sampleRate, bpm); ok {
+		out.BeatGrid = &grid
+	}
+	if key, ok := estimateKey(mono, sampleRate); ok {
 		out.Key = key.Tonic
 		out.Scale = key.Scale
 		out.KeyConfidence = key.Confidence
@@ -153,21 +269,35 @@ func analyzeWAVStream(r io.Reader, projectTempo float64) (Result, error) {
 		}
 		out.KeyAlternatives = alts
 	}
-	if chords, summary, ok := estimateChords(analyze, sampleRate); ok {
+	if chords, summary, ok := estimateChords(mono, sampleRate); ok {
 		out.ChordProgression = chords
 		out.ChordSummary = summary
 	}
-	// Structure uses the full decoded audio (URL sources are already capped at
-	// fetch time), so local files get whole-track sections.
 	if sections, ok := estimateSections(mono, sampleRate); ok {
 		out.Sections = sections
 	}
+	loudnessChans := [][]float64{audio.mono}
+	if channels >= 2 {
+		loudnessChans = [][]float64{audio.left, audio.right}
+	}
+	meter := newLoudnessMeter(len(loudnessChans), sampleRate)
+	meter.add(loudnessChans)
+	if loudness, ok := meter.result(); ok {
+		out.Loudness = &loudness
+	}
+	if duration > minSegmentedSec {
+		out.Segments = mergeSegments(decodedWindows(mono, sampleRate))
+		for i := range out.Segments {
+			seg := &out.Segments[i]
+			seg.LoudnessLUFS = meter.integratedBetween(seg.StartSec, seg.StartSec+seg.DurationSec)
+		}
+	}
 	tex := estimateTexture(audio.mono, audio.left, audio.right, sampleRate, channels)
 	out.BrightnessHz = tex.BrightnessHz
 	out.CrestFactorDB = tex.CrestFactorDB
 	out.StereoWidth = tex.StereoWidth
 	bands := BandBalance{}
-	if b, ok := estimateBandBalance(analyze, sampleRate); ok {
+	if b, ok := estimateBandBalance(mono, sampleRate); ok {
 		bands = b
 		out.BandBalance = &b
 	}
@@ -179,8 +309,8 @@ func analyzeWAVStream(r io.Reader, projectTempo float64) (Result, error) {
 		for i := range onsetList {
 			onsetList[i].Beat = round2(onsetList[i].Sec * beatBPM / 60.0)
 		}
-		out.RMSPerBeat = rmsEnvelopePerBeat(analyze, sampleRate, beatBPM)
-		out.RhythmDensity = rhythmDensityOnsetsPerBar(onsets, duration, beatBPM)
+		out.RMSPerBeat = rmsEnvelopePerBeat(mono, sampleRate, beatBPM)
+		out.RhythmDensity = rhythmDensityOnsetsPerBar(onsets, onsetSec, beatBPM)
 	}
 	out.Onsets = onsetList
 	out.MatchAxes = buildMatchAxes(out.RhythmDensity, bands, tex.StereoWidth, tex.CrestFactorDB)
@@ -204,105 +334,36 @@ func validateLocalAudioPath(path string) (string, error) {
 		return "", errors.New("path must be absolute")
 	}
 	ext := strings.ToLower(filepath.Ext(path))
-	if ext != ".wav" {
-		return "", errors.New("only local .wav files are supported in this version")
+	if !supportedExtensions[ext] {
+		return "", fmt.Errorf("unsupported audio extension %q; supported: .wav, .aif, .aiff, .aifc, .flac, .mp3", ext)
 	}
 	return filepath.Clean(path), nil
 }
 
-// wavAudio holds decoded audio: a mono downmix plus the first two channels
-// (left/right) when the source is multi-channel. For mono sources left/right
-// alias the mono signal.
-type wavAudio struct {
+// decodedAudio holds decoded audio: a mono downmix plus the first two
+// channels (left/right) when the source is multi-channel. For mono sources
+// left/right alias the mono signal.
+type decodedAudio struct {
+	format     string
 	mono       []float64
 	left       []float64
 	right      []float64
 	sampleRate int
 	channels   int
+	truncated  bool // decoding stopped at maxDecodedSeconds
 }
 
-func loadWAV(f io.Reader) (wavAudio, error) {
-	var header [12]byte
-	if _, err := io.ReadFull(f, header[:]); err != nil {
-		return wavAudio{}, fmt.Errorf("read wav header: %w", err)
-	}
-	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
-		return wavAudio{}, errors.New("not a RIFF/WAVE file")
-	}
-
-	var (
-		audioFormat   uint16
-		channels      uint16
-		sampleRate    uint32
-		bitsPerSample uint16
-		data          []byte
-	)
-
-	for {
-		var chunkHeader [8]byte
-		if _, err := io.ReadFull(f, chunkHeader[:]); err != nil {
-			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
-				break
-			}
-			return wavAudio{}, err
-		}
-		chunkID := string(chunkHeader[0:4])
-		chunkSize := binary.LittleEndian.Uint32(chunkHeader[4:8])
-
-		// Streaming encoders (e.g. ffmpeg writing to a pipe) cannot seek back to
-		// patch the data chunk size, so it holds a placeholder. Read the data
-		// chunk to EOF instead of trusting the declared size.
-		if chunkID == "data" {
-			payload, err := io.ReadAll(f)
-			if err != nil {
-				return wavAudio{}, fmt.Errorf("read data chunk: %w", err)
-			}
-			data = payload
-			break
-		}
-
-		if chunkSize > maxChunkBytes {
-			return wavAudio{}, fmt.Errorf("%s chunk too large (%d bytes)", chunkID, chunkSize)
-		}
-		payload := make([]byte, chunkSize)
-		if _, err := io.ReadFull(f, payload); err != nil {
-			return wavAudio{}, fmt.Errorf("read %s chunk: %w", chunkID, err)
-		}
-		// Chunks are word-aligned.
-		if chunkSize%2 == 1 {
-			var pad [1]byte
-			_, _ = f.Read(pad[:])
-		}
-		if chunkID == "fmt " {
-			if len(payload) < 16 {
-				return wavAudio{}, errors.New("invalid fmt chunk")
-			}
-			audioFormat = binary.LittleEndian.Uint16(payload[0:2])
-			channels = binary.LittleEndian.Uint16(payload[2:4])
-			sampleRate = binary.LittleEndian.Uint32(payload[4:8])
-			bitsPerSample = binary.LittleEndian.Uint16(payload[14:16])
-		}
-	}
-	if len(data) == 0 || channels == 0 || sampleRate == 0 {
-		return wavAudio{}, errors.New("wav missing fmt/data")
-	}
-	if audioFormat != 1 && audioFormat != 3 {
-		return wavAudio{}, fmt.Errorf("unsupported wav format code %d (need PCM or IEEE float)", audioFormat)
-	}
-
-	chans, err := decodeChannels(data, int(channels), int(bitsPerSample), audioFormat)
-	if err != nil {
-		return wavAudio{}, err
-	}
+// newDecodedAudio builds decodedAudio from per-channel samples in [-1, 1].
+func newDecodedAudio(format string, chans [][]float64, sampleRate int) (decodedAudio, error) {
 	if len(chans) == 0 || len(chans[0]) == 0 {
-		return wavAudio{}, errors.New("no audio samples decoded")
+		return decodedAudio{}, errors.New("no audio samples decoded")
 	}
-
-	audio := wavAudio{
+	audio := decodedAudio{
+		format:     format,
 		mono:       downmix(chans),
 		left:       chans[0],
-		sampleRate: int(sampleRate),
-		channels:   int(channels),
+		sampleRate: sampleRate,
+		channels:   len(chans),
 	}
 	if len(chans) >= 2 {
 		audio.right = chans[1]
@@ -0,0 +1,328 @@
+package audioanalyze
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	maxFolderFiles       = 2000
+	maxFolderWorkers     = 8
+	defaultFolderWorkers = 4
+)
+
+// Folder sort keys accepted by SortFolderEntries.
+const (
+	SortByPath          = "path"
+	SortByBPM           = "bpm"
+	SortByKey           = "key"
+	SortByDuration      = "duration"
+	SortByLoudness      = "lufs"
+	SortByBrightness    = "brightness"
+	SortByDensity       = "density"
+	SortByTempoDistance = "tempo_distance"
+)
+
+// FolderEntry is the one-row summary of a file in a folder analysis. Error is
+// set (and the metrics left zero) when the file could not be analyzed.
+type FolderEntry struct {
+	Path           string  `json:"path"`
+	RelativePath   string  `json:"relative_path"`
+	Format         string  `json:"format,omitempty"`
+	DurationSec    float64 `json:"duration_sec"`
+	BPM            float64 `json:"bpm,omitempty"`
+	BPMConfidence  float64 `json:"bpm_confidence,omitempty"`
+	Key            string  `json:"key,omitempty"`
+	Scale          string  `json:"scale,omitempty"`
+	KeyConfidence  float64 `json:"key_confidence,omitempty"`
+	IntegratedLUFS float64 `json:"integrated_lufs"`
+	BrightnessHz   float64 `json:"brightness_hz,omitempty"`
+	RhythmDensity  float64 `json:"rhythm_density,omitempty" jsonschema:"description=Onsets per bar at the detected tempo"`
+	TempoDistance  float64 `json:"tempo_distance,omitempty" jsonschema:"description=Percent away from the project tempo, counting half/double time as a match"`
+	Cached         bool    `json:"cached,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// FolderReport is the result of AnalyzeFolder.
+type FolderReport struct {
+	Root      string        `json:"root"`
+	Files     []FolderEntry `json:"files"`
+	Analyzed  int           `json:"analyzed"`
+	Cached    int           `json:"cached"`
+	Failed    int           `json:"failed"`
+	Truncated bool          `json:"truncated,omitempty" jsonschema:"description=More than the file limit matched; only the first files in walk order were analyzed"`
+}
+
+// FolderOptions configures AnalyzeFolder.
+type FolderOptions struct {
+	ProjectTempo float64
+	// Workers bounds concurrent analyses; 0 picks min(NumCPU, 4).
+	Workers int
+	// NonRecursive analyzes only files directly inside the folder.
+	NonRecursive bool
+	// Cache, when set, serves and stores per-file results.
+	Cache *Cache
+}
+
+// AnalyzeFolder analyzes every supported audio file under root concurrently
+// and returns one summary row per file, sorted by relative path. Per-file
+// failures are reported in the row rather than failing the batch.
+func AnalyzeFolder(root string, opts FolderOptions) (FolderReport, error) {
+	abs, err := validateLocalFolder(root)
+	if err != nil {
+		return FolderReport{}, err
+	}
+	paths, truncated, err := collectAudioFiles(abs, !opts.NonRecursive)
+	if err != nil {
+		return FolderReport{}, err
+	}
+
+	entries := make([]FolderEntry, len(paths))
+	runWorkers(len(paths), opts.Workers, func(i int) {
+		entries[i] = analyzeFolderFile(abs, paths[i], opts)
+	})
+
+	out := FolderReport{Root: abs, Files: entries, Truncated: truncated}
+	for _, e := range entries {
+		switch {
+		case e.Error != "":
+			out.Failed++
+		case e.Cached:
+			out.Cached++
+			out.Analyzed++
+		default:
+			out.Analyzed++
+		}
+	}
+	return out, nil
+}
+
+// runWorkers calls fn(0..n-1) on a pool of workers goroutines (0 picks
+// min(NumCPU, defaultFolderWorkers), capped at maxFolderWorkers) and waits.
+func runWorkers(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = min(runtime.NumCPU(), defaultFolderWorkers)
+	}
+	workers = max(1, min(workers, maxFolderWorkers, n))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// analyzeMaybeCached analyzes path through cache when it is set.
+func analyzeMaybeCached(cache *Cache, path string, opts AnalyzeOptions) (Result, bool, error) {
+	if cache != nil {
+		return cache.AnalyzeFile(path, opts)
+	}
+	out, err := AnalyzeFileWithOptions(path, opts)
+	return out, false, err
+}
+
+func analyzeFolderFile(root, path string, opts FolderOptions) FolderEntry {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	entry := FolderEntry{Path: path, RelativePath: filepath.ToSlash(rel)}
+	got, cached, err := analyzeMaybeCached(opts.Cache, path, AnalyzeOptions{ProjectTempo: opts.ProjectTempo})
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Format = got.Format
+	entry.DurationSec = round2(got.DurationSec)
+	entry.BPM = got.EstimatedBPM
+	entry.BPMConfidence = got.BPMConfidence
+	entry.Key = got.Key
+	entry.Scale = got.Scale
+	entry.KeyConfidence = got.KeyConfidence
+	if got.Loudness != nil {
+		entry.IntegratedLUFS = got.Loudness.IntegratedLUFS
+	}
+	entry.BrightnessHz = got.BrightnessHz
+	entry.RhythmDensity = got.RhythmDensity
+	entry.TempoDistance = tempoDistancePercent(got.EstimatedBPM, opts.ProjectTempo)
+	entry.Cached = cached
+	return entry
+}
+
+// tempoDistancePercent is how far bpm sits from target in percent, taking the
+// closest of half, same and double time. 0 when either is unknown.
+func tempoDistancePercent(bpm, target float64) float64 {
+	if bpm <= 0 || target <= 0 {
+		return 0
+	}
+	best := math.Inf(1)
+	for _, ratio := range []float64{0.5, 1, 2} {
+		best = math.Min(best, math.Abs(bpm*ratio-target)/target*100)
+	}
+	return round1(best)
+}
+
+func validateLocalFolder(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", errors.New("folder is required")
+	}
+	lower := strings.ToLower(path)
+	if strings.Contains(lower, "://") || strings.HasPrefix(lower, "//") {
+		return "", errors.New("remote URLs are not supported; provide a local folder")
+	}
+	if !filepath.IsAbs(path) {
+		return "", errors.New("folder must be an absolute path")
+	}
+	abs := filepath.Clean(path)
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("stat folder: %w", err)
+	}
+	if !info.IsDir() {
+		return "", errors.New("folder must be a directory")
+	}
+	return abs, nil
+}
+
+// collectAudioFiles lists supported audio files under root in walk order,
+// skipping hidden folders, up to maxFolderFiles.
+func collectAudioFiles(root string, recursive bool) ([]string, bool, error) {
+	var paths []string
+	truncated := false
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if !recursive || strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || !supportedExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if len(paths) >= maxFolderFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("walk folder: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, false, errors.New("no supported audio files (.wav, .aif, .aiff, .aifc, .flac, .mp3) in folder")
+	}
+	return paths, truncated, nil
+}
+
+// SortFolderEntries orders entries in place by one of the SortBy keys.
+// Failed rows always sort last; ties fall back to relative path.
+func SortFolderEntries(entries []FolderEntry, by string, descending bool) error {
+	var cmp func(a, b FolderEntry) int
+	switch strings.ToLower(strings.TrimSpace(by)) {
+	case "", SortByPath:
+		cmp = func(a, b FolderEntry) int { return strings.Compare(a.RelativePath, b.RelativePath) }
+	case SortByBPM:
+		cmp = func(a, b FolderEntry) int { return compareFloat(a.BPM, b.BPM) }
+	case SortByKey:
+		cmp = func(a, b FolderEntry) int { return strings.Compare(a.Key+" "+a.Scale, b.Key+" "+b.Scale) }
+	case SortByDuration:
+		cmp = func(a, b FolderEntry) int { return compareFloat(a.DurationSec, b.DurationSec) }
+	case SortByLoudness:
+		cmp = func(a, b FolderEntry) int { return compareFloat(a.IntegratedLUFS, b.IntegratedLUFS) }
+	case SortByBrightness:
+		cmp = func(a, b FolderEntry) int { return compareFloat(a.BrightnessHz, b.BrightnessHz) }
+	case SortByDensity:
+		cmp = func(a, b FolderEntry) int { return compareFloat(a.RhythmDensity, b.RhythmDensity) }
+	case SortByTempoDistance:
+		cmp = func(a, b FolderEntry) int { return compareFloat(a.TempoDistance, b.TempoDistance) }
+	default:
+		return fmt.Errorf("sort_by must be one of path, bpm, key, duration, lufs, brightness, density, tempo_distance (got %q)", by)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if (a.Error != "") != (b.Error != "") {
+			return a.Error == ""
+		}
+		c := cmp(a, b)
+		if descending {
+			c = -c
+		}
+		if c == 0 {
+			return a.RelativePath < b.RelativePath
+		}
+		return c < 0
+	})
+	return nil
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+var folderCSVHeader = []string{
+	"relative_path", "format", "duration_sec", "bpm", "bpm_confidence", "key", "scale", "key_confidence",
+	"integrated_lufs", "brightness_hz", "rhythm_density", "tempo_distance", "error",
+}
+
+// WriteCSV writes one header row plus one row per file.
+func (r FolderReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(folderCSVHeader); err != nil {
+		return err
+	}
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, e := range r.Files {
+		row := []string{
+			e.RelativePath, e.Format, num(e.DurationSec), num(e.BPM), num(e.BPMConfidence), e.Key, e.Scale, num(e.KeyConfidence),
+			num(e.IntegratedLUFS), num(e.BrightnessHz), num(e.RhythmDensity), num(e.TempoDistance), e.Error,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the whole report as indented JSON.
+func (r FolderReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,120 @@
+package audioanalyze
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeFolderConcurrentWithCache(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeClickWAV(t, filepath.Join(root, "a_120.wav"), 44100, 120, 4)
+	if err := os.MkdirAll(filepath.Join(root, "sub", ".hidden"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeClickWAV(t, filepath.Join(root, "sub", "b_100.wav"), 44100, 100, 4)
+	writeClickWAV(t, filepath.Join(root, "sub", ".hidden", "skip.wav"), 44100, 100, 4)
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "broken.wav"), []byte("not audio"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := AnalyzeFolder(root, FolderOptions{ProjectTempo: 100, Workers: 3, Cache: cache})
+	if err != nil {
+		t.Fatalf("AnalyzeFolder() error = %v", err)
+	}
+	if len(got.Files) != 3 || got.Analyzed != 2 || got.Failed != 1 || got.Cached != 0 {
+		t.Fatalf("report = %+v", got)
+	}
+	byPath := map[string]FolderEntry{}
+	for _, e := range got.Files {
+		byPath[e.RelativePath] = e
+	}
+	if e := byPath["sub/b_100.wav"]; math.Abs(e.BPM-100) > 3 || e.TempoDistance > 3 {
+		t.Errorf("b_100 = %+v", e)
+	}
+	if e := byPath["broken.wav"]; e.Error == "" {
+		t.Errorf("broken.wav should carry an error: %+v", e)
+	}
+
+	again, err := AnalyzeFolder(root, FolderOptions{ProjectTempo: 100, Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Cached != 2 {
+		t.Errorf("second run cached = %d, want 2", again.Cached)
+	}
+
+	flat, err := AnalyzeFolder(root, FolderOptions{NonRecursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flat.Files) != 2 {
+		t.Errorf("non-recursive files = %+v", flat.Files)
+	}
+}
+
+func TestSortFolderEntries(t *testing.T) {
+	t.Parallel()
+
+	entries := []FolderEntry{
+		{RelativePath: "c", BPM: 90, TempoDistance: 10},
+		{RelativePath: "bad", Error: "decode"},
+		{RelativePath: "a", BPM: 128, TempoDistance: 0},
+		{RelativePath: "b", BPM: 100, TempoDistance: 2.5},
+	}
+	if err := SortFolderEntries(entries, SortByBPM, true); err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].RelativePath != "a" || entries[2].RelativePath != "c" || entries[3].RelativePath != "bad" {
+		t.Errorf("bpm desc = %+v", entries)
+	}
+	if err := SortFolderEntries(entries, SortByTempoDistance, false); err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].RelativePath != "a" || entries[1].RelativePath != "b" {
+		t.Errorf("tempo_distance = %+v", entries)
+	}
+	if err := SortFolderEntries(entries, "color", false); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+}
+
+func TestTempoDistanceCountsHalfAndDouble(t *testing.T) {
+	t.Parallel()
+
+	if got := tempoDistancePercent(70, 140); got != 0 {
+		t.Errorf("half time distance = %v, want 0", got)
+	}
+	if got := tempoDistancePercent(126, 120); got != 5 {
+		t.Errorf("distance = %v, want 5", got)
+	}
+}
+
+func TestFolderReportCSV(t *testing.T) {
+	t.Parallel()
+
+	report := FolderReport{Files: []FolderEntry{{RelativePath: "x, y.wav", BPM: 120, Key: "A", Scale: "minor"}}}
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][0] != "x, y.wav" || rows[1][3] != "120" || rows[1][5] != "A" {
+		t.Errorf("rows = %q", rows)
+	}
+}
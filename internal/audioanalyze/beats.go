@@ -0,0 +1,254 @@
+package audioanalyze
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	beatsPerBar        = 4
+	beatTightness      = 100.0 // penalty for deviating from the global period (Ellis 2007)
+	maxGridBeats       = 1024
+	downbeatLowPassHz  = 150.0
+	downbeatAccentSec  = 0.05
+	minTempoCurveBeats = 2 * beatsPerBar
+)
+
+// BeatGrid is a tracked beat grid: per-beat timestamps, bar downbeats and how
+// far the local tempo wanders. FirstDownbeatSec is where bar 1 starts in the
+// file, i.e. where a clip's start marker belongs.
+type BeatGrid struct {
+	BPM                float64      `json:"bpm"`
+	BeatsPerBar        int          `json:"beats_per_bar"`
+	FirstDownbeatSec   float64      `json:"first_downbeat_sec"`
+	DownbeatConfidence float64      `json:"downbeat_confidence" jsonschema:"description=0..1 margin of the chosen bar phase over the runner-up"`
+	BeatTimesSec       []float64    `json:"beat_times_sec"`
+	DownbeatTimesSec   []float64    `json:"downbeat_times_sec"`
+	TempoCurve         []TempoPoint `json:"tempo_curve,omitempty" jsonschema:"description=Local tempo per bar"`
+	TempoDriftBPM      float64      `json:"tempo_drift_bpm" jsonschema:"description=Spread between the fastest and slowest bar; near 0 means a fixed-tempo (programmed) source"`
+}
+
+type TempoPoint struct {
+	Sec float64 `json:"sec"`
+	BPM float64 `json:"bpm"`
+}
+
+// AnalyzeBeatGrid decodes a local audio file and tracks its beat grid without
+// running the full analysis.
+func AnalyzeBeatGrid(path string) (BeatGrid, error) {
+	f, _, err := openLocalAudio(path)
+	if err != nil {
+		return BeatGrid{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	audio, err := loadAudio(io.LimitReader(f, maxFileBytes+1))
+	if err != nil {
+		return BeatGrid{}, err
+	}
+	samples := audio.mono
+	bpm, _ := estimateBPM(samples, audio.sampleRate)
+	grid, ok := trackBeats(samples, audio.sampleRate, bpm)
+	if !ok {
+		return BeatGrid{}, errors.New("could not track a beat grid (too short or no steady pulse)")
+	}
+	return grid, nil
+}
+
+// trackBeats places beats with dynamic programming over the onset envelope:
+// each beat maximizes onset strength while keeping its spacing close to the
+// global period, so the grid follows small tempo drift instead of assuming
+// beat 0 sits at sample 0. Downbeats are the bar phase with the most low-end
+// accent (kicks usually land on 1).
+func trackBeats(samples []float64, sampleRate int, bpm float64) (BeatGrid, bool) {
+	if bpm <= 0 || sampleRate <= 0 {
+		return BeatGrid{}, false
+	}
+	env := energyEnvelope(samples, envelopeHop)
+	hopSec := float64(envelopeHop) / float64(sampleRate)
+	period := 60 / bpm / hopSec
+	if len(env) < int(4*period) || period < 2 {
+		return BeatGrid{}, false
+	}
+
+	onset := onsetStrength(env)
+	frames := dpBeatFrames(onset, period)
+	if len(frames) < beatsPerBar {
+		return BeatGrid{}, false
+	}
+	if len(frames) > maxGridBeats {
+		frames = frames[:maxGridBeats]
+	}
+	beats := make([]float64, len(frames))
+	for i, f := range frames {
+		beats[i] = float64(f) * hopSec
+	}
+
+	phase, confidence := downbeatPhase(samples, sampleRate, beats, onset, frames)
+	grid := BeatGrid{
+		BPM:                round1(bpm),
+		BeatsPerBar:        beatsPerBar,
+		DownbeatConfidence: round2(confidence),
+		BeatTimesSec:       make([]float64, len(beats)),
+	}
+	for i, b := range beats {
+		grid.BeatTimesSec[i] = round3(b)
+		if i%beatsPerBar == phase {
+			grid.DownbeatTimesSec = append(grid.DownbeatTimesSec, round3(b))
+		}
+	}
+	grid.FirstDownbeatSec = grid.DownbeatTimesSec[0]
+	grid.TempoCurve, grid.TempoDriftBPM = tempoCurve(beats, phase)
+	return grid, true
+}
+
+// onsetStrength is the half-wave rectified envelope difference, normalized to
+// unit standard deviation so the DP tightness weight is scale-free.
+func onsetStrength(env []float64) []float64 {
+	out := make([]float64, len(env))
+	for i := 1; i < len(env); i++ {
+		if d := env[i] - env[i-1]; d > 0 {
+			out[i] = d
+		}
+	}
+	_, std := meanStd(out)
+	if std > 0 {
+		for i := range out {
+			out[i] /= std
+		}
+	}
+	return out
+}
+
+func dpBeatFrames(onset []float64, period float64) []int {
+	n := len(onset)
+	score := make([]float64, n)
+	backlink := make([]int, n)
+	minGap := int(math.Round(period / 2))
+	maxGap := int(math.Round(period * 2))
+	for i := 0; i < n; i++ {
+		best := 0.0
+		backlink[i] = -1
+		for j := i - maxGap; j <= i-minGap; j++ {
+			if j < 0 {
+				continue
+			}
+			dev := math.Log(float64(i-j) / period)
+			candidate := score[j] - beatTightness*dev*dev
+			if backlink[i] == -1 || candidate > best {
+				best = candidate
+				backlink[i] = j
+			}
+		}
+		if backlink[i] == -1 || best < 0 {
+			// Starting a fresh chain here beats any penalized predecessor.
+			best = 0
+			backlink[i] = -1
+		}
+		score[i] = onset[i] + best
+	}
+
+	// End on the best-scoring frame within the last period.
+	end := n - 1
+	for i := n - 1; i >= 0 && i >= n-int(period); i-- {
+		if score[i] > score[end] {
+			end = i
+		}
+	}
+	var frames []int
+	for i := end; i >= 0; i = backlink[i] {
+		frames = append(frames, i)
+	}
+	sort.Ints(frames)
+	return frames
+}
+
+// downbeatPhase picks which beat in each group of four is the downbeat by
+// comparing low-passed energy (kick weight) plus onset strength per phase.
+func downbeatPhase(samples []float64, sampleRate int, beats, onset []float64, frames []int) (int, float64) {
+	low := onePoleLowPass(samples, sampleRate, downbeatLowPassHz)
+	window := int(downbeatAccentSec * float64(sampleRate))
+	var lowTotal float64
+	accents := make([]float64, len(beats))
+	lows := make([]float64, len(beats))
+	for i, b := range beats {
+		start := int(b * float64(sampleRate))
+		end := min(start+window, len(low))
+		var sum float64
+		for _, s := range low[start:end] {
+			sum += s * s
+		}
+		lows[i] = sum
+		lowTotal += sum
+	}
+	meanLow := lowTotal / float64(len(beats))
+	for i := range beats {
+		accents[i] = onset[frames[i]]
+		if meanLow > 0 {
+			accents[i] += 2 * lows[i] / meanLow
+		}
+	}
+
+	var scores [beatsPerBar]float64
+	var counts [beatsPerBar]int
+	for i, a := range accents {
+		scores[i%beatsPerBar] += a
+		counts[i%beatsPerBar]++
+	}
+	best, second := 0, -1
+	for p := range scores {
+		if counts[p] > 0 {
+			scores[p] /= float64(counts[p])
+		}
+		if p == 0 {
+			continue
+		}
+		if scores[p] > scores[best] {
+			second = best
+			best = p
+		} else if second == -1 || scores[p] > scores[second] {
+			second = p
+		}
+	}
+	if scores[best] <= 0 || second == -1 {
+		return best, 0
+	}
+	return best, clamp01((scores[best] - scores[second]) / scores[best])
+}
+
+func onePoleLowPass(samples []float64, sampleRate int, cutoffHz float64) []float64 {
+	alpha := 1 - math.Exp(-2*math.Pi*cutoffHz/float64(sampleRate))
+	out := make([]float64, len(samples))
+	var y float64
+	for i, x := range samples {
+		y += alpha * (x - y)
+		out[i] = y
+	}
+	return out
+}
+
+// tempoCurve reports one local tempo per bar (downbeat to downbeat) and the
+// spread between the fastest and slowest bar.
+func tempoCurve(beats []float64, phase int) ([]TempoPoint, float64) {
+	if len(beats)-phase < minTempoCurveBeats {
+		return nil, 0
+	}
+	var curve []TempoPoint
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i := phase; i+beatsPerBar < len(beats); i += beatsPerBar {
+		span := beats[i+beatsPerBar] - beats[i]
+		if span <= 0 {
+			continue
+		}
+		bpm := 60 * beatsPerBar / span
+		curve = append(curve, TempoPoint{Sec: round3(beats[i]), BPM: round1(bpm)})
+		lo = math.Min(lo, bpm)
+		hi = math.Max(hi, bpm)
+	}
+	if len(curve) == 0 {
+		return nil, 0
+	}
+	return curve, round1(hi - lo)
+}
@@ -0,0 +1,68 @@
+package audioanalyze
+
+import (
+	"math"
+	"testing"
+)
+
+// barPattern renders 4/4 at bpm starting at offsetSec: a 60 Hz kick on every
+// downbeat and a quieter high click on the other beats. The first `pickup`
+// beats precede bar 1.
+func barPattern(sampleRate int, bpm, offsetSec float64, pickup, beats int) []float64 {
+	beatSec := 60 / bpm
+	total := int((offsetSec + float64(beats+1)*beatSec) * float64(sampleRate))
+	out := make([]float64, total)
+	for b := 0; b < beats; b++ {
+		start := int((offsetSec + float64(b)*beatSec) * float64(sampleRate))
+		downbeat := (b-pickup)%beatsPerBar == 0
+		n := sampleRate / 20 // 50 ms hit
+		for i := 0; i < n && start+i < total; i++ {
+			t := float64(i) / float64(sampleRate)
+			decay := math.Exp(-t * 40)
+			if downbeat {
+				out[start+i] += 0.9 * decay * math.Sin(2*math.Pi*60*t)
+			} else {
+				out[start+i] += 0.5 * decay * math.Sin(2*math.Pi*3000*t)
+			}
+		}
+	}
+	return out
+}
+
+func TestTrackBeatsFindsDownbeatAfterPickup(t *testing.T) {
+	t.Parallel()
+
+	const rate = 44100
+	// 0.25 s of silence, one pickup beat, then bar 1 at 0.25 + 0.5 = 0.75 s.
+	samples := barPattern(rate, 120, 0.25, 1, 33)
+	bpm, _ := estimateBPM(samples, rate)
+	grid, ok := trackBeats(samples, rate, bpm)
+	if !ok {
+		t.Fatal("trackBeats() not ok")
+	}
+	if math.Abs(grid.BPM-120) > 3 {
+		t.Errorf("bpm = %v, want ~120", grid.BPM)
+	}
+	if len(grid.BeatTimesSec) < 30 {
+		t.Fatalf("beats = %d, want >= 30", len(grid.BeatTimesSec))
+	}
+	if math.Abs(grid.FirstDownbeatSec-0.75) > 0.03 {
+		t.Errorf("first downbeat = %v, want ~0.75", grid.FirstDownbeatSec)
+	}
+	for i := 1; i < len(grid.DownbeatTimesSec); i++ {
+		if gap := grid.DownbeatTimesSec[i] - grid.DownbeatTimesSec[i-1]; math.Abs(gap-2) > 0.05 {
+			t.Errorf("bar %d length = %v, want 2s", i, gap)
+		}
+	}
+	if grid.TempoDriftBPM > 2 || len(grid.TempoCurve) < 6 {
+		t.Errorf("tempo curve = %+v drift %v", grid.TempoCurve, grid.TempoDriftBPM)
+	}
+}
+
+func TestTrackBeatsRejectsSilence(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := trackBeats(make([]float64, 44100), 44100, 0); ok {
+		t.Error("expected no grid without a tempo")
+	}
+}
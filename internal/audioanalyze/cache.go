@@ -0,0 +1,194 @@
+package audioanalyze
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AnalyzerVersion identifies the analysis algorithms. Bump it whenever a
+// change alters any Result field for the same input so cached results from
+// the old analyzer are ignored and cleared.
+const AnalyzerVersion = 3
+
+// cacheEntry is one cached analysis on disk.
+type cacheEntry struct {
+	AnalyzerVersion int       `json:"analyzer_version"`
+	ContentSHA256   string    `json:"content_sha256"`
+	Options         string    `json:"options"`
+	Result          Result    `json:"result"`
+	CachedAt        time.Time `json:"cached_at"`
+}
+
+// Cache stores analysis results on disk keyed by file content hash, analyzer
+// version and options, so re-analyzing an unchanged sample (even at a new
+// path) skips decoding. Entries live under dir/v<AnalyzerVersion>; folders of
+// other versions are removed on the first write.
+type Cache struct {
+	dir   string
+	prune sync.Once
+}
+
+func NewCache(dir string) (*Cache, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("analysis cache dir is required")
+	}
+	return &Cache{dir: dir}, nil
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// AnalyzeFile returns the cached result for path's content when present and
+// otherwise runs AnalyzeFileWithOptions and stores the result. The bool
+// reports a cache hit. Cache write failures never fail the analysis.
+func (c *Cache) AnalyzeFile(path string, opts AnalyzeOptions) (Result, bool, error) {
+	return c.analyze(path, opts, true)
+}
+
+// Refresh re-analyzes path, ignoring any cached result, and replaces it.
+func (c *Cache) Refresh(path string, opts AnalyzeOptions) (Result, error) {
+	out, _, err := c.analyze(path, opts, false)
+	return out, err
+}
+
+func (c *Cache) analyze(path string, opts AnalyzeOptions, lookup bool) (Result, bool, error) {
+	resolution, err := normalizeSpectrumResolution(opts.Spectrum)
+	if err != nil {
+		return Result{}, false, err
+	}
+	opts.Spectrum = resolution
+	sum, abs, err := hashLocalAudio(path)
+	if err != nil {
+		return Result{}, false, err
+	}
+	key := cacheOptionsKey(opts)
+	entryPath := c.entryPath(sum, key)
+	if lookup {
+		if entry, ok := readCacheEntry(entryPath, sum, key); ok {
+			out := entry.Result
+			out.Path = abs
+			return out, true, nil
+		}
+	}
+
+	out, err := AnalyzeFileWithOptions(abs, opts)
+	if err != nil {
+		return Result{}, false, err
+	}
+	_ = c.write(entryPath, cacheEntry{
+		AnalyzerVersion: AnalyzerVersion,
+		ContentSHA256:   sum,
+		Options:         key,
+		Result:          out,
+		CachedAt:        time.Now().UTC(),
+	})
+	return out, false, nil
+}
+
+// cacheOptionsKey encodes the options that change a Result.
+func cacheOptionsKey(opts AnalyzeOptions) string {
+	return fmt.Sprintf("tempo=%g;spectrum=%s;streaming=%t", opts.ProjectTempo, opts.Spectrum, opts.Streaming)
+}
+
+func (c *Cache) versionDir() string {
+	return filepath.Join(c.dir, fmt.Sprintf("v%d", AnalyzerVersion))
+}
+
+func (c *Cache) entryPath(contentSum, optionsKey string) string {
+	h := sha256.Sum256([]byte(contentSum + "|" + optionsKey))
+	name := hex.EncodeToString(h[:])
+	return filepath.Join(c.versionDir(), name[:2], name+".json")
+}
+
+func readCacheEntry(path, contentSum, optionsKey string) (cacheEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+	if entry.AnalyzerVersion != AnalyzerVersion || entry.ContentSHA256 != contentSum || entry.Options != optionsKey {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// write stores entry atomically (temp file + rename) so concurrent analyses
+// of the same file never leave a torn entry.
+func (c *Cache) write(path string, entry cacheEntry) error {
+	c.prune.Do(c.removeStaleVersions)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create analysis cache dir: %w", err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode analysis cache entry: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return fmt.Errorf("write analysis cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write analysis cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write analysis cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write analysis cache entry: %w", err)
+	}
+	return nil
+}
+
+// versionDirPattern matches the folders versionDir creates. The cache dir is
+// user-set and may be shared, so nothing else in it is ever removed.
+var versionDirPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// removeStaleVersions deletes cache folders written by other analyzer
+// versions; their results can never be served again.
+func (c *Cache) removeStaleVersions() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	current := filepath.Base(c.versionDir())
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || name == current || !versionDirPattern.MatchString(name) {
+			continue
+		}
+		_ = os.RemoveAll(filepath.Join(c.dir, name))
+	}
+}
+
+// hashLocalAudio validates path and returns the SHA-256 of its content and
+// the cleaned absolute path.
+func hashLocalAudio(path string) (string, string, error) {
+	f, abs, _, err := openLocalAudioFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", "", fmt.Errorf("read audio file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), abs, nil
+}
@@ -0,0 +1,98 @@
+package audioanalyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheHitAcrossPathsAndOptions(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCache(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "clicks.wav")
+	writeClickWAV(t, path, 44100, 120, 4)
+
+	first, hit, err := cache.AnalyzeFile(path, AnalyzeOptions{ProjectTempo: 120})
+	if err != nil || hit {
+		t.Fatalf("first AnalyzeFile() hit = %v, err = %v", hit, err)
+	}
+	second, hit, err := cache.AnalyzeFile(path, AnalyzeOptions{ProjectTempo: 120})
+	if err != nil || !hit {
+		t.Fatalf("second AnalyzeFile() hit = %v, err = %v", hit, err)
+	}
+	if second.EstimatedBPM != first.EstimatedBPM || second.OnsetCount != first.OnsetCount {
+		t.Errorf("cached = %+v, fresh = %+v", second, first)
+	}
+
+	// Same content at another path hits and reports the new path.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyPath := filepath.Join(dir, "copy.wav")
+	if err := os.WriteFile(copyPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	moved, hit, err := cache.AnalyzeFile(copyPath, AnalyzeOptions{ProjectTempo: 120})
+	if err != nil || !hit || moved.Path != copyPath {
+		t.Errorf("copy hit = %v, path = %q, err = %v", hit, moved.Path, err)
+	}
+
+	// Options that change the result miss.
+	if _, hit, err := cache.AnalyzeFile(path, AnalyzeOptions{ProjectTempo: 90}); err != nil || hit {
+		t.Errorf("new tempo hit = %v, err = %v", hit, err)
+	}
+	if _, err := cache.Refresh(path, AnalyzeOptions{ProjectTempo: 120}); err != nil {
+		t.Errorf("Refresh() error = %v", err)
+	}
+}
+
+func TestCacheInvalidatesOnContentAndVersion(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join(t.TempDir(), "cache")
+	for _, dir := range []string{"v0/ab", "vocals", "v2-backup"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache, err := NewCache(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "clicks.wav")
+	writeClickWAV(t, path, 44100, 120, 4)
+	if _, _, err := cache.AnalyzeFile(path, AnalyzeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "v0")); !os.IsNotExist(err) {
+		t.Errorf("stale version dir still present: %v", err)
+	}
+	for _, dir := range []string{"vocals", "v2-backup"} {
+		if _, err := os.Stat(filepath.Join(root, dir)); err != nil {
+			t.Errorf("unrelated dir %s removed: %v", dir, err)
+		}
+	}
+
+	writeClickWAV(t, path, 44100, 100, 4)
+	got, hit, err := cache.AnalyzeFile(path, AnalyzeOptions{})
+	if err != nil || hit {
+		t.Fatalf("changed content hit = %v, err = %v", hit, err)
+	}
+	if got.EstimatedBPM < 95 || got.EstimatedBPM > 105 {
+		t.Errorf("estimated_bpm = %v, want ~100 after rewrite", got.EstimatedBPM)
+	}
+}
+
+func TestNewCacheRequiresDir(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewCache(" "); err == nil {
+		t.Error("expected error for empty dir")
+	}
+}
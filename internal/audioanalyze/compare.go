@@ -0,0 +1,355 @@
+package audioanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// mixBands are the tonal-balance regions engineers talk about when matching a
+// reference; finer than BandBalance so suggestions can name a region.
+var mixBands = []struct {
+	name   string
+	lowHz  float64
+	highHz float64
+}{
+	{"sub", 20, 60},
+	{"low", 60, 200},
+	{"low_mid", 200, 500},
+	{"mid", 500, 2000},
+	{"high_mid", 2000, 6000},
+	{"high", 6000, 20000},
+}
+
+const (
+	bandSuggestDB       = 2.0
+	loudnessSuggestLU   = 1.0
+	truePeakCeilingDBTP = -1.0
+	widthSuggestDelta   = 0.1
+	crestSuggestDB      = 2.0
+	brightSuggestRatio  = 0.15
+	densitySuggestBar   = 2.0
+	tempoSameTolerance  = 0.03
+)
+
+// FileSummary is the per-file side of a comparison.
+type FileSummary struct {
+	Path           string  `json:"path"`
+	DurationSec    float64 `json:"duration_sec"`
+	BPM            float64 `json:"bpm,omitempty"`
+	Key            string  `json:"key,omitempty"`
+	IntegratedLUFS float64 `json:"integrated_lufs"`
+	TruePeakDBTP   float64 `json:"true_peak_dbtp"`
+	LoudnessRange  float64 `json:"loudness_range_lu"`
+	StereoWidth    float64 `json:"stereo_width"`
+	CrestFactorDB  float64 `json:"crest_factor_db"`
+	BrightnessHz   float64 `json:"brightness_hz"`
+	RhythmDensity  float64 `json:"rhythm_density"`
+}
+
+// MetricDelta is mix minus reference for one scalar metric.
+type MetricDelta struct {
+	Metric    string  `json:"metric"`
+	Mix       float64 `json:"mix"`
+	Reference float64 `json:"reference"`
+	Delta     float64 `json:"delta"`
+	Unit      string  `json:"unit,omitempty"`
+}
+
+// BandDelta compares the level of one frequency region relative to each
+// file's total energy, so it reflects tonal balance independent of loudness.
+type BandDelta struct {
+	Band        string  `json:"band"`
+	LowHz       float64 `json:"low_hz"`
+	HighHz      float64 `json:"high_hz"`
+	MixDB       float64 `json:"mix_db"`
+	ReferenceDB float64 `json:"reference_db"`
+	DeltaDB     float64 `json:"delta_db"`
+}
+
+// Comparison is a reference-vs-mix report: per-metric deltas, band balance,
+// match-axis deltas, tempo/key agreement, and plain-language suggestions.
+type Comparison struct {
+	Mix            FileSummary   `json:"mix"`
+	Reference      FileSummary   `json:"reference"`
+	Metrics        []MetricDelta `json:"metrics"`
+	Bands          []BandDelta   `json:"bands"`
+	MatchAxes      []MetricDelta `json:"match_axes"`
+	TempoAgreement string        `json:"tempo_agreement" jsonschema:"description=same, half_double, different, or unknown"`
+	KeyAgreement   string        `json:"key_agreement" jsonschema:"description=same, relative, fifth, different, or unknown"`
+	Suggestions    []string      `json:"suggestions"`
+}
+
+// CompareFiles analyzes a mix (bounce) and a reference track and reports how
+// the mix differs. Both must be local files in a supported format.
+func CompareFiles(mixPath, referencePath string, projectTempo float64) (Comparison, error) {
+	mix, mixBandsDB, err := analyzeForCompare(mixPath, projectTempo)
+	if err != nil {
+		return Comparison{}, fmt.Errorf("mix: %w", err)
+	}
+	ref, refBandsDB, err := analyzeForCompare(referencePath, projectTempo)
+	if err != nil {
+		return Comparison{}, fmt.Errorf("reference: %w", err)
+	}
+	return compareResults(mix, ref, mixBandsDB, refBandsDB), nil
+}
+
+func analyzeForCompare(path string, projectTempo float64) (Result, []float64, error) {
+	f, abs, err := openLocalAudio(path)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	audio, err := loadAudio(io.LimitReader(f, maxFileBytes+1))
+	if err != nil {
+		return Result{}, nil, err
+	}
+	res, err := analyzeDecoded(audio, projectTempo)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	res.Path = abs
+	bands, ok := mixBandLevels(audio.mono, audio.sampleRate)
+	if !ok {
+		return Result{}, nil, errors.New("audio too short for band analysis")
+	}
+	return res, bands, nil
+}
+
+// mixBandLevels returns each mixBands region's energy in dB relative to the
+// total spectral energy, averaged over up to 200 frames.
+func mixBandLevels(samples []float64, sampleRate int) ([]float64, bool) {
+	if sampleRate <= 0 || len(samples) < prodFrameSize {
+		return nil, false
+	}
+	window := hannWindow(prodFrameSize)
+	buf := make([]float64, prodFrameSize)
+	energy := make([]float64, len(mixBands))
+	var total float64
+	// Spread the frames across the file so a quiet intro doesn't dominate.
+	frameCount := (len(samples)-prodFrameSize)/prodHopSize + 1
+	stride := max(1, frameCount/200)
+	for frame := 0; frame < frameCount; frame += stride {
+		start := frame * prodHopSize
+		for i := 0; i < prodFrameSize; i++ {
+			buf[i] = samples[start+i] * window[i]
+		}
+		re, im := fftReal(buf)
+		for bin := 1; bin < prodFrameSize/2; bin++ {
+			freq := float64(bin) * float64(sampleRate) / float64(prodFrameSize)
+			e := re[bin]*re[bin] + im[bin]*im[bin]
+			total += e
+			for b, band := range mixBands {
+				if freq >= band.lowHz && freq < band.highHz {
+					energy[b] += e
+					break
+				}
+			}
+		}
+	}
+	if total <= 1e-18 {
+		return nil, false
+	}
+	out := make([]float64, len(mixBands))
+	for b, e := range energy {
+		out[b] = round1(10 * math.Log10(math.Max(e/total, 1e-12)))
+	}
+	return out, true
+}
+
+func summarize(r Result) FileSummary {
+	s := FileSummary{
+		Path:          r.Path,
+		DurationSec:   round2(r.DurationSec),
+		BPM:           r.EstimatedBPM,
+		StereoWidth:   r.StereoWidth,
+		CrestFactorDB: r.CrestFactorDB,
+		BrightnessHz:  r.BrightnessHz,
+		RhythmDensity: r.RhythmDensity,
+	}
+	if r.Key != "" {
+		s.Key = r.Key + " " + r.Scale
+	}
+	if r.Loudness != nil {
+		s.IntegratedLUFS = r.Loudness.IntegratedLUFS
+		s.TruePeakDBTP = r.Loudness.TruePeakDBTP
+		s.LoudnessRange = r.Loudness.LoudnessRangeLU
+	} else {
+		s.IntegratedLUFS = loudnessFloorLUFS
+		s.TruePeakDBTP = loudnessFloorLUFS
+	}
+	return s
+}
+
+func compareResults(mix, ref Result, mixBandsDB, refBandsDB []float64) Comparison {
+	m, r := summarize(mix), summarize(ref)
+	out := Comparison{Mix: m, Reference: r}
+	metric := func(name string, mv, rv float64, unit string) {
+		out.Metrics = append(out.Metrics, MetricDelta{Metric: name, Mix: mv, Reference: rv, Delta: round2(mv - rv), Unit: unit})
+	}
+	metric("integrated_lufs", m.IntegratedLUFS, r.IntegratedLUFS, "LU")
+	metric("true_peak_dbtp", m.TruePeakDBTP, r.TruePeakDBTP, "dB")
+	metric("loudness_range_lu", m.LoudnessRange, r.LoudnessRange, "LU")
+	metric("stereo_width", m.StereoWidth, r.StereoWidth, "")
+	metric("crest_factor_db", m.CrestFactorDB, r.CrestFactorDB, "dB")
+	metric("brightness_hz", m.BrightnessHz, r.BrightnessHz, "Hz")
+	metric("rhythm_density", m.RhythmDensity, r.RhythmDensity, "onsets/bar")
+
+	for b, band := range mixBands {
+		out.Bands = append(out.Bands, BandDelta{
+			Band:        band.name,
+			LowHz:       band.lowHz,
+			HighHz:      band.highHz,
+			MixDB:       mixBandsDB[b],
+			ReferenceDB: refBandsDB[b],
+			DeltaDB:     round1(mixBandsDB[b] - refBandsDB[b]),
+		})
+	}
+	refAxes := make(map[string]float64, len(ref.MatchAxes))
+	for _, a := range ref.MatchAxes {
+		refAxes[a.Name] = a.Score
+	}
+	for _, a := range mix.MatchAxes {
+		rv := refAxes[a.Name]
+		out.MatchAxes = append(out.MatchAxes, MetricDelta{Metric: a.Name, Mix: a.Score, Reference: rv, Delta: round2(a.Score - rv)})
+	}
+
+	out.TempoAgreement = tempoAgreement(m.BPM, r.BPM)
+	out.KeyAgreement = keyAgreement(mix.Key, mix.Scale, ref.Key, ref.Scale)
+	out.Suggestions = compareSuggestions(out)
+	return out
+}
+
+func tempoAgreement(a, b float64) string {
+	if a <= 0 || b <= 0 {
+		return "unknown"
+	}
+	ratio := a / b
+	switch {
+	case math.Abs(ratio-1) <= tempoSameTolerance:
+		return "same"
+	case math.Abs(ratio-2) <= 2*tempoSameTolerance || math.Abs(ratio-0.5) <= tempoSameTolerance/2:
+		return "half_double"
+	default:
+		return "different"
+	}
+}
+
+// keyAgreement classifies two key estimates: identical, relative major/minor,
+// a fifth apart in the same mode, or unrelated.
+func keyAgreement(tonicA, scaleA, tonicB, scaleB string) string {
+	pa, pb := pitchClassIndex(tonicA), pitchClassIndex(tonicB)
+	if pa < 0 || pb < 0 {
+		return "unknown"
+	}
+	// Compare relative majors so A minor and C major count as relatives.
+	relMajor := func(pc int, scale string) int {
+		if scale == "minor" {
+			return (pc + 3) % 12
+		}
+		return pc
+	}
+	switch {
+	case pa == pb && scaleA == scaleB:
+		return "same"
+	case scaleA != scaleB && relMajor(pa, scaleA) == relMajor(pb, scaleB):
+		return "relative"
+	case scaleA == scaleB && ((pa-pb+12)%12 == 7 || (pb-pa+12)%12 == 7):
+		return "fifth"
+	default:
+		return "different"
+	}
+}
+
+func pitchClassIndex(name string) int {
+	for i, n := range pitchClassNames {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// compareSuggestions turns the largest deltas into short, actionable notes.
+// They describe differences, not a verdict: the reference is a target only if
+// the user wants it to be.
+func compareSuggestions(c Comparison) []string {
+	var out []string
+	if d := c.Mix.IntegratedLUFS - c.Reference.IntegratedLUFS; math.Abs(d) >= loudnessSuggestLU {
+		dir := "louder"
+		if d < 0 {
+			dir = "quieter"
+		}
+		out = append(out, fmt.Sprintf("mix is %.1f LU %s than reference — level-match before judging tone", math.Abs(d), dir))
+	}
+	if c.Mix.TruePeakDBTP > truePeakCeilingDBTP {
+		out = append(out, fmt.Sprintf("mix true peak %.1f dBTP — leave about 1 dB headroom for lossy encoding", c.Mix.TruePeakDBTP))
+	}
+	for _, b := range c.Bands {
+		if math.Abs(b.DeltaDB) < bandSuggestDB {
+			continue
+		}
+		action := "cut"
+		if b.DeltaDB < 0 {
+			action = "boost"
+		}
+		out = append(out, fmt.Sprintf("%s %+.1f dB vs reference — consider a %s around %s",
+			strings.ReplaceAll(b.Band, "_", "-"), b.DeltaDB, action, bandRangeLabel(b.LowHz, b.HighHz)))
+	}
+	if d := c.Mix.StereoWidth - c.Reference.StereoWidth; math.Abs(d) >= widthSuggestDelta {
+		if d > 0 {
+			out = append(out, fmt.Sprintf("mix is wider than reference (%.2f vs %.2f) — check mono compatibility", c.Mix.StereoWidth, c.Reference.StereoWidth))
+		} else {
+			out = append(out, fmt.Sprintf("mix is narrower than reference (%.2f vs %.2f) — widen pads/FX, keep low end centered", c.Mix.StereoWidth, c.Reference.StereoWidth))
+		}
+	}
+	if d := c.Mix.CrestFactorDB - c.Reference.CrestFactorDB; math.Abs(d) >= crestSuggestDB {
+		if d > 0 {
+			out = append(out, fmt.Sprintf("mix is %.1f dB more dynamic (crest factor) — reference is more compressed/limited", d))
+		} else {
+			out = append(out, fmt.Sprintf("mix is %.1f dB more compressed (crest factor) — transients may be flattened", -d))
+		}
+	}
+	if c.Reference.BrightnessHz > 0 {
+		if ratio := c.Mix.BrightnessHz/c.Reference.BrightnessHz - 1; math.Abs(ratio) >= brightSuggestRatio {
+			dir := "brighter"
+			if ratio < 0 {
+				dir = "darker"
+			}
+			out = append(out, fmt.Sprintf("mix is %s than reference (centroid %.0f vs %.0f Hz)", dir, c.Mix.BrightnessHz, c.Reference.BrightnessHz))
+		}
+	}
+	if d := c.Mix.RhythmDensity - c.Reference.RhythmDensity; math.Abs(d) >= densitySuggestBar && c.Reference.RhythmDensity > 0 {
+		dir := "busier"
+		if d < 0 {
+			dir = "sparser"
+		}
+		out = append(out, fmt.Sprintf("mix rhythm is %s (%.1f vs %.1f onsets/bar)", dir, c.Mix.RhythmDensity, c.Reference.RhythmDensity))
+	}
+	switch c.TempoAgreement {
+	case "half_double":
+		out = append(out, "tempos are half/double of each other — compare feel at the same pulse")
+	case "different":
+		out = append(out, fmt.Sprintf("tempos differ (%.1f vs %.1f BPM)", c.Mix.BPM, c.Reference.BPM))
+	}
+	if c.KeyAgreement == "different" {
+		out = append(out, fmt.Sprintf("keys differ (%s vs %s) — tonal balance comparisons still hold, harmonic ones don't", c.Mix.Key, c.Reference.Key))
+	}
+	if len(out) == 0 {
+		out = append(out, "mix is within tolerance of the reference on every measured axis")
+	}
+	return out
+}
+
+func bandRangeLabel(lowHz, highHz float64) string {
+	format := func(hz float64) string {
+		if hz >= 1000 {
+			return fmt.Sprintf("%gk", hz/1000)
+		}
+		return fmt.Sprintf("%g", hz)
+	}
+	return format(lowHz) + "–" + format(highHz) + " Hz"
+}
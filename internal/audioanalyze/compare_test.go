@@ -0,0 +1,99 @@
+package audioanalyze
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMixBandLevels(t *testing.T) {
+	t.Parallel()
+
+	const rate = 44100
+	bass := sine(rate, 2, 100, -6, 0)
+	bright := sine(rate, 2, 3000, -6, 0)
+	bassDB, ok := mixBandLevels(bass, rate)
+	if !ok {
+		t.Fatal("mixBandLevels() not ok")
+	}
+	brightDB, _ := mixBandLevels(bright, rate)
+	// 100 Hz lives in "low" (index 1), 3 kHz in "high_mid" (index 4).
+	if bassDB[1] < -1 || bassDB[4] > -30 {
+		t.Errorf("bass bands = %v", bassDB)
+	}
+	if brightDB[4] < -1 || brightDB[1] > -30 {
+		t.Errorf("bright bands = %v", brightDB)
+	}
+}
+
+func TestKeyAndTempoAgreement(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		ta, sa, tb, sb, want string
+	}{
+		{"A", "minor", "A", "minor", "same"},
+		{"A", "minor", "C", "major", "relative"},
+		{"G", "major", "C", "major", "fifth"},
+		{"C", "major", "F#", "major", "different"},
+		{"", "", "C", "major", "unknown"},
+	}
+	for _, tc := range cases {
+		if got := keyAgreement(tc.ta, tc.sa, tc.tb, tc.sb); got != tc.want {
+			t.Errorf("keyAgreement(%s %s, %s %s) = %q, want %q", tc.ta, tc.sa, tc.tb, tc.sb, got, tc.want)
+		}
+	}
+	if got := tempoAgreement(120, 121); got != "same" {
+		t.Errorf("tempoAgreement(120,121) = %q", got)
+	}
+	if got := tempoAgreement(170, 85); got != "half_double" {
+		t.Errorf("tempoAgreement(170,85) = %q", got)
+	}
+	if got := tempoAgreement(128, 100); got != "different" {
+		t.Errorf("tempoAgreement(128,100) = %q", got)
+	}
+}
+
+func TestCompareSuggestionsNameBands(t *testing.T) {
+	t.Parallel()
+
+	mix := Result{Loudness: &Loudness{IntegratedLUFS: -12, TruePeakDBTP: -0.2}, StereoWidth: 0.3}
+	ref := Result{Loudness: &Loudness{IntegratedLUFS: -9, TruePeakDBTP: -1}, StereoWidth: 0.3}
+	mixBandsDB := []float64{-20, -6, -7, -8, -12, -20}
+	refBandsDB := []float64{-20, -6, -10, -8, -12, -20}
+	c := compareResults(mix, ref, mixBandsDB, refBandsDB)
+	joined := strings.Join(c.Suggestions, "\n")
+	for _, want := range []string{"low-mid +3.0 dB vs reference", "3.0 LU quieter", "true peak -0.2 dBTP"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("suggestions missing %q:\n%s", want, joined)
+		}
+	}
+	if c.Bands[2].DeltaDB != 3 || c.Metrics[0].Delta != -3 {
+		t.Errorf("bands/metrics = %+v / %+v", c.Bands[2], c.Metrics[0])
+	}
+}
+
+func TestCompareFilesClickTracks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	mixPath := filepath.Join(dir, "mix.wav")
+	refPath := filepath.Join(dir, "ref.wav")
+	writeClickWAV(t, mixPath, 44100, 120, 4)
+	writeClickWAV(t, refPath, 44100, 120, 4)
+	c, err := CompareFiles(mixPath, refPath, 120)
+	if err != nil {
+		t.Fatalf("CompareFiles() error = %v", err)
+	}
+	if c.TempoAgreement != "same" || len(c.Bands) != len(mixBands) {
+		t.Errorf("comparison = %+v", c)
+	}
+	for _, b := range c.Bands {
+		if b.DeltaDB != 0 {
+			t.Errorf("identical files differ in %s by %v dB", b.Band, b.DeltaDB)
+		}
+	}
+	if _, err := CompareFiles(mixPath, filepath.Join(dir, "missing.wav"), 0); err == nil || !strings.HasPrefix(err.Error(), "reference:") {
+		t.Errorf("error = %v, want reference error", err)
+	}
+}
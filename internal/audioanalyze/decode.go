@@ -0,0 +1,536 @@
+package audioanalyze
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/hajimehoshi/go-mp3"
+	"github.com/mewkiz/flac"
+)
+
+// maxDecodedSeconds bounds how much audio loadAudio expands into memory. Each
+// channel plus the mono mix is held as float64, so five minutes of 44.1 kHz
+// stereo is already ~320 MB and the folder tools run several analyses at once;
+// longer files go through the streaming path instead.
+const maxDecodedSeconds = 5 * 60
+
+var supportedExtensions = map[string]bool{
+	".wav":  true,
+	".aif":  true,
+	".aiff": true,
+	".aifc": true,
+	".flac": true,
+	".mp3":  true,
+}
+
+// audioStream yields decoded audio a chunk at a time so long files can be
+// analyzed in bounded memory. read returns up to max frames per channel (all
+// channels the same length) and io.EOF once the stream is exhausted.
+type audioStream interface {
+	format() string
+	sampleRate() int
+	channels() int
+	read(max int) ([][]float64, error)
+}
+
+// streamChunkFrames is how many frames loadAudio pulls per read.
+const streamChunkFrames = 1 << 16
+
+// loadAudio decodes a whole stream into memory, up to maxDecodedSeconds;
+// truncated reports that audio remained after that.
+func loadAudio(r io.Reader) (decodedAudio, error) {
+	stream, err := openAudioStream(r)
+	if err != nil {
+		return decodedAudio{}, err
+	}
+	chans, rest, err := decodeHead(stream, maxDecodedSeconds*stream.sampleRate())
+	if err != nil {
+		return decodedAudio{}, err
+	}
+	audio, err := newDecodedAudio(stream.format(), chans, stream.sampleRate())
+	audio.truncated = rest != nil
+	return audio, err
+}
+
+// decodeHead reads up to maxFrames per channel from stream. rest is nil when
+// that was the whole stream; otherwise it yields the remaining audio.
+func decodeHead(stream audioStream, maxFrames int) ([][]float64, audioStream, error) {
+	chans := make([][]float64, stream.channels())
+	for len(chans[0]) < maxFrames {
+		chunk, err := stream.read(min(streamChunkFrames, maxFrames-len(chans[0])))
+		for ch := range chans {
+			if ch < len(chunk) {
+				chans[ch] = append(chans[ch], chunk[ch]...)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return chans, nil, nil
+		}
+		if err != nil {
+			// A corrupt tail (common in truncated MP3 downloads) still leaves
+			// the decoded head worth analyzing.
+			if len(chans[0]) > 0 {
+				return chans, nil, nil
+			}
+			return nil, nil, err
+		}
+	}
+	// A stream exactly maxFrames long is complete; read one more frame to tell.
+	next, err := stream.read(1)
+	if err != nil || len(next) == 0 || len(next[0]) == 0 {
+		return chans, nil, nil
+	}
+	return chans, &prefixedStream{audioStream: stream, head: next}, nil
+}
+
+// prefixedStream replays already-decoded head frames before reading on from
+// the wrapped stream.
+type prefixedStream struct {
+	audioStream
+	head [][]float64
+}
+
+func (s *prefixedStream) read(max int) ([][]float64, error) {
+	if len(s.head) == 0 || len(s.head[0]) == 0 {
+		return s.audioStream.read(max)
+	}
+	n := min(max, len(s.head[0]))
+	out := make([][]float64, len(s.head))
+	for ch := range s.head {
+		out[ch] = s.head[ch][:n]
+		s.head[ch] = s.head[ch][n:]
+	}
+	return out, nil
+}
+
+// openAudioStream sniffs the container from the first bytes of r and opens
+// the matching pure-Go decoder. The file extension is not trusted: Splice and
+// Live exports are occasionally misnamed.
+func openAudioStream(r io.Reader) (audioStream, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(12)
+	if err != nil && len(header) < 4 {
+		return nil, fmt.Errorf("read audio header: %w", err)
+	}
+	switch sniffAudioFormat(header) {
+	case "wav":
+		return openWAVStream(br)
+	case "aiff":
+		return openAIFFStream(br)
+	case "flac":
+		return openFLACStream(br)
+	case "mp3":
+		return openMP3Stream(br)
+	case "ogg":
+		return nil, errors.New("ogg vorbis is not supported yet; convert to wav, aiff or flac")
+	default:
+		return nil, errors.New("unrecognized audio format (need wav, aiff, flac or mp3)")
+	}
+}
+
+func sniffAudioFormat(header []byte) string {
+	switch {
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		return "wav"
+	case len(header) >= 12 && string(header[0:4]) == "FORM" &&
+		(string(header[8:12]) == "AIFF" || string(header[8:12]) == "AIFC"):
+		return "aiff"
+	case len(header) >= 4 && string(header[0:4]) == "fLaC":
+		return "flac"
+	case len(header) >= 4 && string(header[0:4]) == "OggS":
+		return "ogg"
+	case len(header) >= 3 && string(header[0:3]) == "ID3":
+		// ID3v2 tags prefix both MP3 and (rarely) FLAC; MP3 is far more common
+		// and the FLAC decoder would reject a tagged stream anyway.
+		return "mp3"
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		return "mp3"
+	default:
+		return ""
+	}
+}
+
+// pcmStream reads interleaved fixed-width frames from r and splits each chunk
+// with decode.
+type pcmStream struct {
+	r          io.Reader
+	name       string
+	rate       int
+	chans      int
+	frameBytes int
+	decode     func(data []byte) ([][]float64, error)
+	buf        []byte
+}
+
+func (s *pcmStream) format() string  { return s.name }
+func (s *pcmStream) sampleRate() int { return s.rate }
+func (s *pcmStream) channels() int   { return s.chans }
+
+func (s *pcmStream) read(max int) ([][]float64, error) {
+	if max < 1 {
+		max = 1
+	}
+	if cap(s.buf) < max*s.frameBytes {
+		s.buf = make([]byte, max*s.frameBytes)
+	}
+	buf := s.buf[:max*s.frameBytes]
+	n, err := io.ReadFull(s.r, buf)
+	n -= n % s.frameBytes
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) && n == 0 {
+		return nil, fmt.Errorf("read %s samples: %w", s.name, err)
+	}
+	if n == 0 {
+		return nil, io.EOF
+	}
+	return s.decode(buf[:n])
+}
+
+// openWAVStream reads the RIFF header up to the data chunk. Streaming
+// encoders (e.g. ffmpeg writing to a pipe) cannot seek back to patch the data
+// chunk size, so it holds a placeholder; the data chunk is read to EOF instead
+// of trusting the declared size.
+func openWAVStream(r io.Reader) (audioStream, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("read wav header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF/WAVE file")
+	}
+
+	var (
+		audioFormat   uint16
+		channels      uint16
+		sampleRate    uint32
+		bitsPerSample uint16
+		haveData      bool
+	)
+	for !haveData {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		chunkID := string(chunkHeader[0:4])
+		chunkSize := binary.LittleEndian.Uint32(chunkHeader[4:8])
+		if chunkID == "data" {
+			haveData = true
+			break
+		}
+
+		if chunkSize > maxChunkBytes {
+			return nil, fmt.Errorf("%s chunk too large (%d bytes)", chunkID, chunkSize)
+		}
+		payload := make([]byte, chunkSize)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, fmt.Errorf("read %s chunk: %w", chunkID, err)
+		}
+		// Chunks are word-aligned.
+		if chunkSize%2 == 1 {
+			var pad [1]byte
+			_, _ = r.Read(pad[:])
+		}
+		if chunkID == "fmt " {
+			if len(payload) < 16 {
+				return nil, errors.New("invalid fmt chunk")
+			}
+			audioFormat = binary.LittleEndian.Uint16(payload[0:2])
+			channels = binary.LittleEndian.Uint16(payload[2:4])
+			sampleRate = binary.LittleEndian.Uint32(payload[4:8])
+			bitsPerSample = binary.LittleEndian.Uint16(payload[14:16])
+		}
+	}
+	if !haveData || channels == 0 || sampleRate == 0 {
+		return nil, errors.New("wav missing fmt/data")
+	}
+	if audioFormat != 1 && audioFormat != 3 {
+		return nil, fmt.Errorf("unsupported wav format code %d (need PCM or IEEE float)", audioFormat)
+	}
+	width, _, err := sampleDecoder(int(bitsPerSample), audioFormat)
+	if err != nil {
+		return nil, err
+	}
+	return &pcmStream{
+		r:          r,
+		name:       "wav",
+		rate:       int(sampleRate),
+		chans:      int(channels),
+		frameBytes: width * int(channels),
+		decode: func(b []byte) ([][]float64, error) {
+			return decodeChannels(b, int(channels), int(bitsPerSample), audioFormat)
+		},
+	}, nil
+}
+
+// openAIFFStream decodes uncompressed AIFF and AIFC (NONE/twos, sowt, fl32,
+// fl64). Chunks may appear in any order; when SSND precedes COMM its payload
+// is buffered (bounded by maxFileBytes) until the format is known.
+func openAIFFStream(r io.Reader) (audioStream, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("read aiff header: %w", err)
+	}
+	if string(header[0:4]) != "FORM" {
+		return nil, errors.New("not an AIFF file")
+	}
+	aifc := string(header[8:12]) == "AIFC"
+
+	var (
+		channels    int
+		bits        int
+		sampleRate  float64
+		compression = "NONE"
+		haveComm    bool
+		data        io.Reader
+	)
+	for data == nil || !haveComm {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		chunkID := string(chunkHeader[0:4])
+		chunkSize := binary.BigEndian.Uint32(chunkHeader[4:8])
+
+		if chunkID == "SSND" {
+			if chunkSize < 8 {
+				return nil, fmt.Errorf("invalid SSND chunk size %d", chunkSize)
+			}
+			var ssnd [8]byte
+			if _, err := io.ReadFull(r, ssnd[:]); err != nil {
+				return nil, fmt.Errorf("read SSND chunk: %w", err)
+			}
+			offset := int64(binary.BigEndian.Uint32(ssnd[0:4]))
+			if 8+offset > int64(chunkSize) {
+				return nil, errors.New("invalid SSND offset")
+			}
+			if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+				return nil, fmt.Errorf("read SSND chunk: %w", err)
+			}
+			payload := io.LimitReader(r, int64(chunkSize)-8-offset)
+			if haveComm {
+				data = payload
+				break
+			}
+			buffered, err := io.ReadAll(io.LimitReader(payload, maxFileBytes))
+			if err != nil {
+				return nil, fmt.Errorf("read SSND chunk: %w", err)
+			}
+			data = bytes.NewReader(buffered)
+		} else {
+			if chunkSize > maxChunkBytes {
+				return nil, fmt.Errorf("%s chunk too large (%d bytes)", chunkID, chunkSize)
+			}
+			payload := make([]byte, chunkSize)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return nil, fmt.Errorf("read %s chunk: %w", chunkID, err)
+			}
+			if chunkID == "COMM" {
+				if len(payload) < 18 {
+					return nil, errors.New("invalid COMM chunk")
+				}
+				channels = int(binary.BigEndian.Uint16(payload[0:2]))
+				bits = int(binary.BigEndian.Uint16(payload[6:8]))
+				sampleRate = extendedToFloat64(payload[8:18])
+				if aifc && len(payload) >= 22 {
+					compression = string(payload[18:22])
+				}
+				haveComm = true
+			}
+		}
+		// Chunks are word-aligned.
+		if chunkSize%2 == 1 {
+			var pad [1]byte
+			_, _ = r.Read(pad[:])
+		}
+	}
+	if !haveComm || data == nil || channels == 0 || sampleRate <= 0 {
+		return nil, errors.New("aiff missing COMM/SSND")
+	}
+
+	width, decode, err := aiffSampleDecoder(bits, compression)
+	if err != nil {
+		return nil, err
+	}
+	frame := width * channels
+	return &pcmStream{
+		r:          data,
+		name:       "aiff",
+		rate:       int(math.Round(sampleRate)),
+		chans:      channels,
+		frameBytes: frame,
+		decode: func(b []byte) ([][]float64, error) {
+			n := len(b) / frame
+			chans := make([][]float64, channels)
+			for ch := range chans {
+				chans[ch] = make([]float64, n)
+			}
+			for i := 0; i < n; i++ {
+				for ch := 0; ch < channels; ch++ {
+					chans[ch][i] = decode(b[i*frame+ch*width:])
+				}
+			}
+			return chans, nil
+		},
+	}, nil
+}
+
+// aiffSampleDecoder returns the byte width and decode function for an AIFF
+// sample format. Integer samples are big-endian two's complement except for
+// AIFC "sowt", which is little-endian.
+func aiffSampleDecoder(bits int, compression string) (int, func([]byte) float64, error) {
+	switch compression {
+	case "NONE", "twos":
+		switch bits {
+		case 8:
+			return 1, func(b []byte) float64 { return float64(int8(b[0])) / 128.0 }, nil
+		case 16:
+			return 2, func(b []byte) float64 {
+				return float64(int16(binary.BigEndian.Uint16(b[0:2]))) / 32768.0
+			}, nil
+		case 24:
+			return 3, func(b []byte) float64 {
+				v := int32(b[0])<<16 | int32(b[1])<<8 | int32(b[2])
+				if v&0x800000 != 0 {
+					v |= ^0xFFFFFF
+				}
+				return float64(v) / 8388608.0
+			}, nil
+		case 32:
+			return 4, func(b []byte) float64 {
+				return float64(int32(binary.BigEndian.Uint32(b[0:4]))) / 2147483648.0
+			}, nil
+		}
+	case "sowt":
+		switch bits {
+		case 16:
+			return 2, func(b []byte) float64 {
+				return float64(int16(binary.LittleEndian.Uint16(b[0:2]))) / 32768.0
+			}, nil
+		case 24:
+			return 3, func(b []byte) float64 {
+				v := int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16
+				if v&0x800000 != 0 {
+					v |= ^0xFFFFFF
+				}
+				return float64(v) / 8388608.0
+			}, nil
+		}
+	case "fl32", "FL32":
+		return 4, func(b []byte) float64 {
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b[0:4])))
+		}, nil
+	case "fl64", "FL64":
+		return 8, func(b []byte) float64 {
+			return math.Float64frombits(binary.BigEndian.Uint64(b[0:8]))
+		}, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported aiff encoding: compression=%q bits=%d", compression, bits)
+}
+
+// extendedToFloat64 converts the 80-bit IEEE 754 extended float AIFF uses for
+// its sample rate.
+func extendedToFloat64(b []byte) float64 {
+	exp := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exp == 0 && mantissa == 0 {
+		return 0
+	}
+	v := math.Ldexp(float64(mantissa), exp-16383-63)
+	if b[0]&0x80 != 0 {
+		v = -v
+	}
+	return v
+}
+
+// flacStream decodes a FLAC stream frame by frame, holding back samples that
+// overflow the requested chunk.
+type flacStream struct {
+	stream  *flac.Stream
+	rate    int
+	chans   int
+	pending [][]float64
+	done    bool
+}
+
+// openFLACStream reads the FLAC stream header.
+func openFLACStream(r io.Reader) (audioStream, error) {
+	stream, err := flac.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("read flac header: %w", err)
+	}
+	channels := int(stream.Info.NChannels)
+	sampleRate := int(stream.Info.SampleRate)
+	if channels == 0 || sampleRate == 0 {
+		return nil, errors.New("flac missing stream info")
+	}
+	return &flacStream{stream: stream, rate: sampleRate, chans: channels, pending: make([][]float64, channels)}, nil
+}
+
+func (s *flacStream) format() string  { return "flac" }
+func (s *flacStream) sampleRate() int { return s.rate }
+func (s *flacStream) channels() int   { return s.chans }
+
+func (s *flacStream) read(max int) ([][]float64, error) {
+	for !s.done && len(s.pending[0]) < max {
+		f, err := s.stream.ParseNext()
+		if errors.Is(err, io.EOF) {
+			s.done = true
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode flac frame: %w", err)
+		}
+		if len(f.Subframes) != s.chans {
+			return nil, errors.New("flac frame channel count changed mid-stream")
+		}
+		scale := 1 / float64(int64(1)<<(f.BitsPerSample-1))
+		for ch, sub := range f.Subframes {
+			for _, v := range sub.Samples {
+				s.pending[ch] = append(s.pending[ch], float64(v)*scale)
+			}
+		}
+	}
+	n := min(max, len(s.pending[0]))
+	if n == 0 {
+		return nil, io.EOF
+	}
+	out := make([][]float64, s.chans)
+	for ch := range out {
+		out[ch] = append([]float64(nil), s.pending[ch][:n]...)
+		s.pending[ch] = append(s.pending[ch][:0], s.pending[ch][n:]...)
+	}
+	return out, nil
+}
+
+// openMP3Stream decodes MPEG-1/2 Layer III audio. The decoder always emits
+// 16-bit stereo, so mono MP3s report two identical channels.
+func openMP3Stream(r io.Reader) (audioStream, error) {
+	dec, err := mp3.NewDecoder(r)
+	if err != nil {
+		return nil, fmt.Errorf("read mp3 header: %w", err)
+	}
+	sampleRate := dec.SampleRate()
+	if sampleRate <= 0 {
+		return nil, errors.New("mp3 missing sample rate")
+	}
+	return &pcmStream{
+		r:          dec,
+		name:       "mp3",
+		rate:       sampleRate,
+		chans:      2,
+		frameBytes: 4, // 16-bit little-endian stereo
+		decode: func(b []byte) ([][]float64, error) {
+			return decodeChannels(b, 2, 16, 1)
+		},
+	}, nil
+}
@@ -0,0 +1,222 @@
+package audioanalyze
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+func TestSniffAudioFormat(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"RIFF\x00\x00\x00\x00WAVE":  "wav",
+		"FORM\x00\x00\x00\x00AIFF":  "aiff",
+		"FORM\x00\x00\x00\x00AIFC":  "aiff",
+		"fLaC\x00\x00\x00\x22abcd":  "flac",
+		"OggS\x00\x02\x00\x00abcd":  "ogg",
+		"ID3\x04\x00\x00\x00\x00ab": "mp3",
+		"\xff\xfb\x90\x64\x00\x00":  "mp3",
+		"not audio at all":          "",
+	}
+	for in, want := range cases {
+		if got := sniffAudioFormat([]byte(in)); got != want {
+			t.Errorf("sniffAudioFormat(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExtendedToFloat64(t *testing.T) {
+	t.Parallel()
+
+	// 44100 Hz as written by every AIFF encoder.
+	b := []byte{0x40, 0x0E, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}
+	if got := extendedToFloat64(b); got != 44100 {
+		t.Errorf("extendedToFloat64 = %v, want 44100", got)
+	}
+}
+
+func TestAnalyzeAIFFClickTrack(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "clicks_120.aif")
+	if err := os.WriteFile(path, clickAIFFBytes(t, 44100, 120, 4, "", 16), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AnalyzeFile(path, 120)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if got.Format != "aiff" || got.SampleRate != 44100 || got.Channels != 1 {
+		t.Errorf("format = %s %d %d", got.Format, got.SampleRate, got.Channels)
+	}
+	if math.Abs(got.EstimatedBPM-120) > 3 {
+		t.Errorf("estimated_bpm = %v, want ~120", got.EstimatedBPM)
+	}
+}
+
+func TestLoadAIFCEncodings(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		compression string
+		bits        int
+	}{{"sowt", 16}, {"fl32", 32}, {"NONE", 24}} {
+		audio, err := loadAudio(bytes.NewReader(clickAIFFBytes(t, 48000, 120, 1, tc.compression, tc.bits)))
+		if err != nil {
+			t.Fatalf("%s: loadAudio() error = %v", tc.compression, err)
+		}
+		if audio.sampleRate != 48000 || len(audio.mono) != 48000 {
+			t.Errorf("%s: rate/len = %d/%d", tc.compression, audio.sampleRate, len(audio.mono))
+		}
+		if math.Abs(audio.mono[0]-0.5) > 1e-3 || audio.mono[1000] != 0 {
+			t.Errorf("%s: samples = %v / %v, want 0.5 / 0", tc.compression, audio.mono[0], audio.mono[1000])
+		}
+	}
+}
+
+func TestLoadFLACClickTrack(t *testing.T) {
+	t.Parallel()
+
+	audio, err := loadAudio(bytes.NewReader(clickFLACBytes(t, 44100, 120, 2)))
+	if err != nil {
+		t.Fatalf("loadAudio() error = %v", err)
+	}
+	if audio.format != "flac" || audio.sampleRate != 44100 || len(audio.mono) != 88200 {
+		t.Errorf("audio = %s %d %d", audio.format, audio.sampleRate, len(audio.mono))
+	}
+	if math.Abs(audio.mono[0]-0.5) > 1e-3 || audio.mono[1000] != 0 {
+		t.Errorf("samples = %v / %v, want 0.5 / 0", audio.mono[0], audio.mono[1000])
+	}
+}
+
+func TestLoadAudioRejectsOgg(t *testing.T) {
+	t.Parallel()
+
+	if _, err := loadAudio(bytes.NewReader([]byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00"))); err == nil {
+		t.Fatal("expected ogg rejection")
+	}
+}
+
+// clickPCM returns half-scale 5 ms clicks on every beat.
+func clickPCM(sampleRate, bpm, seconds int) []float64 {
+	out := make([]float64, sampleRate*seconds)
+	interval := int(float64(sampleRate) * 60 / float64(bpm))
+	for i := 0; i < len(out); i += interval {
+		for j := i; j < i+sampleRate/200 && j < len(out); j++ {
+			out[j] = 0.5
+		}
+	}
+	return out
+}
+
+// clickAIFFBytes builds a mono click track as AIFF (compression "") or AIFC.
+func clickAIFFBytes(t *testing.T, sampleRate, bpm, seconds int, compression string, bits int) []byte {
+	t.Helper()
+	var data bytes.Buffer
+	for _, s := range clickPCM(sampleRate, bpm, seconds) {
+		switch {
+		case compression == "fl32":
+			_ = binary.Write(&data, binary.BigEndian, float32(s))
+		case compression == "sowt":
+			_ = binary.Write(&data, binary.LittleEndian, int16(s*32767))
+		case bits == 24:
+			v := int32(s * 8388607)
+			data.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		default:
+			_ = binary.Write(&data, binary.BigEndian, int16(s*32767))
+		}
+	}
+
+	var comm bytes.Buffer
+	_ = binary.Write(&comm, binary.BigEndian, uint16(1))
+	_ = binary.Write(&comm, binary.BigEndian, uint32(sampleRate*seconds))
+	_ = binary.Write(&comm, binary.BigEndian, uint16(bits))
+	comm.Write(float64ToExtended(float64(sampleRate)))
+	form := "AIFF"
+	if compression != "" {
+		form = "AIFC"
+		comm.WriteString(compression)
+		comm.Write([]byte{0, 0}) // empty pascal-string name, padded
+	}
+
+	var body bytes.Buffer
+	body.WriteString(form)
+	body.WriteString("COMM")
+	_ = binary.Write(&body, binary.BigEndian, uint32(comm.Len()))
+	body.Write(comm.Bytes())
+	body.WriteString("SSND")
+	_ = binary.Write(&body, binary.BigEndian, uint32(8+data.Len()))
+	_ = binary.Write(&body, binary.BigEndian, uint64(0)) // offset, block size
+	body.Write(data.Bytes())
+
+	var out bytes.Buffer
+	out.WriteString("FORM")
+	_ = binary.Write(&out, binary.BigEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func float64ToExtended(v float64) []byte {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, frac in [0.5, 1)
+	b := make([]byte, 10)
+	binary.BigEndian.PutUint16(b[0:2], uint16(exp-1+16383))
+	binary.BigEndian.PutUint64(b[2:10], uint64(frac*(1<<64)))
+	return b
+}
+
+// clickFLACBytes encodes a mono 16-bit click track with verbatim subframes.
+func clickFLACBytes(t *testing.T, sampleRate, bpm, seconds int) []byte {
+	t.Helper()
+	const blockSize = 4096
+	pcm := clickPCM(sampleRate, bpm, seconds)
+	info := &meta.StreamInfo{
+		BlockSizeMin:  blockSize,
+		BlockSizeMax:  blockSize,
+		SampleRate:    uint32(sampleRate),
+		NChannels:     1,
+		BitsPerSample: 16,
+		NSamples:      uint64(len(pcm)),
+	}
+	var out bytes.Buffer
+	enc, err := flac.NewEncoder(&out, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for num := 0; num*blockSize < len(pcm); num++ {
+		end := min((num+1)*blockSize, len(pcm))
+		samples := make([]int32, 0, blockSize)
+		for _, s := range pcm[num*blockSize : end] {
+			samples = append(samples, int32(s*32767))
+		}
+		f := &frame.Frame{
+			Header: frame.Header{
+				HasFixedBlockSize: true,
+				BlockSize:         uint16(len(samples)),
+				SampleRate:        uint32(sampleRate),
+				Channels:          frame.ChannelsMono,
+				BitsPerSample:     16,
+				Num:               uint64(num),
+			},
+			Subframes: []*frame.Subframe{{
+				SubHeader: frame.SubHeader{Pred: frame.PredVerbatim},
+				Samples:   samples,
+				NSamples:  len(samples),
+			}},
+		}
+		if err := enc.WriteFrame(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,108 @@
+package audioanalyze
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// drumLoop renders `bars` bars of 4/4 at bpm after offsetSec of silence:
+// kick on 1 and the and-of-3, snare on 2 and 4, hats on the other off-beat 8ths.
+func drumLoop(sampleRate int, bpm, offsetSec float64, bars int) []float64 {
+	beatSec := 60 / bpm
+	total := int((offsetSec + float64(bars*4+1)*beatSec) * float64(sampleRate))
+	out := make([]float64, total)
+	rng := rand.New(rand.NewSource(7))
+	hit := func(beat float64, gen func(t float64) float64) {
+		start := int((offsetSec + beat*beatSec) * float64(sampleRate))
+		for i := 0; i < sampleRate/8 && start+i < total; i++ {
+			out[start+i] += gen(float64(i) / float64(sampleRate))
+		}
+	}
+	for bar := 0; bar < bars; bar++ {
+		base := float64(bar * 4)
+		for _, b := range []float64{0, 2.5} {
+			hit(base+b, func(t float64) float64 { return 0.9 * math.Exp(-t*25) * math.Sin(2*math.Pi*55*t) })
+		}
+		for _, b := range []float64{1, 3} {
+			hit(base+b, func(t float64) float64 {
+				return math.Exp(-t*30) * (0.3*math.Sin(2*math.Pi*190*t) + 0.6*(2*rng.Float64()-1))
+			})
+		}
+		for _, b := range []float64{0.5, 1.5, 3.5} {
+			// Differenced noise tilts the energy toward the top octaves.
+			prev := 0.0
+			hit(base+b, func(t float64) float64 {
+				n := 2*rng.Float64() - 1
+				v := (n - prev) / 2
+				prev = n
+				return 0.6 * math.Exp(-t*60) * v
+			})
+		}
+	}
+	return out
+}
+
+func TestClassifyDrum(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		shape drumShape
+		want  string
+	}{
+		{drumShape{LowShare: 0.8, BodyShare: 0.2}, DrumKick},
+		{drumShape{LowShare: 0.02, BodyShare: 0.05, HighShare: 0.8, Flatness: 0.5}, DrumHat},
+		{drumShape{LowShare: 0.05, BodyShare: 0.3, HighShare: 0.5, Flatness: 0.4}, DrumSnare},
+		{drumShape{LowShare: 0.2, BodyShare: 0.8, Flatness: 0.01}, DrumOther},
+	}
+	for _, tc := range cases {
+		if got := classifyDrum(tc.shape); got != tc.want {
+			t.Errorf("classifyDrum(%+v) = %q, want %q", tc.shape, got, tc.want)
+		}
+	}
+}
+
+func TestExtractDrumHitsClassifiesLoop(t *testing.T) {
+	t.Parallel()
+
+	const rate = 44100
+	samples := drumLoop(rate, 120, 0.25, 4)
+	got, err := extractDrumHits(samples, rate, 120, nil)
+	if err != nil {
+		t.Fatalf("extractDrumHits() error = %v", err)
+	}
+	if math.Abs(got.FirstDownbeatSec-0.25) > 0.03 {
+		t.Errorf("first downbeat = %v, want ~0.25", got.FirstDownbeatSec)
+	}
+	want := map[float64]string{0: DrumKick, 0.5: DrumHat, 1: DrumSnare, 2.5: DrumKick, 3: DrumSnare, 3.5: DrumHat}
+	found := map[float64]string{}
+	for _, h := range got.Hits {
+		found[math.Round(h.Beat*2)/2] = h.Class
+		if off := h.Beat - math.Round(h.Beat*2)/2; math.Abs(off) > 0.06 {
+			t.Errorf("hit at beat %v is %v off the 8th grid", h.Beat, off)
+		}
+	}
+	for beat, class := range want {
+		if found[beat] != class {
+			t.Errorf("beat %v class = %q, want %q (hits %+v)", beat, found[beat], class, got.Hits)
+		}
+	}
+	if len(got.Hits) < 24 {
+		t.Errorf("hits = %d, want ~28", len(got.Hits))
+	}
+}
+
+func TestExtractDrumHitsExplicitDownbeatCountsPickups(t *testing.T) {
+	t.Parallel()
+
+	const rate = 44100
+	samples := drumLoop(rate, 120, 0, 2)
+	downbeat := 2.0 // bar 2
+	got, err := extractDrumHits(samples, rate, 120, &downbeat)
+	if err != nil {
+		t.Fatalf("extractDrumHits() error = %v", err)
+	}
+	if got.PickupHits < 6 || got.Hits[0].Beat != 0 || got.Hits[0].Class != DrumKick {
+		t.Errorf("pickups = %d first hit = %+v", got.PickupHits, got.Hits[0])
+	}
+}
@@ -0,0 +1,79 @@
+package audioanalyze
+
+import (
+	"math"
+	"testing"
+)
+
+// sine returns seconds of a sine at the given peak level in dBFS.
+func sine(sampleRate int, seconds, freq, dbfs, phase float64) []float64 {
+	amp := math.Pow(10, dbfs/20)
+	out := make([]float64, int(seconds*float64(sampleRate)))
+	for i := range out {
+		out[i] = amp * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)+phase)
+	}
+	return out
+}
+
+func TestMeasureLoudnessEBUReferenceTone(t *testing.T) {
+	t.Parallel()
+
+	// EBU Tech 3341: a stereo 1 kHz sine at -23 dBFS reads -23.0 LUFS.
+	for _, rate := range []int{44100, 48000} {
+		tone := sine(rate, 10, 1000, -23, 0)
+		got, ok := measureLoudness([][]float64{tone, tone}, rate)
+		if !ok {
+			t.Fatalf("%d Hz: measureLoudness() not ok", rate)
+		}
+		if math.Abs(got.IntegratedLUFS+23) > 0.1 {
+			t.Errorf("%d Hz: integrated = %v, want -23.0", rate, got.IntegratedLUFS)
+		}
+		if got.LoudnessRangeLU > 0.2 {
+			t.Errorf("%d Hz: LRA = %v, want ~0", rate, got.LoudnessRangeLU)
+		}
+		if math.Abs(got.MaxShortTermLUFS+23) > 0.1 || len(got.ShortTermLUFS) < 7 || len(got.MomentaryLUFS) < 9 {
+			t.Errorf("%d Hz: curves = %+v", rate, got)
+		}
+	}
+}
+
+func TestMeasureLoudnessRangeAndGating(t *testing.T) {
+	t.Parallel()
+
+	const rate = 48000
+	loud := sine(rate, 20, 1000, -20, 0)
+	quiet := sine(rate, 20, 1000, -30, 0)
+	silence := make([]float64, 5*rate)
+	signal := append(append(append([]float64{}, loud...), quiet...), silence...)
+	got, ok := measureLoudness([][]float64{signal, signal}, rate)
+	if !ok {
+		t.Fatal("measureLoudness() not ok")
+	}
+	// EBU Tech 3342 case 1: -20/-30 LUFS halves give LRA 10 LU.
+	if math.Abs(got.LoudnessRangeLU-10) > 1 {
+		t.Errorf("LRA = %v, want ~10", got.LoudnessRangeLU)
+	}
+	// Silence is gated out; the -30 part sits inside the -10 LU relative gate.
+	if got.IntegratedLUFS < -24 || got.IntegratedLUFS > -22 {
+		t.Errorf("integrated = %v, want around -22.6", got.IntegratedLUFS)
+	}
+	if got.ShortTermLUFS[len(got.ShortTermLUFS)-1] != loudnessFloorLUFS {
+		t.Errorf("silent tail = %v, want floor", got.ShortTermLUFS[len(got.ShortTermLUFS)-1])
+	}
+}
+
+func TestTruePeakFindsInterSamplePeak(t *testing.T) {
+	t.Parallel()
+
+	// A quarter-rate sine offset by 45 degrees never lands a sample on its
+	// crest: sample peak is -3 dB while the true peak is 0 dBTP.
+	const rate = 48000
+	tone := sine(rate, 1, rate/4, 0, math.Pi/4)
+	samplePeak, _ := levels(tone)
+	if sp := 20 * math.Log10(samplePeak); math.Abs(sp+3.01) > 0.05 {
+		t.Fatalf("sample peak = %v dBFS, want -3.01", sp)
+	}
+	if tp := truePeakDB([][]float64{tone}); math.Abs(tp) > 0.5 {
+		t.Errorf("true peak = %v dBTP, want ~0", tp)
+	}
+}
@@ -0,0 +1,96 @@
+package audioanalyze
+
+import (
+	"math"
+)
+
+// Features is the timbre and rhythm fingerprint compared by
+// FeatureDistance. Every value comes from a regular (non-streaming)
+// analysis of the file.
+type Features struct {
+	BrightnessHz  float64     `json:"brightness_hz" jsonschema:"description=Spectral centroid"`
+	CrestFactorDB float64     `json:"crest_factor_db" jsonschema:"description=Peak over RMS; high for punchy transients, low for sustained or limited sounds"`
+	Bands         BandBalance `json:"band_balance"`
+	DurationSec   float64     `json:"duration_sec"`
+	OnsetDensity  float64     `json:"onset_density" jsonschema:"description=Onsets per second over the analyzed audio"`
+}
+
+// featureWeights balance the axes FeatureDistance compares. Brightness and
+// duration compare as ratios (two octaves / doublings per unit), onset density
+// as a log ratio, crest in 12 dB steps and band shares in halves, so a
+// difference of 1 on any axis is about "clearly a different sound".
+var featureWeights = struct {
+	brightness, crest, bands, duration, density float64
+}{brightness: 1.0, crest: 0.8, bands: 1.2, duration: 0.6, density: 0.8}
+
+// FeaturesOf extracts the comparison features from an analysis result.
+func FeaturesOf(r Result) Features {
+	f := Features{
+		BrightnessHz:  r.BrightnessHz,
+		CrestFactorDB: r.CrestFactorDB,
+		DurationSec:   round2(r.DurationSec),
+	}
+	if r.BandBalance != nil {
+		f.Bands = *r.BandBalance
+	}
+	// Onsets are only detected in the first maxOnsetSec.
+	analyzed := math.Min(r.DurationSec, maxOnsetSec)
+	if analyzed > 0 {
+		f.OnsetDensity = round2(float64(r.OnsetCount) / analyzed)
+	}
+	return f
+}
+
+// FeatureDistance is the weighted Euclidean distance between two feature
+// sets on the scaled axes described at featureWeights; 0 means identical.
+func FeatureDistance(a, b Features) float64 {
+	w := featureWeights
+	terms := []float64{
+		w.brightness * (octaves(a.BrightnessHz, 20) - octaves(b.BrightnessHz, 20)) / 2,
+		w.crest * (a.CrestFactorDB - b.CrestFactorDB) / 12,
+		w.bands * (a.Bands.Low - b.Bands.Low) * 2,
+		w.bands * (a.Bands.Mid - b.Bands.Mid) * 2,
+		w.bands * (a.Bands.High - b.Bands.High) * 2,
+		w.duration * (octaves(a.DurationSec, 0.05) - octaves(b.DurationSec, 0.05)) / 2,
+		w.density * (math.Log2(1+a.OnsetDensity) - math.Log2(1+b.OnsetDensity)),
+	}
+	sum := 0.0
+	for _, t := range terms {
+		sum += t * t
+	}
+	return math.Sqrt(sum)
+}
+
+// Similarity maps a FeatureDistance onto 0..1, where 1 is identical.
+func Similarity(distance float64) float64 {
+	return math.Round(math.Exp(-distance)*1000) / 1000
+}
+
+// octaves is log2(v) with v raised to floor so silence and zero durations
+// stay finite.
+func octaves(v, floor float64) float64 {
+	return math.Log2(math.Max(v, floor))
+}
+
+// FeatureResult is the outcome of extracting features from one file.
+type FeatureResult struct {
+	Path     string
+	Features Features
+	Cached   bool
+	Err      error
+}
+
+// ExtractFeatures analyzes paths concurrently (workers as in
+// FolderOptions.Workers) and returns one result per path in input order.
+// Per-file failures are reported in FeatureResult.Err.
+func ExtractFeatures(paths []string, workers int, cache *Cache) []FeatureResult {
+	out := make([]FeatureResult, len(paths))
+	runWorkers(len(paths), workers, func(i int) {
+		got, cached, err := analyzeMaybeCached(cache, paths[i], AnalyzeOptions{})
+		out[i] = FeatureResult{Path: paths[i], Cached: cached, Err: err}
+		if err == nil {
+			out[i].Features = FeaturesOf(got)
+		}
+	})
+	return out
+}
@@ -0,0 +1,78 @@
+package audioanalyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFeatureDistance(t *testing.T) {
+	t.Parallel()
+
+	kick := Features{BrightnessHz: 300, CrestFactorDB: 14, Bands: BandBalance{Low: 0.8, Mid: 0.15, High: 0.05}, DurationSec: 0.4, OnsetDensity: 2.5}
+	otherKick := Features{BrightnessHz: 360, CrestFactorDB: 13, Bands: BandBalance{Low: 0.75, Mid: 0.2, High: 0.05}, DurationSec: 0.5, OnsetDensity: 2}
+	hat := Features{BrightnessHz: 7000, CrestFactorDB: 16, Bands: BandBalance{Low: 0.02, Mid: 0.18, High: 0.8}, DurationSec: 0.15, OnsetDensity: 6}
+	pad := Features{BrightnessHz: 900, CrestFactorDB: 5, Bands: BandBalance{Low: 0.3, Mid: 0.6, High: 0.1}, DurationSec: 8, OnsetDensity: 0.1}
+
+	if d := FeatureDistance(kick, kick); d != 0 {
+		t.Errorf("self distance = %v, want 0", d)
+	}
+	if FeatureDistance(kick, hat) != FeatureDistance(hat, kick) {
+		t.Error("distance is not symmetric")
+	}
+	near := FeatureDistance(kick, otherKick)
+	if near >= FeatureDistance(kick, hat) || near >= FeatureDistance(kick, pad) {
+		t.Errorf("kick-kick %.3f should beat kick-hat %.3f and kick-pad %.3f",
+			near, FeatureDistance(kick, hat), FeatureDistance(kick, pad))
+	}
+	if s := Similarity(0); s != 1 {
+		t.Errorf("Similarity(0) = %v", s)
+	}
+}
+
+func TestExtractFeaturesRanksClicksTogether(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ref := filepath.Join(dir, "ref_120.wav")
+	writeClickWAV(t, ref, 44100, 120, 4)
+	clicks := filepath.Join(dir, "clicks_124.wav")
+	writeClickWAV(t, clicks, 44100, 124, 4)
+	bass := filepath.Join(dir, "bass_drone.wav")
+	if err := os.WriteFile(bass, monoWAVBytes(t, tones(44100, 4, 55, 110), 44100), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.wav")
+	if err := os.WriteFile(broken, []byte("not audio"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := ExtractFeatures([]string{ref, clicks, bass, broken}, 3, cache)
+	if len(got) != 4 || got[0].Path != ref || got[3].Path != broken {
+		t.Fatalf("results out of order: %+v", got)
+	}
+	for _, r := range got[:3] {
+		if r.Err != nil {
+			t.Fatalf("%s: %v", r.Path, r.Err)
+		}
+	}
+	if got[3].Err == nil {
+		t.Error("expected an error for the broken file")
+	}
+	if got[0].Features.OnsetDensity <= got[2].Features.OnsetDensity {
+		t.Errorf("click density %.2f should exceed drone density %.2f", got[0].Features.OnsetDensity, got[2].Features.OnsetDensity)
+	}
+	ref0 := got[0].Features
+	if FeatureDistance(ref0, got[1].Features) >= FeatureDistance(ref0, got[2].Features) {
+		t.Errorf("clicks should be nearer than the drone: %+v vs %+v vs %+v", ref0, got[1].Features, got[2].Features)
+	}
+
+	again := ExtractFeatures([]string{ref}, 1, cache)
+	if !again[0].Cached || again[0].Features != ref0 {
+		t.Errorf("second extraction = %+v, want cached identical features", again[0])
+	}
+}
@@ -0,0 +1,286 @@
+package audioanalyze
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Fractional-octave spectrum analysis for mixing decisions. The long FFT frame
+// trades time resolution for the ~5 Hz bins the 25 Hz third-octave band needs.
+
+const (
+	spectrumFrameSize     = 8192
+	spectrumHopSize       = 4096
+	spectrumMaxFrames     = 400
+	spectrumMinHz         = 24.0    // keeps the nominal 25 Hz band (24.8 Hz base-2 center)
+	spectrumMaxHz         = 20200.0 // keeps the nominal 20 kHz band (20.16 kHz)
+	tiltMinHz             = 50.0
+	tiltMaxHz             = 16000.0
+	resonanceMinHz        = 40.0
+	resonanceMaxHz        = 16000.0
+	resonanceProminenceDB = 6.0
+	maxResonances         = 5
+	maxSectionSpectra     = 12
+)
+
+// Spectrum resolutions accepted by AnalyzeOptions.Spectrum.
+const (
+	SpectrumThirdOctave = "third_octave"
+	SpectrumSixthOctave = "sixth_octave"
+)
+
+// Spectrum is an averaged fractional-octave spectrum. Band levels are dB
+// relative to the file's total energy, so two files compare by tonal balance
+// rather than loudness.
+type Spectrum struct {
+	Resolution      string            `json:"resolution"`
+	Bands           []SpectrumBand    `json:"bands"`
+	TiltDBPerOctave float64           `json:"tilt_db_per_octave" jsonschema:"description=Slope of band levels vs octave; 0 = pink-noise flat, more negative = darker"`
+	Resonances      []Resonance       `json:"resonances,omitempty" jsonschema:"description=Narrow peaks that stick out of the smoothed spectrum"`
+	Sections        []SectionSpectrum `json:"sections,omitempty"`
+}
+
+type SpectrumBand struct {
+	CenterHz float64 `json:"center_hz"`
+	LevelDB  float64 `json:"level_db"`
+}
+
+type Resonance struct {
+	FrequencyHz  float64 `json:"frequency_hz"`
+	ProminenceDB float64 `json:"prominence_db" jsonschema:"description=Height above the 1/3-octave smoothed spectrum"`
+}
+
+// SectionSpectrum is the band levels (same centers as Spectrum.Bands) for
+// one detected section.
+type SectionSpectrum struct {
+	StartSec        float64   `json:"start_sec"`
+	DurationSec     float64   `json:"duration_sec"`
+	Label           string    `json:"label"`
+	LevelsDB        []float64 `json:"levels_db"`
+	TiltDBPerOctave float64   `json:"tilt_db_per_octave"`
+}
+
+// normalizeSpectrumResolution maps user input onto a resolution constant; ""
+// means no spectrum.
+func normalizeSpectrumResolution(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "none":
+		return "", nil
+	case "third", "third_octave", "1/3":
+		return SpectrumThirdOctave, nil
+	case "sixth", "sixth_octave", "1/6":
+		return SpectrumSixthOctave, nil
+	default:
+		return "", fmt.Errorf("spectrum must be third_octave or sixth_octave (got %q)", raw)
+	}
+}
+
+// analyzeSpectrum builds the whole-file spectrum plus one per section.
+func analyzeSpectrum(samples []float64, sampleRate int, resolution string, sections []Section) (Spectrum, error) {
+	perOctave := 3
+	if resolution == SpectrumSixthOctave {
+		perOctave = 6
+	}
+	power, ok := averagePowerSpectrum(samples)
+	if !ok || sampleRate <= 0 {
+		return Spectrum{}, errors.New("audio too short for spectrum analysis")
+	}
+	centers := fractionalOctaveCenters(perOctave, float64(sampleRate))
+	levels := bandLevelsDB(power, sampleRate, centers, perOctave)
+	out := Spectrum{
+		Resolution:      resolution,
+		TiltDBPerOctave: round2(spectralTilt(centers, levels)),
+		Resonances:      findResonances(power, sampleRate),
+	}
+	for i, c := range centers {
+		out.Bands = append(out.Bands, SpectrumBand{CenterHz: round1(c), LevelDB: round1(levels[i])})
+	}
+
+	for _, sec := range sections {
+		if len(out.Sections) >= maxSectionSpectra {
+			break
+		}
+		start := int(sec.StartSec * float64(sampleRate))
+		end := min(len(samples), start+int(sec.DurationSec*float64(sampleRate)))
+		if start >= end {
+			continue
+		}
+		secPower, ok := averagePowerSpectrum(samples[start:end])
+		if !ok {
+			continue
+		}
+		secLevels := bandLevelsDB(secPower, sampleRate, centers, perOctave)
+		rounded := make([]float64, len(secLevels))
+		for i, l := range secLevels {
+			rounded[i] = round1(l)
+		}
+		out.Sections = append(out.Sections, SectionSpectrum{
+			StartSec:        sec.StartSec,
+			DurationSec:     sec.DurationSec,
+			Label:           sec.Label,
+			LevelsDB:        rounded,
+			TiltDBPerOctave: round2(spectralTilt(centers, secLevels)),
+		})
+	}
+	return out, nil
+}
+
+// averagePowerSpectrum is the mean Hann-windowed power per FFT bin over up to
+// spectrumMaxFrames frames spread across the signal.
+func averagePowerSpectrum(samples []float64) ([]float64, bool) {
+	if len(samples) < spectrumFrameSize {
+		return nil, false
+	}
+	window := hannWindow(spectrumFrameSize)
+	buf := make([]float64, spectrumFrameSize)
+	power := make([]float64, spectrumFrameSize/2)
+	frameCount := (len(samples)-spectrumFrameSize)/spectrumHopSize + 1
+	stride := max(1, frameCount/spectrumMaxFrames)
+	frames := 0
+	for frame := 0; frame < frameCount; frame += stride {
+		start := frame * spectrumHopSize
+		for i := range buf {
+			buf[i] = samples[start+i] * window[i]
+		}
+		re, im := fftReal(buf)
+		for bin := range power {
+			power[bin] += re[bin]*re[bin] + im[bin]*im[bin]
+		}
+		frames++
+	}
+	var total float64
+	for bin := range power {
+		power[bin] /= float64(frames)
+		total += power[bin]
+	}
+	return power, total > 1e-18
+}
+
+// fractionalOctaveCenters returns base-2 band centers anchored at 1 kHz
+// between spectrumMinHz and min(spectrumMaxHz, Nyquist).
+func fractionalOctaveCenters(perOctave int, sampleRate float64) []float64 {
+	top := math.Min(spectrumMaxHz, sampleRate/2/math.Pow(2, 1/float64(2*perOctave)))
+	var out []float64
+	for k := int(math.Ceil(math.Log2(spectrumMinHz/1000) * float64(perOctave))); ; k++ {
+		c := 1000 * math.Pow(2, float64(k)/float64(perOctave))
+		if c > top {
+			break
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// bandLevelsDB sums bin power inside each band (edges at center·2^(±1/2N))
+// and reports it in dB relative to the total. Bands narrower than a bin take
+// the power density of the nearest bin scaled to the band width.
+func bandLevelsDB(power []float64, sampleRate int, centers []float64, perOctave int) []float64 {
+	binHz := float64(sampleRate) / spectrumFrameSize
+	var total float64
+	for _, p := range power[1:] {
+		total += p
+	}
+	half := math.Pow(2, 1/float64(2*perOctave))
+	out := make([]float64, len(centers))
+	for i, c := range centers {
+		lo, hi := c/half, c*half
+		var sum float64
+		hits := 0
+		for bin := int(math.Ceil(lo / binHz)); bin < len(power) && float64(bin)*binHz < hi; bin++ {
+			if bin < 1 {
+				continue
+			}
+			sum += power[bin]
+			hits++
+		}
+		if hits == 0 {
+			nearest := min(len(power)-1, max(1, int(math.Round(c/binHz))))
+			sum = power[nearest] * (hi - lo) / binHz
+		}
+		out[i] = 10 * math.Log10(math.Max(sum/total, 1e-12))
+	}
+	return out
+}
+
+// spectralTilt fits band level against log2(frequency) over tiltMinHz..tiltMaxHz.
+// Fractional-octave bands of pink noise carry equal power, so 0 means pink.
+func spectralTilt(centers, levels []float64) float64 {
+	var xs, ys []float64
+	for i, c := range centers {
+		if c >= tiltMinHz && c <= tiltMaxHz && levels[i] > -100 {
+			xs = append(xs, math.Log2(c))
+			ys = append(ys, levels[i])
+		}
+	}
+	if len(xs) < 2 {
+		return 0
+	}
+	mx, my := mean(xs), mean(ys)
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - mx) * (ys[i] - my)
+		den += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// findResonances looks for bins that rise well above a 1/3-octave smoothed
+// version of the spectrum — the narrow boosts an engineer would notch out.
+func findResonances(power []float64, sampleRate int) []Resonance {
+	binHz := float64(sampleRate) / spectrumFrameSize
+	db := make([]float64, len(power))
+	for i, p := range power {
+		db[i] = 10 * math.Log10(math.Max(p, 1e-20))
+	}
+	// Prefix sums of dB make the variable-width smoothing window O(1) per bin.
+	prefix := make([]float64, len(db)+1)
+	for i, v := range db {
+		prefix[i+1] = prefix[i] + v
+	}
+	sixth := math.Pow(2, 1.0/6)
+	var found []Resonance
+	for bin := 2; bin < len(db)-1; bin++ {
+		freq := float64(bin) * binHz
+		if freq < resonanceMinHz || freq > resonanceMaxHz {
+			continue
+		}
+		if db[bin] < db[bin-1] || db[bin] < db[bin+1] {
+			continue
+		}
+		lo := max(1, int(freq/sixth/binHz))
+		hi := min(len(db)-1, int(freq*sixth/binHz)+1)
+		if hi-lo < 4 {
+			// Too few bins to tell a resonance from the local slope.
+			continue
+		}
+		smoothed := (prefix[hi+1] - prefix[lo]) / float64(hi-lo+1)
+		if prom := db[bin] - smoothed; prom >= resonanceProminenceDB {
+			found = append(found, Resonance{FrequencyHz: round1(freq), ProminenceDB: round1(prom)})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ProminenceDB > found[j].ProminenceDB })
+	// Keep the strongest peak per 1/6 octave.
+	var out []Resonance
+	for _, r := range found {
+		clash := false
+		for _, kept := range out {
+			if r.FrequencyHz/kept.FrequencyHz < sixth && kept.FrequencyHz/r.FrequencyHz < sixth {
+				clash = true
+				break
+			}
+		}
+		if !clash {
+			out = append(out, r)
+		}
+		if len(out) >= maxResonances {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package audioanalyze
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func whiteNoise(sampleRate int, seconds, amp float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]float64, int(seconds*float64(sampleRate)))
+	for i := range out {
+		out[i] = amp * (2*rng.Float64() - 1)
+	}
+	return out
+}
+
+func TestFractionalOctaveCenters(t *testing.T) {
+	t.Parallel()
+
+	third := fractionalOctaveCenters(3, 48000)
+	if len(third) != 30 || math.Abs(third[0]-24.8) > 0.1 {
+		t.Errorf("third-octave centers = %d starting %v, want 30 from 24.8", len(third), third[0])
+	}
+	if sixth := fractionalOctaveCenters(6, 48000); len(sixth) != 59 {
+		t.Errorf("sixth-octave centers = %d, want 59", len(sixth))
+	}
+}
+
+func TestAnalyzeSpectrumTiltAndResonance(t *testing.T) {
+	t.Parallel()
+
+	const rate = 44100
+	noise := whiteNoise(rate, 4, 0.3, 1)
+	spec, err := analyzeSpectrum(noise, rate, SpectrumThirdOctave, nil)
+	if err != nil {
+		t.Fatalf("analyzeSpectrum() error = %v", err)
+	}
+	// White noise gains 3 dB per octave in fractional-octave bands.
+	if math.Abs(spec.TiltDBPerOctave-3) > 0.5 {
+		t.Errorf("white-noise tilt = %v, want ~3", spec.TiltDBPerOctave)
+	}
+	if len(spec.Resonances) != 0 {
+		t.Errorf("white noise resonances = %+v, want none", spec.Resonances)
+	}
+
+	tone := sine(rate, 4, 1000, -12, 0)
+	for i := range noise {
+		noise[i] += tone[i]
+	}
+	spec, err = analyzeSpectrum(noise, rate, SpectrumSixthOctave, []Section{
+		{StartSec: 0, DurationSec: 2, Label: "low"},
+		{StartSec: 2, DurationSec: 2, Label: "high"},
+	})
+	if err != nil {
+		t.Fatalf("analyzeSpectrum() error = %v", err)
+	}
+	if len(spec.Resonances) == 0 || math.Abs(spec.Resonances[0].FrequencyHz-1000) > 10 {
+		t.Errorf("resonances = %+v, want a peak at ~1 kHz", spec.Resonances)
+	}
+	if spec.Resolution != SpectrumSixthOctave || len(spec.Sections) != 2 || len(spec.Sections[0].LevelsDB) != len(spec.Bands) {
+		t.Errorf("spectrum shape = %s, %d sections", spec.Resolution, len(spec.Sections))
+	}
+}
+
+func TestNormalizeSpectrumResolution(t *testing.T) {
+	t.Parallel()
+
+	if got, _ := normalizeSpectrumResolution("1/3"); got != SpectrumThirdOctave {
+		t.Errorf("1/3 = %q", got)
+	}
+	if got, _ := normalizeSpectrumResolution(""); got != "" {
+		t.Errorf("empty = %q", got)
+	}
+	if _, err := normalizeSpectrumResolution("octave"); err == nil {
+		t.Error("expected error for unsupported resolution")
+	}
+}
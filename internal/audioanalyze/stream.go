@@ -0,0 +1,264 @@
+package audioanalyze
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+// Streaming analysis for files too long to hold in memory (DJ mixes, full
+// stems). Audio is decoded chunk by chunk; loudness and levels accumulate over
+// the whole file while tempo and key are estimated per window, so memory stays
+// at one window of mono samples regardless of length.
+
+const (
+	streamWindowSec     = 30.0
+	minStreamWindowSec  = 5.0 // shorter tails join the previous window
+	streamTempoTolerant = 0.03
+	maxStreamWindows    = 6 * 60 * 60 / int(streamWindowSec) // 6 hours
+)
+
+// Segment is a stretch of a streamed file with a steady tempo and key:
+// consecutive windows are merged while both agree, so a DJ mix reports one
+// segment per track (or per key change) rather than per window.
+type Segment struct {
+	StartSec      float64 `json:"start_sec"`
+	DurationSec   float64 `json:"duration_sec"`
+	BPM           float64 `json:"bpm,omitempty"`
+	BPMConfidence float64 `json:"bpm_confidence,omitempty"`
+	Key           string  `json:"key,omitempty"`
+	Scale         string  `json:"scale,omitempty"`
+	KeyConfidence float64 `json:"key_confidence,omitempty"`
+	LoudnessLUFS  float64 `json:"loudness_lufs"`
+	Windows       int     `json:"windows"`
+}
+
+// analyzeStreaming decodes r chunk by chunk and reports whole-file levels and
+// loudness plus per-segment tempo and key. Onsets, chords, beat grid, texture
+// and spectrum need the whole signal in memory and are left empty.
+func analyzeStreaming(r io.Reader, projectTempo float64) (Result, error) {
+	stream, err := openAudioStream(r)
+	if err != nil {
+		return Result{}, err
+	}
+	return analyzeAudioStream(stream, projectTempo)
+}
+
+// analyzeAudioStream is analyzeStreaming on an opened stream.
+func analyzeAudioStream(stream audioStream, projectTempo float64) (Result, error) {
+	sampleRate := stream.sampleRate()
+	channels := stream.channels()
+	if sampleRate <= 0 || channels <= 0 {
+		return Result{}, errors.New("invalid audio stream format")
+	}
+
+	meterChans := min(channels, 2)
+	meter := newLoudnessMeter(meterChans, sampleRate)
+	windowFrames := int(streamWindowSec * float64(sampleRate))
+	window := make([]float64, 0, windowFrames)
+	var (
+		windows   []Segment
+		frames    int
+		peak      float64
+		sumSquare float64
+	)
+	flush := func() {
+		if len(window) == 0 {
+			return
+		}
+		start := float64(frames-len(window)) / float64(sampleRate)
+		windows = addWindow(windows, window, sampleRate, start)
+		window = window[:0]
+	}
+
+	for {
+		chunk, err := stream.read(min(streamChunkFrames, windowFrames-len(window)))
+		if len(chunk) > 0 && len(chunk[0]) > 0 {
+			mono := downmix(chunk)
+			for _, s := range mono {
+				peak = math.Max(peak, math.Abs(s))
+				sumSquare += s * s
+			}
+			meter.add(chunk[:meterChans])
+			window = append(window, mono...)
+			frames += len(mono)
+			if len(window) >= windowFrames {
+				flush()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if frames > 0 {
+				break // keep what decoded before a corrupt tail
+			}
+			return Result{}, err
+		}
+	}
+	flush()
+	if frames == 0 {
+		return Result{}, errors.New("no audio samples decoded")
+	}
+
+	duration := float64(frames) / float64(sampleRate)
+	out := Result{
+		Format:            stream.format(),
+		DurationSec:       duration,
+		SampleRate:        sampleRate,
+		Channels:          channels,
+		PeakLevel:         peak,
+		RMSLevel:          math.Sqrt(sumSquare / float64(frames)),
+		SuggestedWarpMode: "complex",
+		Streamed:          true,
+	}
+	if loudness, ok := meter.result(); ok {
+		// Per-second curves grow without bound on long mixes; segments carry
+		// their own loudness instead.
+		loudness.MomentaryLUFS = nil
+		loudness.ShortTermLUFS = nil
+		out.Loudness = &loudness
+	}
+	out.Segments = mergeSegments(windows)
+	for i := range out.Segments {
+		seg := &out.Segments[i]
+		seg.LoudnessLUFS = meter.integratedBetween(seg.StartSec, seg.StartSec+seg.DurationSec)
+	}
+	out.EstimatedBPM, out.BPMConfidence = dominantTempo(windows)
+	out.BPMAlternatives = bpmAlternatives(out.EstimatedBPM, out.BPMConfidence)
+	out.Key, out.Scale, out.KeyConfidence = dominantKey(windows)
+	if projectTempo > 0 {
+		out.LengthBarsAtBPM = duration * (projectTempo / 60) / 4
+	}
+	return out, nil
+}
+
+// addWindow analyzes one window starting at startSec and appends it. A tail
+// shorter than minStreamWindowSec extends the previous window instead.
+func addWindow(windows []Segment, samples []float64, sampleRate int, startSec float64) []Segment {
+	dur := float64(len(samples)) / float64(sampleRate)
+	if dur < minStreamWindowSec && len(windows) > 0 {
+		windows[len(windows)-1].DurationSec += dur
+	} else if len(windows) < maxStreamWindows {
+		windows = append(windows, analyzeWindow(samples, sampleRate, startSec, dur))
+	}
+	return windows
+}
+
+// decodedWindows cuts audio already in memory into the same windows the
+// streaming pass uses.
+func decodedWindows(mono []float64, sampleRate int) []Segment {
+	size := int(streamWindowSec * float64(sampleRate))
+	var windows []Segment
+	for start := 0; start < len(mono); start += size {
+		end := min(start+size, len(mono))
+		windows = addWindow(windows, mono[start:end], sampleRate, float64(start)/float64(sampleRate))
+	}
+	return windows
+}
+
+// analyzeWindow estimates tempo and key for one window of mono samples.
+func analyzeWindow(samples []float64, sampleRate int, startSec, durationSec float64) Segment {
+	seg := Segment{StartSec: round2(startSec), DurationSec: durationSec, Windows: 1}
+	seg.BPM, seg.BPMConfidence = estimateBPM(samples, sampleRate)
+	if key, ok := estimateKey(samples, sampleRate); ok {
+		seg.Key, seg.Scale, seg.KeyConfidence = key.Tonic, key.Scale, key.Confidence
+	}
+	return seg
+}
+
+// mergeSegments joins consecutive windows that share key and scale and whose
+// tempos agree within streamTempoTolerant. BPM and confidences of a merged
+// segment are duration-weighted averages.
+func mergeSegments(windows []Segment) []Segment {
+	var out []Segment
+	for _, w := range windows {
+		if n := len(out); n > 0 && sameTempoKey(out[n-1], w) {
+			last := &out[n-1]
+			total := last.DurationSec + w.DurationSec
+			last.BPM = (last.BPM*last.DurationSec + w.BPM*w.DurationSec) / total
+			last.BPMConfidence = (last.BPMConfidence*last.DurationSec + w.BPMConfidence*w.DurationSec) / total
+			last.KeyConfidence = (last.KeyConfidence*last.DurationSec + w.KeyConfidence*w.DurationSec) / total
+			last.DurationSec = total
+			last.Windows += w.Windows
+			continue
+		}
+		out = append(out, w)
+	}
+	for i := range out {
+		out[i].DurationSec = round2(out[i].DurationSec)
+		out[i].BPM = round1(out[i].BPM)
+		out[i].BPMConfidence = round2(out[i].BPMConfidence)
+		out[i].KeyConfidence = round2(out[i].KeyConfidence)
+	}
+	return out
+}
+
+func sameTempoKey(a, b Segment) bool {
+	if a.Key != b.Key || a.Scale != b.Scale {
+		return false
+	}
+	if a.BPM <= 0 || b.BPM <= 0 {
+		return a.BPM == b.BPM
+	}
+	return math.Abs(a.BPM-b.BPM)/a.BPM <= streamTempoTolerant
+}
+
+// dominantTempo is the duration-weighted median window tempo; confidence is
+// the share of audio whose window agrees with it.
+func dominantTempo(windows []Segment) (float64, float64) {
+	type weighted struct{ bpm, dur float64 }
+	var tempos []weighted
+	var total float64
+	for _, w := range windows {
+		if w.BPM > 0 {
+			tempos = append(tempos, weighted{w.BPM, w.DurationSec})
+			total += w.DurationSec
+		}
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	sort.Slice(tempos, func(i, j int) bool { return tempos[i].bpm < tempos[j].bpm })
+	var acc, median float64
+	for _, t := range tempos {
+		acc += t.dur
+		if acc >= total/2 {
+			median = t.bpm
+			break
+		}
+	}
+	var agree float64
+	for _, t := range tempos {
+		if math.Abs(t.bpm-median)/median <= streamTempoTolerant {
+			agree += t.dur
+		}
+	}
+	return round1(median), round2(agree / total)
+}
+
+// dominantKey votes across windows by duration times key confidence.
+func dominantKey(windows []Segment) (string, string, float64) {
+	votes := map[[2]string]float64{}
+	var total float64
+	for _, w := range windows {
+		if w.Key == "" {
+			continue
+		}
+		weight := w.DurationSec * w.KeyConfidence
+		votes[[2]string{w.Key, w.Scale}] += weight
+		total += weight
+	}
+	var best [2]string
+	var bestWeight float64
+	for k, v := range votes {
+		if v > bestWeight || (v == bestWeight && k[0]+k[1] < best[0]+best[1]) {
+			best, bestWeight = k, v
+		}
+	}
+	if total == 0 {
+		return "", "", 0
+	}
+	return best[0], best[1], round2(bestWeight / total)
+}
@@ -0,0 +1,206 @@
+package audioanalyze
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// monoWAVBytes encodes samples in [-1, 1] as a mono 16-bit WAV.
+func monoWAVBytes(t *testing.T, samples []float64, sampleRate int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dataBytes := len(samples) * 2
+	buf.WriteString("RIFF")
+	write(uint32(36 + dataBytes))
+	buf.WriteString("WAVEfmt ")
+	write(uint32(16))
+	write(uint16(1))
+	write(uint16(1))
+	write(uint32(sampleRate))
+	write(uint32(sampleRate * 2))
+	write(uint16(2))
+	write(uint16(16))
+	buf.WriteString("data")
+	write(uint32(dataBytes))
+	for _, s := range samples {
+		write(int16(math.Max(-1, math.Min(1, s)) * 32767))
+	}
+	return buf.Bytes()
+}
+
+// tonalClicks is a sustained triad under beat clicks.
+func tonalClicks(sampleRate, bpm, seconds int, freqs ...float64) []float64 {
+	out := tones(sampleRate, seconds, freqs...)
+	clicks := clickPCM(sampleRate, bpm, seconds)
+	for i := range out {
+		out[i] = 0.4*out[i] + clicks[i]
+	}
+	return out
+}
+
+func TestLoudnessMeterChunkedMatchesWhole(t *testing.T) {
+	t.Parallel()
+
+	const rate = 48000
+	left := sine(rate, 5, 997, -12, 0)
+	right := sine(rate, 5, 3000, -9, 0.3)
+	whole, ok := measureLoudness([][]float64{left, right}, rate)
+	if !ok {
+		t.Fatal("measureLoudness() not ok")
+	}
+	m := newLoudnessMeter(2, rate)
+	for start, step := 0, 1; start < len(left); step = step*3 + 7 {
+		end := min(len(left), start+step)
+		m.add([][]float64{left[start:end], right[start:end]})
+		start = end
+	}
+	chunked, ok := m.result()
+	if !ok {
+		t.Fatal("chunked result not ok")
+	}
+	if chunked.IntegratedLUFS != whole.IntegratedLUFS || chunked.TruePeakDBTP != whole.TruePeakDBTP ||
+		chunked.LoudnessRangeLU != whole.LoudnessRangeLU || len(chunked.ShortTermLUFS) != len(whole.ShortTermLUFS) {
+		t.Errorf("chunked = %+v, whole = %+v", chunked, whole)
+	}
+}
+
+func TestAnalyzeStreamingSegmentsTempoAndKey(t *testing.T) {
+	t.Parallel()
+
+	const rate = 22050
+	first := tonalClicks(rate, 120, 60, 261.63, 329.63, 392.00) // C major
+	second := tonalClicks(rate, 96, 60, 369.99, 466.16, 554.37) // F# major
+	wav := monoWAVBytes(t, append(first, second...), rate)
+
+	got, err := analyzeStreaming(bytes.NewReader(wav), 120)
+	if err != nil {
+		t.Fatalf("analyzeStreaming() error = %v", err)
+	}
+	if !got.Streamed || math.Abs(got.DurationSec-120) > 0.01 || got.LengthBarsAtBPM != 60 {
+		t.Errorf("result = %+v", got)
+	}
+	if len(got.Segments) != 2 {
+		t.Fatalf("segments = %+v, want 2", got.Segments)
+	}
+	a, b := got.Segments[0], got.Segments[1]
+	if a.Key != "C" || a.Scale != "major" || math.Abs(a.BPM-120) > 3 || a.Windows != 2 {
+		t.Errorf("first segment = %+v", a)
+	}
+	if b.Key != "F#" || math.Abs(b.BPM-96) > 3 || b.StartSec != 60 {
+		t.Errorf("second segment = %+v", b)
+	}
+	if got.Loudness == nil || got.Loudness.MomentaryLUFS != nil || a.LoudnessLUFS >= 0 {
+		t.Errorf("loudness = %+v, segment lufs %v", got.Loudness, a.LoudnessLUFS)
+	}
+}
+
+func TestMergeSegmentsAndDominantTempo(t *testing.T) {
+	t.Parallel()
+
+	windows := []Segment{
+		{StartSec: 0, DurationSec: 30, BPM: 124, BPMConfidence: 0.8, Key: "A", Scale: "minor", KeyConfidence: 0.7, Windows: 1},
+		{StartSec: 30, DurationSec: 30, BPM: 124.5, BPMConfidence: 0.6, Key: "A", Scale: "minor", KeyConfidence: 0.5, Windows: 1},
+		{StartSec: 60, DurationSec: 12, BPM: 132, BPMConfidence: 0.9, Key: "D", Scale: "minor", KeyConfidence: 0.9, Windows: 1},
+	}
+	merged := mergeSegments(windows)
+	if len(merged) != 2 || merged[0].DurationSec != 60 || merged[0].BPM != 124.3 || merged[0].KeyConfidence != 0.6 {
+		t.Errorf("merged = %+v", merged)
+	}
+	if bpm, conf := dominantTempo(windows); bpm != 124.5 || conf != 0.83 {
+		t.Errorf("dominantTempo = %v, %v", bpm, conf)
+	}
+	if key, scale, _ := dominantKey(windows); key != "A" || scale != "minor" {
+		t.Errorf("dominantKey = %s %s", key, scale)
+	}
+}
+
+func TestAnalyzeFileStreamsPastDecodeLimit(t *testing.T) {
+	t.Parallel()
+
+	const rate = 8000
+	seconds := maxDecodedSeconds + 60
+	path := filepath.Join(t.TempDir(), "long.wav")
+	if err := os.WriteFile(path, monoWAVBytes(t, clickPCM(rate, 120, seconds), rate), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := AnalyzeFileWithOptions(path, AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFileWithOptions() error = %v", err)
+	}
+	if !got.Streamed || math.Abs(got.DurationSec-float64(seconds)) > 0.01 {
+		t.Errorf("streamed/duration = %v/%v, want true/%d", got.Streamed, got.DurationSec, seconds)
+	}
+	if !strings.HasPrefix(got.Note, fmt.Sprintf("Longer than %d minutes", maxDecodedSeconds/60)) || math.Abs(got.EstimatedBPM-120) > 3 {
+		t.Errorf("note/bpm = %q/%v", got.Note, got.EstimatedBPM)
+	}
+	if _, err := AnalyzeFileWithOptions(path, AnalyzeOptions{Spectrum: SpectrumThirdOctave}); err == nil {
+		t.Error("spectrum past the decode limit should fail")
+	}
+}
+
+func TestAnalyzeDecodedCoversWholeTrack(t *testing.T) {
+	t.Parallel()
+
+	const rate = 22050
+	first := tonalClicks(rate, 120, 60, 261.63, 329.63, 392.00) // C major
+	second := tonalClicks(rate, 96, 60, 369.99, 466.16, 554.37) // F# major
+	got, err := analyzeStream(bytes.NewReader(monoWAVBytes(t, append(first, second...), rate)), 120)
+	if err != nil {
+		t.Fatalf("analyzeStream() error = %v", err)
+	}
+	if got.Streamed || len(got.Segments) != 2 || got.Segments[1].Key != "F#" || math.Abs(got.Segments[1].BPM-96) > 3 {
+		t.Errorf("segments = %+v", got.Segments)
+	}
+	if n := len(got.ChordProgression); n == 0 || got.ChordProgression[n-1].StartSec < 60 {
+		t.Errorf("chord progression stops at the first minute: %+v", got.ChordProgression)
+	}
+	if got.BeatGrid == nil || got.BeatGrid.BeatTimesSec[len(got.BeatGrid.BeatTimesSec)-1] < 60 {
+		t.Error("beat grid stops at the first minute")
+	}
+	if got.Segments[0].LoudnessLUFS >= 0 {
+		t.Errorf("segment loudness = %v", got.Segments[0].LoudnessLUFS)
+	}
+}
+
+func TestDecodeHeadReportsRest(t *testing.T) {
+	t.Parallel()
+
+	const rate = 8000
+	samples := clickPCM(rate, 120, 2)
+	for _, tc := range []struct {
+		maxFrames int
+		rest      bool
+	}{{len(samples), false}, {len(samples) - 1, true}} {
+		stream, err := openAudioStream(bytes.NewReader(monoWAVBytes(t, samples, rate)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		head, rest, err := decodeHead(stream, tc.maxFrames)
+		if err != nil || len(head[0]) != tc.maxFrames || (rest != nil) != tc.rest {
+			t.Fatalf("maxFrames %d: head %d, rest %v, err %v", tc.maxFrames, len(head[0]), rest != nil, err)
+		}
+		if rest == nil {
+			continue
+		}
+		tail, err := rest.read(streamChunkFrames)
+		if err != nil || len(tail[0]) != 1 {
+			t.Errorf("rest = %d frames, err %v", len(tail[0]), err)
+		}
+		if _, err := rest.read(streamChunkFrames); !errors.Is(err, io.EOF) {
+			t.Errorf("rest after tail: err = %v, want EOF", err)
+		}
+	}
+}
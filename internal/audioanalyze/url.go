@@ -88,7 +88,7 @@ func AnalyzeURL(ctx context.Context, rawURL string, projectTempo float64) (Resul
 	_ = dlWaitErr
 	_ = ffWaitErr
 
-	out, err := analyzeWAVStream(bytes.NewReader(wav), projectTempo)
+	out, err := analyzeStream(bytes.NewReader(wav), projectTempo)
 	if err != nil {
 		return Result{}, err
 	}
@@ -77,9 +77,9 @@ func TestAnalyzeWAVStreamReader(t *testing.T) {
 	t.Parallel()
 
 	buf := clickWAVBytes(t, 44100, 120, 4)
-	got, err := analyzeWAVStream(bytes.NewReader(buf), 120)
+	got, err := analyzeStream(bytes.NewReader(buf), 120)
 	if err != nil {
-		t.Fatalf("analyzeWAVStream() error = %v", err)
+		t.Fatalf("analyzeStream() error = %v", err)
 	}
 	if got.SampleRate != 44100 || got.Channels != 1 {
 		t.Errorf("format = %#v", got)
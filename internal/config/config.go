@@ -13,6 +13,7 @@ const (
 	defaultAbletonPort       = 11000
 	defaultAbletonClientPort = 11001
 	defaultTimeoutMs         = 500
+	defaultTasteBackend      = "json"
 )
 
 // Config holds the application configuration.
@@ -22,7 +23,14 @@ type Config struct {
 	AbletonClientPort int
 	Timeout           time.Duration
 	TasteProfilePath  string
+	TasteUsersDir     string
+	TasteUser         string // optional; empty means the default profile at TasteProfilePath
+	TasteBackend      string // json or sqlite
+	TasteDBPath       string // SQLite database used when TasteBackend is sqlite
 	SplicePath        string // optional; empty means auto-detect common Splice folders
+	AnalysisCacheDir  string
+	SpliceIndexDir    string
+	LibrariesFile     string // optional extra sample library roots; missing file means none
 }
 
 // Load reads configuration from environment variables with defaults.
@@ -37,7 +45,14 @@ func Load() Config {
 		AbletonClientPort: envInt("ABLETON_OSC_CLIENT_PORT", defaultAbletonClientPort),
 		Timeout:           envDurationMs("ABLETON_OSC_TIMEOUT_MS", defaultTimeoutMs),
 		TasteProfilePath:  envString("ABLETON_OSC_TASTE_PROFILE_PATH", defaultTasteProfilePath()),
+		TasteUsersDir:     envString("ABLETON_OSC_TASTE_USERS_DIR", defaultTasteUsersDir()),
+		TasteUser:         strings.TrimSpace(os.Getenv("ABLETON_OSC_TASTE_USER")),
+		TasteBackend:      strings.ToLower(envString("ABLETON_OSC_TASTE_BACKEND", defaultTasteBackend)),
+		TasteDBPath:       envString("ABLETON_OSC_TASTE_DB_PATH", defaultTasteDBPath()),
 		SplicePath:        strings.TrimSpace(os.Getenv("ABLETON_OSC_SPLICE_PATH")),
+		AnalysisCacheDir:  envString("ABLETON_OSC_ANALYSIS_CACHE_DIR", defaultAnalysisCacheDir()),
+		SpliceIndexDir:    envString("ABLETON_OSC_SPLICE_INDEX_DIR", defaultSpliceIndexDir()),
+		LibrariesFile:     envString("ABLETON_OSC_LIBRARIES_FILE", defaultLibrariesFile()),
 	}
 }
 
@@ -49,6 +64,46 @@ func defaultTasteProfilePath() string {
 	return filepath.Join(dir, "ableton-osc-mcp", "taste-profile.json")
 }
 
+func defaultTasteUsersDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ableton-osc-mcp", "taste-users")
+}
+
+func defaultTasteDBPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ableton-osc-mcp", "taste.db")
+}
+
+func defaultAnalysisCacheDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ableton-osc-mcp", "analysis-cache")
+}
+
+func defaultSpliceIndexDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ableton-osc-mcp", "splice-index")
+}
+
+func defaultLibrariesFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ableton-osc-mcp", "libraries.json")
+}
+
 func envString(key string, def string) string {
 	v := strings.TrimSpace(os.Getenv(key))
 	if v == "" {
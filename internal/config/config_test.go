@@ -7,7 +7,7 @@ import (
 
 func TestLoadDefaults(t *testing.T) {
 	// Clear any env vars that might be set.
-	for _, key := range []string{"ABLETON_OSC_HOST", "ABLETON_OSC_PORT", "ABLETON_OSC_CLIENT_PORT", "ABLETON_OSC_TIMEOUT_MS", "ABLETON_OSC_TASTE_PROFILE_PATH", "ABLETON_OSC_SPLICE_PATH"} {
+	for _, key := range []string{"ABLETON_OSC_HOST", "ABLETON_OSC_PORT", "ABLETON_OSC_CLIENT_PORT", "ABLETON_OSC_TIMEOUT_MS", "ABLETON_OSC_TASTE_PROFILE_PATH", "ABLETON_OSC_TASTE_USERS_DIR", "ABLETON_OSC_TASTE_USER", "ABLETON_OSC_TASTE_BACKEND", "ABLETON_OSC_TASTE_DB_PATH", "ABLETON_OSC_SPLICE_PATH", "ABLETON_OSC_ANALYSIS_CACHE_DIR", "ABLETON_OSC_SPLICE_INDEX_DIR", "ABLETON_OSC_LIBRARIES_FILE"} {
 		t.Setenv(key, "")
 	}
 
@@ -28,9 +28,30 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.TasteProfilePath == "" {
 		t.Error("TasteProfilePath is empty")
 	}
+	if cfg.TasteUsersDir == "" {
+		t.Error("TasteUsersDir is empty")
+	}
+	if cfg.TasteUser != "" {
+		t.Errorf("TasteUser = %q, want empty (default profile)", cfg.TasteUser)
+	}
+	if cfg.TasteBackend != "json" {
+		t.Errorf("TasteBackend = %q, want json", cfg.TasteBackend)
+	}
+	if cfg.TasteDBPath == "" {
+		t.Error("TasteDBPath is empty")
+	}
 	if cfg.SplicePath != "" {
 		t.Errorf("SplicePath = %q, want empty (auto-detect)", cfg.SplicePath)
 	}
+	if cfg.AnalysisCacheDir == "" {
+		t.Error("AnalysisCacheDir is empty")
+	}
+	if cfg.SpliceIndexDir == "" {
+		t.Error("SpliceIndexDir is empty")
+	}
+	if cfg.LibrariesFile == "" {
+		t.Error("LibrariesFile is empty")
+	}
 }
 
 func TestLoadFromEnv(t *testing.T) {
@@ -39,7 +60,14 @@ func TestLoadFromEnv(t *testing.T) {
 	t.Setenv("ABLETON_OSC_CLIENT_PORT", "12001")
 	t.Setenv("ABLETON_OSC_TIMEOUT_MS", "1000")
 	t.Setenv("ABLETON_OSC_TASTE_PROFILE_PATH", "/tmp/taste-profile.json")
+	t.Setenv("ABLETON_OSC_TASTE_USERS_DIR", "/tmp/taste-users")
+	t.Setenv("ABLETON_OSC_TASTE_USER", "mika")
+	t.Setenv("ABLETON_OSC_TASTE_BACKEND", "SQLite")
+	t.Setenv("ABLETON_OSC_TASTE_DB_PATH", "/tmp/taste.db")
 	t.Setenv("ABLETON_OSC_SPLICE_PATH", "/tmp/Splice")
+	t.Setenv("ABLETON_OSC_ANALYSIS_CACHE_DIR", "/tmp/analysis-cache")
+	t.Setenv("ABLETON_OSC_SPLICE_INDEX_DIR", "/tmp/splice-index")
+	t.Setenv("ABLETON_OSC_LIBRARIES_FILE", "/tmp/libraries.json")
 
 	cfg := Load()
 
@@ -58,9 +86,30 @@ func TestLoadFromEnv(t *testing.T) {
 	if cfg.TasteProfilePath != "/tmp/taste-profile.json" {
 		t.Errorf("TasteProfilePath = %q, want custom path", cfg.TasteProfilePath)
 	}
+	if cfg.TasteUsersDir != "/tmp/taste-users" {
+		t.Errorf("TasteUsersDir = %q, want custom dir", cfg.TasteUsersDir)
+	}
+	if cfg.TasteUser != "mika" {
+		t.Errorf("TasteUser = %q, want mika", cfg.TasteUser)
+	}
+	if cfg.TasteBackend != "sqlite" {
+		t.Errorf("TasteBackend = %q, want sqlite", cfg.TasteBackend)
+	}
+	if cfg.TasteDBPath != "/tmp/taste.db" {
+		t.Errorf("TasteDBPath = %q, want custom path", cfg.TasteDBPath)
+	}
 	if cfg.SplicePath != "/tmp/Splice" {
 		t.Errorf("SplicePath = %q, want /tmp/Splice", cfg.SplicePath)
 	}
+	if cfg.AnalysisCacheDir != "/tmp/analysis-cache" {
+		t.Errorf("AnalysisCacheDir = %q, want custom dir", cfg.AnalysisCacheDir)
+	}
+	if cfg.SpliceIndexDir != "/tmp/splice-index" {
+		t.Errorf("SpliceIndexDir = %q, want custom dir", cfg.SpliceIndexDir)
+	}
+	if cfg.LibrariesFile != "/tmp/libraries.json" {
+		t.Errorf("LibrariesFile = %q, want custom path", cfg.LibrariesFile)
+	}
 }
 
 func TestLoadInvalidEnvFallsBackToDefaults(t *testing.T) {
@@ -0,0 +1,493 @@
+package splice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	indexVersion    = 1
+	maxIndexFiles   = 200000
+	indexStaleAfter = 10 * time.Minute
+)
+
+// Entry is one indexed sample plus the file state it was parsed from, so a
+// refresh only re-reads files whose size or modification time changed.
+type Entry struct {
+	Sample
+	Size    int64 `json:"size"`
+	ModUnix int64 `json:"mod_unix_nano"`
+
+	tokens []string
+}
+
+// Index is a persisted catalogue of one library root.
+type Index struct {
+	Version   int       `json:"version"`
+	Library   string    `json:"library"`
+	Root      string    `json:"root"`
+	Include   []string  `json:"include,omitempty"`
+	Exclude   []string  `json:"exclude,omitempty"`
+	BuiltAt   time.Time `json:"built_at"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// RefreshStats describes the last refresh of an index.
+type RefreshStats struct {
+	Refreshed bool `json:"refreshed"`
+	Files     int  `json:"files"`
+	Reused    int  `json:"reused"`
+	Parsed    int  `json:"parsed"`
+	Removed   int  `json:"removed"`
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Indexer keeps one Index per library root in memory and, when dir is set,
+// on disk as dir/<hash of root path and globs>.json.
+type Indexer struct {
+	dir     string
+	mu      sync.Mutex
+	indexes map[string]*Index
+}
+
+// NewIndexer returns an Indexer persisting under dir; an empty dir keeps
+// indexes in memory only.
+func NewIndexer(dir string) *Indexer {
+	return &Indexer{dir: strings.TrimSpace(dir), indexes: map[string]*Index{}}
+}
+
+// Load returns the index for root, refreshing it when forced, missing, or
+// older than indexStaleAfter. Changing a root's globs starts a new index;
+// renaming it only relabels the samples. The returned Index must not be
+// modified.
+func (x *Indexer) Load(root Root, force bool) (*Index, RefreshStats, error) {
+	path, err := validateRoot(root.Path)
+	if err != nil {
+		return nil, RefreshStats{}, err
+	}
+	root.Path = path
+	key := indexKey(root)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	idx := x.indexes[key]
+	if idx == nil {
+		idx = x.readIndex(root)
+	}
+	if idx != nil && idx.Library != root.Name {
+		idx = idx.relabel(root.Name)
+	}
+	if idx != nil && !force && time.Since(idx.BuiltAt) < indexStaleAfter {
+		x.indexes[key] = idx
+		return idx, RefreshStats{Files: len(idx.Entries), Truncated: idx.Truncated}, nil
+	}
+	next, stats, err := refreshIndex(root, idx)
+	if err != nil {
+		return nil, RefreshStats{}, err
+	}
+	x.indexes[key] = next
+	if x.dir != "" {
+		if err := x.writeIndex(next); err != nil {
+			return nil, RefreshStats{}, err
+		}
+	}
+	return next, stats, nil
+}
+
+// indexKey identifies a root's index by path and globs; the name is only a
+// label.
+func indexKey(root Root) string {
+	sum := sha256.Sum256([]byte(root.Path + "\x00" + strings.Join(root.Include, "\x00") + "\x01" + strings.Join(root.Exclude, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (x *Indexer) indexPath(root Root) string {
+	return filepath.Join(x.dir, indexKey(root)+".json")
+}
+
+// relabel returns a copy of idx whose samples carry library name.
+func (idx *Index) relabel(name string) *Index {
+	out := *idx
+	out.Library = name
+	out.Entries = make([]Entry, len(idx.Entries))
+	for i, e := range idx.Entries {
+		e.Library = name
+		out.Entries[i] = e
+	}
+	return &out
+}
+
+// readIndex loads a persisted index, treating a missing, corrupt or
+// old-version file as absent so it is rebuilt.
+func (x *Indexer) readIndex(root Root) *Index {
+	if x.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(x.indexPath(root))
+	if err != nil {
+		return nil
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil || idx.Version != indexVersion || idx.Root != root.Path {
+		return nil
+	}
+	for i := range idx.Entries {
+		idx.Entries[i].tokens = parseNameMeta(idx.Entries[i].RelativePath).Tokens
+	}
+	return &idx
+}
+
+func (x *Indexer) writeIndex(idx *Index) error {
+	if err := os.MkdirAll(x.dir, 0o700); err != nil {
+		return fmt.Errorf("create splice index dir: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("encode splice index: %w", err)
+	}
+	path := x.indexPath(Root{Path: idx.Root, Include: idx.Include, Exclude: idx.Exclude})
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write splice index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write splice index: %w", err)
+	}
+	return nil
+}
+
+func validateRoot(root string) (string, error) {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return "", errors.New("splice library path is required")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("resolve splice path: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("splice library: %w", err)
+	}
+	if !info.IsDir() {
+		return "", errors.New("splice library path must be a directory")
+	}
+	return abs, nil
+}
+
+// refreshIndex walks root and rebuilds the entry list, reusing prev entries
+// whose size and modification time are unchanged.
+func refreshIndex(root Root, prev *Index) (*Index, RefreshStats, error) {
+	known := map[string]Entry{}
+	if prev != nil {
+		for _, e := range prev.Entries {
+			known[e.RelativePath] = e
+		}
+	}
+	next := &Index{
+		Version: indexVersion,
+		Library: root.Name,
+		Root:    root.Path,
+		Include: root.Include,
+		Exclude: root.Exclude,
+		BuiltAt: time.Now().UTC(),
+	}
+	stats := RefreshStats{Refreshed: true}
+	stillThere := 0
+	err := filepath.WalkDir(root.Path, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || path == root.Path {
+			return nil
+		}
+		rel, err := filepath.Rel(root.Path, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || name == "node_modules" || root.skipsDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !audioExtensions[strings.ToLower(filepath.Ext(d.Name()))] || !root.accepts(rel) {
+			return nil
+		}
+		if len(next.Entries) >= maxIndexFiles {
+			next.Truncated = true
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		old, ok := known[rel]
+		if ok {
+			stillThere++
+		}
+		if ok && old.Size == info.Size() && old.ModUnix == info.ModTime().UnixNano() {
+			if old.tokens == nil {
+				old.tokens = parseNameMeta(rel).Tokens
+			}
+			old.Library = root.Name
+			next.Entries = append(next.Entries, old)
+			stats.Reused++
+			return nil
+		}
+		next.Entries = append(next.Entries, newEntry(root, rel, path, info))
+		stats.Parsed++
+		return nil
+	})
+	if err != nil {
+		return nil, RefreshStats{}, fmt.Errorf("index splice library: %w", err)
+	}
+	stats.Files = len(next.Entries)
+	stats.Removed = len(known) - stillThere
+	stats.Truncated = next.Truncated
+	return next, stats, nil
+}
+
+func newEntry(root Root, rel, path string, info fs.FileInfo) Entry {
+	meta := parseNameMeta(rel)
+	abs := filepath.Join(root.Path, filepath.FromSlash(rel))
+	e := Entry{
+		Sample: Sample{
+			Library:      root.Name,
+			Name:         filepath.Base(path),
+			RelativePath: rel,
+			AbsolutePath: abs,
+			BPM:          meta.BPM,
+			Key:          meta.Key,
+			Scale:        meta.Scale,
+			Kind:         meta.Kind,
+			Tags:         meta.Tags,
+		},
+		Size:    info.Size(),
+		ModUnix: info.ModTime().UnixNano(),
+		tokens:  meta.Tokens,
+	}
+	if dur, err := probeDuration(abs); err == nil && dur > 0 {
+		e.DurationSec = math.Round(dur*1000) / 1000
+	}
+	return e
+}
+
+// Query selects samples from an Index. Zero-valued fields do not filter.
+type Query struct {
+	Text           string
+	BPMMin         float64
+	BPMMax         float64
+	Key            string // e.g. "Am", "F# minor"; a bare tonic matches either scale
+	Kind           string // KindLoop or KindOneShot
+	Tags           []string
+	MinDurationSec float64
+	MaxDurationSec float64
+	MaxResults     int
+}
+
+// Search returns up to q.MaxResults (default 20, max 50) matches from Filter.
+func (idx *Index) Search(q Query) ([]Sample, error) {
+	return Catalog{idx}.Search(q)
+}
+
+// Filter returns every match in idx; see Catalog.Filter.
+func (idx *Index) Filter(q Query) ([]Sample, error) {
+	return Catalog{idx}.Filter(q)
+}
+
+// Catalog searches several library indexes as one.
+type Catalog []*Index
+
+// Search returns up to q.MaxResults (default 20, max 50) matches from Filter.
+func (c Catalog) Search(q Query) ([]Sample, error) {
+	maxResults := q.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultMaxResults
+	}
+	if maxResults > maxMaxResults {
+		maxResults = maxMaxResults
+	}
+	all, err := c.Filter(q)
+	if err != nil {
+		return nil, err
+	}
+	return all[:min(len(all), maxResults)], nil
+}
+
+// Filter returns every match across the catalog, best text score first,
+// ignoring MaxResults. Every query word must match a name or folder token
+// exactly, by prefix, or within one edit for words of four letters or more.
+// Filters on BPM, key or duration exclude samples whose names don't state
+// them.
+func (c Catalog) Filter(q Query) ([]Sample, error) {
+	var key, scale string
+	if strings.TrimSpace(q.Key) != "" {
+		var ok bool
+		if key, scale, ok = ParseKey(q.Key); !ok {
+			return nil, fmt.Errorf("key must look like Am, F#m, Bb or \"C major\" (got %q)", q.Key)
+		}
+	}
+	kind := strings.ToLower(strings.TrimSpace(q.Kind))
+	switch kind {
+	case "", KindLoop, KindOneShot:
+	case "oneshot", "one-shot", "one shot":
+		kind = KindOneShot
+	default:
+		return nil, fmt.Errorf("kind must be loop or one_shot (got %q)", q.Kind)
+	}
+	var tags []string
+	for _, t := range q.Tags {
+		tag := strings.ToLower(strings.TrimSpace(t))
+		if canonical, ok := instrumentTags[tag]; ok {
+			tag = canonical
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	words := tokenize(q.Text)
+
+	type scored struct {
+		sample Sample
+		score  int
+	}
+	var hits []scored
+	for _, e := range c.entries() {
+		if q.BPMMin > 0 && (e.BPM == 0 || e.BPM < q.BPMMin) {
+			continue
+		}
+		if q.BPMMax > 0 && (e.BPM == 0 || e.BPM > q.BPMMax) {
+			continue
+		}
+		if key != "" && (e.Key != key || (scale != "" && e.Scale != scale)) {
+			continue
+		}
+		if kind != "" && e.Kind != kind {
+			continue
+		}
+		if !hasAllTags(e.Tags, tags) {
+			continue
+		}
+		if q.MinDurationSec > 0 && (e.DurationSec == 0 || e.DurationSec < q.MinDurationSec) {
+			continue
+		}
+		if q.MaxDurationSec > 0 && (e.DurationSec == 0 || e.DurationSec > q.MaxDurationSec) {
+			continue
+		}
+		score, ok := matchWords(words, e.tokens)
+		if !ok {
+			continue
+		}
+		hits = append(hits, scored{e.Sample, score})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i].sample, hits[j].sample
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		if a.Library != b.Library {
+			return a.Library < b.Library
+		}
+		return a.RelativePath < b.RelativePath
+	})
+	out := make([]Sample, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, h.sample)
+	}
+	return out, nil
+}
+
+func (c Catalog) entries() []Entry {
+	if len(c) == 1 {
+		return c[0].Entries
+	}
+	var out []Entry
+	for _, idx := range c {
+		out = append(out, idx.Entries...)
+	}
+	return out
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchWords scores how well query words match tokens: 3 per exact token, 2
+// per prefix, 1 per near miss. Any unmatched word rejects the sample.
+func matchWords(words, tokens []string) (int, bool) {
+	total := 0
+	for _, w := range words {
+		best := 0
+		for _, tok := range tokens {
+			switch {
+			case tok == w:
+				best = 3
+			case best < 2 && strings.HasPrefix(tok, w):
+				best = 2
+			case best < 1 && len(w) >= 4 && withinOneEdit(w, tok):
+				best = 1
+			}
+			if best == 3 {
+				break
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// withinOneEdit reports whether a and b differ by at most one insertion,
+// deletion or substitution.
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i, j, edits := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(a) == len(b) {
+			i++
+		}
+		j++
+	}
+	return edits+(len(b)-j)+(len(a)-i) <= 1
+}
@@ -0,0 +1,172 @@
+package splice
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeWAVHeader writes a 16-bit mono WAV whose data chunk holds seconds of
+// silence at 8 kHz.
+func writeWAVHeader(t *testing.T, path string, seconds float64) {
+	t.Helper()
+	const rate = 8000
+	dataBytes := uint32(seconds * rate * 2)
+	buf := make([]byte, 0, 44+int(dataBytes))
+	le := binary.LittleEndian
+	buf = append(buf, "RIFF"...)
+	buf = le.AppendUint32(buf, 36+dataBytes)
+	buf = append(buf, "WAVEfmt "...)
+	buf = le.AppendUint32(buf, 16)
+	buf = le.AppendUint16(buf, 1)
+	buf = le.AppendUint16(buf, 1)
+	buf = le.AppendUint32(buf, rate)
+	buf = le.AppendUint32(buf, rate*2)
+	buf = le.AppendUint16(buf, 2)
+	buf = le.AppendUint16(buf, 16)
+	buf = append(buf, "data"...)
+	buf = le.AppendUint32(buf, dataBytes)
+	buf = append(buf, make([]byte, dataBytes)...)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexSearchFilters(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeWAVHeader(t, filepath.Join(root, "House", "Loops", "HS_bass_loop_124_Am.wav"), 1.5)
+	writeWAVHeader(t, filepath.Join(root, "House", "Loops", "HS_chord_loop_124_Cmaj.wav"), 2)
+	writeWAVHeader(t, filepath.Join(root, "House", "One Shots", "HS_kick_punchy.wav"), 0.25)
+	writeWAVHeader(t, filepath.Join(root, "Trap", "Loops", "TR_bass_loop_140_Am.wav"), 3)
+	mustWrite(t, filepath.Join(root, "House", "notes.txt"), "x")
+
+	idx, stats, err := NewIndexer("").Load(Root{Name: SpliceLibraryName, Path: root}, false)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if stats.Files != 4 || stats.Parsed != 4 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	names := func(q Query) []string {
+		t.Helper()
+		got, err := idx.Search(q)
+		if err != nil {
+			t.Fatalf("Search(%+v) error = %v", q, err)
+		}
+		var out []string
+		for _, s := range got {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+	if got := names(Query{Text: "bass", BPMMin: 120, BPMMax: 128, Key: "A minor"}); len(got) != 1 || got[0] != "HS_bass_loop_124_Am.wav" {
+		t.Errorf("bass 120-128 Am = %v", got)
+	}
+	if got := names(Query{Kind: "one shot"}); len(got) != 1 || got[0] != "HS_kick_punchy.wav" {
+		t.Errorf("one shots = %v", got)
+	}
+	if got := names(Query{Tags: []string{"chord"}}); len(got) != 1 || got[0] != "HS_chord_loop_124_Cmaj.wav" {
+		t.Errorf("chord tag = %v", got)
+	}
+	if got := names(Query{MinDurationSec: 1, MaxDurationSec: 2.5}); len(got) != 2 {
+		t.Errorf("duration 1-2.5 = %v", got)
+	}
+	if got := names(Query{Text: "punchi kick"}); len(got) != 1 {
+		t.Errorf("fuzzy = %v", got)
+	}
+	if got := names(Query{Text: "house bass"}); len(got) != 1 {
+		t.Errorf("folder token = %v", got)
+	}
+	if _, err := idx.Search(Query{Key: "H#"}); err == nil {
+		t.Error("expected key error")
+	}
+	if _, err := idx.Search(Query{Kind: "stem"}); err == nil {
+		t.Error("expected kind error")
+	}
+}
+
+func TestIndexerPersistsAndRefreshesIncrementally(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := t.TempDir()
+	keep := filepath.Join(root, "keep_loop_100_Dm.wav")
+	change := filepath.Join(root, "change.wav")
+	gone := filepath.Join(root, "gone.wav")
+	writeWAVHeader(t, keep, 1)
+	writeWAVHeader(t, change, 1)
+	writeWAVHeader(t, gone, 1)
+	if _, _, err := NewIndexer(dir).Load(Root{Name: SpliceLibraryName, Path: root}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh Indexer reads the persisted index instead of walking again.
+	idx, stats, err := NewIndexer(dir).Load(Root{Name: SpliceLibraryName, Path: root}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Refreshed || len(idx.Entries) != 3 {
+		t.Errorf("persisted load stats = %+v", stats)
+	}
+
+	writeWAVHeader(t, change, 2)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(change, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	writeWAVHeader(t, filepath.Join(root, "new.wav"), 1)
+	idx, stats, err = NewIndexer(dir).Load(Root{Name: SpliceLibraryName, Path: root}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Reused != 1 || stats.Parsed != 2 || stats.Removed != 1 || stats.Files != 3 {
+		t.Errorf("refresh stats = %+v", stats)
+	}
+	for _, e := range idx.Entries {
+		if e.Name == "change.wav" && e.DurationSec != 2 {
+			t.Errorf("changed duration = %v, want 2", e.DurationSec)
+		}
+	}
+	if got, _ := idx.Search(Query{Text: "keep", Key: "Dm"}); len(got) != 1 {
+		t.Errorf("reused entry lost its tokens: %+v", got)
+	}
+}
+
+func TestProbeDurationAIFFAndFLAC(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// AIFF: COMM with 44100 frames at 44100 Hz (80-bit extended 0x400EAC44...).
+	aiff := []byte("FORM\x00\x00\x00\x2eAIFFCOMM\x00\x00\x00\x12\x00\x01\x00\x00\xac\x44\x00\x10\x40\x0e\xac\x44\x00\x00\x00\x00\x00\x00")
+	aiffPath := filepath.Join(dir, "a.aiff")
+	if err := os.WriteFile(aiffPath, aiff, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := probeDuration(aiffPath); err != nil || got != 1 {
+		t.Errorf("aiff duration = %v, %v", got, err)
+	}
+
+	// FLAC STREAMINFO: 48000 Hz, 96000 total samples.
+	info := make([]byte, 34)
+	packed := uint64(48000)<<44 | uint64(1)<<41 | uint64(15)<<36 | 96000
+	binary.BigEndian.PutUint64(info[10:18], packed)
+	flac := append([]byte("fLaC\x80\x00\x00\x22"), info...)
+	flacPath := filepath.Join(dir, "a.flac")
+	if err := os.WriteFile(flacPath, flac, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := probeDuration(flacPath); err != nil || got != 2 {
+		t.Errorf("flac duration = %v, %v", got, err)
+	}
+}
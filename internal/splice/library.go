@@ -11,7 +11,6 @@ import (
 const (
 	defaultMaxResults = 20
 	maxMaxResults     = 50
-	maxWalkFiles      = 5000
 )
 
 var audioExtensions = map[string]bool{
@@ -23,10 +22,20 @@ var audioExtensions = map[string]bool{
 	".ogg":  true,
 }
 
+// Sample is a library file plus what its name and header say about it. BPM,
+// key and kind come from Splice naming ("_120_Am_", "Loops" folders) and are
+// empty when the name doesn't state them; DurationSec is 0 for MP3/OGG.
 type Sample struct {
-	Name         string `json:"name"`
-	RelativePath string `json:"relative_path"`
-	AbsolutePath string `json:"absolute_path"`
+	Library      string   `json:"library" jsonschema:"description=Library root name (splice or a name from the libraries file)"`
+	Name         string   `json:"name"`
+	RelativePath string   `json:"relative_path"`
+	AbsolutePath string   `json:"absolute_path"`
+	BPM          float64  `json:"bpm,omitempty"`
+	Key          string   `json:"key,omitempty"`
+	Scale        string   `json:"scale,omitempty"`
+	Kind         string   `json:"kind,omitempty" jsonschema:"description=loop or one_shot"`
+	Tags         []string `json:"tags,omitempty" jsonschema:"description=Instrument tags from file and folder names (kick, snare, bass, pad, vocal...)"`
+	DurationSec  float64  `json:"duration_sec,omitempty"`
 }
 
 type Library struct {
@@ -60,82 +69,16 @@ func Resolve(configured string) (Library, error) {
 	return Library{Source: "missing"}, errors.New("splice library not found; set ABLETON_OSC_SPLICE_PATH or install/sync the Splice desktop app")
 }
 
+// Search runs a one-off text query over root without persisting an index.
+// Use an Indexer to keep the index between calls.
 func Search(root, query string, maxResults int) ([]Sample, error) {
-	root = strings.TrimSpace(root)
-	if root == "" {
-		return nil, errors.New("splice library path is required")
-	}
-	info, err := os.Stat(root)
+	idx, _, err := NewIndexer("").Load(Root{Name: SpliceLibraryName, Path: root}, true)
 	if err != nil {
-		return nil, fmt.Errorf("splice library: %w", err)
-	}
-	if !info.IsDir() {
-		return nil, errors.New("splice library path must be a directory")
-	}
-	if maxResults <= 0 {
-		maxResults = defaultMaxResults
-	}
-	if maxResults > maxMaxResults {
-		maxResults = maxMaxResults
-	}
-	query = strings.ToLower(strings.TrimSpace(query))
-
-	var (
-		matches []Sample
-		seen    int
-	)
-	err = filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return nil
-		}
-		if d.IsDir() {
-			name := d.Name()
-			if strings.HasPrefix(name, ".") || name == "node_modules" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		seen++
-		if seen > maxWalkFiles {
-			return errors.New("splice library walk limit reached; narrow the query or set a deeper ABLETON_OSC_SPLICE_PATH")
-		}
-		ext := strings.ToLower(filepath.Ext(d.Name()))
-		if !audioExtensions[ext] {
-			return nil
-		}
-		rel, relErr := filepath.Rel(root, path)
-		if relErr != nil {
-			return nil
-		}
-		base := d.Name()
-		if query != "" {
-			haystack := strings.ToLower(rel + " " + strings.TrimSuffix(base, ext))
-			if !strings.Contains(haystack, query) {
-				return nil
-			}
-		}
-		abs, absErr := filepath.Abs(path)
-		if absErr != nil {
-			abs = path
-		}
-		matches = append(matches, Sample{
-			Name:         base,
-			RelativePath: filepath.ToSlash(rel),
-			AbsolutePath: abs,
-		})
-		if len(matches) >= maxResults {
-			return errStopWalk
-		}
-		return nil
-	})
-	if err != nil && !errors.Is(err, errStopWalk) {
 		return nil, err
 	}
-	return matches, nil
+	return idx.Search(Query{Text: query, MaxResults: maxResults})
 }
 
-var errStopWalk = errors.New("stop walk")
-
 func defaultCandidates() []string {
 	home, err := os.UserHomeDir()
 	if err != nil || home == "" {
@@ -0,0 +1,215 @@
+package splice
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sample kinds parsed from names and folders.
+const (
+	KindLoop    = "loop"
+	KindOneShot = "one_shot"
+)
+
+const (
+	minNameBPM = 50
+	maxNameBPM = 220
+)
+
+var (
+	tokenSplit = regexp.MustCompile(`[^a-z0-9#]+`)
+	bpmToken   = regexp.MustCompile(`^(?:bpm)?(\d{2,3})(?:bpm)?$`)
+	keyToken   = regexp.MustCompile(`^([a-g])(#|b)?(m|min|minor|maj|major)?$`)
+)
+
+// flatToSharp maps flat spellings onto the sharp names used by analysis.
+var flatToSharp = map[string]string{"Db": "C#", "Eb": "D#", "Gb": "F#", "Ab": "G#", "Bb": "A#", "Cb": "B", "Fb": "E"}
+
+// instrumentTags maps name tokens onto a canonical instrument tag.
+var instrumentTags = map[string]string{
+	"kick": "kick", "kicks": "kick", "bd": "kick",
+	"snare": "snare", "snares": "snare", "snr": "snare", "rim": "snare",
+	"clap": "clap", "claps": "clap",
+	"hat": "hat", "hats": "hat", "hihat": "hat", "hihats": "hat", "hh": "hat", "ohh": "hat", "chh": "hat",
+	"cymbal": "cymbal", "crash": "cymbal", "ride": "cymbal",
+	"perc": "perc", "percussion": "perc", "shaker": "perc", "tom": "perc", "toms": "perc", "conga": "perc", "bongo": "perc",
+	"drum": "drums", "drums": "drums", "beat": "drums", "break": "drums", "breaks": "drums", "top": "drums", "tops": "drums",
+	"bass": "bass", "808": "bass", "sub": "bass", "reese": "bass",
+	"synth": "synth", "synths": "synth", "lead": "synth", "leads": "synth", "pluck": "synth", "plucks": "synth", "arp": "synth",
+	"pad": "pad", "pads": "pad", "atmos": "pad", "drone": "pad",
+	"keys": "keys", "piano": "keys", "rhodes": "keys", "organ": "keys", "epiano": "keys",
+	"guitar": "guitar", "gtr": "guitar",
+	"vocal": "vocal", "vocals": "vocal", "vox": "vocal", "voice": "vocal", "chant": "vocal",
+	"fx": "fx", "sfx": "fx", "riser": "fx", "impact": "fx", "sweep": "fx", "uplifter": "fx", "downlifter": "fx",
+	"chord": "chords", "chords": "chords", "stab": "chords", "stabs": "chords",
+	"string": "strings", "strings": "strings", "violin": "strings", "cello": "strings",
+	"brass": "brass", "horn": "brass", "horns": "brass", "trumpet": "brass", "sax": "brass",
+}
+
+// nameMeta is what a sample's file and folder names say about it.
+type nameMeta struct {
+	BPM    float64
+	Key    string
+	Scale  string
+	Kind   string
+	Tags   []string
+	Tokens []string
+}
+
+// tokenize lowercases s and splits it into search tokens.
+func tokenize(s string) []string {
+	var out []string
+	for _, tok := range tokenSplit.Split(strings.ToLower(s), -1) {
+		if tok != "" {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// parseNameMeta reads Splice naming conventions ("BPM_Key" tokens such as
+// "_120_Am_", "Loops"/"One Shots" folders, instrument words) from the path
+// relative to the library root. File-name tokens win over folder tokens.
+func parseNameMeta(relativePath string) nameMeta {
+	parts := strings.Split(strings.TrimSuffix(relativePath, path.Ext(relativePath)), "/")
+	var meta nameMeta
+	tagSeen := map[string]bool{}
+	// Walk from the file name up so the most specific value is found first.
+	for i := len(parts) - 1; i >= 0; i-- {
+		tokens := tokenize(parts[i])
+		meta.Tokens = append(meta.Tokens, tokens...)
+		for j, tok := range tokens {
+			following := tokenAt(tokens, j+1)
+			if meta.BPM == 0 {
+				if bpm, ok := parseBPMToken(tok); ok {
+					meta.BPM = bpm
+					continue
+				}
+			}
+			if meta.Key == "" {
+				afterBPM := j > 0 && isBPMToken(tokens[j-1])
+				if key, scale, ok := parseKeyToken(tok, following, afterBPM); ok {
+					meta.Key, meta.Scale = key, scale
+					continue
+				}
+			}
+			if meta.Kind == "" {
+				meta.Kind = kindFromTokens(tok, following)
+			}
+			if tag, ok := instrumentTags[tok]; ok && !tagSeen[tag] {
+				tagSeen[tag] = true
+				meta.Tags = append(meta.Tags, tag)
+			}
+		}
+	}
+	if meta.Kind == "" && meta.BPM > 0 {
+		meta.Kind = KindLoop
+	}
+	return meta
+}
+
+func tokenAt(tokens []string, i int) string {
+	if i >= 0 && i < len(tokens) {
+		return tokens[i]
+	}
+	return ""
+}
+
+func kindFromTokens(tok, following string) string {
+	switch tok {
+	case "loop", "loops", "looped":
+		return KindLoop
+	case "oneshot", "oneshots", "shot", "shots", "hit", "hits":
+		return KindOneShot
+	case "one":
+		if following == "shot" || following == "shots" {
+			return KindOneShot
+		}
+	}
+	return ""
+}
+
+func isBPMToken(tok string) bool {
+	_, ok := parseBPMToken(tok)
+	return ok
+}
+
+func parseBPMToken(tok string) (float64, bool) {
+	m := bpmToken.FindStringSubmatch(tok)
+	if m == nil {
+		return 0, false
+	}
+	bpm, err := strconv.Atoi(m[1])
+	if err != nil || bpm < minNameBPM || bpm > maxNameBPM {
+		return 0, false
+	}
+	return float64(bpm), true
+}
+
+// parseKeyToken accepts "am", "c#m", "ebmaj", "fminor" and, right after a BPM
+// token or before "major"/"minor", a bare letter ("120_A" or "A_minor"). Bare
+// letters elsewhere are too ambiguous ("Vol A") to count.
+func parseKeyToken(tok, following string, afterBPM bool) (string, string, bool) {
+	m := keyToken.FindStringSubmatch(tok)
+	if m == nil {
+		return "", "", false
+	}
+	quality := m[3]
+	if quality == "" {
+		switch following {
+		case "minor", "min":
+			quality = "minor"
+		case "major", "maj":
+			quality = "major"
+		default:
+			if !afterBPM {
+				return "", "", false
+			}
+		}
+	}
+	key := strings.ToUpper(m[1]) + m[2]
+	if sharp, ok := flatToSharp[key]; ok {
+		key = sharp
+	}
+	scale := "major"
+	if quality == "m" || quality == "min" || quality == "minor" {
+		scale = "minor"
+	}
+	return key, scale, true
+}
+
+// ParseKey reads a key such as "Am", "F# minor", "Bb" or "C major". scale is
+// empty when the text does not say.
+func ParseKey(s string) (string, string, bool) {
+	tokens := tokenize(s)
+	if len(tokens) == 0 || len(tokens) > 2 {
+		return "", "", false
+	}
+	m := keyToken.FindStringSubmatch(tokens[0])
+	if m == nil {
+		return "", "", false
+	}
+	key := strings.ToUpper(m[1]) + m[2]
+	if sharp, ok := flatToSharp[key]; ok {
+		key = sharp
+	}
+	quality := m[3]
+	if len(tokens) == 2 {
+		if quality != "" {
+			return "", "", false
+		}
+		quality = tokens[1]
+	}
+	switch quality {
+	case "":
+		return key, "", true
+	case "m", "min", "minor":
+		return key, "minor", true
+	case "maj", "major":
+		return key, "major", true
+	default:
+		return "", "", false
+	}
+}
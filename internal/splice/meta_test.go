@@ -0,0 +1,74 @@
+package splice
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNameMeta(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		rel   string
+		bpm   float64
+		key   string
+		scale string
+		kind  string
+		tags  []string
+	}{
+		{"Packs/Deep House/Loops/Bass/DH_bass_loop_120_Am.wav", 120, "A", "minor", KindLoop, []string{"bass"}},
+		{"Packs/Trap/One Shots/808s/TR_808_C#.wav", 0, "", "", KindOneShot, []string{"bass"}},
+		{"Packs/Lofi/LF_keys_85_Ebmaj_rhodes.aif", 85, "D#", "major", KindLoop, []string{"keys"}},
+		{"Packs/Lofi/LF_pad_90_A_minor.wav", 90, "A", "minor", KindLoop, []string{"pad"}},
+		{"Packs/Kit/Vol A/kick_01.wav", 0, "", "", "", []string{"kick"}},
+		{"Packs/Techno/128bpm_hihat_top_Fm.flac", 128, "F", "minor", KindLoop, []string{"hat", "drums"}},
+	}
+	for _, tt := range tests {
+		got := parseNameMeta(tt.rel)
+		if got.BPM != tt.bpm || got.Key != tt.key || got.Scale != tt.scale || got.Kind != tt.kind || !reflect.DeepEqual(got.Tags, tt.tags) {
+			t.Errorf("parseNameMeta(%q) = %+v", tt.rel, got)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, key, scale string
+		ok             bool
+	}{
+		{"Am", "A", "minor", true},
+		{"F# minor", "F#", "minor", true},
+		{"Bb", "A#", "", true},
+		{"C major", "C", "major", true},
+		{"H", "", "", false},
+		{"Am minor", "", "", false},
+	}
+	for _, tt := range tests {
+		key, scale, ok := ParseKey(tt.in)
+		if key != tt.key || scale != tt.scale || ok != tt.ok {
+			t.Errorf("ParseKey(%q) = %q, %q, %v", tt.in, key, scale, ok)
+		}
+	}
+}
+
+func TestWithinOneEdit(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		a, b string
+		want bool
+	}{
+		{"snare", "snare", true},
+		{"snar", "snare", true},
+		{"sanre", "snare", false},
+		{"snere", "snare", true},
+		{"kick", "kicks", true},
+		{"kick", "click", false},
+	} {
+		if got := withinOneEdit(tt.a, tt.b); got != tt.want {
+			t.Errorf("withinOneEdit(%q, %q) = %v", tt.a, tt.b, got)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package splice
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const maxProbeChunks = 64
+
+// probeDuration reads just enough of a WAV, AIFF or FLAC header to compute its
+// length in seconds. Other formats (MP3, OGG) report 0: their length needs a
+// frame scan, which is too slow for indexing a whole library.
+func probeDuration(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		return probeWAV(f)
+	case ".aif", ".aiff", ".aifc":
+		return probeAIFF(f)
+	case ".flac":
+		return probeFLAC(f)
+	default:
+		return 0, nil
+	}
+}
+
+func probeWAV(r io.ReadSeeker) (float64, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return 0, errors.New("not a RIFF/WAVE file")
+	}
+	var byteRate uint32
+	for range maxProbeChunks {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return 0, err
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch id {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if size < 16 {
+				return 0, errors.New("short fmt chunk")
+			}
+			if _, err := io.ReadFull(r, fmtChunk[:]); err != nil {
+				return 0, err
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:12])
+			size -= 16
+		case "data":
+			if byteRate == 0 {
+				return 0, errors.New("data chunk before fmt chunk")
+			}
+			return float64(size) / float64(byteRate), nil
+		}
+		if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+	}
+	return 0, errors.New("no data chunk")
+}
+
+func probeAIFF(r io.ReadSeeker) (float64, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	if string(header[0:4]) != "FORM" || (string(header[8:12]) != "AIFF" && string(header[8:12]) != "AIFC") {
+		return 0, errors.New("not an AIFF file")
+	}
+	for range maxProbeChunks {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return 0, err
+		}
+		size := int64(binary.BigEndian.Uint32(chunk[4:8]))
+		if string(chunk[0:4]) == "COMM" {
+			var comm [18]byte
+			if size < 18 {
+				return 0, errors.New("short COMM chunk")
+			}
+			if _, err := io.ReadFull(r, comm[:]); err != nil {
+				return 0, err
+			}
+			frames := binary.BigEndian.Uint32(comm[2:6])
+			rate := extendedToFloat(comm[8:18])
+			if rate <= 0 {
+				return 0, errors.New("invalid AIFF sample rate")
+			}
+			return float64(frames) / rate, nil
+		}
+		if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+	}
+	return 0, errors.New("no COMM chunk")
+}
+
+// extendedToFloat decodes the 80-bit IEEE extended float AIFF uses for its
+// sample rate.
+func extendedToFloat(b []byte) float64 {
+	exp := int(binary.BigEndian.Uint16(b[0:2]) & 0x7fff)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exp == 0 && mantissa == 0 {
+		return 0
+	}
+	v := float64(mantissa) * math.Pow(2, float64(exp-16383-63))
+	if b[0]&0x80 != 0 {
+		v = -v
+	}
+	return v
+}
+
+func probeFLAC(r io.Reader) (float64, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:4]); err != nil {
+		return 0, err
+	}
+	if string(header[:4]) != "fLaC" {
+		return 0, errors.New("not a FLAC file")
+	}
+	// STREAMINFO is always the first metadata block.
+	if _, err := io.ReadFull(r, header[:4]); err != nil {
+		return 0, err
+	}
+	if header[0]&0x7f != 0 {
+		return 0, errors.New("FLAC STREAMINFO missing")
+	}
+	var info [34]byte
+	if _, err := io.ReadFull(r, info[:]); err != nil {
+		return 0, err
+	}
+	// Bits 80..99 sample rate, 100..102 channels, 103..107 bps, 108..143 total samples.
+	packed := binary.BigEndian.Uint64(info[10:18])
+	rate := packed >> 44
+	total := packed & (1<<36 - 1)
+	if rate == 0 {
+		return 0, errors.New("invalid FLAC sample rate")
+	}
+	return float64(total) / float64(rate), nil
+}
@@ -0,0 +1,154 @@
+package splice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SpliceLibraryName is the name the Splice folder gets among library roots.
+const SpliceLibraryName = "splice"
+
+var libraryNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.\-]*$`)
+
+// Root is a named sample folder. Include and Exclude are globs over paths
+// relative to Path ("*" within a folder, "**" across folders); patterns
+// without a "/" match the file or folder name at any depth. An empty Include
+// accepts every audio file.
+type Root struct {
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// rootsFile is the on-disk list of extra library roots.
+type rootsFile struct {
+	Libraries []Root `json:"libraries"`
+}
+
+// LoadRoots reads library roots from a JSON file of the form
+// {"libraries":[{"name":"loopmasters","path":"~/Samples/Loopmasters","exclude":["**/_old/**"]}]}.
+// A missing file means no extra roots.
+func LoadRoots(file string) ([]Root, error) {
+	file = strings.TrimSpace(file)
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(expandHome(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read libraries file: %w", err)
+	}
+	var parsed rootsFile
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("parse libraries file %s: %w", file, err)
+	}
+	seen := map[string]bool{}
+	roots := make([]Root, 0, len(parsed.Libraries))
+	for _, r := range parsed.Libraries {
+		r, err := r.normalize()
+		if err != nil {
+			return nil, fmt.Errorf("libraries file %s: %w", file, err)
+		}
+		if r.Name == SpliceLibraryName {
+			return nil, fmt.Errorf("libraries file %s: library name %q is reserved for the Splice folder", file, SpliceLibraryName)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("libraries file %s: duplicate library name %q", file, r.Name)
+		}
+		seen[r.Name] = true
+		roots = append(roots, r)
+	}
+	return roots, nil
+}
+
+func (r Root) normalize() (Root, error) {
+	r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+	if !libraryNamePattern.MatchString(r.Name) {
+		return Root{}, fmt.Errorf("library name %q must be lowercase letters, digits, '.', '_' or '-'", r.Name)
+	}
+	p := strings.TrimSpace(r.Path)
+	if p == "" {
+		return Root{}, fmt.Errorf("library %q: path is required", r.Name)
+	}
+	abs, err := filepath.Abs(expandHome(p))
+	if err != nil {
+		return Root{}, fmt.Errorf("library %q: %w", r.Name, err)
+	}
+	r.Path = abs
+	for _, patterns := range [][]string{r.Include, r.Exclude} {
+		for _, p := range patterns {
+			if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+				return Root{}, fmt.Errorf("library %q: bad glob %q", r.Name, p)
+			}
+		}
+	}
+	return r, nil
+}
+
+// accepts reports whether the file at rel (slash-separated, relative to the
+// root) passes the include and exclude globs.
+func (r Root) accepts(rel string) bool {
+	if matchAny(r.Exclude, rel) {
+		return false
+	}
+	return len(r.Include) == 0 || matchAny(r.Include, rel)
+}
+
+// skipsDir reports whether a folder is excluded outright, so its subtree is
+// never walked.
+func (r Root) skipsDir(rel string) bool {
+	return matchAny(r.Exclude, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated relative path case-insensitively.
+func matchGlob(pattern, rel string) bool {
+	pattern = strings.ToLower(strings.Trim(filepath.ToSlash(strings.TrimSpace(pattern)), "/"))
+	rel = strings.ToLower(strings.TrimSuffix(rel, "/"))
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
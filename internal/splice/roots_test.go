@@ -0,0 +1,105 @@
+package splice
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.wav", "Drums/Kick.WAV", true},
+		{"*.wav", "Drums/Kick.aif", false},
+		{"_old", "Packs/_old", true},
+		{"**/_old/**", "Packs/_old/kick.wav", true},
+		{"**/_old/**", "_old/kick.wav", true},
+		{"**/_old/**", "Packs/older/kick.wav", false},
+		{"loops/**", "Loops/a/b.wav", true},
+		{"loops/**", "One Shots/a.wav", false},
+		{"loops/*.wav", "Loops/a/b.wav", false},
+		{"", "a.wav", false},
+	}
+	for _, tc := range cases {
+		if got := matchGlob(tc.pattern, tc.rel); got != tc.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tc.pattern, tc.rel, got, tc.want)
+		}
+	}
+}
+
+func TestLoadRoots(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if roots, err := LoadRoots(filepath.Join(dir, "missing.json")); err != nil || roots != nil {
+		t.Fatalf("missing file: roots = %v, err = %v", roots, err)
+	}
+
+	file := filepath.Join(dir, "libraries.json")
+	mustWrite(t, file, `{"libraries":[{"name":" Loopmasters ","path":"`+filepath.ToSlash(dir)+`/lm","exclude":["**/_old/**"]}]}`)
+	roots, err := LoadRoots(file)
+	if err != nil {
+		t.Fatalf("LoadRoots() error = %v", err)
+	}
+	if len(roots) != 1 || roots[0].Name != "loopmasters" || roots[0].Path != filepath.Join(dir, "lm") {
+		t.Fatalf("roots = %+v", roots)
+	}
+
+	bad := map[string]string{
+		"reserved":  `{"libraries":[{"name":"splice","path":"/x"}]}`,
+		"duplicate": `{"libraries":[{"name":"a","path":"/x"},{"name":"a","path":"/y"}]}`,
+		"name":      `{"libraries":[{"name":"My Packs","path":"/x"}]}`,
+		"path":      `{"libraries":[{"name":"a"}]}`,
+		"glob":      `{"libraries":[{"name":"a","path":"/x","include":["[a"]}]}`,
+	}
+	for label, body := range bad {
+		mustWrite(t, file, body)
+		if _, err := LoadRoots(file); err == nil || !strings.Contains(err.Error(), "libraries file") {
+			t.Errorf("%s: err = %v", label, err)
+		}
+	}
+}
+
+func TestCatalogSearchesRootsWithGlobs(t *testing.T) {
+	t.Parallel()
+
+	spliceDir, packsDir := t.TempDir(), t.TempDir()
+	writeWAVHeader(t, filepath.Join(spliceDir, "Loops", "bass_loop_124_Am.wav"), 1)
+	writeWAVHeader(t, filepath.Join(packsDir, "Deep", "bass_loop_122_Am.wav"), 1)
+	writeWAVHeader(t, filepath.Join(packsDir, "Deep", "bass_loop_120_Am.aif"), 1)
+	writeWAVHeader(t, filepath.Join(packsDir, "_old", "bass_loop_90_Am.wav"), 1)
+
+	indexer := NewIndexer("")
+	a, _, err := indexer.Load(Root{Name: SpliceLibraryName, Path: spliceDir}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := indexer.Load(Root{Name: "packs", Path: packsDir, Include: []string{"*.wav"}, Exclude: []string{"_old"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Catalog{b, a}.Search(Query{Text: "bass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, s := range got {
+		labels = append(labels, s.Library+":"+s.RelativePath)
+	}
+	want := "packs:Deep/bass_loop_122_Am.wav,splice:Loops/bass_loop_124_Am.wav"
+	if strings.Join(labels, ",") != want {
+		t.Errorf("results = %v, want %s", labels, want)
+	}
+
+	renamed, _, err := indexer.Load(Root{Name: "deep", Path: packsDir, Include: []string{"*.wav"}, Exclude: []string{"_old"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Entries[0].Library != "deep" || b.Entries[0].Library != "packs" {
+		t.Errorf("rename should relabel a copy: got %q, original %q", renamed.Entries[0].Library, b.Entries[0].Library)
+	}
+}
@@ -0,0 +1,159 @@
+package taste
+
+import (
+	"sort"
+	"time"
+)
+
+// Backend persists one user's preferences. Store keeps them in a JSON file;
+// SQLiteStore keeps them as rows of a shared SQLite database.
+type Backend interface {
+	// Record appends a preference and returns the updated summary.
+	Record(preference Preference) (Summary, error)
+	// RecordAll appends several preferences at once, e.g. the pairs of a
+	// ranking.
+	RecordAll(preferences []Preference) (Summary, error)
+	Load() (Profile, error)
+	// Summary counts the stored choices without returning them.
+	Summary() (Summary, error)
+	// Replace overwrites every stored preference, e.g. with an import or a
+	// merge.
+	Replace(profile Profile) error
+	// Preferences returns the matching preferences, oldest first.
+	Preferences(filter Filter) ([]Preference, error)
+	Path() string
+}
+
+// Summary is the size of a profile and its choices counted per instrument
+// and variation.
+type Summary struct {
+	Preferences int
+	Tallies     []Tally // sorted by instrument, then variation
+}
+
+// Tally counts the choices of one instrument+variation. Accepted and
+// Rejected are choices against the source; ranking pairs between two
+// variations (Versus set) are counted apart as VersusWins and VersusLosses.
+type Tally struct {
+	Instrument   string
+	Variation    string
+	Accepted     int
+	Rejected     int
+	VersusWins   int
+	VersusLosses int
+}
+
+func (t *Tally) add(preferred string, versus bool, n int) {
+	switch {
+	case versus && preferred == "variation":
+		t.VersusWins += n
+	case versus:
+		t.VersusLosses += n
+	case preferred == "variation":
+		t.Accepted += n
+	default:
+		t.Rejected += n
+	}
+}
+
+// summaryBuilder collects counts into a Summary.
+type summaryBuilder struct {
+	summary Summary
+	index   map[string]int
+}
+
+func (b *summaryBuilder) add(instrument, variation, preferred string, versus bool, n int) {
+	if b.index == nil {
+		b.index = map[string]int{}
+	}
+	key := instrument + "\x00" + variation
+	i, ok := b.index[key]
+	if !ok {
+		i = len(b.summary.Tallies)
+		b.index[key] = i
+		b.summary.Tallies = append(b.summary.Tallies, Tally{Instrument: instrument, Variation: variation})
+	}
+	b.summary.Tallies[i].add(preferred, versus, n)
+	b.summary.Preferences += n
+}
+
+func (b *summaryBuilder) done() Summary {
+	sort.Slice(b.summary.Tallies, func(i, j int) bool {
+		a, c := b.summary.Tallies[i], b.summary.Tallies[j]
+		if a.Instrument != c.Instrument {
+			return a.Instrument < c.Instrument
+		}
+		return a.Variation < c.Variation
+	})
+	if b.summary.Tallies == nil {
+		b.summary.Tallies = []Tally{}
+	}
+	return b.summary
+}
+
+// Summary counts the profile's choices in memory.
+func (p Profile) Summary() Summary {
+	var b summaryBuilder
+	for _, pref := range p.Preferences {
+		b.add(pref.Instrument, pref.Variation, pref.Preferred, pref.Versus != "", 1)
+	}
+	return b.done()
+}
+
+// Filter selects preferences. Empty fields match everything; Limit keeps the
+// most recent matches.
+type Filter struct {
+	Instrument string
+	Variation  string
+	Preferred  string
+	User       string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+func (f Filter) matches(p Preference) bool {
+	switch {
+	case f.Instrument != "" && p.Instrument != f.Instrument,
+		f.Variation != "" && p.Variation != f.Variation,
+		f.Preferred != "" && p.Preferred != f.Preferred,
+		f.User != "" && p.User != f.User,
+		!f.Since.IsZero() && p.RecordedAt.Before(f.Since),
+		!f.Until.IsZero() && !p.RecordedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// apply filters prefs in memory, for backends without a query engine.
+func (f Filter) apply(prefs []Preference) []Preference {
+	out := []Preference{}
+	for _, p := range prefs {
+		if f.matches(p) {
+			out = append(out, p)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].RecordedAt.Before(out[j].RecordedAt) })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+// Summary loads the file and counts it in memory.
+func (s *Store) Summary() (Summary, error) {
+	profile, err := s.Load()
+	if err != nil {
+		return Summary{}, err
+	}
+	return profile.Summary(), nil
+}
+
+// Preferences loads the file and filters it in memory.
+func (s *Store) Preferences(filter Filter) ([]Preference, error) {
+	profile, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	return filter.apply(profile.Preferences), nil
+}
@@ -0,0 +1,126 @@
+package taste
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Estimate is the Beta-Bernoulli posterior for one option: how often a
+// variation (or parameter level) was preferred over its source, starting
+// from a uniform Beta(1, 1) prior. Counts are weighted by
+// Preference.EffectiveWeight, so merged team profiles favour heavier users.
+type Estimate struct {
+	Name     string  `json:"name"`
+	Accepted float64 `json:"accepted"`
+	Rejected float64 `json:"rejected"`
+	Mean     float64 `json:"mean" jsonschema:"description=Posterior acceptance rate"`
+	Upper    float64 `json:"upper" jsonschema:"description=Mean plus one posterior standard deviation; untried options score high so they get explored"`
+}
+
+func newEstimate(name string, accepted, rejected float64) Estimate {
+	a, b := accepted+1, rejected+1
+	mean := a / (a + b)
+	sd := math.Sqrt(a * b / ((a + b) * (a + b) * (a + b + 1)))
+	return Estimate{
+		Name:     name,
+		Accepted: round3(accepted),
+		Rejected: round3(rejected),
+		Mean:     round3(mean),
+		Upper:    round3(mean + sd),
+	}
+}
+
+// RankVariations scores each variation of instrument by the upper bound of
+// its acceptance posterior (optimism in the face of uncertainty), best
+// first. Ties keep the order of variations.
+func (p Profile) RankVariations(instrument string, variations []string) []Estimate {
+	counts := map[string][2]float64{}
+	for _, pref := range p.Preferences {
+		if pref.Instrument != instrument {
+			continue
+		}
+		c := counts[pref.Variation]
+		if pref.Preferred == "variation" {
+			c[0] += pref.EffectiveWeight()
+		} else {
+			c[1] += pref.EffectiveWeight()
+		}
+		counts[pref.Variation] = c
+	}
+	out := make([]Estimate, 0, len(variations))
+	for _, v := range variations {
+		out = append(out, newEstimate(v, counts[v][0], counts[v][1]))
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Upper > out[j].Upper })
+	return out
+}
+
+// LevelChoice is the parameter level picked by PickLevel.
+type LevelChoice struct {
+	Value     float64    `json:"value"`
+	Observed  int        `json:"observed" jsonschema:"description=Recorded choices that carried this parameter"`
+	Estimates []Estimate `json:"estimates,omitempty"`
+}
+
+// PickLevel chooses a parameter level (strength, velocity delta, mix delta
+// size) for instrument+variation. Each recorded choice whose value reads
+// through value counts for the nearest level; the level with the best
+// posterior mean wins, ties going to the one closest to def. With nothing
+// recorded it returns def.
+func (p Profile) PickLevel(instrument, variation string, levels []float64, def float64, value func(Preference) (float64, bool)) LevelChoice {
+	accepted := make([]float64, len(levels))
+	rejected := make([]float64, len(levels))
+	observed := 0
+	for _, pref := range p.Preferences {
+		if pref.Instrument != instrument || (variation != "" && pref.Variation != variation) {
+			continue
+		}
+		v, ok := value(pref)
+		if !ok {
+			continue
+		}
+		i := nearestLevel(levels, v)
+		if pref.Preferred == "variation" {
+			accepted[i] += pref.EffectiveWeight()
+		} else {
+			rejected[i] += pref.EffectiveWeight()
+		}
+		observed++
+	}
+	if observed == 0 {
+		return LevelChoice{Value: def}
+	}
+
+	out := LevelChoice{Observed: observed}
+	best := -1
+	var bestMean float64
+	for i, level := range levels {
+		e := newEstimate(formatLevel(level), accepted[i], rejected[i])
+		out.Estimates = append(out.Estimates, e)
+		closer := best >= 0 && math.Abs(level-def) < math.Abs(levels[best]-def)
+		if best < 0 || e.Mean > bestMean || (e.Mean == bestMean && closer) {
+			best, bestMean = i, e.Mean
+		}
+	}
+	out.Value = levels[best]
+	return out
+}
+
+func nearestLevel(levels []float64, v float64) int {
+	best := 0
+	for i, level := range levels {
+		if math.Abs(level-v) < math.Abs(levels[best]-v) {
+			best = i
+		}
+	}
+	return best
+}
+
+func formatLevel(v float64) string {
+	return strconv.FormatFloat(round3(v), 'f', -1, 64)
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,73 @@
+package taste
+
+import "testing"
+
+func strengthOf(p Preference) (float64, bool) {
+	if p.Params == nil || p.Params.Strength == nil {
+		return 0, false
+	}
+	return *p.Params.Strength, true
+}
+
+func pref(instrument, variation, preferred string, strength float64) Preference {
+	p := Preference{Instrument: instrument, Variation: variation, Preferred: preferred}
+	if strength > 0 {
+		p.Params = &VariationParams{Strength: &strength}
+	}
+	return p
+}
+
+func TestRankVariationsExploresUntriedAndExploitsWinners(t *testing.T) {
+	t.Parallel()
+
+	cold := Profile{}.RankVariations("drum", []string{"density", "fill", "groove"})
+	if cold[0].Name != "density" || cold[0].Upper != cold[2].Upper {
+		t.Errorf("cold start = %+v, want ties in input order", cold)
+	}
+
+	p := Profile{Preferences: []Preference{
+		pref("drum", "groove", "variation", 0),
+		pref("drum", "groove", "variation", 0),
+		pref("drum", "groove", "variation", 0),
+		pref("drum", "groove", "variation", 0),
+		pref("drum", "density", "source", 0),
+		pref("drum", "density", "source", 0),
+		pref("drum", "density", "source", 0),
+		pref("bass", "groove", "source", 0),
+	}}
+	got := p.RankVariations("drum", []string{"density", "fill", "groove"})
+	if got[0].Name != "groove" || got[0].Accepted != 4 || got[2].Name != "density" {
+		t.Errorf("ranked = %+v", got)
+	}
+	if got[1].Name != "fill" || got[1].Accepted+got[1].Rejected != 0 {
+		t.Errorf("untried fill should sit between winner and loser: %+v", got)
+	}
+}
+
+func TestPickLevel(t *testing.T) {
+	t.Parallel()
+
+	levels := []float64{0.3, 0.45, 0.6, 0.75, 0.9}
+	if got := (Profile{}).PickLevel("drum", "groove", levels, 0.6, strengthOf); got.Value != 0.6 || got.Observed != 0 {
+		t.Errorf("cold start = %+v", got)
+	}
+
+	p := Profile{Preferences: []Preference{
+		pref("drum", "groove", "variation", 0.32),
+		pref("drum", "groove", "variation", 0.28),
+		pref("drum", "groove", "source", 0.9),
+		pref("drum", "groove", "source", 0.6),
+		pref("drum", "groove", "variation", 0), // no strength recorded
+		pref("drum", "fill", "variation", 0.9),
+	}}
+	got := p.PickLevel("drum", "groove", levels, 0.6, strengthOf)
+	if got.Value != 0.3 || got.Observed != 4 || len(got.Estimates) != len(levels) {
+		t.Errorf("learned = %+v", got)
+	}
+
+	// A single rejection at the default moves to the nearest untried level.
+	p = Profile{Preferences: []Preference{pref("drum", "groove", "source", 0.6)}}
+	if got := p.PickLevel("drum", "groove", levels, 0.6, strengthOf); got.Value != 0.45 {
+		t.Errorf("after one rejection = %+v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package taste
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MergeSource is one profile going into Merge.
+type MergeSource struct {
+	User    string
+	Weight  float64 // 0 counts as 1
+	Profile Profile
+}
+
+// Conflict is a choice two sources recorded at the same moment for the same
+// variation but answered differently.
+type Conflict struct {
+	RecordedAt       time.Time `json:"recorded_at"`
+	Instrument       string    `json:"instrument"`
+	Variation        string    `json:"variation"`
+	KeptUser         string    `json:"kept_user" jsonschema:"description=Answer kept: the higher weight, the earlier source on ties"`
+	KeptPreferred    string    `json:"kept_preferred"`
+	DroppedUser      string    `json:"dropped_user"`
+	DroppedPreferred string    `json:"dropped_preferred"`
+}
+
+// MergeReport counts what Merge kept and dropped.
+type MergeReport struct {
+	Merged     int        `json:"merged"`
+	Duplicates int        `json:"duplicates" jsonschema:"description=Choices already present with the same timestamp and answer"`
+	Conflicts  []Conflict `json:"conflicts,omitempty"`
+}
+
+// Merge combines profiles into one, oldest choice first. Choices are the
+// same when they share recorded_at, instrument, variation, versus and
+// parameters (see mergeKey); identical
+// answers are kept once and differing ones are resolved by weight. Each
+// kept choice carries its user and the product of the source weight and
+// any weight it already had.
+func Merge(sources []MergeSource) (Profile, MergeReport, error) {
+	out := Profile{Version: profileVersion, Preferences: []Preference{}}
+	var report MergeReport
+	index := map[string]int{}
+	for _, source := range sources {
+		if source.Weight < 0 {
+			return Profile{}, MergeReport{}, fmt.Errorf("weight for %s must be >= 0", source.User)
+		}
+		weight := source.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		for _, pref := range source.Profile.Preferences {
+			if pref.User == "" {
+				pref.User = source.User
+			}
+			pref.Weight = pref.EffectiveWeight() * weight
+			if pref.Weight == 1 {
+				pref.Weight = 0
+			}
+			key, err := mergeKey(pref)
+			if err != nil {
+				return Profile{}, MergeReport{}, err
+			}
+			i, seen := index[key]
+			if !seen {
+				index[key] = len(out.Preferences)
+				out.Preferences = append(out.Preferences, pref)
+				continue
+			}
+			kept := out.Preferences[i]
+			if kept.Preferred == pref.Preferred {
+				report.Duplicates++
+				continue
+			}
+			conflict := Conflict{
+				RecordedAt: pref.RecordedAt,
+				Instrument: pref.Instrument,
+				Variation:  pref.Variation,
+			}
+			if pref.EffectiveWeight() > kept.EffectiveWeight() {
+				out.Preferences[i] = pref
+				kept, pref = pref, kept
+			}
+			conflict.KeptUser, conflict.KeptPreferred = kept.User, kept.Preferred
+			conflict.DroppedUser, conflict.DroppedPreferred = pref.User, pref.Preferred
+			report.Conflicts = append(report.Conflicts, conflict)
+		}
+	}
+	sort.SliceStable(out.Preferences, func(i, j int) bool {
+		return out.Preferences[i].RecordedAt.Before(out.Preferences[j].RecordedAt)
+	})
+	report.Merged = len(out.Preferences)
+	return out, report, nil
+}
+
+// mergeKey identifies a choice across profiles. The pairs of one ranking
+// share recorded_at, so the compared variation and parameters are part of
+// the key.
+func mergeKey(pref Preference) (string, error) {
+	params, err := json.Marshal(pref.Params)
+	if err != nil {
+		return "", fmt.Errorf("encode taste preference: %w", err)
+	}
+	return strings.Join([]string{
+		pref.RecordedAt.UTC().Format(time.RFC3339Nano),
+		pref.Instrument, pref.Variation, pref.Versus, string(params),
+	}, "\x00"), nil
+}
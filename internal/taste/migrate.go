@@ -0,0 +1,70 @@
+package taste
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// profileV1 is the version 1 file layout: preferences without parameters or
+// project context.
+type profileV1 struct {
+	Version     int `json:"version"`
+	Preferences []struct {
+		Instrument string    `json:"instrument"`
+		Variation  string    `json:"variation"`
+		Preferred  string    `json:"preferred"`
+		Note       string    `json:"note,omitempty"`
+		RecordedAt time.Time `json:"recorded_at"`
+	} `json:"preferences"`
+}
+
+// decodeProfile parses a profile file of any supported version and returns
+// it at profileVersion. migratedFrom is the file's version when older.
+func decodeProfile(data []byte) (Profile, int, error) {
+	var head struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return Profile{}, 0, fmt.Errorf("parse taste profile: %w", err)
+	}
+
+	var (
+		profile      Profile
+		migratedFrom int
+	)
+	switch head.Version {
+	case 1:
+		var old profileV1
+		if err := json.Unmarshal(data, &old); err != nil {
+			return Profile{}, 0, fmt.Errorf("parse taste profile: %w", err)
+		}
+		profile = migrateV1(old)
+		migratedFrom = 1
+	case profileVersion:
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return Profile{}, 0, fmt.Errorf("parse taste profile: %w", err)
+		}
+	default:
+		return Profile{}, 0, fmt.Errorf("unsupported taste profile version: %d", head.Version)
+	}
+	if profile.Preferences == nil {
+		profile.Preferences = []Preference{}
+	}
+	return profile, migratedFrom, nil
+}
+
+// migrateV1 keeps every v1 choice; parameters and context stay unknown.
+func migrateV1(old profileV1) Profile {
+	out := Profile{Version: profileVersion, Preferences: make([]Preference, 0, len(old.Preferences))}
+	for _, p := range old.Preferences {
+		out.Preferences = append(out.Preferences, Preference{
+			Instrument: p.Instrument,
+			Variation:  p.Variation,
+			Preferred:  p.Preferred,
+			Note:       p.Note,
+			RecordedAt: p.RecordedAt,
+		})
+	}
+	return out
+}
@@ -10,16 +10,58 @@ import (
 	"time"
 )
 
-const profileVersion = 1
+// profileVersion 2 added Params and Context to each preference; version 1
+// files are migrated on load and rewritten on the next Record.
+const profileVersion = 2
 
 type Preference struct {
-	Instrument string    `json:"instrument"`
-	Variation  string    `json:"variation"`
-	Preferred  string    `json:"preferred"`
-	Note       string    `json:"note,omitempty"`
+	Instrument string           `json:"instrument"`
+	Variation  string           `json:"variation"`
+	Preferred  string           `json:"preferred"`
+	Note       string           `json:"note,omitempty"`
+	Params     *VariationParams `json:"params,omitempty"`
+	Context    *Context         `json:"context,omitempty"`
+	// Versus is the other variation when a ranking compared two variations
+	// rather than a variation and its source.
+	Versus string `json:"versus,omitempty"`
+	// User and Weight are set on choices merged into a shared profile.
+	User       string    `json:"user,omitempty"`
+	Weight     float64   `json:"weight,omitempty"`
 	RecordedAt time.Time `json:"recorded_at"`
 }
 
+// EffectiveWeight is how much the choice counts; unweighted choices count 1.
+func (p Preference) EffectiveWeight() float64 {
+	if p.Weight <= 0 {
+		return 1
+	}
+	return p.Weight
+}
+
+// VariationParams are the settings that produced the B version. Only the
+// ones the variation used are set.
+type VariationParams struct {
+	Strength      *float64   `json:"strength,omitempty"`
+	Seed          *int64     `json:"seed,omitempty"`
+	VelocityDelta *int       `json:"velocity_delta,omitempty"`
+	MixDeltas     []MixDelta `json:"mix_deltas,omitempty"`
+	DeviceIndices []int      `json:"device_indices,omitempty"`
+}
+
+// MixDelta is one track volume change of a mix variation.
+type MixDelta struct {
+	TrackIndex int     `json:"track_index"`
+	Delta      float64 `json:"delta"`
+}
+
+// Context is where in the project the choice was made.
+type Context struct {
+	TempoBPM   float64 `json:"tempo_bpm,omitempty"`
+	Key        string  `json:"key,omitempty"`
+	TrackIndex *int    `json:"track_index,omitempty"`
+	TrackName  string  `json:"track_name,omitempty"`
+}
+
 type Profile struct {
 	Version     int          `json:"version"`
 	Preferences []Preference `json:"preferences"`
@@ -41,50 +83,95 @@ func (s *Store) Path() string {
 	return s.path
 }
 
-func (s *Store) Record(preference Preference) (Profile, error) {
+func (s *Store) Record(preference Preference) (Summary, error) {
+	return s.RecordAll([]Preference{preference})
+}
+
+// RecordAll appends preferences in one rewrite of the file.
+func (s *Store) RecordAll(preferences []Preference) (Summary, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	profile, err := s.load()
+	profile, migratedFrom, err := s.load()
 	if err != nil {
-		return Profile{}, err
+		return Summary{}, err
+	}
+	if migratedFrom != 0 {
+		if err := s.backup(migratedFrom); err != nil {
+			return Summary{}, err
+		}
 	}
-	if preference.RecordedAt.IsZero() {
-		preference.RecordedAt = time.Now().UTC()
+	now := time.Now().UTC()
+	for _, preference := range preferences {
+		if preference.RecordedAt.IsZero() {
+			preference.RecordedAt = now
+		}
+		profile.Preferences = append(profile.Preferences, preference)
 	}
-	profile.Preferences = append(profile.Preferences, preference)
 	if err := s.save(profile); err != nil {
-		return Profile{}, err
+		return Summary{}, err
+	}
+	return profile.Summary(), nil
+}
+
+// Replace overwrites the stored profile, e.g. with an import or a merge.
+func (s *Store) Replace(profile Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile.Version = profileVersion
+	if profile.Preferences == nil {
+		profile.Preferences = []Preference{}
+	}
+	return s.save(profile)
+}
+
+// ReadProfile reads a profile file written by any Store version, such as an
+// export from another machine.
+func ReadProfile(path string) (Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, fmt.Errorf("read taste profile: %w", err)
 	}
-	return profile, nil
+	profile, _, err := decodeProfile(data)
+	return profile, err
 }
 
 func (s *Store) Load() (Profile, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.load()
+	profile, _, err := s.load()
+	return profile, err
 }
 
-func (s *Store) load() (Profile, error) {
+// load reads the profile, migrating older versions in memory. migratedFrom
+// is the on-disk version when it was older than profileVersion, else 0.
+func (s *Store) load() (profile Profile, migratedFrom int, err error) {
 	data, err := os.ReadFile(s.path)
 	if errors.Is(err, os.ErrNotExist) {
-		return Profile{Version: profileVersion, Preferences: []Preference{}}, nil
+		return Profile{Version: profileVersion, Preferences: []Preference{}}, 0, nil
 	}
 	if err != nil {
-		return Profile{}, fmt.Errorf("read taste profile: %w", err)
+		return Profile{}, 0, fmt.Errorf("read taste profile: %w", err)
 	}
+	return decodeProfile(data)
+}
 
-	var profile Profile
-	if err := json.Unmarshal(data, &profile); err != nil {
-		return Profile{}, fmt.Errorf("parse taste profile: %w", err)
+// backup copies an older-version profile to <path>.v<N>.bak before it is
+// first rewritten, keeping an existing backup.
+func (s *Store) backup(version int) error {
+	target := fmt.Sprintf("%s.v%d.bak", s.path, version)
+	if _, err := os.Stat(target); err == nil {
+		return nil
 	}
-	if profile.Version != profileVersion {
-		return Profile{}, fmt.Errorf("unsupported taste profile version: %d", profile.Version)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("read taste profile for backup: %w", err)
 	}
-	if profile.Preferences == nil {
-		profile.Preferences = []Preference{}
+	if err := os.WriteFile(target, data, 0o600); err != nil {
+		return fmt.Errorf("back up taste profile: %w", err)
 	}
-	return profile, nil
+	return nil
 }
 
 func (s *Store) save(profile Profile) error {
@@ -1,7 +1,11 @@
 package taste
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -16,7 +20,7 @@ func TestStoreRecordAndLoad(t *testing.T) {
 	}
 
 	recordedAt := time.Date(2026, time.July, 18, 7, 0, 0, 0, time.UTC)
-	profile, err := store.Record(Preference{
+	summary, err := store.Record(Preference{
 		Instrument: "drum",
 		Variation:  "groove",
 		Preferred:  "variation",
@@ -26,8 +30,9 @@ func TestStoreRecordAndLoad(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Record() error = %v", err)
 	}
-	if profile.Version != profileVersion || len(profile.Preferences) != 1 {
-		t.Fatalf("Record() profile = %#v", profile)
+	want := Summary{Preferences: 1, Tallies: []Tally{{Instrument: "drum", Variation: "groove", Accepted: 1}}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Fatalf("Record() summary = %#v, want %#v", summary, want)
 	}
 
 	loaded, err := store.Load()
@@ -65,3 +70,73 @@ func TestNewStoreRejectsEmptyPath(t *testing.T) {
 		t.Fatal("NewStore(\"\") error = nil, want error")
 	}
 }
+
+func TestStoreMigratesV1Profile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "taste-profile.json")
+	v1 := `{"version":1,"preferences":[{"instrument":"bass","variation":"staccato","preferred":"source","note":"too choppy","recorded_at":"2026-07-01T10:00:00Z"}]}`
+	if err := os.WriteFile(path, []byte(v1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Version != profileVersion || len(loaded.Preferences) != 1 {
+		t.Fatalf("Load() = %#v", loaded)
+	}
+	if got := loaded.Preferences[0]; got.Variation != "staccato" || got.Note != "too choppy" || got.Params != nil || got.Context != nil {
+		t.Errorf("migrated preference = %#v", got)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() must not write a backup, stat err = %v", err)
+	}
+
+	strength := 0.4
+	summary, err := store.Record(Preference{
+		Instrument: "drum",
+		Variation:  "groove",
+		Preferred:  "variation",
+		Params:     &VariationParams{Strength: &strength},
+		Context:    &Context{TempoBPM: 124, Key: "A minor", TrackName: "Drums"},
+	})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if summary.Preferences != 2 {
+		t.Fatalf("Record() preferences = %d, want 2", summary.Preferences)
+	}
+	backup, err := os.ReadFile(path + ".v1.bak")
+	if err != nil || string(backup) != v1 {
+		t.Errorf("backup = %q, %v", backup, err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"version": 2`) || !strings.Contains(string(raw), `"tempo_bpm": 124`) {
+		t.Errorf("rewritten profile = %s", raw)
+	}
+}
+
+func TestStoreRejectsNewerProfile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "taste-profile.json")
+	if err := os.WriteFile(path, []byte(`{"version":99,"preferences":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Fatal("Load() error = nil for an unknown version")
+	}
+}
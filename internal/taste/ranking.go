@@ -0,0 +1,93 @@
+package taste
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SourceVersion marks the unvaried version in a Ranking.
+const SourceVersion = "source"
+
+// RankedVersion is one auditioned version. Variation is SourceVersion for
+// the original; Params are the settings that produced a variation.
+type RankedVersion struct {
+	Variation string
+	Params    *VariationParams
+}
+
+// Ranking is a listener's order of several versions of one instrument,
+// best first.
+type Ranking struct {
+	Instrument string
+	Versions   []RankedVersion
+	Note       string
+	Context    *Context
+	RecordedAt time.Time
+}
+
+// Pairwise turns the ranking into one choice per pair of versions. A
+// variation against the source is an ordinary choice. Two variations give
+// two half-weight choices, an acceptance for the higher one and a rejection
+// for the lower one, each naming the other in Versus, so every pair counts
+// once in total. All pairs share the ranking's recorded_at.
+func (r Ranking) Pairwise() ([]Preference, error) {
+	if len(r.Versions) < 2 {
+		return nil, errors.New("a ranking needs at least two versions")
+	}
+	sources := 0
+	seen := map[string]bool{}
+	for _, v := range r.Versions {
+		if v.Variation == SourceVersion {
+			sources++
+		}
+		params, err := json.Marshal(v.Params)
+		if err != nil {
+			return nil, fmt.Errorf("encode ranked version: %w", err)
+		}
+		key := v.Variation + "\x00" + string(params)
+		if seen[key] {
+			return nil, fmt.Errorf("ranking lists %s twice with the same parameters", v.Variation)
+		}
+		seen[key] = true
+	}
+	if sources > 1 {
+		return nil, errors.New("a ranking can hold only one source version")
+	}
+	at := r.RecordedAt
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	var prefs []Preference
+	choice := func(v RankedVersion, preferred, versus string, weight float64) Preference {
+		return Preference{
+			Instrument: r.Instrument,
+			Variation:  v.Variation,
+			Versus:     versus,
+			Preferred:  preferred,
+			Note:       r.Note,
+			Params:     v.Params,
+			Context:    r.Context,
+			Weight:     weight,
+			RecordedAt: at,
+		}
+	}
+	for i, better := range r.Versions {
+		for _, worse := range r.Versions[i+1:] {
+			switch {
+			case better.Variation == SourceVersion:
+				prefs = append(prefs, choice(worse, "source", "", 0))
+			case worse.Variation == SourceVersion:
+				prefs = append(prefs, choice(better, "variation", "", 0))
+			default:
+				prefs = append(prefs,
+					choice(better, "variation", worse.Variation, 0.5),
+					choice(worse, "source", better.Variation, 0.5),
+				)
+			}
+		}
+	}
+	return prefs, nil
+}
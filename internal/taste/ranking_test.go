@@ -0,0 +1,93 @@
+package taste
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRankingPairwise(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, time.October, 12, 21, 0, 0, 0, time.UTC)
+	soft, hard := 0.3, 0.9
+	r := Ranking{
+		Instrument: "drum",
+		Versions: []RankedVersion{
+			{Variation: "groove", Params: &VariationParams{Strength: &soft}},
+			{Variation: SourceVersion},
+			{Variation: "groove", Params: &VariationParams{Strength: &hard}},
+		},
+		Note:       "gentle swing wins",
+		RecordedAt: at,
+	}
+	prefs, err := r.Pairwise()
+	if err != nil {
+		t.Fatalf("Pairwise() error = %v", err)
+	}
+	type pair struct {
+		Variation, Versus, Preferred string
+		Strength                     float64
+		Weight                       float64
+	}
+	var got []pair
+	for _, p := range prefs {
+		s, _ := strengthOf(p)
+		got = append(got, pair{p.Variation, p.Versus, p.Preferred, s, p.EffectiveWeight()})
+		if !p.RecordedAt.Equal(at) || p.Note != r.Note || p.Instrument != "drum" {
+			t.Errorf("pair %+v lost ranking fields", p)
+		}
+	}
+	want := []pair{
+		{"groove", "", "variation", 0.3, 1},
+		{"groove", "groove", "variation", 0.3, 0.5},
+		{"groove", "groove", "source", 0.9, 0.5},
+		{"groove", "", "source", 0.9, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairwise() = %+v, want %+v", got, want)
+	}
+
+	// The half-weight pairs must survive the SQLite round trip and merge
+	// as distinct choices.
+	store := openTestSQLite(t, filepath.Join(t.TempDir(), "taste.db")).Profile(DefaultUser)
+	summary, err := store.RecordAll(prefs)
+	if err != nil {
+		t.Fatalf("RecordAll() error = %v", err)
+	}
+	wantSummary := Summary{Preferences: 4, Tallies: []Tally{
+		{Instrument: "drum", Variation: "groove", Accepted: 1, Rejected: 1, VersusWins: 1, VersusLosses: 1},
+	}}
+	if !reflect.DeepEqual(summary, wantSummary) {
+		t.Errorf("RecordAll() = %+v, want %+v", summary, wantSummary)
+	}
+	profile, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(profile.Preferences, prefs) {
+		t.Errorf("Load() = %+v, want %+v", profile.Preferences, prefs)
+	}
+	merged, report, err := Merge([]MergeSource{{User: "a", Profile: profile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Preferences) != 4 || report.Duplicates != 0 {
+		t.Errorf("Merge() kept %d, report %+v", len(merged.Preferences), report)
+	}
+}
+
+func TestRankingPairwiseRejectsBadRankings(t *testing.T) {
+	t.Parallel()
+
+	for name, versions := range map[string][]RankedVersion{
+		"single":      {{Variation: "fill"}},
+		"two sources": {{Variation: SourceVersion}, {Variation: "fill"}, {Variation: SourceVersion}},
+		"duplicate":   {{Variation: "fill"}, {Variation: "fill"}},
+	} {
+		if _, err := (Ranking{Instrument: "drum", Versions: versions}).Pairwise(); err == nil {
+			t.Errorf("%s: Pairwise() error = nil", name)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package taste
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultReportWindow = 7 * 24 * time.Hour
+	// trendThreshold is the change in acceptance rate reported as drift.
+	trendThreshold  = 0.1
+	maxNoteClusters = 10
+	maxNoteExamples = 3
+	unknownBucket   = "unknown"
+)
+
+// Rate is an acceptance rate with its 95% Wilson score interval. Counts are
+// plain choices against the source; merge weights are not applied, and
+// ranking pairs between two variations (Versus set) are left out.
+type Rate struct {
+	Accepted int     `json:"accepted"`
+	Rejected int     `json:"rejected"`
+	Rate     float64 `json:"rate" jsonschema:"description=Share of choices that preferred the variation"`
+	Low      float64 `json:"low" jsonschema:"description=Lower end of the 95% confidence interval"`
+	High     float64 `json:"high" jsonschema:"description=Upper end of the 95% confidence interval"`
+}
+
+func newRate(accepted, rejected int) Rate {
+	r := Rate{Accepted: accepted, Rejected: rejected, High: 1}
+	n := float64(accepted + rejected)
+	if n == 0 {
+		return r
+	}
+	const z = 1.96
+	p := float64(accepted) / n
+	denom := 1 + z*z/n
+	center := (p + z*z/(2*n)) / denom
+	half := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / denom
+	r.Rate = round3(p)
+	r.Low = round3(math.Max(0, center-half))
+	r.High = round3(math.Min(1, center+half))
+	return r
+}
+
+func (r Rate) add(pref Preference) Rate {
+	if pref.Versus != "" {
+		return r
+	}
+	if pref.Preferred == "variation" {
+		return newRate(r.Accepted+1, r.Rejected)
+	}
+	return newRate(r.Accepted, r.Rejected+1)
+}
+
+// VariationRate is the acceptance rate of one instrument+variation, plus
+// its ranking pairs against other variations, counted separately.
+type VariationRate struct {
+	Instrument   string `json:"instrument"`
+	Variation    string `json:"variation"`
+	VersusWins   int    `json:"versus_wins,omitempty" jsonschema:"description=Ranking pairs this variation won against another variation"`
+	VersusLosses int    `json:"versus_losses,omitempty" jsonschema:"description=Ranking pairs this variation lost to another variation"`
+	Rate
+}
+
+// Trend compares the recent window with the choices before it.
+type Trend struct {
+	Instrument string  `json:"instrument"`
+	Variation  string  `json:"variation"`
+	Recent     Rate    `json:"recent"`
+	Earlier    Rate    `json:"earlier"`
+	AllTime    Rate    `json:"all_time"`
+	Change     float64 `json:"change" jsonschema:"description=Recent rate minus earlier rate; 0 when there is nothing earlier"`
+	Direction  string  `json:"direction" jsonschema:"description=up, down, steady, or new when there were no earlier choices"`
+}
+
+// Bucket groups choices by a project property such as tempo range or key.
+type Bucket struct {
+	Label      string          `json:"label"`
+	Overall    Rate            `json:"overall"`
+	Variations []VariationRate `json:"variations"`
+}
+
+// NoteCluster is a keyword shared by several notes.
+type NoteCluster struct {
+	Keyword  string   `json:"keyword"`
+	Notes    int      `json:"notes"`
+	Accepted int      `json:"accepted" jsonschema:"description=Notes on choices that preferred the variation"`
+	Rejected int      `json:"rejected"`
+	Examples []string `json:"examples"`
+}
+
+// Report summarizes a profile for spotting how preferences drift.
+type Report struct {
+	Preferences  int             `json:"preferences"`
+	First        *time.Time      `json:"first,omitempty"`
+	Last         *time.Time      `json:"last,omitempty"`
+	WindowStart  time.Time       `json:"window_start"`
+	Overall      []VariationRate `json:"overall"`
+	Trends       []Trend         `json:"trends"`
+	ByTempo      []Bucket        `json:"by_tempo"`
+	ByKey        []Bucket        `json:"by_key"`
+	NoteClusters []NoteCluster   `json:"note_clusters"`
+}
+
+// ReportOptions tune Report. Zero values mean now and the last 7 days.
+type ReportOptions struct {
+	Now    time.Time
+	Window time.Duration
+}
+
+// Report computes acceptance rates with confidence intervals, recent trends,
+// tempo and key breakdowns and keyword clusters of the notes.
+func (p Profile) Report(opts ReportOptions) Report {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now().UTC()
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultReportWindow
+	}
+	out := Report{
+		Preferences: len(p.Preferences),
+		WindowStart: opts.Now.Add(-opts.Window),
+	}
+	var recent, earlier []Preference
+	for _, pref := range p.Preferences {
+		at := pref.RecordedAt
+		if out.First == nil || at.Before(*out.First) {
+			out.First = &at
+		}
+		if out.Last == nil || at.After(*out.Last) {
+			out.Last = &at
+		}
+		if at.Before(out.WindowStart) {
+			earlier = append(earlier, pref)
+		} else {
+			recent = append(recent, pref)
+		}
+	}
+
+	out.Overall = variationRates(p.Preferences)
+	out.Trends = trends(out.Overall, variationRates(recent), variationRates(earlier))
+	out.ByTempo = buckets(p.Preferences, tempoLabel, tempoOrder)
+	out.ByKey = buckets(p.Preferences, keyLabel, nil)
+	out.NoteClusters = noteClusters(p.Preferences)
+	return out
+}
+
+func variationRates(prefs []Preference) []VariationRate {
+	index := map[string]int{}
+	var out []VariationRate
+	for _, pref := range prefs {
+		key := pref.Instrument + "\x00" + pref.Variation
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, VariationRate{Instrument: pref.Instrument, Variation: pref.Variation, Rate: newRate(0, 0)})
+		}
+		switch {
+		case pref.Versus == "":
+			out[i].Rate = out[i].Rate.add(pref)
+		case pref.Preferred == "variation":
+			out[i].VersusWins++
+		default:
+			out[i].VersusLosses++
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Instrument != out[j].Instrument {
+			return out[i].Instrument < out[j].Instrument
+		}
+		return out[i].Variation < out[j].Variation
+	})
+	if out == nil {
+		out = []VariationRate{}
+	}
+	return out
+}
+
+// trends lists every variation chosen inside the window.
+func trends(all, recent, earlier []VariationRate) []Trend {
+	find := func(rates []VariationRate, instrument, variation string) Rate {
+		for _, r := range rates {
+			if r.Instrument == instrument && r.Variation == variation {
+				return r.Rate
+			}
+		}
+		return newRate(0, 0)
+	}
+	out := []Trend{}
+	for _, r := range recent {
+		t := Trend{
+			Instrument: r.Instrument,
+			Variation:  r.Variation,
+			Recent:     r.Rate,
+			Earlier:    find(earlier, r.Instrument, r.Variation),
+			AllTime:    find(all, r.Instrument, r.Variation),
+			Direction:  "new",
+		}
+		if t.Earlier.Accepted+t.Earlier.Rejected > 0 {
+			t.Change = round3(t.Recent.Rate - t.Earlier.Rate)
+			switch {
+			case t.Change >= trendThreshold:
+				t.Direction = "up"
+			case t.Change <= -trendThreshold:
+				t.Direction = "down"
+			default:
+				t.Direction = "steady"
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// buckets groups prefs by label; order fixes the label order, otherwise
+// labels sort alphabetically. Choices without the property go last as
+// unknown.
+func buckets(prefs []Preference, label func(Preference) string, order []string) []Bucket {
+	grouped := map[string][]Preference{}
+	for _, pref := range prefs {
+		l := label(pref)
+		grouped[l] = append(grouped[l], pref)
+	}
+	labels := make([]string, 0, len(grouped))
+	if order != nil {
+		for _, l := range order {
+			if _, ok := grouped[l]; ok {
+				labels = append(labels, l)
+			}
+		}
+	} else {
+		for l := range grouped {
+			if l != unknownBucket {
+				labels = append(labels, l)
+			}
+		}
+		sort.Strings(labels)
+	}
+	if _, ok := grouped[unknownBucket]; ok {
+		labels = append(labels, unknownBucket)
+	}
+
+	out := make([]Bucket, 0, len(labels))
+	for _, l := range labels {
+		b := Bucket{Label: l, Overall: newRate(0, 0), Variations: variationRates(grouped[l])}
+		for _, pref := range grouped[l] {
+			b.Overall = b.Overall.add(pref)
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+var tempoOrder = []string{"under 90", "90-109", "110-129", "130-149", "150+"}
+
+func tempoLabel(pref Preference) string {
+	if pref.Context == nil || pref.Context.TempoBPM <= 0 {
+		return unknownBucket
+	}
+	switch bpm := pref.Context.TempoBPM; {
+	case bpm < 90:
+		return tempoOrder[0]
+	case bpm < 110:
+		return tempoOrder[1]
+	case bpm < 130:
+		return tempoOrder[2]
+	case bpm < 150:
+		return tempoOrder[3]
+	default:
+		return tempoOrder[4]
+	}
+}
+
+func keyLabel(pref Preference) string {
+	if pref.Context == nil || strings.TrimSpace(pref.Context.Key) == "" {
+		return unknownBucket
+	}
+	return strings.TrimSpace(pref.Context.Key)
+}
+
+var noteStopwords = map[string]bool{
+	"the": true, "and": true, "but": true, "for": true, "with": true, "too": true,
+	"more": true, "less": true, "was": true, "are": true, "this": true, "that": true,
+	"than": true, "not": true, "just": true, "very": true, "bit": true, "feels": true,
+	"feel": true, "sounds": true, "sound": true, "its": true, "it's": true, "like": true,
+	"better": true, "worse": true, "little": true, "much": true, "has": true, "have": true,
+}
+
+// noteClusters groups notes by the keywords two or more of them share,
+// most common first. Ranking pairs between two variations repeat the
+// ranking's note and are skipped.
+func noteClusters(prefs []Preference) []NoteCluster {
+	byKeyword := map[string]*NoteCluster{}
+	for _, pref := range prefs {
+		if pref.Note == "" || pref.Versus != "" {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, word := range strings.FieldsFunc(strings.ToLower(pref.Note), func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '\''
+		}) {
+			word = strings.Trim(word, "'")
+			if len([]rune(word)) < 3 || noteStopwords[word] || seen[word] {
+				continue
+			}
+			seen[word] = true
+			c, ok := byKeyword[word]
+			if !ok {
+				c = &NoteCluster{Keyword: word}
+				byKeyword[word] = c
+			}
+			c.Notes++
+			if pref.Preferred == "variation" {
+				c.Accepted++
+			} else {
+				c.Rejected++
+			}
+			if len(c.Examples) < maxNoteExamples {
+				c.Examples = append(c.Examples, pref.Note)
+			}
+		}
+	}
+	out := []NoteCluster{}
+	for _, c := range byKeyword {
+		if c.Notes >= 2 {
+			out = append(out, *c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Notes != out[j].Notes {
+			return out[i].Notes > out[j].Notes
+		}
+		return out[i].Keyword < out[j].Keyword
+	})
+	if len(out) > maxNoteClusters {
+		out = out[:maxNoteClusters]
+	}
+	return out
+}
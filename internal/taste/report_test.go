@@ -0,0 +1,72 @@
+package taste
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRateWilsonInterval(t *testing.T) {
+	t.Parallel()
+
+	r := newRate(8, 2)
+	if r.Rate != 0.8 || r.Low != 0.49 || r.High != 0.943 {
+		t.Errorf("newRate(8, 2) = %+v", r)
+	}
+	if empty := newRate(0, 0); empty.Rate != 0 || empty.Low != 0 || empty.High != 1 {
+		t.Errorf("newRate(0, 0) = %+v", empty)
+	}
+}
+
+func TestProfileReport(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	ctx := func(bpm float64, key string) *Context { return &Context{TempoBPM: bpm, Key: key} }
+	p := Profile{Preferences: []Preference{
+		{Instrument: "drum", Variation: "groove", Preferred: "source", Note: "Groove too loose", Context: ctx(124, "A minor"), RecordedAt: daysAgo(30)},
+		{Instrument: "drum", Variation: "groove", Preferred: "source", Note: "loose hats", Context: ctx(126, "A minor"), RecordedAt: daysAgo(20)},
+		{Instrument: "drum", Variation: "groove", Preferred: "variation", Note: "Tighter, punchy kick", Context: ctx(140, "C major"), RecordedAt: daysAgo(2)},
+		{Instrument: "drum", Variation: "groove", Preferred: "variation", Note: "punchy", Context: ctx(142, "C major"), RecordedAt: daysAgo(1)},
+		{Instrument: "scene", Variation: "lift", Preferred: "variation", RecordedAt: daysAgo(1)},
+	}}
+
+	got := p.Report(ReportOptions{Now: now})
+	if got.Preferences != 5 || !got.First.Equal(daysAgo(30)) || !got.Last.Equal(daysAgo(1)) {
+		t.Errorf("span = %d %v..%v", got.Preferences, got.First, got.Last)
+	}
+	if len(got.Overall) != 2 || got.Overall[0].Variation != "groove" || got.Overall[0].Rate.Rate != 0.5 {
+		t.Errorf("overall = %+v", got.Overall)
+	}
+
+	if len(got.Trends) != 2 {
+		t.Fatalf("trends = %+v", got.Trends)
+	}
+	if tr := got.Trends[0]; tr.Direction != "up" || tr.Change != 1 || tr.Recent.Accepted != 2 || tr.Earlier.Rejected != 2 {
+		t.Errorf("groove trend = %+v", tr)
+	}
+	if tr := got.Trends[1]; tr.Variation != "lift" || tr.Direction != "new" {
+		t.Errorf("lift trend = %+v", tr)
+	}
+
+	var tempos []string
+	for _, b := range got.ByTempo {
+		tempos = append(tempos, b.Label)
+	}
+	if len(tempos) != 3 || tempos[0] != "110-129" || tempos[1] != "130-149" || tempos[2] != "unknown" {
+		t.Errorf("tempo buckets = %v", tempos)
+	}
+	if b := got.ByKey[0]; b.Label != "A minor" || b.Overall.Rejected != 2 || got.ByKey[len(got.ByKey)-1].Label != "unknown" {
+		t.Errorf("key buckets = %+v", got.ByKey)
+	}
+
+	if len(got.NoteClusters) != 2 {
+		t.Fatalf("clusters = %+v", got.NoteClusters)
+	}
+	if c := got.NoteClusters[0]; c.Keyword != "loose" || c.Notes != 2 || c.Rejected != 2 {
+		t.Errorf("first cluster = %+v", c)
+	}
+	if c := got.NoteClusters[1]; c.Keyword != "punchy" || c.Accepted != 2 || len(c.Examples) != 2 {
+		t.Errorf("second cluster = %+v", c)
+	}
+}
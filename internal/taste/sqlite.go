@@ -0,0 +1,417 @@
+package taste
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go driver, registered as "sqlite"
+)
+
+// sqliteTimeLayout is fixed-width so recorded_at sorts as text.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS preferences (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	profile     TEXT NOT NULL,
+	instrument  TEXT NOT NULL,
+	variation   TEXT NOT NULL,
+	preferred   TEXT NOT NULL,
+	note        TEXT NOT NULL DEFAULT '',
+	params      TEXT,
+	context     TEXT,
+	versus      TEXT NOT NULL DEFAULT '',
+	user        TEXT NOT NULL DEFAULT '',
+	weight      REAL NOT NULL DEFAULT 0,
+	recorded_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS preferences_profile_time ON preferences (profile, recorded_at);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);`
+
+// sqliteAddedColumns were added to preferences after its first release;
+// databases created before rankings lack versus and gain it on open.
+var sqliteAddedColumns = []struct{ name, definition string }{
+	{"versus", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// jsonImportKey marks in meta that ImportJSON has run.
+const jsonImportKey = "json_import"
+
+// SQLiteDB holds every user's preferences in one SQLite file. Each write is
+// a single-row transaction, and WAL mode with a busy timeout lets several
+// server processes write to the same file.
+type SQLiteDB struct {
+	path string
+	db   *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteDB, error) {
+	if path == "" {
+		return nil, errors.New("taste database path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create taste database directory: %w", err)
+	}
+	// Every transaction here writes, so each begins IMMEDIATE: it takes the
+	// write lock up front and waits on busy_timeout, instead of reading
+	// first and failing with SQLITE_BUSY when another process wrote
+	// meanwhile (ImportJSON checks meta and then inserts).
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open taste database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create taste database schema: %w", err)
+	}
+	if err := addSQLiteColumns(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &SQLiteDB{path: path, db: db}, nil
+}
+
+// addSQLiteColumns adds missing sqliteAddedColumns. Another process may add
+// the same column first, so a duplicate column error counts as done.
+func addSQLiteColumns(db *sql.DB) error {
+	for _, column := range sqliteAddedColumns {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('preferences') WHERE name = ?`, column.name).Scan(&n); err != nil {
+			return fmt.Errorf("inspect taste database schema: %w", err)
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE preferences ADD COLUMN ` + column.name + ` ` + column.definition); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return fmt.Errorf("add %s to taste database: %w", column.name, err)
+		}
+	}
+	return nil
+}
+
+func (d *SQLiteDB) Close() error {
+	return d.db.Close()
+}
+
+// Profile returns the store of one user.
+func (d *SQLiteDB) Profile(name string) *SQLiteStore {
+	return &SQLiteStore{db: d, profile: name}
+}
+
+func (d *SQLiteDB) open(name string) Backend {
+	return d.Profile(name)
+}
+
+func (d *SQLiteDB) names() ([]string, error) {
+	rows, err := d.db.Query(`SELECT DISTINCT profile FROM preferences ORDER BY profile`)
+	if err != nil {
+		return nil, fmt.Errorf("list taste users: %w", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("list taste users: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// JSONImport reports what ImportJSON copied.
+type JSONImport struct {
+	Skipped     bool           `json:"skipped,omitempty"`
+	Profiles    map[string]int `json:"profiles,omitempty"`
+	Preferences int            `json:"preferences"`
+}
+
+// ImportJSON copies the JSON profiles of a Users directory layout (the
+// default profile at defaultPath, others as dir/<name>.json) into the
+// database once. Later calls are skipped, so preferences recorded in JSON
+// after switching are not copied twice.
+func (d *SQLiteDB) ImportJSON(defaultPath, dir string) (JSONImport, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return JSONImport{}, fmt.Errorf("import taste profiles: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	var done string
+	err = tx.QueryRow(`SELECT value FROM meta WHERE key = ?`, jsonImportKey).Scan(&done)
+	if err == nil {
+		return JSONImport{Skipped: true}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return JSONImport{}, fmt.Errorf("import taste profiles: %w", err)
+	}
+
+	files := jsonSource{defaultPath: defaultPath, dir: dir}
+	names, err := files.names()
+	if err != nil {
+		return JSONImport{}, err
+	}
+	profiles := []string{DefaultUser}
+	for _, name := range names {
+		if name != DefaultUser && userNamePattern.MatchString(name) {
+			profiles = append(profiles, name)
+		}
+	}
+	result := JSONImport{Profiles: map[string]int{}}
+	for _, name := range profiles {
+		profile, err := ReadProfile(files.path(name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return JSONImport{}, fmt.Errorf("import %s: %w", name, err)
+		}
+		for _, pref := range profile.Preferences {
+			if err := insertPreference(tx, name, pref); err != nil {
+				return JSONImport{}, err
+			}
+		}
+		result.Profiles[name] = len(profile.Preferences)
+		result.Preferences += len(profile.Preferences)
+	}
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES (?, ?)`, jsonImportKey, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return JSONImport{}, fmt.Errorf("import taste profiles: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return JSONImport{}, fmt.Errorf("import taste profiles: %w", err)
+	}
+	return result, nil
+}
+
+// SQLiteStore is one user's preferences in a SQLiteDB.
+type SQLiteStore struct {
+	db      *SQLiteDB
+	profile string
+}
+
+func (s *SQLiteStore) Path() string {
+	return s.db.path
+}
+
+func (s *SQLiteStore) Record(preference Preference) (Summary, error) {
+	return s.RecordAll([]Preference{preference})
+}
+
+// RecordAll inserts preferences in one transaction and counts the profile
+// in the same transaction, so the summary includes exactly these writes.
+func (s *SQLiteStore) RecordAll(preferences []Preference) (Summary, error) {
+	tx, err := s.db.db.Begin()
+	if err != nil {
+		return Summary{}, fmt.Errorf("write taste preference: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	now := time.Now().UTC()
+	for _, preference := range preferences {
+		if preference.RecordedAt.IsZero() {
+			preference.RecordedAt = now
+		}
+		if err := insertPreference(tx, s.profile, preference); err != nil {
+			return Summary{}, err
+		}
+	}
+	summary, err := summarize(tx, s.profile)
+	if err != nil {
+		return Summary{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Summary{}, fmt.Errorf("write taste preference: %w", err)
+	}
+	return summary, nil
+}
+
+func (s *SQLiteStore) Summary() (Summary, error) {
+	return summarize(s.db.db, s.profile)
+}
+
+type sqlQueryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// summarize counts a profile's choices in SQL rather than loading them.
+func summarize(db sqlQueryer, profile string) (Summary, error) {
+	rows, err := db.Query(`SELECT instrument, variation, versus != '', preferred, COUNT(*)
+		FROM preferences WHERE profile = ?
+		GROUP BY instrument, variation, versus != '', preferred`, profile)
+	if err != nil {
+		return Summary{}, fmt.Errorf("summarize taste preferences: %w", err)
+	}
+	defer rows.Close()
+	var b summaryBuilder
+	for rows.Next() {
+		var instrument, variation, preferred string
+		var versus bool
+		var n int
+		if err := rows.Scan(&instrument, &variation, &versus, &preferred, &n); err != nil {
+			return Summary{}, fmt.Errorf("summarize taste preferences: %w", err)
+		}
+		b.add(instrument, variation, preferred, versus, n)
+	}
+	if err := rows.Err(); err != nil {
+		return Summary{}, fmt.Errorf("summarize taste preferences: %w", err)
+	}
+	return b.done(), nil
+}
+
+func (s *SQLiteStore) Load() (Profile, error) {
+	prefs, err := s.Preferences(Filter{})
+	if err != nil {
+		return Profile{}, err
+	}
+	return Profile{Version: profileVersion, Preferences: prefs}, nil
+}
+
+func (s *SQLiteStore) Replace(profile Profile) error {
+	tx, err := s.db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("replace taste profile: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(`DELETE FROM preferences WHERE profile = ?`, s.profile); err != nil {
+		return fmt.Errorf("replace taste profile: %w", err)
+	}
+	for _, pref := range profile.Preferences {
+		if err := insertPreference(tx, s.profile, pref); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("replace taste profile: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Preferences(filter Filter) ([]Preference, error) {
+	where := []string{"profile = ?"}
+	args := []interface{}{s.profile}
+	for _, c := range []struct{ column, value string }{
+		{"instrument", filter.Instrument},
+		{"variation", filter.Variation},
+		{"preferred", filter.Preferred},
+		{"user", filter.User},
+	} {
+		if c.value != "" {
+			where = append(where, c.column+" = ?")
+			args = append(args, c.value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "recorded_at >= ?")
+		args = append(args, filter.Since.UTC().Format(sqliteTimeLayout))
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "recorded_at < ?")
+		args = append(args, filter.Until.UTC().Format(sqliteTimeLayout))
+	}
+	query := `SELECT instrument, variation, versus, preferred, note, params, context, user, weight, recorded_at
+		FROM preferences WHERE ` + strings.Join(where, " AND ") + ` ORDER BY recorded_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query taste preferences: %w", err)
+	}
+	defer rows.Close()
+	prefs := []Preference{}
+	for rows.Next() {
+		pref, err := scanPreference(rows)
+		if err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, pref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query taste preferences: %w", err)
+	}
+	for i, j := 0, len(prefs)-1; i < j; i, j = i+1, j-1 {
+		prefs[i], prefs[j] = prefs[j], prefs[i]
+	}
+	return prefs, nil
+}
+
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertPreference(db sqlExecer, profile string, pref Preference) error {
+	params, err := nullableJSON(pref.Params)
+	if err != nil {
+		return err
+	}
+	context, err := nullableJSON(pref.Context)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO preferences
+		(profile, instrument, variation, versus, preferred, note, params, context, user, weight, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		profile, pref.Instrument, pref.Variation, pref.Versus, pref.Preferred, pref.Note, params, context,
+		pref.User, pref.Weight, pref.RecordedAt.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return fmt.Errorf("write taste preference: %w", err)
+	}
+	return nil
+}
+
+func nullableJSON[T any](v *T) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode taste preference: %w", err)
+	}
+	return string(data), nil
+}
+
+func scanPreference(rows *sql.Rows) (Preference, error) {
+	var pref Preference
+	var params, context sql.NullString
+	var recordedAt string
+	if err := rows.Scan(&pref.Instrument, &pref.Variation, &pref.Versus, &pref.Preferred, &pref.Note,
+		&params, &context, &pref.User, &pref.Weight, &recordedAt); err != nil {
+		return Preference{}, fmt.Errorf("read taste preference: %w", err)
+	}
+	if params.Valid {
+		pref.Params = &VariationParams{}
+		if err := json.Unmarshal([]byte(params.String), pref.Params); err != nil {
+			return Preference{}, fmt.Errorf("read taste preference params: %w", err)
+		}
+	}
+	if context.Valid {
+		pref.Context = &Context{}
+		if err := json.Unmarshal([]byte(context.String), pref.Context); err != nil {
+			return Preference{}, fmt.Errorf("read taste preference context: %w", err)
+		}
+	}
+	at, err := time.Parse(sqliteTimeLayout, recordedAt)
+	if err != nil {
+		return Preference{}, fmt.Errorf("read taste preference time: %w", err)
+	}
+	pref.RecordedAt = at
+	return pref, nil
+}
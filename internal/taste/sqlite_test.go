@@ -0,0 +1,202 @@
+package taste
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func openTestSQLite(t *testing.T, path string) *SQLiteDB {
+	t.Helper()
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestSQLiteStoreRoundTripAndQuery(t *testing.T) {
+	t.Parallel()
+
+	db := openTestSQLite(t, filepath.Join(t.TempDir(), "taste.db"))
+	store := db.Profile("mika")
+	base := time.Date(2026, time.October, 10, 20, 0, 0, 123456789, time.UTC)
+	strength := 0.45
+	track := 3
+	first := Preference{
+		Instrument: "drum", Variation: "groove", Preferred: "variation", Note: "swingy",
+		Params:     &VariationParams{Strength: &strength, MixDeltas: []MixDelta{{TrackIndex: 1, Delta: -0.05}}},
+		Context:    &Context{TempoBPM: 122, Key: "F minor", TrackIndex: &track, TrackName: "Drums"},
+		RecordedAt: base,
+	}
+	if _, err := store.Record(first); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := store.Record(Preference{Instrument: "bass", Variation: "staccato", Preferred: "source", User: "ren", Weight: 2, RecordedAt: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Profile("other").Record(Preference{Instrument: "drum", Variation: "fill", Preferred: "source", RecordedAt: base}); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if profile.Version != profileVersion || len(profile.Preferences) != 4 {
+		t.Fatalf("Load() = %+v", profile)
+	}
+	if got := profile.Preferences[0]; !reflect.DeepEqual(got, first) {
+		t.Errorf("first preference = %+v, want %+v", got, first)
+	}
+	// The GROUP BY summary agrees with counting the loaded rows.
+	summary, err := store.Summary()
+	if err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+	if want := profile.Summary(); !reflect.DeepEqual(summary, want) {
+		t.Errorf("Summary() = %+v, want %+v", summary, want)
+	}
+
+	got, err := store.Preferences(Filter{Instrument: "bass", User: "ren", Since: base.Add(90 * time.Minute), Limit: 1})
+	if err != nil {
+		t.Fatalf("Preferences() error = %v", err)
+	}
+	if len(got) != 1 || !got[0].RecordedAt.Equal(base.Add(3*time.Hour)) || got[0].Weight != 2 {
+		t.Errorf("Preferences() = %+v", got)
+	}
+
+	if err := store.Replace(Profile{Preferences: profile.Preferences[:1]}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	users := NewSQLiteUsers(db)
+	names, err := users.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{DefaultUser, "mika", "other"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+	if profile, _ := store.Load(); len(profile.Preferences) != 1 {
+		t.Errorf("after Replace = %d preferences", len(profile.Preferences))
+	}
+}
+
+func TestSQLiteConcurrentWriters(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "taste.db")
+	// Two handles stand in for two server processes sharing the file.
+	handles := []*SQLiteDB{openTestSQLite(t, path), openTestSQLite(t, path)}
+	const perWriter = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*perWriter)
+	for w, db := range handles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				_, err := db.Profile(DefaultUser).Record(Preference{Instrument: "scene", Variation: "lift", Preferred: "variation", Note: fmt.Sprintf("w%d-%d", w, i)})
+				if err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent Record() error = %v", err)
+	}
+	profile, err := handles[0].Profile(DefaultUser).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.Preferences) != 2*perWriter {
+		t.Errorf("preferences = %d, want %d", len(profile.Preferences), 2*perWriter)
+	}
+}
+
+func TestSQLiteImportJSONOnce(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	jsonUsers, err := NewUsers(filepath.Join(dir, "taste-profile.json"), filepath.Join(dir, "users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for user, n := range map[string]int{DefaultUser: 2, "mika": 1} {
+		_, store, _ := jsonUsers.Lookup(user)
+		for i := 0; i < n; i++ {
+			if _, err := store.Record(Preference{Instrument: "drum", Variation: "fill", Preferred: "source"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	db := openTestSQLite(t, filepath.Join(dir, "taste.db"))
+	got, err := db.ImportJSON(filepath.Join(dir, "taste-profile.json"), filepath.Join(dir, "users"))
+	if err != nil {
+		t.Fatalf("ImportJSON() error = %v", err)
+	}
+	if got.Skipped || got.Preferences != 3 || got.Profiles["mika"] != 1 {
+		t.Errorf("ImportJSON() = %+v", got)
+	}
+	again, err := db.ImportJSON(filepath.Join(dir, "taste-profile.json"), filepath.Join(dir, "users"))
+	if err != nil || !again.Skipped {
+		t.Errorf("second ImportJSON() = %+v, %v", again, err)
+	}
+	if profile, _ := db.Profile(DefaultUser).Load(); len(profile.Preferences) != 2 {
+		t.Errorf("default profile = %d preferences, want 2", len(profile.Preferences))
+	}
+}
+
+func TestOpenSQLiteAddsVersusToOlderDatabases(t *testing.T) {
+	t.Parallel()
+
+	// The preferences table as first released, before rankings added versus.
+	path := filepath.Join(t.TempDir(), "taste.db")
+	old, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Exec(`CREATE TABLE preferences (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		profile     TEXT NOT NULL,
+		instrument  TEXT NOT NULL,
+		variation   TEXT NOT NULL,
+		preferred   TEXT NOT NULL,
+		note        TEXT NOT NULL DEFAULT '',
+		params      TEXT,
+		context     TEXT,
+		user        TEXT NOT NULL DEFAULT '',
+		weight      REAL NOT NULL DEFAULT 0,
+		recorded_at TEXT NOT NULL
+	);
+	INSERT INTO preferences (profile, instrument, variation, preferred, recorded_at)
+	VALUES ('default', 'drum', 'fill', 'source', '2026-10-01T10:00:00.000000000Z');`); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store := openTestSQLite(t, path).Profile(DefaultUser)
+	summary, err := store.Record(Preference{Instrument: "drum", Variation: "fill", Versus: "groove", Preferred: "variation", Weight: 0.5})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	want := Summary{Preferences: 2, Tallies: []Tally{{Instrument: "drum", Variation: "fill", Rejected: 1, VersusWins: 1}}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Record() = %+v, want %+v", summary, want)
+	}
+	// A second open finds the column already there.
+	openTestSQLite(t, path)
+}
@@ -0,0 +1,162 @@
+package taste
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultUser is the profile at the configured taste profile path, used when
+// no user is selected.
+const DefaultUser = "default"
+
+var userNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Users hands out one Backend per named user. The active user is the one
+// tools use when a call names none.
+type Users struct {
+	source userSource
+
+	mu     sync.Mutex
+	stores map[string]Backend
+	active string
+}
+
+// userSource opens a user's backend and lists users with saved choices
+// (besides DefaultUser).
+type userSource interface {
+	open(name string) Backend
+	names() ([]string, error)
+}
+
+// NewUsers keeps each user in a JSON file: DefaultUser at defaultPath,
+// every other user in dir as <name>.json.
+func NewUsers(defaultPath, dir string) (*Users, error) {
+	if defaultPath == "" {
+		return nil, errors.New("taste profile path is required")
+	}
+	if dir == "" {
+		return nil, errors.New("taste users directory is required")
+	}
+	return newUsers(jsonSource{defaultPath: defaultPath, dir: dir}), nil
+}
+
+// NewSQLiteUsers keeps every user in db.
+func NewSQLiteUsers(db *SQLiteDB) *Users {
+	return newUsers(db)
+}
+
+func newUsers(source userSource) *Users {
+	return &Users{source: source, stores: map[string]Backend{}, active: DefaultUser}
+}
+
+// NormalizeUser lower-cases and validates a user name; empty stays empty.
+func NormalizeUser(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", nil
+	}
+	if !userNamePattern.MatchString(name) {
+		return "", fmt.Errorf("taste user %q must be 1-64 letters, digits, - or _", name)
+	}
+	return name, nil
+}
+
+// Lookup returns the user name and store for name, or for the active user
+// when name is empty.
+func (u *Users) Lookup(name string) (string, Backend, error) {
+	name, err := NormalizeUser(name)
+	if err != nil {
+		return "", nil, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if name == "" {
+		name = u.active
+	}
+	return name, u.store(name), nil
+}
+
+func (u *Users) store(name string) Backend {
+	if s, ok := u.stores[name]; ok {
+		return s
+	}
+	s := u.source.open(name)
+	u.stores[name] = s
+	return s
+}
+
+// Active is the user selected for this session.
+func (u *Users) Active() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.active
+}
+
+// SetActive selects the user later calls default to; empty selects
+// DefaultUser. The user's profile is created by its first recorded choice.
+func (u *Users) SetActive(name string) (Backend, error) {
+	name, err := NormalizeUser(name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = DefaultUser
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.active = name
+	return u.store(name), nil
+}
+
+// List returns DefaultUser and every user with a saved profile, sorted.
+func (u *Users) List() ([]string, error) {
+	saved, err := u.source.names()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{DefaultUser}
+	for _, name := range saved {
+		if name != DefaultUser && userNamePattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names, nil
+}
+
+// jsonSource is the JSON file layout of NewUsers.
+type jsonSource struct {
+	defaultPath string
+	dir         string
+}
+
+func (s jsonSource) path(name string) string {
+	if name == DefaultUser {
+		return s.defaultPath
+	}
+	return filepath.Join(s.dir, name+".json")
+}
+
+func (s jsonSource) open(name string) Backend {
+	return &Store{path: s.path(name)}
+}
+
+func (s jsonSource) names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("list taste users: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
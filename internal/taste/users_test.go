@@ -0,0 +1,90 @@
+package taste
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUsersLookupAndList(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	users, err := NewUsers(filepath.Join(dir, "taste-profile.json"), filepath.Join(dir, "users"))
+	if err != nil {
+		t.Fatalf("NewUsers() error = %v", err)
+	}
+	name, store, err := users.Lookup("")
+	if err != nil || name != DefaultUser || store.Path() != filepath.Join(dir, "taste-profile.json") {
+		t.Fatalf("Lookup(\"\") = %q %v %v", name, store, err)
+	}
+	if _, err := users.SetActive(" Mika "); err != nil {
+		t.Fatalf("SetActive() error = %v", err)
+	}
+	name, store, _ = users.Lookup("")
+	if name != "mika" || store.Path() != filepath.Join(dir, "users", "mika.json") {
+		t.Errorf("active store = %q %s", name, store.Path())
+	}
+	if _, err := store.Record(Preference{Instrument: "drum", Variation: "fill", Preferred: "variation"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := users.Lookup("../etc"); err == nil {
+		t.Error("expected an error for a path-like user name")
+	}
+	got, err := users.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if want := []string{DefaultUser, "mika"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func TestMergeDeduplicatesAndResolvesConflicts(t *testing.T) {
+	t.Parallel()
+
+	at := func(min int) time.Time { return time.Date(2026, time.October, 1, 10, min, 0, 0, time.UTC) }
+	shared := Preference{Instrument: "drum", Variation: "groove", Preferred: "variation", RecordedAt: at(1)}
+	mika := Profile{Preferences: []Preference{
+		{Instrument: "bass", Variation: "staccato", Preferred: "variation", RecordedAt: at(5)},
+		shared,
+		{Instrument: "scene", Variation: "lift", Preferred: "source", RecordedAt: at(3)},
+	}}
+	ren := Profile{Preferences: []Preference{
+		shared,
+		{Instrument: "scene", Variation: "lift", Preferred: "variation", RecordedAt: at(3)},
+		{Instrument: "mix", Variation: "volume", Preferred: "source", RecordedAt: at(2)},
+	}}
+
+	got, report, err := Merge([]MergeSource{{User: "mika", Profile: mika}, {User: "ren", Weight: 2, Profile: ren}})
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if report.Merged != 4 || report.Duplicates != 1 || len(report.Conflicts) != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if c := report.Conflicts[0]; c.KeptUser != "ren" || c.KeptPreferred != "variation" || c.DroppedUser != "mika" {
+		t.Errorf("conflict = %+v", c)
+	}
+	for i := 1; i < len(got.Preferences); i++ {
+		if got.Preferences[i].RecordedAt.Before(got.Preferences[i-1].RecordedAt) {
+			t.Fatalf("preferences not in time order: %+v", got.Preferences)
+		}
+	}
+	if p := got.Preferences[0]; p.User != "mika" || p.Weight != 0 {
+		t.Errorf("first = %+v, want mika's groove at weight 1", p)
+	}
+	if p := got.Preferences[1]; p.User != "ren" || p.EffectiveWeight() != 2 {
+		t.Errorf("second = %+v, want ren's mix at weight 2", p)
+	}
+
+	// Heavier users count more in the bandit estimates.
+	if e := got.RankVariations("scene", []string{"lift"})[0]; e.Accepted != 2 || e.Rejected != 0 {
+		t.Errorf("weighted estimate = %+v", e)
+	}
+
+	if _, _, err := Merge([]MergeSource{{User: "x", Weight: -1}}); err == nil {
+		t.Error("expected an error for a negative weight")
+	}
+}
@@ -8,8 +8,11 @@ import (
 )
 
 type AnalyzeLocalAudioInput struct {
-	Path         string   `json:"path" jsonschema:"description=Absolute local path to a .wav file you already have (no URLs)"`
+	Path         string   `json:"path" jsonschema:"description=Absolute local path to a .wav, .aif/.aiff/.aifc, .flac or .mp3 file you already have (no URLs)"`
 	ProjectTempo *float64 `json:"project_tempo,omitempty" jsonschema:"description=Optional project BPM to estimate length in bars,minimum=20,maximum=400"`
+	Spectrum     string   `json:"spectrum,omitempty" jsonschema:"description=Optional detail level: third_octave or sixth_octave adds an averaged spectrum, spectral tilt, resonances and per-section spectra (default none, keeps the response small)"`
+	Streaming    bool     `json:"streaming,omitempty" jsonschema:"description=Analyze the whole file chunk by chunk in bounded memory (for DJ mixes and full stems) and report per-segment tempo/key; files over 80 MiB or 5 minutes stream automatically. Skips onsets, chords, beat grid, texture and spectrum"`
+	Refresh      bool     `json:"refresh,omitempty" jsonschema:"description=Ignore any cached result for this file and re-analyze"`
 }
 
 type AnalyzeLocalAudioOutput struct {
@@ -20,14 +23,17 @@ type AnalyzeLocalAudioOutput struct {
 	Channels          int                            `json:"channels"`
 	PeakLevel         float64                        `json:"peak_level"`
 	RMSLevel          float64                        `json:"rms_level"`
+	Loudness          *audioanalyze.Loudness         `json:"loudness,omitempty"`
 	EstimatedBPM      float64                        `json:"estimated_bpm"`
 	BPMConfidence     float64                        `json:"bpm_confidence"`
 	BPMAlternatives   []audioanalyze.TempoHypothesis `json:"bpm_alternatives,omitempty"`
+	BeatGrid          *audioanalyze.BeatGrid         `json:"beat_grid,omitempty"`
 	OnsetCount        int                            `json:"onset_count"`
 	Onsets            []audioanalyze.Onset           `json:"onsets,omitempty"`
 	RhythmDensity     float64                        `json:"rhythm_density,omitempty"`
 	RMSPerBeat        []float64                      `json:"rms_per_beat,omitempty"`
 	BandBalance       *audioanalyze.BandBalance      `json:"band_balance,omitempty"`
+	Spectrum          *audioanalyze.Spectrum         `json:"spectrum,omitempty"`
 	SuggestedWarpMode string                         `json:"suggested_warp_mode"`
 	Key               string                         `json:"key,omitempty"`
 	Scale             string                         `json:"scale,omitempty"`
@@ -36,30 +42,51 @@ type AnalyzeLocalAudioOutput struct {
 	ChordProgression  []audioanalyze.ChordSegment    `json:"chord_progression,omitempty"`
 	ChordSummary      string                         `json:"chord_summary,omitempty"`
 	Sections          []audioanalyze.Section         `json:"sections,omitempty"`
+	Segments          []audioanalyze.Segment         `json:"segments,omitempty"`
 	MatchAxes         []audioanalyze.MatchAxis       `json:"match_axes,omitempty"`
 	BrightnessHz      float64                        `json:"brightness_hz,omitempty"`
 	CrestFactorDB     float64                        `json:"crest_factor_db,omitempty"`
 	StereoWidth       float64                        `json:"stereo_width"`
 	LengthBarsAtBPM   float64                        `json:"length_bars_at_project_tempo,omitempty"`
+	Streamed          bool                           `json:"streamed,omitempty"`
+	Cached            bool                           `json:"cached" jsonschema:"description=Served from the on-disk analysis cache (same file content and analyzer version)"`
 	Note              string                         `json:"note"`
 	NextStep          string                         `json:"next_step"`
 }
 
-func NewAbletonAnalyzeLocalAudio(g *genkit.Genkit) ai.Tool {
+func NewAbletonAnalyzeLocalAudio(g *genkit.Genkit, cache *audioanalyze.Cache) ai.Tool {
 	return genkit.DefineTool(g, "ableton_analyze_local_audio",
-		"Analyze a local .wav for sampling placement: duration/levels, BPM (+ half/double alternatives), key/scale (+ alternative), chords, section map, onset grid {beat,sec,strength}, rhythm_density, rms_per_beat, band_balance (low/mid/high), match_axes (density/low-end/space), and texture (brightness/dynamics/stereo). No URLs, no melody/note extraction.",
+		"Analyze a local audio file (.wav, .aiff, .flac, .mp3) for sampling placement: duration/levels, EBU R128 loudness (integrated/short-term/momentary LUFS, LRA, true peak), BPM (+ half/double alternatives), beat grid (beat/downbeat times, tempo drift, first downbeat), key/scale (+ alternative), chords, section map, onset grid {beat,sec,strength}, rhythm_density, rms_per_beat, band_balance (low/mid/high), match_axes (density/low-end/space), and texture (brightness/dynamics/stereo). Optional spectrum=third_octave|sixth_octave adds a fine spectrum with tilt, resonances and per-section spectra. streaming=true (automatic over 80 MiB or 5 minutes) analyzes whole DJ mixes or stems in bounded memory and returns segments with their own tempo, key and loudness. Results are cached on disk by file content (cached=true on a hit; refresh=true re-analyzes). No URLs, no melody/note extraction.",
 		func(_ *ai.ToolContext, input AnalyzeLocalAudioInput) (AnalyzeLocalAudioOutput, error) {
-			return analyzeLocalAudio(input)
+			return analyzeLocalAudio(input, cache)
 		},
 	)
 }
 
-func analyzeLocalAudio(input AnalyzeLocalAudioInput) (AnalyzeLocalAudioOutput, error) {
+// analyzeLocalAudio analyzes input.Path through cache when one is given.
+func analyzeLocalAudio(input AnalyzeLocalAudioInput, cache *audioanalyze.Cache) (AnalyzeLocalAudioOutput, error) {
 	projectTempo := 0.0
 	if input.ProjectTempo != nil {
 		projectTempo = *input.ProjectTempo
 	}
-	got, err := audioanalyze.AnalyzeFile(input.Path, projectTempo)
+	opts := audioanalyze.AnalyzeOptions{
+		ProjectTempo: projectTempo,
+		Spectrum:     input.Spectrum,
+		Streaming:    input.Streaming,
+	}
+	var (
+		got    audioanalyze.Result
+		cached bool
+		err    error
+	)
+	switch {
+	case cache == nil:
+		got, err = audioanalyze.AnalyzeFileWithOptions(input.Path, opts)
+	case input.Refresh:
+		got, err = cache.Refresh(input.Path, opts)
+	default:
+		got, cached, err = cache.AnalyzeFile(input.Path, opts)
+	}
 	if err != nil {
 		return AnalyzeLocalAudioOutput{}, err
 	}
@@ -71,14 +98,17 @@ func analyzeLocalAudio(input AnalyzeLocalAudioInput) (AnalyzeLocalAudioOutput, e
 		Channels:          got.Channels,
 		PeakLevel:         got.PeakLevel,
 		RMSLevel:          got.RMSLevel,
+		Loudness:          got.Loudness,
 		EstimatedBPM:      got.EstimatedBPM,
 		BPMConfidence:     got.BPMConfidence,
 		BPMAlternatives:   got.BPMAlternatives,
+		BeatGrid:          got.BeatGrid,
 		OnsetCount:        got.OnsetCount,
 		Onsets:            got.Onsets,
 		RhythmDensity:     got.RhythmDensity,
 		RMSPerBeat:        got.RMSPerBeat,
 		BandBalance:       got.BandBalance,
+		Spectrum:          got.Spectrum,
 		SuggestedWarpMode: got.SuggestedWarpMode,
 		Key:               got.Key,
 		Scale:             got.Scale,
@@ -87,11 +117,14 @@ func analyzeLocalAudio(input AnalyzeLocalAudioInput) (AnalyzeLocalAudioOutput, e
 		ChordProgression:  got.ChordProgression,
 		ChordSummary:      got.ChordSummary,
 		Sections:          got.Sections,
+		Segments:          got.Segments,
 		MatchAxes:         got.MatchAxes,
 		BrightnessHz:      got.BrightnessHz,
 		CrestFactorDB:     got.CrestFactorDB,
 		StereoWidth:       got.StereoWidth,
 		LengthBarsAtBPM:   got.LengthBarsAtBPM,
+		Streamed:          got.Streamed,
+		Cached:            cached,
 		Note:              got.Note,
 		NextStep:          "Use match_axes + band_balance for arrangement decisions; for chop placement use onsets/rms_per_beat. If loading into Live, call ableton_match_clip_tempo with suggested_warp_mode.",
 	}, nil
@@ -5,12 +5,14 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
 )
 
 func TestAnalyzeLocalAudioRejectsURL(t *testing.T) {
 	t.Parallel()
 
-	_, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: "https://youtube.com/watch?v=abc"})
+	_, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: "https://youtube.com/watch?v=abc"}, nil)
 	if err == nil {
 		t.Fatal("expected URL rejection")
 	}
@@ -22,7 +24,7 @@ func TestAnalyzeLocalAudioWAV(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "tone.wav")
 	writeSilentWAV(t, path, 44100, 1)
 	tempo := 128.0
-	got, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: path, ProjectTempo: &tempo})
+	got, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: path, ProjectTempo: &tempo}, nil)
 	if err != nil {
 		t.Fatalf("analyzeLocalAudio() error = %v", err)
 	}
@@ -31,6 +33,30 @@ func TestAnalyzeLocalAudioWAV(t *testing.T) {
 	}
 }
 
+func TestAnalyzeLocalAudioReportsCached(t *testing.T) {
+	t.Parallel()
+
+	cache, err := audioanalyze.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	writeSilentWAV(t, path, 44100, 1)
+	for i, want := range []bool{false, true} {
+		got, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: path}, cache)
+		if err != nil {
+			t.Fatalf("call %d error = %v", i, err)
+		}
+		if got.Cached != want {
+			t.Errorf("call %d cached = %v, want %v", i, got.Cached, want)
+		}
+	}
+	got, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: path, Refresh: true}, cache)
+	if err != nil || got.Cached {
+		t.Errorf("refresh cached = %v, err = %v", got.Cached, err)
+	}
+}
+
 func writeSilentWAV(t *testing.T, path string, sampleRate, seconds int) {
 	t.Helper()
 	samples := sampleRate * seconds
@@ -63,3 +89,31 @@ func writeSilentWAV(t *testing.T, path string, sampleRate, seconds int) {
 		t.Fatal(err)
 	}
 }
+
+func TestCompareAudioFilesRejectsURL(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	writeSilentWAV(t, path, 44100, 1)
+	_, err := compareAudioFiles(CompareAudioFilesInput{MixPath: path, ReferencePath: "https://example.com/ref.wav"})
+	if err == nil {
+		t.Fatal("expected URL rejection for the reference")
+	}
+}
+
+func TestAnalyzeLocalAudioSpectrumDetail(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	writeSilentWAV(t, path, 44100, 1)
+	got, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: path}, nil)
+	if err != nil {
+		t.Fatalf("analyzeLocalAudio() error = %v", err)
+	}
+	if got.Spectrum != nil {
+		t.Error("spectrum should be omitted by default")
+	}
+	if _, err := analyzeLocalAudio(AnalyzeLocalAudioInput{Path: path, Spectrum: "octave"}, nil); err == nil {
+		t.Error("expected error for unsupported spectrum resolution")
+	}
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+)
+
+const (
+	defaultFolderRows = 100
+	maxFolderRows     = 500
+)
+
+type AnalyzeFolderInput struct {
+	Folder       string   `json:"folder" jsonschema:"description=Absolute local folder (e.g. a Splice pack or stems folder); subfolders are included"`
+	ProjectTempo *float64 `json:"project_tempo,omitempty" jsonschema:"description=Optional project BPM; fills tempo_distance (half/double time counts as a match) and enables sort_by=tempo_distance,minimum=20,maximum=400"`
+	SortBy       string   `json:"sort_by,omitempty" jsonschema:"description=path (default), bpm, key, duration, lufs, brightness, density or tempo_distance"`
+	Descending   bool     `json:"descending,omitempty"`
+	Recursive    *bool    `json:"recursive,omitempty" jsonschema:"description=Include subfolders (default true)"`
+	Workers      int      `json:"workers,omitempty" jsonschema:"description=Concurrent analyses (default min(CPUs, 4)),minimum=1,maximum=8"`
+	Limit        int      `json:"limit,omitempty" jsonschema:"description=Rows returned after sorting (default 100); the report file always has every row,minimum=1,maximum=500"`
+	Report       string   `json:"report,omitempty" jsonschema:"description=Optionally write csv or json next to the folder as <folder>-analysis.csv/.json"`
+}
+
+type AnalyzeFolderOutput struct {
+	Root       string                     `json:"root"`
+	Files      []audioanalyze.FolderEntry `json:"files"`
+	Total      int                        `json:"total"`
+	Analyzed   int                        `json:"analyzed"`
+	Cached     int                        `json:"cached"`
+	Failed     int                        `json:"failed"`
+	Truncated  bool                       `json:"truncated,omitempty"`
+	ReportPath string                     `json:"report_path,omitempty"`
+	NextStep   string                     `json:"next_step"`
+}
+
+func NewAbletonAnalyzeFolder(g *genkit.Genkit, cache *audioanalyze.Cache) ai.Tool {
+	return genkit.DefineTool(g, "ableton_analyze_folder",
+		"Analyze every local audio file in a folder (Splice pack, stems) concurrently and return a sortable summary per file: BPM, key/scale, duration, integrated LUFS, brightness, rhythm density and distance from project_tempo. Reuses the analysis cache. Optional report=csv|json writes <folder>-analysis.csv/.json next to the folder. No URLs.",
+		func(_ *ai.ToolContext, input AnalyzeFolderInput) (AnalyzeFolderOutput, error) {
+			return analyzeFolder(input, cache)
+		},
+	)
+}
+
+func analyzeFolder(input AnalyzeFolderInput, cache *audioanalyze.Cache) (AnalyzeFolderOutput, error) {
+	reportFormat := strings.ToLower(strings.TrimSpace(input.Report))
+	if reportFormat != "" && reportFormat != "csv" && reportFormat != "json" {
+		return AnalyzeFolderOutput{}, fmt.Errorf("report must be csv or json (got %q)", input.Report)
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultFolderRows
+	}
+	if limit > maxFolderRows {
+		return AnalyzeFolderOutput{}, fmt.Errorf("limit must be <= %d", maxFolderRows)
+	}
+	projectTempo := 0.0
+	if input.ProjectTempo != nil {
+		projectTempo = *input.ProjectTempo
+	}
+	// Validate the sort key before spending time on analysis.
+	if err := audioanalyze.SortFolderEntries(nil, input.SortBy, input.Descending); err != nil {
+		return AnalyzeFolderOutput{}, err
+	}
+
+	report, err := audioanalyze.AnalyzeFolder(input.Folder, audioanalyze.FolderOptions{
+		ProjectTempo: projectTempo,
+		Workers:      input.Workers,
+		NonRecursive: input.Recursive != nil && !*input.Recursive,
+		Cache:        cache,
+	})
+	if err != nil {
+		return AnalyzeFolderOutput{}, err
+	}
+	if err := audioanalyze.SortFolderEntries(report.Files, input.SortBy, input.Descending); err != nil {
+		return AnalyzeFolderOutput{}, err
+	}
+
+	out := AnalyzeFolderOutput{
+		Root:      report.Root,
+		Files:     report.Files,
+		Total:     len(report.Files),
+		Analyzed:  report.Analyzed,
+		Cached:    report.Cached,
+		Failed:    report.Failed,
+		Truncated: report.Truncated,
+		NextStep:  "Pick rows whose key matches the project and tempo_distance is small, then load them with ableton_load_splice_sample or drag them in and call ableton_match_clip_tempo.",
+	}
+	if len(out.Files) > limit {
+		out.Files = out.Files[:limit]
+	}
+	if reportFormat != "" {
+		path, err := writeFolderReport(report, reportFormat)
+		if err != nil {
+			return AnalyzeFolderOutput{}, err
+		}
+		out.ReportPath = path
+	}
+	return out, nil
+}
+
+// writeFolderReport writes report as <root>-analysis.<format> beside the
+// analyzed folder, replacing an earlier report.
+func writeFolderReport(report audioanalyze.FolderReport, format string) (string, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "csv" {
+		err = report.WriteCSV(&buf)
+	} else {
+		err = report.WriteJSON(&buf)
+	}
+	if err != nil {
+		return "", fmt.Errorf("encode folder report: %w", err)
+	}
+	path := filepath.Join(filepath.Dir(report.Root), filepath.Base(report.Root)+"-analysis."+format)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return "", fmt.Errorf("write folder report: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeFolderLimitAndCSVReport(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join(t.TempDir(), "pack")
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.wav", "b.wav", "c.wav"} {
+		writeSilentWAV(t, filepath.Join(root, name), 44100, 1)
+	}
+
+	got, err := analyzeFolder(AnalyzeFolderInput{Folder: root, SortBy: "path", Descending: true, Limit: 2, Report: "csv"}, nil)
+	if err != nil {
+		t.Fatalf("analyzeFolder() error = %v", err)
+	}
+	if got.Total != 3 || len(got.Files) != 2 || got.Files[0].RelativePath != "c.wav" {
+		t.Errorf("got = %+v", got)
+	}
+	if got.ReportPath != root+"-analysis.csv" {
+		t.Fatalf("report_path = %q", got.ReportPath)
+	}
+	f, err := os.Open(got.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Errorf("report rows = %d, want header + 3", len(rows))
+	}
+}
+
+func TestAnalyzeFolderRejectsBadInput(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, input := range []AnalyzeFolderInput{
+		{Folder: root, Report: "xlsx"},
+		{Folder: root, SortBy: "color"},
+		{Folder: root, Limit: 501},
+		{Folder: "https://example.com/pack"},
+		{Folder: root}, // no audio files
+	} {
+		if _, err := analyzeFolder(input, nil); err == nil {
+			t.Errorf("analyzeFolder(%+v) expected error", input)
+		}
+	}
+}
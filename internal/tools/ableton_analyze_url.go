@@ -20,9 +20,11 @@ type AnalyzeAudioURLOutput struct {
 	Channels          int                            `json:"channels"`
 	PeakLevel         float64                        `json:"peak_level"`
 	RMSLevel          float64                        `json:"rms_level"`
+	Loudness          *audioanalyze.Loudness         `json:"loudness,omitempty"`
 	EstimatedBPM      float64                        `json:"estimated_bpm"`
 	BPMConfidence     float64                        `json:"bpm_confidence"`
 	BPMAlternatives   []audioanalyze.TempoHypothesis `json:"bpm_alternatives,omitempty"`
+	BeatGrid          *audioanalyze.BeatGrid         `json:"beat_grid,omitempty"`
 	OnsetCount        int                            `json:"onset_count"`
 	RhythmDensity     float64                        `json:"rhythm_density,omitempty"`
 	RMSPerBeat        []float64                      `json:"rms_per_beat,omitempty"`
@@ -64,9 +66,11 @@ func NewAbletonAnalyzeAudioURL(g *genkit.Genkit) ai.Tool {
 				Channels:          got.Channels,
 				PeakLevel:         got.PeakLevel,
 				RMSLevel:          got.RMSLevel,
+				Loudness:          got.Loudness,
 				EstimatedBPM:      got.EstimatedBPM,
 				BPMConfidence:     got.BPMConfidence,
 				BPMAlternatives:   got.BPMAlternatives,
+				BeatGrid:          got.BeatGrid,
 				OnsetCount:        got.OnsetCount,
 				RhythmDensity:     got.RhythmDensity,
 				RMSPerBeat:        got.RMSPerBeat,
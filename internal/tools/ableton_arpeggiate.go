@@ -0,0 +1,375 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+)
+
+const (
+	defaultArpPattern = "up"
+	defaultArpRate    = 0.25 // 1/16
+	defaultArpOctaves = 1
+	defaultArpGate    = 0.8
+	minArpRate        = 0.0625
+	maxArpRate        = 4.0
+	maxArpOctaves     = 4
+)
+
+type ArpeggiateClipInput struct {
+	TrackIndex       int      `json:"track_index" jsonschema:"minimum=0"`
+	SourceClipIndex  int      `json:"source_clip_index" jsonschema:"description=Chord clip to arpeggiate,minimum=0"`
+	TargetClipIndex  int      `json:"target_clip_index" jsonschema:"description=Must be an empty clip slot,minimum=0"`
+	TargetTrackIndex *int     `json:"target_track_index,omitempty" jsonschema:"description=Track for the arpeggiated clip (default: same track),minimum=0"`
+	Pattern          string   `json:"pattern,omitempty" jsonschema:"description=up, down, up_down, converge, random, or repeat (whole chord re-triggered every step, like a note repeat) (default up)"`
+	Rate             *float64 `json:"rate,omitempty" jsonschema:"description=Step length in beats (0.25 = 1/16, 0.5 = 1/8, 0.3333 = 1/8 triplet; default 0.25),minimum=0.0625,maximum=4"`
+	Octaves          *int     `json:"octaves,omitempty" jsonschema:"description=Octave range the pattern climbs through (default 1),minimum=1,maximum=4"`
+	Gate             *float64 `json:"gate,omitempty" jsonschema:"description=Note length as a fraction of the step (default 0.8; >1 overlaps),minimum=0.05,maximum=1.5"`
+	Swing            *float64 `json:"swing,omitempty" jsonschema:"description=Delay every second step 0-1 (default 0),minimum=0,maximum=1"`
+	ChordMemory      []int    `json:"chord_memory,omitempty" jsonschema:"description=Semitone offsets stacked on every source note before arpeggiating (e.g. [0,7,12] turns a mono line into power chords)"`
+	StrumOffset      *float64 `json:"strum_offset,omitempty" jsonschema:"description=Beats between voices of each repeat hit, low to high (repeat pattern only; default 0),minimum=0,maximum=0.5"`
+	Seed             *int64   `json:"seed,omitempty" jsonschema:"description=Optional RNG seed for reproducible random patterns"`
+	Fire             bool     `json:"fire,omitempty" jsonschema:"description=Fire the arpeggiated clip after creating it"`
+}
+
+type ArpeggiateClipOutput struct {
+	TrackIndex       int     `json:"track_index"`
+	SourceClipIndex  int     `json:"source_clip_index"`
+	TargetTrackIndex int     `json:"target_track_index"`
+	TargetClipIndex  int     `json:"target_clip_index"`
+	Pattern          string  `json:"pattern"`
+	Rate             float64 `json:"rate"`
+	Octaves          int     `json:"octaves"`
+	Gate             float64 `json:"gate"`
+	Swing            float64 `json:"swing"`
+	ChordMemory      []int   `json:"chord_memory,omitempty"`
+	StrumOffset      float64 `json:"strum_offset,omitempty"`
+	Seed             int64   `json:"seed"`
+	LengthBeats      float64 `json:"length_beats"`
+	NotesAdded       int     `json:"notes_added"`
+	Fired            bool    `json:"fired"`
+}
+
+type arpOptions struct {
+	Pattern string
+	Rate    float64
+	Octaves int
+	Gate    float64
+	Swing   float64
+	Memory  []int   // semitone offsets stacked on each source note
+	Strum   float64 // beats between voices of a repeat hit
+	Seed    int64
+}
+
+func NewAbletonArpeggiateClip(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_arpeggiate_clip",
+		"Ableton Live: render an arpeggio (up, down, up_down, converge, random), note repeat, chord memory or strum from a chord clip into editable notes in an empty slot, with rate, octave range, gate and swing — instead of live MIDI effects whose output can't be edited",
+		func(_ *ai.ToolContext, input ArpeggiateClipInput) (ArpeggiateClipOutput, error) {
+			return arpeggiateClip(client, input)
+		},
+	)
+}
+
+func arpeggiateClip(client variationClient, input ArpeggiateClipInput) (ArpeggiateClipOutput, error) {
+	if err := validateTrackClipIndices(input.TrackIndex, input.SourceClipIndex); err != nil {
+		return ArpeggiateClipOutput{}, err
+	}
+	if input.TargetClipIndex < 0 {
+		return ArpeggiateClipOutput{}, errors.New("target_clip_index must be >= 0")
+	}
+	targetTrack := input.TrackIndex
+	if input.TargetTrackIndex != nil {
+		if *input.TargetTrackIndex < 0 {
+			return ArpeggiateClipOutput{}, errors.New("target_track_index must be >= 0")
+		}
+		targetTrack = *input.TargetTrackIndex
+	}
+	if targetTrack == input.TrackIndex && input.SourceClipIndex == input.TargetClipIndex {
+		return ArpeggiateClipOutput{}, errors.New("source and target clip slots must differ")
+	}
+	opts, err := resolveArpOptions(input)
+	if err != nil {
+		return ArpeggiateClipOutput{}, err
+	}
+
+	targetHasClip, err := queryClipHasClip(client, targetTrack, input.TargetClipIndex)
+	if err != nil {
+		return ArpeggiateClipOutput{}, fmt.Errorf("check target slot: %w", err)
+	}
+	if targetHasClip {
+		return ArpeggiateClipOutput{}, errors.New("target_clip_index must be an empty slot to preserve A/B comparison")
+	}
+
+	res, err := client.Query("/live/clip/get/notes", int32(input.TrackIndex), int32(input.SourceClipIndex))
+	if err != nil {
+		return ArpeggiateClipOutput{}, fmt.Errorf("get source notes: %w", err)
+	}
+	_, _, sourceNotes, err := parseClipNotesResponse(res)
+	if err != nil {
+		return ArpeggiateClipOutput{}, fmt.Errorf("get source notes: %w", err)
+	}
+	if len(sourceNotes) == 0 {
+		return ArpeggiateClipOutput{}, errors.New("source clip has no notes to arpeggiate")
+	}
+	clipLength := queryClipLength(client, input.TrackIndex, input.SourceClipIndex)
+	if clipLength <= 0 {
+		clipLength = inferredClipLength(sourceNotes)
+	}
+
+	notes := arpeggiateNotes(sourceNotes, clipLength, opts, rand.New(rand.NewSource(opts.Seed)))
+	if len(notes) == 0 {
+		return ArpeggiateClipOutput{}, errors.New("arpeggio produced no playable notes")
+	}
+
+	if err := client.Send("/live/clip_slot/create_clip",
+		int32(targetTrack), int32(input.TargetClipIndex), float32(clipLength),
+	); err != nil {
+		return ArpeggiateClipOutput{}, fmt.Errorf("create clip: %w", err)
+	}
+	created, err := queryClipHasClip(client, targetTrack, input.TargetClipIndex)
+	if err != nil {
+		return ArpeggiateClipOutput{}, fmt.Errorf("verify clip: %w", err)
+	}
+	if !created {
+		return ArpeggiateClipOutput{}, errors.New("clip was not created (is the target track a MIDI track?)")
+	}
+	if err := client.Send("/live/clip/add/notes", addNotesArgs(targetTrack, input.TargetClipIndex, notes)...); err != nil {
+		return ArpeggiateClipOutput{}, fmt.Errorf("add arpeggio notes: %w", err)
+	}
+	if err := client.Send("/live/clip/set/name", int32(targetTrack), int32(input.TargetClipIndex), "Arp: "+opts.Pattern); err != nil {
+		return ArpeggiateClipOutput{}, fmt.Errorf("set arpeggio clip name: %w", err)
+	}
+
+	fired := false
+	if input.Fire {
+		if err := client.Send("/live/clip_slot/fire", int32(targetTrack), int32(input.TargetClipIndex)); err != nil {
+			return ArpeggiateClipOutput{}, fmt.Errorf("fire arpeggio clip: %w", err)
+		}
+		fired = true
+	}
+
+	return ArpeggiateClipOutput{
+		TrackIndex:       input.TrackIndex,
+		SourceClipIndex:  input.SourceClipIndex,
+		TargetTrackIndex: targetTrack,
+		TargetClipIndex:  input.TargetClipIndex,
+		Pattern:          opts.Pattern,
+		Rate:             opts.Rate,
+		Octaves:          opts.Octaves,
+		Gate:             opts.Gate,
+		Swing:            opts.Swing,
+		ChordMemory:      opts.Memory,
+		StrumOffset:      opts.Strum,
+		Seed:             opts.Seed,
+		LengthBeats:      clipLength,
+		NotesAdded:       len(notes),
+		Fired:            fired,
+	}, nil
+}
+
+func resolveArpOptions(input ArpeggiateClipInput) (arpOptions, error) {
+	opts := arpOptions{
+		Pattern: strings.ToLower(strings.TrimSpace(input.Pattern)),
+		Rate:    defaultArpRate,
+		Octaves: defaultArpOctaves,
+		Gate:    defaultArpGate,
+		Seed:    resolveSeed(input.Seed),
+	}
+	if opts.Pattern == "" {
+		opts.Pattern = defaultArpPattern
+	}
+	switch opts.Pattern {
+	case "up", "down", "up_down", "converge", "random", "repeat":
+	default:
+		return arpOptions{}, errors.New("pattern must be up, down, up_down, converge, random, or repeat")
+	}
+	if input.Rate != nil {
+		opts.Rate = *input.Rate
+	}
+	if input.Octaves != nil {
+		opts.Octaves = *input.Octaves
+	}
+	if input.Gate != nil {
+		opts.Gate = *input.Gate
+	}
+	if input.Swing != nil {
+		opts.Swing = *input.Swing
+	}
+	if input.StrumOffset != nil {
+		opts.Strum = *input.StrumOffset
+	}
+	if opts.Strum < 0 || opts.Strum > 0.5 {
+		return arpOptions{}, errors.New("strum_offset must be between 0 and 0.5")
+	}
+	for _, offset := range input.ChordMemory {
+		if offset < -24 || offset > 24 {
+			return arpOptions{}, errors.New("chord_memory offsets must be between -24 and 24 semitones")
+		}
+	}
+	opts.Memory = input.ChordMemory
+	if opts.Rate < minArpRate || opts.Rate > maxArpRate {
+		return arpOptions{}, fmt.Errorf("rate must be between %.4g and %.4g beats", minArpRate, maxArpRate)
+	}
+	if opts.Octaves < 1 || opts.Octaves > maxArpOctaves {
+		return arpOptions{}, fmt.Errorf("octaves must be between 1 and %d", maxArpOctaves)
+	}
+	if opts.Gate < 0.05 || opts.Gate > 1.5 {
+		return arpOptions{}, errors.New("gate must be between 0.05 and 1.5")
+	}
+	if opts.Swing < 0 || opts.Swing > 1 {
+		return arpOptions{}, errors.New("swing must be between 0 and 1")
+	}
+	return opts, nil
+}
+
+// arpeggiateNotes steps through the clip on the rate grid and plays the next
+// note of the pattern built from whatever chord is sounding at each step. The
+// pattern restarts whenever the chord changes.
+func arpeggiateNotes(source []MidiNote, clipLength float64, opts arpOptions, rng *rand.Rand) []MidiNote {
+	source = applyChordMemory(source, opts.Memory)
+	var out []MidiNote
+	var prevKey string
+	var sequence []MidiNote
+	position := 0
+	steps := int(math.Ceil(clipLength/opts.Rate - 1e-9))
+	for step := 0; step < steps; step++ {
+		t := float64(step) * opts.Rate
+		chord := soundingNotes(source, t)
+		if len(chord) == 0 {
+			prevKey = ""
+			continue
+		}
+		if key := chordKey(chord); key != prevKey {
+			prevKey = key
+			sequence = arpSequence(chord, opts)
+			position = 0
+		}
+
+		start := t
+		if opts.Swing > 0 {
+			start = applySwing(t, opts.Swing, opts.Rate)
+		}
+		duration := math.Min(opts.Rate*opts.Gate, clipLength-start)
+		if duration <= 0 {
+			continue
+		}
+		var hits []MidiNote
+		switch opts.Pattern {
+		case "repeat":
+			hits = sequence
+		case "random":
+			hits = []MidiNote{sequence[rng.Intn(len(sequence))]}
+		default:
+			hits = []MidiNote{sequence[position%len(sequence)]}
+		}
+		position++
+		for i, h := range hits {
+			if h.Pitch < 0 || h.Pitch > 127 {
+				continue
+			}
+			at := start + float64(i)*opts.Strum
+			length := duration - float64(i)*opts.Strum
+			if length <= 0 {
+				continue
+			}
+			out = append(out, MidiNote{Pitch: h.Pitch, StartTime: at, Duration: length, Velocity: h.Velocity})
+		}
+	}
+	return out
+}
+
+// applyChordMemory stacks the offsets on every note, like Live's Chord device.
+// Duplicate pitches at the same start are dropped.
+func applyChordMemory(notes []MidiNote, offsets []int) []MidiNote {
+	if len(offsets) == 0 {
+		return notes
+	}
+	type slot struct {
+		pitch int
+		start float64
+	}
+	seen := make(map[slot]bool)
+	out := make([]MidiNote, 0, len(notes)*len(offsets))
+	for _, n := range notes {
+		for _, offset := range offsets {
+			c := n
+			c.Pitch += offset
+			key := slot{c.Pitch, c.StartTime}
+			if c.Pitch < 0 || c.Pitch > 127 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// soundingNotes returns the unmuted notes held at time t, lowest first.
+func soundingNotes(notes []MidiNote, t float64) []MidiNote {
+	var out []MidiNote
+	for _, n := range notes {
+		if midiMuteValue(n.Mute) {
+			continue
+		}
+		if n.StartTime <= t+1e-6 && n.StartTime+n.Duration > t+1e-6 {
+			out = append(out, n)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Pitch < out[j].Pitch })
+	return out
+}
+
+func chordKey(chord []MidiNote) string {
+	var b strings.Builder
+	for _, n := range chord {
+		fmt.Fprintf(&b, "%d,", n.Pitch)
+	}
+	return b.String()
+}
+
+// arpSequence expands a chord across the octave range and orders it for the
+// pattern. Velocities come from the source notes.
+func arpSequence(chord []MidiNote, opts arpOptions) []MidiNote {
+	asc := make([]MidiNote, 0, len(chord)*opts.Octaves)
+	for o := 0; o < opts.Octaves; o++ {
+		for _, n := range chord {
+			asc = append(asc, MidiNote{Pitch: n.Pitch + 12*o, Velocity: n.Velocity})
+		}
+	}
+	switch opts.Pattern {
+	case "down":
+		out := make([]MidiNote, len(asc))
+		for i := range asc {
+			out[i] = asc[len(asc)-1-i]
+		}
+		return out
+	case "up_down":
+		out := append([]MidiNote(nil), asc...)
+		for i := len(asc) - 2; i > 0; i-- {
+			out = append(out, asc[i])
+		}
+		return out
+	case "converge":
+		out := make([]MidiNote, 0, len(asc))
+		for lo, hi := 0, len(asc)-1; lo <= hi; lo, hi = lo+1, hi-1 {
+			out = append(out, asc[lo])
+			if hi != lo {
+				out = append(out, asc[hi])
+			}
+		}
+		return out
+	case "repeat":
+		return chord
+	default:
+		return asc
+	}
+}
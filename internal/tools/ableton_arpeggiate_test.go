@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func arpChord() []MidiNote {
+	return []MidiNote{
+		{Pitch: 60, StartTime: 0, Duration: 2, Velocity: 90},
+		{Pitch: 64, StartTime: 0, Duration: 2, Velocity: 80},
+		{Pitch: 67, StartTime: 0, Duration: 2, Velocity: 70},
+		{Pitch: 65, StartTime: 2, Duration: 2, Velocity: 90},
+		{Pitch: 69, StartTime: 2, Duration: 2, Velocity: 90},
+		{Pitch: 72, StartTime: 2, Duration: 2, Velocity: 90},
+	}
+}
+
+func pitchesOf(notes []MidiNote) []int {
+	out := make([]int, 0, len(notes))
+	for _, n := range notes {
+		out = append(out, n.Pitch)
+	}
+	return out
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestArpeggiateNotesPatterns(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern string
+		octaves int
+		want    []int // first 8 steps (1/4-beat rate over the C chord)
+	}{
+		{pattern: "up", octaves: 1, want: []int{60, 64, 67, 60, 64, 67, 60, 64}},
+		{pattern: "down", octaves: 1, want: []int{67, 64, 60, 67, 64, 60, 67, 64}},
+		{pattern: "up_down", octaves: 1, want: []int{60, 64, 67, 64, 60, 64, 67, 64}},
+		{pattern: "converge", octaves: 1, want: []int{60, 67, 64, 60, 67, 64, 60, 67}},
+		{pattern: "up", octaves: 2, want: []int{60, 64, 67, 72, 76, 79, 60, 64}},
+	}
+	for _, tc := range cases {
+		opts := arpOptions{Pattern: tc.pattern, Rate: 0.25, Octaves: tc.octaves, Gate: 0.8}
+		notes := arpeggiateNotes(arpChord(), 4, opts, rand.New(rand.NewSource(1)))
+		if len(notes) != 16 {
+			t.Fatalf("%s: notes = %d, want 16", tc.pattern, len(notes))
+		}
+		if got := pitchesOf(notes[:8]); !equalInts(got, tc.want) {
+			t.Errorf("%s x%d = %v, want %v", tc.pattern, tc.octaves, got, tc.want)
+		}
+		// The pattern restarts on the F chord at beat 2.
+		if tc.pattern == "up" && tc.octaves == 1 && notes[8].Pitch != 65 {
+			t.Errorf("pattern did not restart on chord change: %d", notes[8].Pitch)
+		}
+	}
+}
+
+func TestArpeggiateNotesRepeatGateSwing(t *testing.T) {
+	t.Parallel()
+
+	opts := arpOptions{Pattern: "repeat", Rate: 0.5, Octaves: 1, Gate: 0.5, Swing: 1}
+	notes := arpeggiateNotes(arpChord(), 4, opts, rand.New(rand.NewSource(1)))
+	if len(notes) != 24 {
+		t.Fatalf("repeat notes = %d, want 24", len(notes))
+	}
+	if notes[0].Duration != 0.25 || notes[0].Velocity != 90 || notes[1].Velocity != 80 {
+		t.Errorf("repeat note = %+v / %+v", notes[0], notes[1])
+	}
+	// Second step (beat 0.5) swings late by a third of the step.
+	if got := notes[3].StartTime; got < 0.66 || got > 0.67 {
+		t.Errorf("swung start = %v, want ~0.667", got)
+	}
+}
+
+func TestArpeggiateNotesChordMemoryStrum(t *testing.T) {
+	t.Parallel()
+
+	mono := []MidiNote{{Pitch: 48, StartTime: 0, Duration: 1, Velocity: 100}}
+	opts := arpOptions{Pattern: "repeat", Rate: 0.5, Octaves: 1, Gate: 1, Memory: []int{0, 7, 12}, Strum: 0.05}
+	notes := arpeggiateNotes(mono, 1, opts, rand.New(rand.NewSource(1)))
+	if got := pitchesOf(notes); !equalInts(got, []int{48, 55, 60, 48, 55, 60}) {
+		t.Fatalf("pitches = %v", got)
+	}
+	if notes[1].StartTime != 0.05 || notes[2].StartTime != 0.1 || notes[2].Duration != 0.4 {
+		t.Errorf("strummed voices = %+v / %+v", notes[1], notes[2])
+	}
+}
+
+func TestArpeggiateNotesRandomSeeded(t *testing.T) {
+	t.Parallel()
+
+	opts := arpOptions{Pattern: "random", Rate: 0.25, Octaves: 2, Gate: 0.8}
+	a := arpeggiateNotes(arpChord(), 4, opts, rand.New(rand.NewSource(7)))
+	b := arpeggiateNotes(arpChord(), 4, opts, rand.New(rand.NewSource(7)))
+	if !equalInts(pitchesOf(a), pitchesOf(b)) {
+		t.Error("same seed should produce the same random arpeggio")
+	}
+}
+
+func TestArpeggiateClipStub(t *testing.T) {
+	t.Parallel()
+
+	payload := []interface{}{int32(0), int32(0)}
+	for _, n := range arpChord() {
+		payload = append(payload, int32(n.Pitch), float32(n.StartTime), float32(n.Duration), int32(n.Velocity), false)
+	}
+	client := &recipeClientStub{
+		queries: map[string][]interface{}{
+			"/live/clip_slot/get/has_clip": {int32(0), int32(1), int32(0)},
+			"/live/clip/get/notes":         payload,
+			"/live/clip/get/length":        {int32(0), int32(0), float32(4)},
+		},
+	}
+	seed := int64(3)
+	out, err := arpeggiateClip(&hasClipToggleStub{recipeClientStub: client}, ArpeggiateClipInput{
+		TrackIndex:      0,
+		SourceClipIndex: 0,
+		TargetClipIndex: 1,
+		Seed:            &seed,
+	})
+	if err != nil {
+		t.Fatalf("arpeggiateClip() error = %v", err)
+	}
+	if out.NotesAdded != 16 || out.Pattern != "up" || out.Rate != 0.25 || out.Seed != 3 {
+		t.Errorf("out = %+v", out)
+	}
+	assertSent(t, client, "/live/clip/add/notes")
+}
+
+func TestArpeggiateClipValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := arpeggiateClip(&recipeClientStub{}, ArpeggiateClipInput{SourceClipIndex: 1, TargetClipIndex: 1})
+	if err == nil || !strings.Contains(err.Error(), "differ") {
+		t.Errorf("error = %v, want differ error", err)
+	}
+	_, err = arpeggiateClip(&recipeClientStub{}, ArpeggiateClipInput{TargetClipIndex: 1, Pattern: "spiral"})
+	if err == nil || !strings.Contains(err.Error(), "pattern") {
+		t.Errorf("error = %v, want pattern error", err)
+	}
+}
@@ -20,6 +20,7 @@ const (
 	auditionBarQuantization = 4
 	auditionSongTimeEpsilon = 1e-3
 	auditionPollInterval    = 20 * time.Millisecond
+	auditionTimingNote      = "Waits on Live song time with 1-bar clip trigger quantization (restored afterward). Switches land on the next bar boundary."
 )
 
 type AuditionABInput struct {
@@ -75,33 +76,94 @@ func auditionAB(client auditionClient, input AuditionABInput, sleep auditionSlee
 	if err != nil {
 		return AuditionABOutput{}, err
 	}
+
+	run, err := runAudition(client, sleep, auditionPlan{
+		targetType:    targetType,
+		trackIndex:    input.TrackIndex,
+		indices:       []int{input.SourceIndex, input.VariationIndex},
+		bars:          bars,
+		cycles:        cycles,
+		beatsPerBar:   beatsPerBarOverride,
+		startPlayback: input.StartPlayback,
+		stopAfter:     input.StopAfter,
+	})
+	if err != nil {
+		return AuditionABOutput{}, err
+	}
+
+	var trackIndex *int
+	if input.TrackIndex != nil {
+		index := *input.TrackIndex
+		trackIndex = &index
+	}
+	return AuditionABOutput{
+		TargetType:       targetType,
+		TrackIndex:       trackIndex,
+		SourceIndex:      input.SourceIndex,
+		VariationIndex:   input.VariationIndex,
+		BarsPerVersion:   bars,
+		Cycles:           cycles,
+		BeatsPerBar:      run.beatsPerBar,
+		TempoBPM:         run.tempo,
+		DurationSec:      run.durationSec,
+		PlaybackStarted:  run.playbackStarted,
+		Instrument:       instrument,
+		Variation:        variation,
+		FinalVersion:     "variation",
+		TimingNote:       auditionTimingNote,
+		PreferencePrompt: auditionPreferencePrompt(targetType, instrument, variation),
+	}, nil
+}
+
+// auditionPlan is one validated audition: indices play in order, once per
+// cycle, for bars each. beatsPerBar 0 reads the signature from Live.
+type auditionPlan struct {
+	targetType    string
+	trackIndex    *int
+	indices       []int
+	bars          int
+	cycles        int
+	beatsPerBar   int
+	startPlayback bool
+	stopAfter     bool
+}
+
+type auditionRun struct {
+	tempo           float64
+	beatsPerBar     int
+	playbackStarted bool
+	durationSec     float64
+}
+
+// runAudition plays the plan on Live song time with 1-bar clip trigger
+// quantization, restoring the previous quantization afterward.
+func runAudition(client auditionClient, sleep auditionSleeper, plan auditionPlan) (auditionRun, error) {
 	if sleep == nil {
 		sleep = time.Sleep
 	}
-
 	tempo, err := queryAuditionTempo(client)
 	if err != nil {
-		return AuditionABOutput{}, err
+		return auditionRun{}, err
 	}
-	beatsPerBar := beatsPerBarOverride
+	beatsPerBar := plan.beatsPerBar
 	if beatsPerBar == 0 {
 		beatsPerBar, err = queryAuditionBeatsPerBar(client)
 		if err != nil {
-			return AuditionABOutput{}, err
+			return auditionRun{}, err
 		}
 	}
 
-	playbackStarted, err := ensureAuditionPlayback(client, input.StartPlayback)
+	playbackStarted, err := ensureAuditionPlayback(client, plan.startPlayback)
 	if err != nil {
-		return AuditionABOutput{}, err
+		return auditionRun{}, err
 	}
 
 	prevQuant, err := queryClipTriggerQuantization(client)
 	if err != nil {
-		return AuditionABOutput{}, err
+		return auditionRun{}, err
 	}
 	if err := client.Send("/live/song/set/clip_trigger_quantization", int32(auditionBarQuantization)); err != nil {
-		return AuditionABOutput{}, fmt.Errorf("set clip trigger quantization: %w", err)
+		return auditionRun{}, fmt.Errorf("set clip trigger quantization: %w", err)
 	}
 	restoreQuant := true
 	defer func() {
@@ -111,55 +173,40 @@ func auditionAB(client auditionClient, input AuditionABInput, sleep auditionSlee
 	}()
 
 	heardBeats := 0.0
-	for i := 0; i < cycles; i++ {
-		heard, err := fireAndHearAudition(client, sleep, targetType, input.TrackIndex, input.SourceIndex, bars, beatsPerBar, tempo)
-		if err != nil {
-			return AuditionABOutput{}, fmt.Errorf("fire A (cycle %d): %w", i+1, err)
+	for cycle := 0; cycle < plan.cycles; cycle++ {
+		for i, index := range plan.indices {
+			heard, err := fireAndHearAudition(client, sleep, plan.targetType, plan.trackIndex, index, plan.bars, beatsPerBar, tempo)
+			if err != nil {
+				return auditionRun{}, fmt.Errorf("fire %s (cycle %d): %w", auditionLabel(i), cycle+1, err)
+			}
+			heardBeats += heard
 		}
-		heardBeats += heard
-		heard, err = fireAndHearAudition(client, sleep, targetType, input.TrackIndex, input.VariationIndex, bars, beatsPerBar, tempo)
-		if err != nil {
-			return AuditionABOutput{}, fmt.Errorf("fire B (cycle %d): %w", i+1, err)
-		}
-		heardBeats += heard
 	}
 
-	if input.StopAfter {
+	if plan.stopAfter {
 		if err := client.Send("/live/song/stop_playing"); err != nil {
-			return AuditionABOutput{}, fmt.Errorf("stop playback: %w", err)
+			return auditionRun{}, fmt.Errorf("stop playback: %w", err)
 		}
 	}
 
 	if err := client.Send("/live/song/set/clip_trigger_quantization", int32(prevQuant)); err != nil {
-		return AuditionABOutput{}, fmt.Errorf("restore clip trigger quantization: %w", err)
+		return auditionRun{}, fmt.Errorf("restore clip trigger quantization: %w", err)
 	}
 	restoreQuant = false
 
-	var trackIndex *int
-	if input.TrackIndex != nil {
-		index := *input.TrackIndex
-		trackIndex = &index
-	}
-	durationSec := heardBeats * 60 / tempo
-	return AuditionABOutput{
-		TargetType:       targetType,
-		TrackIndex:       trackIndex,
-		SourceIndex:      input.SourceIndex,
-		VariationIndex:   input.VariationIndex,
-		BarsPerVersion:   bars,
-		Cycles:           cycles,
-		BeatsPerBar:      beatsPerBar,
-		TempoBPM:         tempo,
-		DurationSec:      durationSec,
-		PlaybackStarted:  playbackStarted,
-		Instrument:       instrument,
-		Variation:        variation,
-		FinalVersion:     "variation",
-		TimingNote:       "Waits on Live song time with 1-bar clip trigger quantization (restored afterward). Switches land on the next bar boundary.",
-		PreferencePrompt: auditionPreferencePrompt(targetType, instrument, variation),
+	return auditionRun{
+		tempo:           tempo,
+		beatsPerBar:     beatsPerBar,
+		playbackStarted: playbackStarted,
+		durationSec:     heardBeats * 60 / tempo,
 	}, nil
 }
 
+// auditionLabel names the i-th version of an audition: A, B, C, ...
+func auditionLabel(i int) string {
+	return string(rune('A' + i))
+}
+
 func fireAndHearAudition(
 	client auditionClient,
 	sleep auditionSleeper,
@@ -314,9 +361,9 @@ func ceilBarBeat(songTime float64, beatsPerBar int) float64 {
 }
 
 func validateAuditionInput(input AuditionABInput) (string, int, int, int, string, string, error) {
-	targetType := strings.ToLower(strings.TrimSpace(input.TargetType))
-	if targetType != "clip" && targetType != "scene" {
-		return "", 0, 0, 0, "", "", errors.New("target_type must be clip or scene")
+	targetType, err := validateAuditionTarget(input.TargetType, input.TrackIndex)
+	if err != nil {
+		return "", 0, 0, 0, "", "", err
 	}
 	if input.SourceIndex < 0 || input.VariationIndex < 0 {
 		return "", 0, 0, 0, "", "", errors.New("source_index and variation_index must be >= 0")
@@ -324,44 +371,63 @@ func validateAuditionInput(input AuditionABInput) (string, int, int, int, string
 	if input.SourceIndex == input.VariationIndex {
 		return "", 0, 0, 0, "", "", errors.New("source_index and variation_index must differ")
 	}
+	bars, cycles, beatsPerBar, err := validateAuditionTiming(input.BarsPerVersion, input.Cycles, input.BeatsPerBar)
+	if err != nil {
+		return "", 0, 0, 0, "", "", err
+	}
+
+	instrument, variation, err := validateAuditionTasteHint(targetType, input.Instrument, input.Variation)
+	if err != nil {
+		return "", 0, 0, 0, "", "", err
+	}
+	return targetType, bars, cycles, beatsPerBar, instrument, variation, nil
+}
+
+// validateAuditionTarget normalizes target_type; clips need a track, scenes
+// must not have one.
+func validateAuditionTarget(targetType string, trackIndex *int) (string, error) {
+	targetType = strings.ToLower(strings.TrimSpace(targetType))
+	if targetType != "clip" && targetType != "scene" {
+		return "", errors.New("target_type must be clip or scene")
+	}
 	if targetType == "clip" {
-		if input.TrackIndex == nil {
-			return "", 0, 0, 0, "", "", errors.New("track_index is required for clip auditions")
+		if trackIndex == nil {
+			return "", errors.New("track_index is required for clip auditions")
 		}
-		if *input.TrackIndex < 0 {
-			return "", 0, 0, 0, "", "", errors.New("track_index must be >= 0")
+		if *trackIndex < 0 {
+			return "", errors.New("track_index must be >= 0")
 		}
-	} else if input.TrackIndex != nil {
-		return "", 0, 0, 0, "", "", errors.New("track_index must be omitted for scene auditions")
+	} else if trackIndex != nil {
+		return "", errors.New("track_index must be omitted for scene auditions")
 	}
+	return targetType, nil
+}
 
+// validateAuditionTiming applies the defaults; beats per bar stays 0 when
+// Live's signature should be used.
+func validateAuditionTiming(barsInput, cyclesInput, beatsInput *int) (int, int, int, error) {
 	bars := defaultAuditionBarsPerVersion
-	if input.BarsPerVersion != nil {
-		bars = *input.BarsPerVersion
+	if barsInput != nil {
+		bars = *barsInput
 	}
 	cycles := defaultAuditionCycles
-	if input.Cycles != nil {
-		cycles = *input.Cycles
+	if cyclesInput != nil {
+		cycles = *cyclesInput
 	}
 	beatsPerBar := 0
-	if input.BeatsPerBar != nil {
-		beatsPerBar = *input.BeatsPerBar
+	if beatsInput != nil {
+		beatsPerBar = *beatsInput
 		if beatsPerBar < 1 || beatsPerBar > 16 {
-			return "", 0, 0, 0, "", "", errors.New("beats_per_bar must be between 1 and 16")
+			return 0, 0, 0, errors.New("beats_per_bar must be between 1 and 16")
 		}
 	}
 	if bars < 1 || bars > 8 {
-		return "", 0, 0, 0, "", "", errors.New("bars_per_version must be between 1 and 8")
+		return 0, 0, 0, errors.New("bars_per_version must be between 1 and 8")
 	}
 	if cycles < 1 || cycles > 4 {
-		return "", 0, 0, 0, "", "", errors.New("cycles must be between 1 and 4")
+		return 0, 0, 0, errors.New("cycles must be between 1 and 4")
 	}
-
-	instrument, variation, err := validateAuditionTasteHint(targetType, input.Instrument, input.Variation)
-	if err != nil {
-		return "", 0, 0, 0, "", "", err
-	}
-	return targetType, bars, cycles, beatsPerBar, instrument, variation, nil
+	return bars, cycles, beatsPerBar, nil
 }
 
 func validateAuditionTasteHint(targetType, instrument, variation string) (string, string, error) {
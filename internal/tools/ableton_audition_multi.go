@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+)
+
+const (
+	minAuditionVersions = 2
+	maxAuditionVersions = 8
+)
+
+type AuditionMultiInput struct {
+	TargetType     string `json:"target_type" jsonschema:"description=What to audition: clip or scene"`
+	TrackIndex     *int   `json:"track_index,omitempty" jsonschema:"description=Required for clip auditions; omit for scenes,minimum=0"`
+	Indices        []int  `json:"indices" jsonschema:"description=Clip slot or scene indices to play in order; labelled A, B, C... (2 to 8)"`
+	BarsPerVersion *int   `json:"bars_per_version,omitempty" jsonschema:"description=Bars to hear each version (default 2),minimum=1,maximum=8"`
+	Cycles         *int   `json:"cycles,omitempty" jsonschema:"description=How many passes through all versions (default 1),minimum=1,maximum=4"`
+	BeatsPerBar    *int   `json:"beats_per_bar,omitempty" jsonschema:"description=Override beats per bar (default: Live signature numerator),minimum=1,maximum=16"`
+	Instrument     string `json:"instrument,omitempty" jsonschema:"description=Optional taste family for the ranking prompt: drum or bass for clips; scene for scenes"`
+	StartPlayback  bool   `json:"start_playback,omitempty" jsonschema:"description=Start Live playback before the audition (also auto-starts when transport is stopped)"`
+	StopAfter      bool   `json:"stop_after,omitempty" jsonschema:"description=Stop playback after the final version"`
+}
+
+type AuditionVersion struct {
+	Label string `json:"label"`
+	Index int    `json:"index" jsonschema:"description=Clip slot or scene index"`
+}
+
+type AuditionMultiOutput struct {
+	TargetType      string            `json:"target_type"`
+	TrackIndex      *int              `json:"track_index,omitempty"`
+	Versions        []AuditionVersion `json:"versions"`
+	PlayOrder       []string          `json:"play_order" jsonschema:"description=Labels in the order they were heard"`
+	BarsPerVersion  int               `json:"bars_per_version"`
+	Cycles          int               `json:"cycles"`
+	BeatsPerBar     int               `json:"beats_per_bar"`
+	TempoBPM        float64           `json:"tempo_bpm"`
+	DurationSec     float64           `json:"duration_sec"`
+	PlaybackStarted bool              `json:"playback_started"`
+	Instrument      string            `json:"instrument,omitempty"`
+	FinalVersion    string            `json:"final_version" jsonschema:"description=Label still playing when the audition ends"`
+	TimingNote      string            `json:"timing_note"`
+	RankingPrompt   string            `json:"ranking_prompt"`
+}
+
+func NewAbletonAuditionMulti(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_audition_multi",
+		"Ableton Live: audition 2 to 8 existing clips or scenes (A, B, C...) in turn on bar boundaries and prompt for a ranking — record it with ableton_record_ranking_preference; use ableton_audition_ab for two versions",
+		func(_ *ai.ToolContext, input AuditionMultiInput) (AuditionMultiOutput, error) {
+			return auditionMulti(client, input, time.Sleep)
+		},
+	)
+}
+
+func auditionMulti(client auditionClient, input AuditionMultiInput, sleep auditionSleeper) (AuditionMultiOutput, error) {
+	targetType, err := validateAuditionTarget(input.TargetType, input.TrackIndex)
+	if err != nil {
+		return AuditionMultiOutput{}, err
+	}
+	if err := validateAuditionIndices(input.Indices); err != nil {
+		return AuditionMultiOutput{}, err
+	}
+	bars, cycles, beatsPerBarOverride, err := validateAuditionTiming(input.BarsPerVersion, input.Cycles, input.BeatsPerBar)
+	if err != nil {
+		return AuditionMultiOutput{}, err
+	}
+	instrument, _, err := validateAuditionTasteHint(targetType, input.Instrument, "")
+	if err != nil {
+		return AuditionMultiOutput{}, err
+	}
+
+	run, err := runAudition(client, sleep, auditionPlan{
+		targetType:    targetType,
+		trackIndex:    input.TrackIndex,
+		indices:       input.Indices,
+		bars:          bars,
+		cycles:        cycles,
+		beatsPerBar:   beatsPerBarOverride,
+		startPlayback: input.StartPlayback,
+		stopAfter:     input.StopAfter,
+	})
+	if err != nil {
+		return AuditionMultiOutput{}, err
+	}
+
+	versions := make([]AuditionVersion, len(input.Indices))
+	labels := make([]string, len(input.Indices))
+	for i, index := range input.Indices {
+		labels[i] = auditionLabel(i)
+		versions[i] = AuditionVersion{Label: labels[i], Index: index}
+	}
+	playOrder := make([]string, 0, cycles*len(labels))
+	for i := 0; i < cycles; i++ {
+		playOrder = append(playOrder, labels...)
+	}
+	var trackIndex *int
+	if input.TrackIndex != nil {
+		index := *input.TrackIndex
+		trackIndex = &index
+	}
+	return AuditionMultiOutput{
+		TargetType:      targetType,
+		TrackIndex:      trackIndex,
+		Versions:        versions,
+		PlayOrder:       playOrder,
+		BarsPerVersion:  bars,
+		Cycles:          cycles,
+		BeatsPerBar:     run.beatsPerBar,
+		TempoBPM:        run.tempo,
+		DurationSec:     run.durationSec,
+		PlaybackStarted: run.playbackStarted,
+		Instrument:      instrument,
+		FinalVersion:    labels[len(labels)-1],
+		TimingNote:      auditionTimingNote,
+		RankingPrompt:   auditionRankingPrompt(targetType, instrument, labels),
+	}, nil
+}
+
+func validateAuditionIndices(indices []int) error {
+	if len(indices) < minAuditionVersions || len(indices) > maxAuditionVersions {
+		return fmt.Errorf("indices must list between %d and %d versions", minAuditionVersions, maxAuditionVersions)
+	}
+	seen := map[int]bool{}
+	for _, index := range indices {
+		if index < 0 {
+			return errors.New("indices must be >= 0")
+		}
+		if seen[index] {
+			return fmt.Errorf("indices lists %d twice", index)
+		}
+		seen[index] = true
+	}
+	return nil
+}
+
+func auditionRankingPrompt(targetType, instrument string, labels []string) string {
+	if instrument == "" {
+		if targetType == "scene" {
+			instrument = "scene"
+		} else {
+			instrument = "drum or bass"
+		}
+	}
+	return fmt.Sprintf(
+		"Rank %s from best to worst. Record with ableton_record_ranking_preference using instrument=%s, one versions entry per label naming its variation (source for the original) and the parameters that produced it, and ranking as the labels best first.",
+		strings.Join(labels, ", "), instrument,
+	)
+}
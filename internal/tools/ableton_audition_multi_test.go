@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditionMultiClip(t *testing.T) {
+	t.Parallel()
+
+	trackIndex := 1
+	bars := 1
+	cycles := 2
+	client := &auditionStub{
+		tempo:        120,
+		songTime:     0.5,
+		signature:    4,
+		isPlaying:    true,
+		quantization: 6,
+	}
+	got, err := auditionMulti(client, AuditionMultiInput{
+		TargetType:     "clip",
+		TrackIndex:     &trackIndex,
+		Indices:        []int{0, 3, 5},
+		BarsPerVersion: &bars,
+		Cycles:         &cycles,
+		Instrument:     "drum",
+		StopAfter:      true,
+	}, func(d time.Duration) {
+		advanceAuditionStub(client, d)
+	})
+	if err != nil {
+		t.Fatalf("auditionMulti() error = %v", err)
+	}
+	wantVersions := []AuditionVersion{{"A", 0}, {"B", 3}, {"C", 5}}
+	if !reflect.DeepEqual(got.Versions, wantVersions) {
+		t.Errorf("versions = %#v, want %#v", got.Versions, wantVersions)
+	}
+	if want := []string{"A", "B", "C", "A", "B", "C"}; !reflect.DeepEqual(got.PlayOrder, want) {
+		t.Errorf("play_order = %v, want %v", got.PlayOrder, want)
+	}
+	if got.FinalVersion != "C" || got.DurationSec <= 0 {
+		t.Errorf("output = %#v", got)
+	}
+	if !strings.Contains(got.RankingPrompt, "A, B, C") || !strings.Contains(got.RankingPrompt, "instrument=drum") {
+		t.Errorf("ranking_prompt = %q", got.RankingPrompt)
+	}
+
+	var fired []interface{}
+	for _, call := range client.calls {
+		if call.address == "/live/clip_slot/fire" {
+			fired = append(fired, call.args[1])
+		}
+	}
+	if want := []interface{}{int32(0), int32(3), int32(5), int32(0), int32(3), int32(5)}; !reflect.DeepEqual(fired, want) {
+		t.Errorf("fired slots = %v, want %v", fired, want)
+	}
+	last := client.calls[len(client.calls)-1]
+	if last.address != "/live/song/set/clip_trigger_quantization" || client.quantization != 6 {
+		t.Errorf("quantization not restored: last send %#v, quantization %d", last, client.quantization)
+	}
+}
+
+func TestAuditionMultiRejectsBadIndices(t *testing.T) {
+	t.Parallel()
+
+	for _, indices := range [][]int{{1}, {0, 1, 2, 3, 4, 5, 6, 7, 8}, {0, 2, 0}, {0, -1}} {
+		client := &auditionStub{tempo: 120, signature: 4, isPlaying: true}
+		if _, err := auditionMulti(client, AuditionMultiInput{TargetType: "scene", Indices: indices}, nil); err == nil {
+			t.Errorf("indices %v: expected error", indices)
+		}
+		if len(client.calls) != 0 {
+			t.Errorf("indices %v: sent %v before validation failed", indices, client.calls)
+		}
+	}
+}
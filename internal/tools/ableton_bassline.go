@@ -0,0 +1,520 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+)
+
+const (
+	defaultBasslineStyle    = "roots"
+	defaultBasslineOctave   = 2 // C2 = MIDI 36
+	defaultBasslineVelocity = 100
+	defaultBasslineKick     = drumPitchKick
+)
+
+var chordRootNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+type GenerateBasslineInput struct {
+	TrackIndex      int      `json:"track_index" jsonschema:"description=Bass MIDI track to write into,minimum=0"`
+	TargetClipIndex int      `json:"target_clip_index" jsonschema:"description=Must be an empty clip slot for the A/B comparison,minimum=0"`
+	Progression     string   `json:"progression,omitempty" jsonschema:"description=Chord progression string as in ableton_build_chord_clip (e.g. 'C G Am F'). Use this or chord_track_index+chord_clip_index"`
+	BeatsPerChord   *float64 `json:"beats_per_chord,omitempty" jsonschema:"description=Beats each progression chord lasts (default 4),minimum=0.25,maximum=16"`
+	ChordTrackIndex *int     `json:"chord_track_index,omitempty" jsonschema:"description=Track of an existing chord clip to follow,minimum=0"`
+	ChordClipIndex  *int     `json:"chord_clip_index,omitempty" jsonschema:"description=Slot of an existing chord clip to follow,minimum=0"`
+	Style           string   `json:"style,omitempty" jsonschema:"description=roots, root_fifth, walking, octave_pump, or syncopated (default roots)"`
+	RootOctave      *int     `json:"root_octave,omitempty" jsonschema:"description=Octave of the bass roots (default 2; C2=MIDI 36),minimum=0,maximum=6"`
+	Velocity        *int     `json:"velocity,omitempty" jsonschema:"description=Note velocity (default 100),minimum=1,maximum=127"`
+	DrumTrackIndex  *int     `json:"drum_track_index,omitempty" jsonschema:"description=Optional drum track whose kick hits set the bass rhythm,minimum=0"`
+	DrumClipIndex   *int     `json:"drum_clip_index,omitempty" jsonschema:"description=Optional drum clip slot whose kick hits set the bass rhythm,minimum=0"`
+	KickPitch       *int     `json:"kick_pitch,omitempty" jsonschema:"description=Kick MIDI pitch in the drum clip (default 36),minimum=0,maximum=127"`
+	Fire            bool     `json:"fire,omitempty" jsonschema:"description=Fire the bassline clip after creating it"`
+}
+
+type GenerateBasslineOutput struct {
+	TrackIndex      int      `json:"track_index"`
+	TargetClipIndex int      `json:"target_clip_index"`
+	Style           string   `json:"style"`
+	ChordSource     string   `json:"chord_source"`
+	Chords          []string `json:"chords"`
+	LengthBeats     float64  `json:"length_beats"`
+	NotesAdded      int      `json:"notes_added"`
+	KickLocked      bool     `json:"kick_locked"`
+	Fired           bool     `json:"fired"`
+}
+
+// timedChord is a chord placed on the clip timeline.
+type timedChord struct {
+	chord    parsedChord
+	start    float64
+	duration float64
+}
+
+func NewAbletonGenerateBassline(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_generate_bassline",
+		"Ableton Live: generate a bassline into an empty slot from a chord progression string or an existing chord clip (roots, root_fifth, walking, octave_pump, syncopated). Optionally lock the rhythm to a drum clip's kick hits. Compare against an existing bass clip with ableton_audition_ab",
+		func(_ *ai.ToolContext, input GenerateBasslineInput) (GenerateBasslineOutput, error) {
+			return generateBassline(client, input)
+		},
+	)
+}
+
+func generateBassline(client variationClient, input GenerateBasslineInput) (GenerateBasslineOutput, error) {
+	if err := validateTrackClipIndices(input.TrackIndex, input.TargetClipIndex); err != nil {
+		return GenerateBasslineOutput{}, err
+	}
+	style := strings.ToLower(strings.TrimSpace(input.Style))
+	if style == "" {
+		style = defaultBasslineStyle
+	}
+	if !isBasslineStyle(style) {
+		return GenerateBasslineOutput{}, errors.New("style must be roots, root_fifth, walking, octave_pump, or syncopated")
+	}
+	octave := defaultBasslineOctave
+	if input.RootOctave != nil {
+		octave = *input.RootOctave
+	}
+	if octave < 0 || octave > 6 {
+		return GenerateBasslineOutput{}, errors.New("root_octave must be between 0 and 6")
+	}
+	velocity := defaultBasslineVelocity
+	if input.Velocity != nil {
+		velocity = *input.Velocity
+	}
+	if velocity < 1 || velocity > 127 {
+		return GenerateBasslineOutput{}, errors.New("velocity must be between 1 and 127")
+	}
+	kickPitch := defaultBasslineKick
+	if input.KickPitch != nil {
+		kickPitch = *input.KickPitch
+	}
+	if kickPitch < 0 || kickPitch > 127 {
+		return GenerateBasslineOutput{}, errors.New("kick_pitch must be 0..127")
+	}
+
+	progression := strings.TrimSpace(input.Progression)
+	useClip := input.ChordTrackIndex != nil || input.ChordClipIndex != nil
+	if (progression != "") == useClip {
+		return GenerateBasslineOutput{}, errors.New("provide either progression or chord_track_index+chord_clip_index (not both)")
+	}
+	useKicks := input.DrumTrackIndex != nil || input.DrumClipIndex != nil
+	if useKicks && (input.DrumTrackIndex == nil || input.DrumClipIndex == nil) {
+		return GenerateBasslineOutput{}, errors.New("drum_track_index and drum_clip_index must be set together")
+	}
+
+	targetHasClip, err := queryClipHasClip(client, input.TrackIndex, input.TargetClipIndex)
+	if err != nil {
+		return GenerateBasslineOutput{}, fmt.Errorf("check target slot: %w", err)
+	}
+	if targetHasClip {
+		return GenerateBasslineOutput{}, errors.New("target_clip_index must be an empty slot to preserve A/B comparison")
+	}
+
+	var chords []timedChord
+	var lengthBeats float64
+	source := "progression"
+	if useClip {
+		if input.ChordTrackIndex == nil || input.ChordClipIndex == nil {
+			return GenerateBasslineOutput{}, errors.New("chord_track_index and chord_clip_index must be set together")
+		}
+		if err := validateTrackClipIndices(*input.ChordTrackIndex, *input.ChordClipIndex); err != nil {
+			return GenerateBasslineOutput{}, err
+		}
+		source = "clip"
+		res, err := client.Query("/live/clip/get/notes", int32(*input.ChordTrackIndex), int32(*input.ChordClipIndex))
+		if err != nil {
+			return GenerateBasslineOutput{}, fmt.Errorf("get chord notes: %w", err)
+		}
+		_, _, chordNotes, err := parseClipNotesResponse(res)
+		if err != nil {
+			return GenerateBasslineOutput{}, fmt.Errorf("get chord notes: %w", err)
+		}
+		lengthBeats = queryClipLength(client, *input.ChordTrackIndex, *input.ChordClipIndex)
+		if lengthBeats <= 0 {
+			lengthBeats = inferredClipLength(chordNotes)
+		}
+		chords = chordsFromNotes(chordNotes, lengthBeats)
+		if len(chords) == 0 {
+			return GenerateBasslineOutput{}, errors.New("no chords recognized in the chord clip")
+		}
+	} else {
+		beatsPerChord := defaultBeatsPerChord
+		if input.BeatsPerChord != nil {
+			beatsPerChord = *input.BeatsPerChord
+		}
+		if beatsPerChord < 0.25 || beatsPerChord > 16 {
+			return GenerateBasslineOutput{}, errors.New("beats_per_chord must be between 0.25 and 16")
+		}
+		parsed, err := parseProgression(progression)
+		if err != nil {
+			return GenerateBasslineOutput{}, err
+		}
+		for i, ch := range parsed {
+			chords = append(chords, timedChord{chord: ch, start: float64(i) * beatsPerChord, duration: beatsPerChord})
+		}
+		lengthBeats = beatsPerChord * float64(len(parsed))
+	}
+
+	var kicks []float64
+	if useKicks {
+		if err := validateTrackClipIndices(*input.DrumTrackIndex, *input.DrumClipIndex); err != nil {
+			return GenerateBasslineOutput{}, err
+		}
+		res, err := client.Query("/live/clip/get/notes", int32(*input.DrumTrackIndex), int32(*input.DrumClipIndex))
+		if err != nil {
+			return GenerateBasslineOutput{}, fmt.Errorf("get drum notes: %w", err)
+		}
+		_, _, drumNotes, err := parseClipNotesResponse(res)
+		if err != nil {
+			return GenerateBasslineOutput{}, fmt.Errorf("get drum notes: %w", err)
+		}
+		drumLength := queryClipLength(client, *input.DrumTrackIndex, *input.DrumClipIndex)
+		if drumLength <= 0 {
+			drumLength = inferredClipLength(drumNotes)
+		}
+		kicks = kickOnsets(drumNotes, kickPitch, drumLength, lengthBeats)
+		if len(kicks) == 0 {
+			return GenerateBasslineOutput{}, fmt.Errorf("drum clip has no kick hits at pitch %d", kickPitch)
+		}
+	}
+
+	notes := basslineNotes(chords, style, octave, velocity, kicks)
+	if len(notes) == 0 {
+		return GenerateBasslineOutput{}, errors.New("bassline produced no playable notes")
+	}
+
+	if err := client.Send("/live/clip_slot/create_clip",
+		int32(input.TrackIndex), int32(input.TargetClipIndex), float32(lengthBeats),
+	); err != nil {
+		return GenerateBasslineOutput{}, fmt.Errorf("create clip: %w", err)
+	}
+	created, err := queryClipHasClip(client, input.TrackIndex, input.TargetClipIndex)
+	if err != nil {
+		return GenerateBasslineOutput{}, fmt.Errorf("verify clip: %w", err)
+	}
+	if !created {
+		return GenerateBasslineOutput{}, errors.New("clip was not created (is the track a MIDI track?)")
+	}
+	if err := client.Send("/live/clip/add/notes", addNotesArgs(input.TrackIndex, input.TargetClipIndex, notes)...); err != nil {
+		return GenerateBasslineOutput{}, fmt.Errorf("add bassline notes: %w", err)
+	}
+	if err := client.Send(
+		"/live/clip/set/name",
+		int32(input.TrackIndex),
+		int32(input.TargetClipIndex),
+		"Bassline: "+style,
+	); err != nil {
+		return GenerateBasslineOutput{}, fmt.Errorf("set bassline clip name: %w", err)
+	}
+
+	fired := false
+	if input.Fire {
+		if err := client.Send("/live/clip_slot/fire", int32(input.TrackIndex), int32(input.TargetClipIndex)); err != nil {
+			return GenerateBasslineOutput{}, fmt.Errorf("fire bassline clip: %w", err)
+		}
+		fired = true
+	}
+
+	names := make([]string, 0, len(chords))
+	for _, ch := range chords {
+		names = append(names, ch.chord.name)
+	}
+	return GenerateBasslineOutput{
+		TrackIndex:      input.TrackIndex,
+		TargetClipIndex: input.TargetClipIndex,
+		Style:           style,
+		ChordSource:     source,
+		Chords:          names,
+		LengthBeats:     lengthBeats,
+		NotesAdded:      len(notes),
+		KickLocked:      useKicks,
+		Fired:           fired,
+	}, nil
+}
+
+func isBasslineStyle(style string) bool {
+	switch style {
+	case "roots", "root_fifth", "walking", "octave_pump", "syncopated":
+		return true
+	default:
+		return false
+	}
+}
+
+// chordRecognitionQualities is the order in which chord templates are tried;
+// earlier entries win ties so plain triads beat sevenths and suspensions.
+var chordRecognitionQualities = []string{"", "m", "7", "maj7", "m7", "dim", "aug", "sus4", "sus2", "5"}
+
+// chordsFromNotes recognizes block chords in a MIDI clip: each distinct note
+// onset starts a segment, the sounding pitch classes are matched against chord
+// templates, and repeated chords are merged.
+func chordsFromNotes(notes []MidiNote, clipLength float64) []timedChord {
+	starts := make([]float64, 0, len(notes))
+	for _, n := range notes {
+		if midiMuteValue(n.Mute) || n.StartTime >= clipLength {
+			continue
+		}
+		dup := false
+		for _, s := range starts {
+			if math.Abs(s-n.StartTime) < 1e-3 {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			starts = append(starts, n.StartTime)
+		}
+	}
+	sort.Float64s(starts)
+
+	var chords []timedChord
+	for i, start := range starts {
+		end := clipLength
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		var pcs [12]bool
+		lowest := 128
+		for _, n := range notes {
+			if midiMuteValue(n.Mute) {
+				continue
+			}
+			if n.StartTime <= start+1e-3 && n.StartTime+n.Duration > start+1e-3 {
+				pcs[n.Pitch%12] = true
+				if n.Pitch < lowest {
+					lowest = n.Pitch
+				}
+			}
+		}
+		ch, ok := recognizeChord(pcs, lowest%12)
+		if !ok {
+			ch = parsedChord{name: "N.C.", rest: true}
+		}
+		if len(chords) > 0 && chords[len(chords)-1].chord.name == ch.name {
+			chords[len(chords)-1].duration = end - chords[len(chords)-1].start
+			continue
+		}
+		chords = append(chords, timedChord{chord: ch, start: start, duration: end - start})
+	}
+	if len(chords) > 0 && chords[0].start > 0 {
+		chords = append([]timedChord{{chord: parsedChord{name: "N.C.", rest: true}, duration: chords[0].start}}, chords...)
+	}
+	return chords
+}
+
+// recognizeChord scores every root/quality template against the pitch-class
+// set (+1 per chord tone present, -1 per tone missing or extra) and prefers
+// the bass note as root on ties.
+func recognizeChord(pcs [12]bool, bassPC int) (parsedChord, bool) {
+	count := 0
+	for _, on := range pcs {
+		if on {
+			count++
+		}
+	}
+	if count == 0 {
+		return parsedChord{}, false
+	}
+	bestScore := math.MinInt
+	var best parsedChord
+	for root := 0; root < 12; root++ {
+		if !pcs[root] {
+			continue
+		}
+		for _, quality := range chordRecognitionQualities {
+			intervals := chordQualityIntervals[quality]
+			score := 0
+			var inTemplate [12]bool
+			for _, iv := range intervals {
+				pc := (root + iv) % 12
+				inTemplate[pc] = true
+				if pcs[pc] {
+					score++
+				} else {
+					score--
+				}
+			}
+			for pc, on := range pcs {
+				if on && !inTemplate[pc] {
+					score--
+				}
+			}
+			score *= 2
+			if root == bassPC {
+				score++
+			}
+			if score > bestScore {
+				bestScore = score
+				best = parsedChord{name: chordRootNames[root] + quality, rootPC: root, intervals: intervals}
+			}
+		}
+	}
+	return best, true
+}
+
+// kickOnsets returns the kick start times of a drum clip, looped to cover
+// lengthBeats.
+func kickOnsets(notes []MidiNote, kickPitch int, drumLength, lengthBeats float64) []float64 {
+	var base []float64
+	for _, n := range notes {
+		if n.Pitch == kickPitch && !midiMuteValue(n.Mute) && n.StartTime < drumLength {
+			base = append(base, n.StartTime)
+		}
+	}
+	sort.Float64s(base)
+	var out []float64
+	for offset := 0.0; offset < lengthBeats && len(base) > 0; offset += drumLength {
+		for _, t := range base {
+			if offset+t < lengthBeats {
+				out = append(out, offset+t)
+			}
+		}
+	}
+	return out
+}
+
+// basslineOnsets returns the note start offsets (relative to the chord start)
+// for a style within one chord of the given length.
+func basslineOnsets(style string, length float64) []float64 {
+	var grid []float64
+	switch style {
+	case "octave_pump":
+		for t := 0.0; t < length-1e-9; t += 0.5 {
+			grid = append(grid, t)
+		}
+	case "syncopated":
+		pattern := []float64{0, 0.75, 1.5, 2.5, 3.25}
+		for bar := 0.0; bar < length-1e-9; bar += 4 {
+			for _, p := range pattern {
+				if bar+p < length-1e-9 {
+					grid = append(grid, bar+p)
+				}
+			}
+		}
+	default:
+		for t := 0.0; t < length-1e-9; t++ {
+			grid = append(grid, t)
+		}
+	}
+	if len(grid) == 0 {
+		grid = []float64{0}
+	}
+	return grid
+}
+
+// basslinePitch picks the pitch for the idx-th of count notes in a chord.
+func basslinePitch(style string, ch parsedChord, next *parsedChord, idx, count, octave int) int {
+	root := ch.rootPC + 12*(octave+1)
+	fifth := root + 7
+	third := root + 4
+	for _, iv := range ch.intervals {
+		switch iv {
+		case 6, 8:
+			fifth = root + iv
+		case 3:
+			third = root + 3
+		case 2, 5:
+			if third == root+4 {
+				third = root + iv
+			}
+		}
+	}
+	switch style {
+	case "root_fifth":
+		if idx%2 == 1 {
+			return fifth
+		}
+	case "octave_pump":
+		if idx%2 == 1 {
+			return root + 12
+		}
+	case "syncopated":
+		if idx == count-1 && count > 2 {
+			return fifth
+		}
+	case "walking":
+		if idx == count-1 && count > 1 && next != nil && !next.rest {
+			// Chromatic approach from a half step below the next root.
+			target := next.rootPC + 12*(octave+1)
+			if target-root > 6 {
+				target -= 12
+			} else if root-target > 6 {
+				target += 12
+			}
+			if target == root {
+				return fifth
+			}
+			return target - 1
+		}
+		return []int{root, third, fifth, third}[idx%4]
+	}
+	return root
+}
+
+// basslineNotes writes the style's rhythm over each chord, or places one note
+// per kick hit when kicks are given.
+func basslineNotes(chords []timedChord, style string, octave, velocity int, kicks []float64) []MidiNote {
+	var notes []MidiNote
+	for ci, tc := range chords {
+		if tc.chord.rest {
+			continue
+		}
+		var next *parsedChord
+		if ci+1 < len(chords) {
+			next = &chords[ci+1].chord
+		}
+		end := tc.start + tc.duration
+		var onsets []float64
+		if kicks != nil {
+			for _, k := range kicks {
+				if k >= tc.start-1e-9 && k < end-1e-9 {
+					onsets = append(onsets, k)
+				}
+			}
+		} else {
+			for _, o := range basslineOnsets(style, tc.duration) {
+				onsets = append(onsets, tc.start+o)
+			}
+		}
+		for i, start := range onsets {
+			noteEnd := end
+			if i+1 < len(onsets) {
+				noteEnd = onsets[i+1]
+			}
+			duration := (noteEnd - start) * 0.9
+			if style == "octave_pump" || style == "syncopated" {
+				duration = math.Min(duration, 0.4)
+			}
+			if duration < 0.05 {
+				duration = 0.05
+			}
+			pitch := basslinePitch(style, tc.chord, next, i, len(onsets), octave)
+			if pitch < 0 || pitch > 127 {
+				continue
+			}
+			vel := velocity
+			if i > 0 && style != "walking" {
+				vel = clampVelocity(velocity - 12)
+			}
+			notes = append(notes, MidiNote{Pitch: pitch, StartTime: start, Duration: duration, Velocity: vel})
+		}
+	}
+	return notes
+}
+
+func clampVelocity(v int) int {
+	if v < 1 {
+		return 1
+	}
+	if v > 127 {
+		return 127
+	}
+	return v
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChordsFromNotes(t *testing.T) {
+	t.Parallel()
+
+	chords, err := parseProgression("C C Am G7")
+	if err != nil {
+		t.Fatalf("parseProgression() error = %v", err)
+	}
+	notes, _ := chordProgressionNotes(chords, 4, defaultChordOctave, 90)
+	got := chordsFromNotes(notes, 16)
+	want := []string{"C", "Am", "G7"}
+	if len(got) != len(want) {
+		t.Fatalf("chords = %+v, want %v", got, want)
+	}
+	for i, name := range want {
+		if got[i].chord.name != name {
+			t.Errorf("chord[%d] = %q, want %q", i, got[i].chord.name, name)
+		}
+	}
+	// Repeated C chords merge into one 8-beat chord.
+	if got[0].duration != 8 || got[1].start != 8 {
+		t.Errorf("merge: first duration %v, second start %v", got[0].duration, got[1].start)
+	}
+}
+
+func TestRecognizeChordPrefersBassRoot(t *testing.T) {
+	t.Parallel()
+
+	// A C E G: both Am7 and C6-like readings share tones; bass A should win.
+	var pcs [12]bool
+	for _, pc := range []int{9, 0, 4, 7} {
+		pcs[pc] = true
+	}
+	ch, ok := recognizeChord(pcs, 9)
+	if !ok || ch.name != "Am7" {
+		t.Errorf("recognizeChord = %q/%v, want Am7", ch.name, ok)
+	}
+}
+
+func TestBasslineNotesStyles(t *testing.T) {
+	t.Parallel()
+
+	chords := []timedChord{
+		{chord: mustChord(t, "C"), start: 0, duration: 4},
+		{chord: mustChord(t, "F"), start: 4, duration: 4},
+	}
+	roots := basslineNotes(chords, "roots", defaultBasslineOctave, 100, nil)
+	if len(roots) != 8 || roots[0].Pitch != 36 || roots[4].Pitch != 41 {
+		t.Errorf("roots = %+v", roots)
+	}
+	fifths := basslineNotes(chords, "root_fifth", defaultBasslineOctave, 100, nil)
+	if fifths[1].Pitch != 43 {
+		t.Errorf("root_fifth second note = %d, want 43", fifths[1].Pitch)
+	}
+	pump := basslineNotes(chords, "octave_pump", defaultBasslineOctave, 100, nil)
+	if len(pump) != 16 || pump[1].Pitch != 48 {
+		t.Errorf("octave_pump = %d notes, second pitch %d", len(pump), pump[1].Pitch)
+	}
+	walking := basslineNotes(chords, "walking", defaultBasslineOctave, 100, nil)
+	// Last note before F approaches from a half step below (E2 = 40).
+	if walking[3].Pitch != 40 {
+		t.Errorf("walking approach = %d, want 40", walking[3].Pitch)
+	}
+}
+
+func TestBasslineNotesKickLocked(t *testing.T) {
+	t.Parallel()
+
+	chords := []timedChord{{chord: mustChord(t, "Am"), start: 0, duration: 8}}
+	kicks := kickOnsets([]MidiNote{
+		{Pitch: drumPitchKick, StartTime: 0, Duration: 0.25, Velocity: 100},
+		{Pitch: drumPitchSnare, StartTime: 1, Duration: 0.25, Velocity: 100},
+		{Pitch: drumPitchKick, StartTime: 2.5, Duration: 0.25, Velocity: 100},
+	}, drumPitchKick, 4, 8)
+	if len(kicks) != 4 || kicks[2] != 4 || kicks[3] != 6.5 {
+		t.Fatalf("kicks = %v", kicks)
+	}
+	notes := basslineNotes(chords, "roots", defaultBasslineOctave, 100, kicks)
+	if len(notes) != 4 {
+		t.Fatalf("notes = %d, want 4", len(notes))
+	}
+	for i, n := range notes {
+		if n.StartTime != kicks[i] || n.Pitch != 45 {
+			t.Errorf("note %d = %+v", i, n)
+		}
+	}
+}
+
+func TestGenerateBasslineFromProgression(t *testing.T) {
+	t.Parallel()
+
+	client := &recipeClientStub{
+		queries: map[string][]interface{}{
+			"/live/clip_slot/get/has_clip": {int32(1), int32(2), int32(0)},
+		},
+	}
+	out, err := generateBassline(&hasClipToggleStub{recipeClientStub: client}, GenerateBasslineInput{
+		TrackIndex:      1,
+		TargetClipIndex: 2,
+		Progression:     "Am F C G",
+		Style:           "root_fifth",
+		Fire:            true,
+	})
+	if err != nil {
+		t.Fatalf("generateBassline() error = %v", err)
+	}
+	if out.LengthBeats != 16 || out.NotesAdded != 16 || out.ChordSource != "progression" || !out.Fired {
+		t.Errorf("out = %+v", out)
+	}
+	assertSent(t, client, "/live/clip_slot/create_clip")
+	assertSent(t, client, "/live/clip/add/notes")
+}
+
+func TestGenerateBasslineValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := generateBassline(&recipeClientStub{}, GenerateBasslineInput{TrackIndex: 0, TargetClipIndex: 1})
+	if err == nil || !strings.Contains(err.Error(), "either progression") {
+		t.Errorf("error = %v, want source exclusivity error", err)
+	}
+	_, err = generateBassline(&recipeClientStub{}, GenerateBasslineInput{Progression: "C", Style: "dubstep"})
+	if err == nil || !strings.Contains(err.Error(), "style") {
+		t.Errorf("error = %v, want style error", err)
+	}
+	client := &recipeClientStub{
+		queries: map[string][]interface{}{
+			"/live/clip_slot/get/has_clip": {int32(0), int32(1), int32(1)},
+		},
+	}
+	_, err = generateBassline(client, GenerateBasslineInput{TargetClipIndex: 1, Progression: "C"})
+	if err == nil || !strings.Contains(err.Error(), "empty slot") {
+		t.Errorf("error = %v, want empty slot error", err)
+	}
+}
+
+// hasClipToggleStub reports an empty slot on the first has_clip query and an
+// existing clip afterwards, mimicking create_clip succeeding.
+type hasClipToggleStub struct {
+	*recipeClientStub
+	checked bool
+}
+
+func (s *hasClipToggleStub) Query(address string, args ...interface{}) ([]interface{}, error) {
+	res, err := s.recipeClientStub.Query(address, args...)
+	if address == "/live/clip_slot/get/has_clip" && err == nil {
+		has := int32(0)
+		if s.checked {
+			has = 1
+		}
+		s.checked = true
+		return []interface{}{res[0], res[1], has}, nil
+	}
+	return res, err
+}
+
+func mustChord(t *testing.T, sym string) parsedChord {
+	t.Helper()
+	ch, err := parseChordSymbol(sym)
+	if err != nil {
+		t.Fatalf("parseChordSymbol(%q) error = %v", sym, err)
+	}
+	return ch
+}
@@ -3,6 +3,8 @@ package tools
 import (
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/firebase/genkit/go/ai"
@@ -16,6 +18,12 @@ const (
 	defaultChordOctave   = 4 // C4 = MIDI 60 (standard); C3 in Ableton's display
 	defaultChordVelocity = 90
 	maxChordSlots        = 64
+
+	defaultChordVoicing = "close"
+	defaultChordRhythm  = "block"
+	defaultStabStep     = 1.0  // one stab per beat
+	defaultArpStep      = 0.5  // 8th-note arpeggio
+	defaultStrumOffset  = 0.04 // beats between strummed voices (~20 ms at 120 BPM)
 )
 
 // chordClipClient is the subset of the OSC client used to build a chord clip.
@@ -27,10 +35,16 @@ type chordClipClient interface {
 type BuildChordClipInput struct {
 	TrackIndex    int      `json:"track_index" jsonschema:"description=Existing MIDI track index to write into,minimum=0"`
 	ClipIndex     int      `json:"clip_index" jsonschema:"description=Empty clip slot index to fill,minimum=0"`
-	Progression   string   `json:"progression" jsonschema:"description=Chord names separated by space/comma/pipe (e.g. 'C G Am F' or 'C | G | Am | F'). Supports m, dim, aug, 7, maj7, m7, sus2, sus4, 5. Use N.C. or - for a rest."`
+	Progression   string   `json:"progression" jsonschema:"description=Chord names separated by space/comma/pipe (e.g. 'C G Am F' or 'C | G/B | Am7 | Fmaj9') or Roman numerals relative to key (e.g. 'ii7 V7 Imaj7', 'i VI III VII', 'I bVII IV', 'V7/V'). Supports m, dim, aug, 6, 7, maj7, m7, m7b5, dim7, 9, maj9, m9, 11, 13, add9, 7b9, 7#9, sus2, sus4, 5 and slash basses (C/E). Use N.C. or - for a rest."`
 	BeatsPerChord *float64 `json:"beats_per_chord,omitempty" jsonschema:"description=Beats each chord lasts (default 4 = one bar in 4/4),minimum=0.25,maximum=16"`
 	RootOctave    *int     `json:"root_octave,omitempty" jsonschema:"description=Octave of the chord roots (default 4; C4=MIDI 60),minimum=0,maximum=8"`
 	Velocity      *int     `json:"velocity,omitempty" jsonschema:"description=Note velocity (default 90),minimum=1,maximum=127"`
+	Key           string   `json:"key,omitempty" jsonschema:"description=Key for Roman-numeral progressions (e.g. Bb major, A minor, F# dorian); defaults to the song key set with ableton_set_song_key"`
+	Voicing       string   `json:"voicing,omitempty" jsonschema:"description=close (stacked from the root), voice_lead (minimal motion between chords), spread (open: root down, third up an octave), or drop2 (default close)"`
+	Inversion     *int     `json:"inversion,omitempty" jsonschema:"description=Inversion for every chord (0=root position, 1=first, 2=second, 3=third); with voice_lead only the first chord,minimum=0,maximum=3"`
+	Rhythm        string   `json:"rhythm,omitempty" jsonschema:"description=block (held chords), stabs (short hits every rhythm_step), arp_up, arp_down (one voice every rhythm_step), or strum (voices offset low to high) (default block)"`
+	RhythmStep    *float64 `json:"rhythm_step,omitempty" jsonschema:"description=Beats between stabs or arpeggio notes (default 1 for stabs, 0.5 for arpeggios),minimum=0.0625,maximum=4"`
+	StrumOffset   *float64 `json:"strum_offset,omitempty" jsonschema:"description=Beats between strummed voices (default 0.04),minimum=0.005,maximum=0.5"`
 	Tempo         *float64 `json:"tempo,omitempty" jsonschema:"description=Optional project tempo (BPM) to set before building,minimum=20,maximum=999"`
 	Fire          bool     `json:"fire,omitempty" jsonschema:"description=Fire the clip after building"`
 }
@@ -39,6 +53,7 @@ type BuildChordClipOutput struct {
 	TrackIndex  int      `json:"track_index"`
 	ClipIndex   int      `json:"clip_index"`
 	Chords      []string `json:"chords"`
+	Key         string   `json:"key,omitempty"`
 	LengthBeats float64  `json:"length_beats"`
 	NotesAdded  int      `json:"notes_added"`
 	TempoSet    float64  `json:"tempo_set,omitempty"`
@@ -48,7 +63,7 @@ type BuildChordClipOutput struct {
 
 func NewAbletonBuildChordClip(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
 	return genkit.DefineTool(g, "ableton_build_chord_clip",
-		"Ableton Live: write a MIDI chord-progression clip into an existing MIDI track from a chord string (e.g. from ableton_analyze_audio_url's chord_summary). Creates the clip, adds block chords, and optionally fires it. A starting-point sketch, not a finished arrangement.",
+		"Ableton Live: write a MIDI chord-progression clip into an existing MIDI track from a chord string (e.g. from ableton_analyze_audio_url's chord_summary) or Roman numerals in a key (default: the song key). Creates the clip, adds chords with the chosen voicing (close, voice_lead, spread, drop2), inversion and rhythm (block, stabs, arp_up, arp_down, strum), and optionally fires it. A starting-point sketch, not a finished arrangement.",
 		func(_ *ai.ToolContext, input BuildChordClipInput) (BuildChordClipOutput, error) {
 			return buildChordClip(client, input)
 		},
@@ -60,10 +75,18 @@ func buildChordClip(client chordClipClient, input BuildChordClipInput) (BuildCho
 		return BuildChordClipOutput{}, err
 	}
 
-	chords, err := parseProgression(input.Progression)
+	key, err := resolveProgressionKey(client, input.Progression, input.Key)
+	if err != nil {
+		return BuildChordClipOutput{}, err
+	}
+	chords, err := parseProgressionInKey(input.Progression, key)
 	if err != nil {
 		return BuildChordClipOutput{}, err
 	}
+	keyName := ""
+	if key != nil {
+		keyName = key.String()
+	}
 
 	beatsPerChord := defaultBeatsPerChord
 	if input.BeatsPerChord != nil {
@@ -89,7 +112,11 @@ func buildChordClip(client chordClipClient, input BuildChordClipInput) (BuildCho
 		return BuildChordClipOutput{}, errors.New("velocity must be between 1 and 127")
 	}
 
-	notes, names := chordProgressionNotes(chords, beatsPerChord, octave, velocity)
+	opts, err := resolveChordRenderOptions(input, beatsPerChord, octave, velocity)
+	if err != nil {
+		return BuildChordClipOutput{}, err
+	}
+	notes, names := renderChordProgression(chords, opts)
 	if len(notes) == 0 {
 		return BuildChordClipOutput{}, errors.New("progression produced no playable notes")
 	}
@@ -152,54 +179,304 @@ func buildChordClip(client chordClipClient, input BuildChordClipInput) (BuildCho
 		TrackIndex:  input.TrackIndex,
 		ClipIndex:   input.ClipIndex,
 		Chords:      names,
+		Key:         keyName,
 		LengthBeats: lengthBeats,
 		NotesAdded:  len(notes),
 		TempoSet:    tempoSet,
 		Fired:       fired,
-		Note:        "Block-chord sketch (root-position triads/sevenths). Use it as a harmonic starting point, then voice-lead and arrange to taste.",
+		Note:        chordClipNote(opts),
 	}, nil
 }
 
+func resolveChordRenderOptions(input BuildChordClipInput, beatsPerChord float64, octave, velocity int) (chordRenderOptions, error) {
+	opts := chordRenderOptions{
+		BeatsPerChord: beatsPerChord,
+		Octave:        octave,
+		Velocity:      velocity,
+		Voicing:       strings.ToLower(strings.TrimSpace(input.Voicing)),
+		Rhythm:        strings.ToLower(strings.TrimSpace(input.Rhythm)),
+		Strum:         defaultStrumOffset,
+	}
+	if opts.Voicing == "" {
+		opts.Voicing = defaultChordVoicing
+	}
+	switch opts.Voicing {
+	case "close", "voice_lead", "spread", "drop2":
+	default:
+		return chordRenderOptions{}, errors.New("voicing must be close, voice_lead, spread, or drop2")
+	}
+	if opts.Rhythm == "" {
+		opts.Rhythm = defaultChordRhythm
+	}
+	switch opts.Rhythm {
+	case "block", "strum":
+	case "stabs":
+		opts.Step = defaultStabStep
+	case "arp_up", "arp_down":
+		opts.Step = defaultArpStep
+	default:
+		return chordRenderOptions{}, errors.New("rhythm must be block, stabs, arp_up, arp_down, or strum")
+	}
+	if input.Inversion != nil {
+		opts.Inversion = *input.Inversion
+	}
+	if opts.Inversion < 0 || opts.Inversion > 3 {
+		return chordRenderOptions{}, errors.New("inversion must be between 0 and 3")
+	}
+	if input.RhythmStep != nil {
+		if *input.RhythmStep < 0.0625 || *input.RhythmStep > 4 {
+			return chordRenderOptions{}, errors.New("rhythm_step must be between 0.0625 and 4")
+		}
+		opts.Step = *input.RhythmStep
+	}
+	if input.StrumOffset != nil {
+		if *input.StrumOffset < 0.005 || *input.StrumOffset > 0.5 {
+			return chordRenderOptions{}, errors.New("strum_offset must be between 0.005 and 0.5")
+		}
+		opts.Strum = *input.StrumOffset
+	}
+	return opts, nil
+}
+
+func chordClipNote(opts chordRenderOptions) string {
+	if opts.Voicing == defaultChordVoicing && opts.Inversion == 0 && opts.Rhythm == defaultChordRhythm {
+		return "Block-chord sketch (root-position chords). Use it as a harmonic starting point, then voice-lead and arrange to taste."
+	}
+	return fmt.Sprintf("Chord sketch (%s voicing, inversion %d, %s rhythm). Use it as a harmonic starting point and arrange to taste.",
+		opts.Voicing, opts.Inversion, opts.Rhythm)
+}
+
 // parsedChord is a single chord in a progression.
 type parsedChord struct {
 	name      string
 	rootPC    int
 	intervals []int
 	rest      bool
+	slashBass bool // bassPC holds the slash-chord bass (e.g. E in C/E)
+	bassPC    int
 }
 
-// chordProgressionNotes lays chords out end to end and returns the MIDI notes
-// plus the resolved chord names (in order).
+// chordRenderOptions controls how a parsed progression becomes MIDI notes.
+type chordRenderOptions struct {
+	BeatsPerChord float64
+	Octave        int
+	Velocity      int
+	Voicing       string  // close, voice_lead, spread, drop2
+	Inversion     int     // 0..3, applied before the voicing
+	Rhythm        string  // block, stabs, arp_up, arp_down, strum
+	Step          float64 // beats between stabs / arpeggio notes
+	Strum         float64 // beats between strummed voices
+}
+
+// chordProgressionNotes lays root-position block chords out end to end and
+// returns the MIDI notes plus the resolved chord names (in order).
 func chordProgressionNotes(chords []parsedChord, beatsPerChord float64, octave, velocity int) ([]MidiNote, []string) {
+	return renderChordProgression(chords, chordRenderOptions{
+		BeatsPerChord: beatsPerChord,
+		Octave:        octave,
+		Velocity:      velocity,
+		Voicing:       defaultChordVoicing,
+		Rhythm:        defaultChordRhythm,
+	})
+}
+
+// renderChordProgression voices each chord, applies the rhythm within its
+// slot, and returns the MIDI notes plus the resolved chord names (in order).
+func renderChordProgression(chords []parsedChord, opts chordRenderOptions) ([]MidiNote, []string) {
 	var notes []MidiNote
 	names := make([]string, 0, len(chords))
+	var prev []int
 	for i, ch := range chords {
 		names = append(names, ch.name)
 		if ch.rest {
 			continue
 		}
-		start := float64(i) * beatsPerChord
-		rootMidi := ch.rootPC + 12*(octave+1)
-		for _, iv := range ch.intervals {
-			pitch := rootMidi + iv
-			if pitch < 0 || pitch > 127 {
-				continue
-			}
-			notes = append(notes, MidiNote{
-				Pitch:     pitch,
-				StartTime: start,
-				Duration:  beatsPerChord,
-				Velocity:  velocity,
-			})
+		pitches := chordVoicing(ch, opts, prev)
+		prev = pitches
+		if ch.slashBass {
+			pitches = append([]int{slashBassPitch(ch.bassPC, pitches)}, pitches...)
 		}
+		start := float64(i) * opts.BeatsPerChord
+		notes = append(notes, chordRhythmNotes(pitches, start, opts)...)
 	}
 	return notes, names
 }
 
+// chordVoicing returns the ascending upper-voice pitches of one chord.
+func chordVoicing(ch parsedChord, opts chordRenderOptions, prev []int) []int {
+	rootMidi := ch.rootPC + 12*(opts.Octave+1)
+	base := make([]int, 0, len(ch.intervals))
+	for _, iv := range ch.intervals {
+		base = append(base, rootMidi+iv)
+	}
+	switch opts.Voicing {
+	case "voice_lead":
+		if len(prev) == 0 {
+			return invertVoices(base, opts.Inversion)
+		}
+		return voiceLeadVoicing(base, prev)
+	case "spread":
+		return spreadVoices(invertVoices(base, opts.Inversion))
+	case "drop2":
+		return dropTwoVoices(invertVoices(base, opts.Inversion))
+	default:
+		return invertVoices(base, opts.Inversion)
+	}
+}
+
+// invertVoices moves the lowest voice up an octave n times.
+func invertVoices(pitches []int, n int) []int {
+	out := append([]int(nil), pitches...)
+	sort.Ints(out)
+	if len(out) < 2 {
+		return out
+	}
+	for i := 0; i < n%len(out); i++ {
+		out = append(out[1:], out[0]+12)
+		sort.Ints(out)
+	}
+	return out
+}
+
+// spreadVoices opens a close voicing: the bass voice drops an octave and the
+// second voice rises an octave.
+func spreadVoices(pitches []int) []int {
+	out := append([]int(nil), pitches...)
+	if len(out) < 3 {
+		return out
+	}
+	out[0] -= 12
+	out[1] += 12
+	sort.Ints(out)
+	return out
+}
+
+// dropTwoVoices drops the second-highest voice by an octave.
+func dropTwoVoices(pitches []int) []int {
+	out := append([]int(nil), pitches...)
+	if len(out) < 3 {
+		return out
+	}
+	out[len(out)-2] -= 12
+	sort.Ints(out)
+	return out
+}
+
+// voiceLeadVoicing picks the inversion/octave of base that moves least from
+// prev, with a small pull back toward the original register so long
+// progressions do not drift.
+func voiceLeadVoicing(base, prev []int) []int {
+	center := meanPitch(base)
+	var best []int
+	bestCost := math.Inf(1)
+	for inv := 0; inv < len(base); inv++ {
+		inverted := invertVoices(base, inv)
+		for _, shift := range []int{-24, -12, 0, 12} {
+			candidate := make([]int, len(inverted))
+			for i, p := range inverted {
+				candidate[i] = p + shift
+			}
+			cost := voiceMotion(candidate, prev) + 0.25*math.Abs(meanPitch(candidate)-center)
+			if cost < bestCost {
+				bestCost = cost
+				best = candidate
+			}
+		}
+	}
+	return best
+}
+
+// voiceMotion is the symmetric nearest-note distance between two voicings.
+func voiceMotion(a, b []int) float64 {
+	nearest := func(p int, set []int) float64 {
+		d := math.Inf(1)
+		for _, q := range set {
+			d = math.Min(d, math.Abs(float64(p-q)))
+		}
+		return d
+	}
+	total := 0.0
+	for _, p := range a {
+		total += nearest(p, b)
+	}
+	for _, q := range b {
+		total += nearest(q, a)
+	}
+	return total
+}
+
+func meanPitch(pitches []int) float64 {
+	if len(pitches) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, p := range pitches {
+		sum += p
+	}
+	return float64(sum) / float64(len(pitches))
+}
+
+// slashBassPitch places the slash bass pitch class below the lowest voice.
+func slashBassPitch(bassPC int, voices []int) int {
+	lowest := voices[0]
+	pitch := lowest - ((lowest-bassPC)%12+12)%12
+	if pitch == lowest {
+		pitch -= 12
+	}
+	return pitch
+}
+
+// chordRhythmNotes spreads one voiced chord over its slot.
+func chordRhythmNotes(pitches []int, start float64, opts chordRenderOptions) []MidiNote {
+	end := start + opts.BeatsPerChord
+	var notes []MidiNote
+	add := func(pitch int, at, duration float64) {
+		if pitch < 0 || pitch > 127 || duration <= 0 {
+			return
+		}
+		notes = append(notes, MidiNote{Pitch: pitch, StartTime: at, Duration: duration, Velocity: opts.Velocity})
+	}
+	switch opts.Rhythm {
+	case "stabs":
+		for t := start; t < end-1e-9; t += opts.Step {
+			for _, p := range pitches {
+				add(p, t, math.Min(opts.Step*0.5, end-t))
+			}
+		}
+	case "arp_up", "arp_down":
+		order := append([]int(nil), pitches...)
+		if opts.Rhythm == "arp_down" {
+			sort.Sort(sort.Reverse(sort.IntSlice(order)))
+		}
+		i := 0
+		for t := start; t < end-1e-9; t += opts.Step {
+			add(order[i%len(order)], t, math.Min(opts.Step, end-t))
+			i++
+		}
+	case "strum":
+		for i, p := range pitches {
+			at := start + float64(i)*opts.Strum
+			if at >= end {
+				break
+			}
+			add(p, at, end-at)
+		}
+	default:
+		for _, p := range pitches {
+			add(p, start, opts.BeatsPerChord)
+		}
+	}
+	return notes
+}
+
 func parseProgression(s string) ([]parsedChord, error) {
-	fields := strings.FieldsFunc(s, func(r rune) bool {
-		return r == ' ' || r == ',' || r == '|' || r == '\t' || r == '\n'
-	})
+	return parseProgressionInKey(s, nil)
+}
+
+// parseProgressionInKey parses chord names and, when key is set, Roman
+// numerals relative to that key.
+func parseProgressionInKey(s string, key *musicalKey) ([]parsedChord, error) {
+	fields := progressionFields(s)
 	if len(fields) == 0 {
 		return nil, errors.New("progression is empty")
 	}
@@ -208,7 +485,18 @@ func parseProgression(s string) ([]parsedChord, error) {
 	}
 	chords := make([]parsedChord, 0, len(fields))
 	for _, f := range fields {
-		ch, err := parseChordSymbol(f)
+		sym := f
+		if isRomanNumeral(f) {
+			if key == nil {
+				return nil, fmt.Errorf("roman numeral %q needs a key", f)
+			}
+			resolved, err := romanToChordSymbol(f, *key)
+			if err != nil {
+				return nil, err
+			}
+			sym = resolved
+		}
+		ch, err := parseChordSymbol(sym)
 		if err != nil {
 			return nil, err
 		}
@@ -218,22 +506,45 @@ func parseProgression(s string) ([]parsedChord, error) {
 }
 
 var chordQualityIntervals = map[string][]int{
-	"":     {0, 4, 7},
-	"maj":  {0, 4, 7},
-	"M":    {0, 4, 7},
-	"m":    {0, 3, 7},
-	"min":  {0, 3, 7},
-	"-":    {0, 3, 7},
-	"dim":  {0, 3, 6},
-	"aug":  {0, 4, 8},
-	"7":    {0, 4, 7, 10},
-	"maj7": {0, 4, 7, 11},
-	"M7":   {0, 4, 7, 11},
-	"m7":   {0, 3, 7, 10},
-	"min7": {0, 3, 7, 10},
-	"sus2": {0, 2, 7},
-	"sus4": {0, 5, 7},
-	"5":    {0, 7},
+	"":      {0, 4, 7},
+	"maj":   {0, 4, 7},
+	"M":     {0, 4, 7},
+	"m":     {0, 3, 7},
+	"min":   {0, 3, 7},
+	"-":     {0, 3, 7},
+	"dim":   {0, 3, 6},
+	"aug":   {0, 4, 8},
+	"+":     {0, 4, 8},
+	"6":     {0, 4, 7, 9},
+	"m6":    {0, 3, 7, 9},
+	"69":    {0, 4, 7, 9, 14},
+	"7":     {0, 4, 7, 10},
+	"maj7":  {0, 4, 7, 11},
+	"M7":    {0, 4, 7, 11},
+	"m7":    {0, 3, 7, 10},
+	"min7":  {0, 3, 7, 10},
+	"mmaj7": {0, 3, 7, 11},
+	"m7b5":  {0, 3, 6, 10},
+	"dim7":  {0, 3, 6, 9},
+	"7b5":   {0, 4, 6, 10},
+	"7#5":   {0, 4, 8, 10},
+	"7sus4": {0, 5, 7, 10},
+	"9":     {0, 4, 7, 10, 14},
+	"maj9":  {0, 4, 7, 11, 14},
+	"m9":    {0, 3, 7, 10, 14},
+	"7b9":   {0, 4, 7, 10, 13},
+	"7#9":   {0, 4, 7, 10, 15},
+	"add9":  {0, 4, 7, 14},
+	"madd9": {0, 3, 7, 14},
+	"11":    {0, 4, 7, 10, 14, 17},
+	"m11":   {0, 3, 7, 10, 14, 17},
+	"add11": {0, 4, 7, 17},
+	"13":    {0, 4, 7, 10, 14, 21},
+	"maj13": {0, 4, 7, 11, 14, 21},
+	"m13":   {0, 3, 7, 10, 14, 21},
+	"sus2":  {0, 2, 7},
+	"sus4":  {0, 5, 7},
+	"5":     {0, 7},
 }
 
 var noteLetterPC = map[byte]int{
@@ -250,15 +561,56 @@ func parseChordSymbol(sym string) (parsedChord, error) {
 		return parsedChord{name: "N.C.", rest: true}, nil
 	}
 
-	letter := trimmed[0]
+	body, bass := trimmed, ""
+	// A slash introduces a bass note (C/E) unless it is part of a quality (6/9).
+	if i := strings.LastIndex(trimmed, "/"); i > 0 && i+1 < len(trimmed) && !isDigit(trimmed[i+1]) {
+		body, bass = trimmed[:i], trimmed[i+1:]
+	} else if i > 0 {
+		body = trimmed[:i] + trimmed[i+1:]
+	}
+
+	pc, name, quality, err := parseNoteName(body)
+	if err != nil {
+		return parsedChord{}, fmt.Errorf("invalid chord root %q", sym)
+	}
+	intervals, ok := chordQualityIntervals[quality]
+	if !ok {
+		return parsedChord{}, fmt.Errorf("unsupported chord quality %q in %q (try m, dim, aug, 6, 7, maj7, m7, m7b5, dim7, 9, maj9, m9, 11, 13, add9, 7b9, 7#9, sus2, sus4, 5)", quality, sym)
+	}
+	ch := parsedChord{
+		name:      name + quality,
+		rootPC:    pc,
+		intervals: intervals,
+	}
+	if bass != "" {
+		bassPC, bassName, extra, err := parseNoteName(bass)
+		if err != nil || extra != "" {
+			return parsedChord{}, fmt.Errorf("invalid slash bass %q in %q", bass, sym)
+		}
+		ch.name += "/" + bassName
+		if bassPC != pc {
+			ch.slashBass = true
+			ch.bassPC = bassPC
+		}
+	}
+	return ch, nil
+}
+
+// parseNoteName reads a note letter plus optional #/b from the start of s and
+// returns its pitch class, canonical spelling, and the remaining text.
+func parseNoteName(s string) (int, string, string, error) {
+	if s == "" {
+		return 0, "", "", errors.New("empty note name")
+	}
+	letter := s[0]
 	pc, ok := noteLetterPC[letter&^0x20] // uppercase the letter
 	if !ok {
-		return parsedChord{}, fmt.Errorf("invalid chord root %q", sym)
+		return 0, "", "", fmt.Errorf("invalid note %q", s)
 	}
 	idx := 1
 	name := strings.ToUpper(string(letter))
-	if idx < len(trimmed) {
-		switch trimmed[idx] {
+	if idx < len(s) {
+		switch s[idx] {
 		case '#':
 			pc = (pc + 1) % 12
 			name += "#"
@@ -269,15 +621,9 @@ func parseChordSymbol(sym string) (parsedChord, error) {
 			idx++
 		}
 	}
+	return pc, name, s[idx:], nil
+}
 
-	quality := trimmed[idx:]
-	intervals, ok := chordQualityIntervals[quality]
-	if !ok {
-		return parsedChord{}, fmt.Errorf("unsupported chord quality %q in %q (try m, dim, aug, 7, maj7, m7, sus2, sus4, 5)", quality, sym)
-	}
-	return parsedChord{
-		name:      name + quality,
-		rootPC:    pc,
-		intervals: intervals,
-	}, nil
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
 }
@@ -27,6 +27,15 @@ func TestParseChordSymbol(t *testing.T) {
 		{in: "Dm", wantName: "Dm", wantRoot: 2, wantCount: 3},
 		{in: "H", wantErr: true},
 		{in: "Cxyz", wantErr: true},
+		{in: "C/E", wantName: "C/E", wantRoot: 0, wantCount: 3},
+		{in: "Am7/G", wantName: "Am7/G", wantRoot: 9, wantCount: 4},
+		{in: "Dm9", wantName: "Dm9", wantRoot: 2, wantCount: 5},
+		{in: "G13", wantName: "G13", wantRoot: 7, wantCount: 6},
+		{in: "Cadd9", wantName: "Cadd9", wantRoot: 0, wantCount: 4},
+		{in: "E7b9", wantName: "E7b9", wantRoot: 4, wantCount: 5},
+		{in: "F11", wantName: "F11", wantRoot: 5, wantCount: 6},
+		{in: "C6/9", wantName: "C69", wantRoot: 0, wantCount: 5},
+		{in: "C/X", wantErr: true},
 	}
 	for _, tc := range cases {
 		tc := tc
@@ -48,6 +57,9 @@ func TestParseChordSymbol(t *testing.T) {
 				}
 				return
 			}
+			if got.name != tc.wantName {
+				t.Errorf("name = %q, want %q", got.name, tc.wantName)
+			}
 			if got.rootPC != tc.wantRoot {
 				t.Errorf("root = %d, want %d", got.rootPC, tc.wantRoot)
 			}
@@ -83,6 +95,121 @@ func TestChordProgressionNotes(t *testing.T) {
 	}
 }
 
+func TestParseChordSymbolSlashBass(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseChordSymbol("C/E")
+	if err != nil {
+		t.Fatalf("parseChordSymbol() error = %v", err)
+	}
+	if !got.slashBass || got.bassPC != 4 {
+		t.Errorf("slash bass = %v/%d, want true/4", got.slashBass, got.bassPC)
+	}
+	notes, _ := chordProgressionNotes([]parsedChord{got}, 4, defaultChordOctave, 90)
+	// E bass under a root-position C triad: 52 + 60/64/67.
+	if len(notes) != 4 || notes[0].Pitch != 52 {
+		t.Errorf("slash chord notes = %+v", notes)
+	}
+}
+
+func TestChordVoicings(t *testing.T) {
+	t.Parallel()
+
+	c := mustChord(t, "Cmaj7")
+	base := chordRenderOptions{BeatsPerChord: 4, Octave: defaultChordOctave, Velocity: 90, Rhythm: "block"}
+	cases := []struct {
+		voicing   string
+		inversion int
+		want      []int
+	}{
+		{voicing: "close", want: []int{60, 64, 67, 71}},
+		{voicing: "close", inversion: 1, want: []int{64, 67, 71, 72}},
+		{voicing: "drop2", want: []int{55, 60, 64, 71}},
+		{voicing: "spread", want: []int{48, 67, 71, 76}},
+	}
+	for _, tc := range cases {
+		opts := base
+		opts.Voicing = tc.voicing
+		opts.Inversion = tc.inversion
+		got := chordVoicing(c, opts, nil)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s/%d = %v, want %v", tc.voicing, tc.inversion, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s/%d = %v, want %v", tc.voicing, tc.inversion, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestVoiceLeadingMinimizesMotion(t *testing.T) {
+	t.Parallel()
+
+	chords, err := parseProgression("C F G C")
+	if err != nil {
+		t.Fatalf("parseProgression() error = %v", err)
+	}
+	opts := chordRenderOptions{BeatsPerChord: 4, Octave: defaultChordOctave, Velocity: 90, Voicing: "voice_lead", Rhythm: "block"}
+	led, _ := renderChordProgression(chords, opts)
+	opts.Voicing = "close"
+	block, _ := renderChordProgression(chords, opts)
+	if motion(led) >= motion(block) {
+		t.Errorf("voice_lead motion %v should be below close %v", motion(led), motion(block))
+	}
+	// C -> F with voice leading keeps the common tone C (60) and moves to F/A: C4 F4 A4.
+	if led[3].Pitch != 60 || led[4].Pitch != 65 || led[5].Pitch != 69 {
+		t.Errorf("F voicing = %d/%d/%d, want 60/65/69", led[3].Pitch, led[4].Pitch, led[5].Pitch)
+	}
+}
+
+func TestChordRhythms(t *testing.T) {
+	t.Parallel()
+
+	pitches := []int{60, 64, 67}
+	opts := chordRenderOptions{BeatsPerChord: 4, Velocity: 90, Rhythm: "stabs", Step: 1}
+	if got := chordRhythmNotes(pitches, 0, opts); len(got) != 12 || got[3].StartTime != 1 || got[0].Duration != 0.5 {
+		t.Errorf("stabs = %+v", got)
+	}
+	opts = chordRenderOptions{BeatsPerChord: 2, Velocity: 90, Rhythm: "arp_down", Step: 0.5}
+	got := chordRhythmNotes(pitches, 4, opts)
+	if len(got) != 4 || got[0].Pitch != 67 || got[3].Pitch != 67 || got[1].StartTime != 4.5 {
+		t.Errorf("arp_down = %+v", got)
+	}
+	opts = chordRenderOptions{BeatsPerChord: 4, Velocity: 90, Rhythm: "strum", Strum: 0.05}
+	got = chordRhythmNotes(pitches, 0, opts)
+	if len(got) != 3 || got[2].StartTime != 0.1 || got[2].Duration != 3.9 {
+		t.Errorf("strum = %+v", got)
+	}
+}
+
+func TestBuildChordClipRejectsUnknownVoicing(t *testing.T) {
+	t.Parallel()
+
+	_, err := buildChordClip(&recipeClientStub{}, BuildChordClipInput{Progression: "C", Voicing: "cluster"})
+	if err == nil {
+		t.Fatal("expected error for unknown voicing")
+	}
+}
+
+// motion sums absolute pitch movement between consecutive chords of equal size.
+func motion(notes []MidiNote) float64 {
+	byStart := map[float64][]int{}
+	var starts []float64
+	for _, n := range notes {
+		if _, ok := byStart[n.StartTime]; !ok {
+			starts = append(starts, n.StartTime)
+		}
+		byStart[n.StartTime] = append(byStart[n.StartTime], n.Pitch)
+	}
+	total := 0.0
+	for i := 1; i < len(starts); i++ {
+		total += voiceMotion(byStart[starts[i]], byStart[starts[i-1]])
+	}
+	return total
+}
+
 func TestBuildChordClipStub(t *testing.T) {
 	t.Parallel()
 
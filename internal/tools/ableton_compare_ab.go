@@ -97,10 +97,32 @@ func compareABVariation(client compareABClient, input CompareABVariationInput, s
 		return CompareABVariationOutput{}, fmt.Errorf("audition after %s variation: %w", kind, err)
 	}
 	out.Audition = audition
-	out.PreferencePrompt = audition.PreferencePrompt
+	out.PreferencePrompt = audition.PreferencePrompt + compareABRecordHint(input, out)
 	return out, nil
 }
 
+// compareABRecordHint lists the B parameters to pass along when recording the
+// choice, so the taste profile keeps what produced the variation.
+func compareABRecordHint(input CompareABVariationInput, out CompareABVariationOutput) string {
+	var args []string
+	if input.Strength != nil {
+		args = append(args, fmt.Sprintf("strength=%g", *input.Strength))
+	}
+	if out.Seed != 0 {
+		args = append(args, fmt.Sprintf("seed=%d", out.Seed))
+	}
+	if input.VelocityDelta != nil {
+		args = append(args, fmt.Sprintf("velocity_delta=%d", *input.VelocityDelta))
+	}
+	if input.TrackIndex != nil {
+		args = append(args, fmt.Sprintf("track_index=%d", *input.TrackIndex))
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return " Also pass " + strings.Join(args, " ") + "."
+}
+
 func createDrumCompare(client compareABClient, input CompareABVariationInput, variation string) (CompareABVariationOutput, AuditionABInput, error) {
 	trackIndex, sourceClip, targetClip, err := requireClipCompareSlots(input)
 	if err != nil {
@@ -110,6 +110,9 @@ func TestCompareABVariationDrum(t *testing.T) {
 	if !strings.Contains(got.PreferencePrompt, "instrument=drum variation=density") {
 		t.Errorf("preference_prompt = %q", got.PreferencePrompt)
 	}
+	if !strings.Contains(got.PreferencePrompt, "strength=") || !strings.Contains(got.PreferencePrompt, "track_index=") {
+		t.Errorf("preference_prompt should list the B parameters: %q", got.PreferencePrompt)
+	}
 	if !hasCompareSend(client.calls, "/live/clip_slot/duplicate_clip_to") {
 		t.Error("expected variation duplicate")
 	}
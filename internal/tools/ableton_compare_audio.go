@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+)
+
+type CompareAudioFilesInput struct {
+	MixPath       string   `json:"mix_path" jsonschema:"description=Absolute local path to your bounce/mix (.wav, .aiff, .flac, .mp3)"`
+	ReferencePath string   `json:"reference_path" jsonschema:"description=Absolute local path to the reference track"`
+	ProjectTempo  *float64 `json:"project_tempo,omitempty" jsonschema:"description=Optional project BPM used when a file's tempo can't be detected,minimum=20,maximum=400"`
+}
+
+type CompareAudioFilesOutput struct {
+	Mix            audioanalyze.FileSummary   `json:"mix"`
+	Reference      audioanalyze.FileSummary   `json:"reference"`
+	Metrics        []audioanalyze.MetricDelta `json:"metrics" jsonschema:"description=Mix minus reference per metric"`
+	Bands          []audioanalyze.BandDelta   `json:"bands" jsonschema:"description=Tonal balance per region relative to each file's total energy (loudness-independent)"`
+	MatchAxes      []audioanalyze.MetricDelta `json:"match_axes"`
+	TempoAgreement string                     `json:"tempo_agreement"`
+	KeyAgreement   string                     `json:"key_agreement"`
+	Suggestions    []string                   `json:"suggestions"`
+	NextStep       string                     `json:"next_step"`
+}
+
+func NewAbletonCompareAudioFiles(g *genkit.Genkit) ai.Tool {
+	return genkit.DefineTool(g, "ableton_compare_audio_files",
+		"Compare a local mix/bounce against a local reference track: per-metric deltas (integrated LUFS, true peak, LRA, stereo width, crest factor, brightness, rhythm density), tonal balance per band (sub/low/low-mid/mid/high-mid/high in dB), match_axes deltas, key/BPM agreement, and plain-language suggestions like \"low-mid +3 dB vs reference\". No URLs.",
+		func(_ *ai.ToolContext, input CompareAudioFilesInput) (CompareAudioFilesOutput, error) {
+			return compareAudioFiles(input)
+		},
+	)
+}
+
+func compareAudioFiles(input CompareAudioFilesInput) (CompareAudioFilesOutput, error) {
+	projectTempo := 0.0
+	if input.ProjectTempo != nil {
+		projectTempo = *input.ProjectTempo
+	}
+	got, err := audioanalyze.CompareFiles(input.MixPath, input.ReferencePath, projectTempo)
+	if err != nil {
+		return CompareAudioFilesOutput{}, err
+	}
+	return CompareAudioFilesOutput{
+		Mix:            got.Mix,
+		Reference:      got.Reference,
+		Metrics:        got.Metrics,
+		Bands:          got.Bands,
+		MatchAxes:      got.MatchAxes,
+		TempoAgreement: got.TempoAgreement,
+		KeyAgreement:   got.KeyAgreement,
+		Suggestions:    got.Suggestions,
+		NextStep:       "Level-match first (integrated_lufs), then work the largest band deltas with EQ on the busiest tracks; re-bounce and compare again.",
+	}, nil
+}
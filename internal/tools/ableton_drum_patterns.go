@@ -0,0 +1,301 @@
+package tools
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+)
+
+const drumPatternVersion = 1
+
+// builtinDrumPatterns are the basic_backbeat/four_on_floor/kick_only presets
+// generated in code by buildDrumPattern.
+var builtinDrumPatterns = []string{"basic_backbeat", "four_on_floor", "kick_only"}
+
+// drumPatternLibrary holds the genre grooves shipped with the binary.
+//
+//go:embed drum_patterns/*.json
+var drumPatternLibrary embed.FS
+
+// DrumPattern is a named, loopable drum groove. Notes use Drum Rack pitches
+// (36 kick, 38 snare, 42 closed hat, ...) and repeat every LengthBeats.
+type DrumPattern struct {
+	Version     int        `json:"version"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	LengthBeats float64    `json:"length_beats"`
+	Notes       []MidiNote `json:"notes"`
+	SavedAt     time.Time  `json:"saved_at,omitzero"`
+}
+
+var drumPatternNameSanitize = regexp.MustCompile(`[^a-zA-Z0-9_.\- ]+`)
+
+func drumPatternDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ableton-osc-mcp", "drum-patterns")
+}
+
+func drumPatternFileName(name string) (string, error) {
+	clean := strings.TrimSpace(name)
+	if clean == "" {
+		return "", errors.New("pattern name is required")
+	}
+	return drumPatternNameSanitize.ReplaceAllString(clean, "_") + ".json", nil
+}
+
+func drumPatternPath(dir, name string) (string, error) {
+	file, err := drumPatternFileName(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, file), nil
+}
+
+func writeDrumPattern(path string, pattern DrumPattern) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create drum pattern dir: %w", err)
+	}
+	data, err := json.MarshalIndent(pattern, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode drum pattern: %w", err)
+	}
+	data = append(data, '\n')
+	return os.WriteFile(path, data, 0o600)
+}
+
+func readDrumPattern(path string) (DrumPattern, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DrumPattern{}, fmt.Errorf("drum pattern not found: %s", strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	if err != nil {
+		return DrumPattern{}, fmt.Errorf("read drum pattern: %w", err)
+	}
+	return decodeDrumPattern(data)
+}
+
+func decodeDrumPattern(data []byte) (DrumPattern, error) {
+	var p DrumPattern
+	if err := json.Unmarshal(data, &p); err != nil {
+		return DrumPattern{}, fmt.Errorf("parse drum pattern: %w", err)
+	}
+	if p.Version != drumPatternVersion {
+		return DrumPattern{}, fmt.Errorf("unsupported drum pattern version: %d", p.Version)
+	}
+	if len(p.Notes) == 0 {
+		return DrumPattern{}, fmt.Errorf("drum pattern %q has no notes", p.Name)
+	}
+	if p.LengthBeats <= 0 {
+		p.LengthBeats = inferredClipLength(p.Notes)
+	}
+	return p, nil
+}
+
+// findDrumPattern resolves a pattern name against the saved patterns in dir
+// first (so a saved groove can override a shipped one), then the library.
+func findDrumPattern(dir, name string) (DrumPattern, error) {
+	path, err := drumPatternPath(dir, name)
+	if err != nil {
+		return DrumPattern{}, err
+	}
+	if _, statErr := os.Stat(path); statErr == nil {
+		return readDrumPattern(path)
+	}
+	data, err := drumPatternLibrary.ReadFile("drum_patterns/" + strings.ToLower(filepath.Base(path)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return DrumPattern{}, fmt.Errorf("drum pattern not found: %s", strings.TrimSpace(name))
+	}
+	if err != nil {
+		return DrumPattern{}, fmt.Errorf("read drum pattern: %w", err)
+	}
+	return decodeDrumPattern(data)
+}
+
+// libraryDrumPatternNames lists the genre patterns embedded in the binary.
+func libraryDrumPatternNames() []string {
+	entries, err := drumPatternLibrary.ReadDir("drum_patterns")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tileDrumPattern repeats the pattern's loop until lengthBeats, dropping notes
+// that would start past the clip end.
+func tileDrumPattern(p DrumPattern, lengthBeats float64) []MidiNote {
+	period := p.LengthBeats
+	if period <= 0 {
+		period = inferredClipLength(p.Notes)
+	}
+	notes := make([]MidiNote, 0, len(p.Notes)*int(lengthBeats/period+1))
+	for offset := 0.0; offset < lengthBeats; offset += period {
+		for _, n := range p.Notes {
+			if n.StartTime >= period {
+				continue
+			}
+			start := offset + n.StartTime
+			if start >= lengthBeats {
+				continue
+			}
+			note := n
+			note.StartTime = start
+			if n.Mute != nil {
+				m := *n.Mute
+				note.Mute = &m
+			}
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// captureDrumPattern reads a clip's notes and loop length into a pattern.
+func captureDrumPattern(client variationClient, trackIndex, clipIndex int, name, description string) (DrumPattern, error) {
+	res, err := client.Query("/live/clip/get/notes", int32(trackIndex), int32(clipIndex))
+	if err != nil {
+		return DrumPattern{}, fmt.Errorf("get notes: %w", err)
+	}
+	_, _, notes, err := parseClipNotesResponse(res)
+	if err != nil {
+		return DrumPattern{}, fmt.Errorf("get notes: %w", err)
+	}
+	if len(notes) == 0 {
+		return DrumPattern{}, errors.New("clip has no notes to save as a pattern")
+	}
+	length := queryClipLength(client, trackIndex, clipIndex)
+	if length <= 0 {
+		length = inferredClipLength(notes)
+	}
+	kept := make([]MidiNote, 0, len(notes))
+	for _, n := range notes {
+		if midiMuteValue(n.Mute) || n.StartTime >= length {
+			continue
+		}
+		kept = append(kept, MidiNote{
+			Pitch:     n.Pitch,
+			StartTime: n.StartTime,
+			Duration:  n.Duration,
+			Velocity:  n.Velocity,
+		})
+	}
+	if len(kept) == 0 {
+		return DrumPattern{}, errors.New("clip has no unmuted notes inside its loop")
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].StartTime != kept[j].StartTime {
+			return kept[i].StartTime < kept[j].StartTime
+		}
+		return kept[i].Pitch < kept[j].Pitch
+	})
+	return DrumPattern{
+		Version:     drumPatternVersion,
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+		LengthBeats: length,
+		Notes:       kept,
+		SavedAt:     time.Now().UTC(),
+	}, nil
+}
+
+type SaveDrumPatternInput struct {
+	TrackIndex  int    `json:"track_index" jsonschema:"minimum=0"`
+	ClipIndex   int    `json:"clip_index" jsonschema:"minimum=0"`
+	Name        string `json:"name" jsonschema:"description=Pattern name (stored as JSON under the app config dir; usable as ableton_setup_drum_track pattern)"`
+	Description string `json:"description,omitempty" jsonschema:"description=Optional short description (genre, tempo range, feel)"`
+}
+
+type DrumPatternOutput struct {
+	Name        string  `json:"name"`
+	Path        string  `json:"path"`
+	LengthBeats float64 `json:"length_beats"`
+	NumNotes    int     `json:"num_notes"`
+}
+
+func NewAbletonSaveDrumPattern(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_save_drum_pattern",
+		"Ableton Live: save a MIDI clip's notes (one loop of the clip) as a reusable drum pattern JSON under the app config dir. Use the name as ableton_setup_drum_track pattern later",
+		func(_ *ai.ToolContext, input SaveDrumPatternInput) (DrumPatternOutput, error) {
+			if err := validateTrackClipIndices(input.TrackIndex, input.ClipIndex); err != nil {
+				return DrumPatternOutput{}, err
+			}
+			path, err := drumPatternPath(drumPatternDir(), input.Name)
+			if err != nil {
+				return DrumPatternOutput{}, err
+			}
+			pattern, err := captureDrumPattern(client, input.TrackIndex, input.ClipIndex, input.Name, input.Description)
+			if err != nil {
+				return DrumPatternOutput{}, err
+			}
+			if err := writeDrumPattern(path, pattern); err != nil {
+				return DrumPatternOutput{}, err
+			}
+			return DrumPatternOutput{
+				Name:        pattern.Name,
+				Path:        path,
+				LengthBeats: pattern.LengthBeats,
+				NumNotes:    len(pattern.Notes),
+			}, nil
+		},
+	)
+}
+
+type ListDrumPatternsOutput struct {
+	Dir     string   `json:"dir"`
+	BuiltIn []string `json:"built_in"`
+	Library []string `json:"library"`
+	Saved   []string `json:"saved"`
+}
+
+func NewAbletonListDrumPatterns(g *genkit.Genkit) ai.Tool {
+	return genkit.DefineTool(g, "ableton_list_drum_patterns",
+		"Ableton Live: list drum patterns available for ableton_setup_drum_track (built-in, genre library, and saved with ableton_save_drum_pattern).",
+		func(_ *ai.ToolContext, _ struct{}) (ListDrumPatternsOutput, error) {
+			dir := drumPatternDir()
+			out := ListDrumPatternsOutput{
+				Dir:     dir,
+				BuiltIn: append([]string{}, builtinDrumPatterns...),
+				Library: libraryDrumPatternNames(),
+				Saved:   []string{},
+			}
+			entries, err := os.ReadDir(dir)
+			if errors.Is(err, os.ErrNotExist) {
+				return out, nil
+			}
+			if err != nil {
+				return ListDrumPatternsOutput{}, fmt.Errorf("read drum pattern dir: %w", err)
+			}
+			for _, e := range entries {
+				if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+					continue
+				}
+				out.Saved = append(out.Saved, strings.TrimSuffix(e.Name(), ".json"))
+			}
+			sort.Strings(out.Saved)
+			return out, nil
+		},
+	)
+}
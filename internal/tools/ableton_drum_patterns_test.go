@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLibraryDrumPatternsDecode(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	names := libraryDrumPatternNames()
+	want := []string{"boom_bap", "dnb_break", "house", "reggaeton", "trap_hats", "uk_garage_2step"}
+	if len(names) != len(want) {
+		t.Fatalf("library = %v, want %v", names, want)
+	}
+	for i, name := range want {
+		if names[i] != name {
+			t.Errorf("library[%d] = %q, want %q", i, names[i], name)
+		}
+		p, err := findDrumPattern(dir, name)
+		if err != nil {
+			t.Fatalf("findDrumPattern(%q) error = %v", name, err)
+		}
+		if p.Name != name || p.LengthBeats <= 0 {
+			t.Errorf("%s: name/length = %q/%v", name, p.Name, p.LengthBeats)
+		}
+		if countPitch(p.Notes, drumPitchKick) == 0 {
+			t.Errorf("%s: expected at least one kick", name)
+		}
+		for _, n := range p.Notes {
+			if n.StartTime < 0 || n.StartTime >= p.LengthBeats || n.Velocity < 1 || n.Velocity > 127 || n.Duration <= 0 {
+				t.Errorf("%s: invalid note %+v", name, n)
+			}
+		}
+	}
+}
+
+func TestBuildDrumPatternLibraryTiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	house, err := findDrumPattern(dir, "house")
+	if err != nil {
+		t.Fatalf("findDrumPattern() error = %v", err)
+	}
+	notes, err := buildDrumPattern(dir, "House", 16)
+	if err != nil {
+		t.Fatalf("buildDrumPattern() error = %v", err)
+	}
+	if len(notes) != 4*len(house.Notes) {
+		t.Errorf("notes = %d, want %d", len(notes), 4*len(house.Notes))
+	}
+	if countPitch(notes, drumPitchKick) != 16 {
+		t.Errorf("kick count = %d, want 16", countPitch(notes, drumPitchKick))
+	}
+
+	// A saved pattern of the same name overrides the library one.
+	saved := DrumPattern{
+		Version:     drumPatternVersion,
+		Name:        "house",
+		LengthBeats: 4,
+		Notes:       []MidiNote{{Pitch: drumPitchKick, StartTime: 0, Duration: 0.25, Velocity: 110}},
+	}
+	if err := writeDrumPattern(filepath.Join(dir, "house.json"), saved); err != nil {
+		t.Fatal(err)
+	}
+	notes, err = buildDrumPattern(dir, "house", 16)
+	if err != nil {
+		t.Fatalf("buildDrumPattern() error = %v", err)
+	}
+	if len(notes) != 4 {
+		t.Errorf("notes with saved override = %d, want 4", len(notes))
+	}
+}
+
+func TestTileDrumPatternTruncates(t *testing.T) {
+	t.Parallel()
+
+	p := DrumPattern{
+		LengthBeats: 4,
+		Notes: []MidiNote{
+			{Pitch: drumPitchKick, StartTime: 0, Duration: 0.25, Velocity: 100},
+			{Pitch: drumPitchSnare, StartTime: 3, Duration: 0.25, Velocity: 100},
+		},
+	}
+	notes := tileDrumPattern(p, 6)
+	if len(notes) != 3 {
+		t.Fatalf("notes = %+v, want 3 notes", notes)
+	}
+	if notes[2].StartTime != 4 || notes[2].Pitch != drumPitchKick {
+		t.Errorf("second loop note = %+v, want kick at 4", notes[2])
+	}
+}
+
+func TestDrumPatternRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "groove.json")
+	in := DrumPattern{
+		Version:     drumPatternVersion,
+		Name:        "groove",
+		LengthBeats: 4,
+		Notes:       []MidiNote{{Pitch: drumPitchKick, StartTime: 0, Duration: 0.25, Velocity: 110}},
+	}
+	if err := writeDrumPattern(path, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := readDrumPattern(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got.Name != "groove" || got.LengthBeats != 4 || len(got.Notes) != 1 {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+	if _, err := readDrumPattern(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+		t.Error("expected error for missing pattern")
+	}
+}
+
+func TestDrumPatternPathSanitize(t *testing.T) {
+	t.Parallel()
+
+	p, err := drumPatternPath(t.TempDir(), "my groove/../x*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if base := filepath.Base(p); base != "my groove_.._x_.json" {
+		t.Errorf("sanitized base = %q", base)
+	}
+	if _, err := drumPatternPath(t.TempDir(), "  "); err == nil {
+		t.Error("empty name should error")
+	}
+}
+
+func TestCaptureDrumPattern(t *testing.T) {
+	t.Parallel()
+
+	client := &recipeClientStub{
+		queries: map[string][]interface{}{
+			"/live/clip/get/notes": {
+				int32(0), int32(1),
+				int32(drumPitchSnare), float32(1), float32(0.25), int32(100), false,
+				int32(drumPitchKick), float32(0), float32(0.25), int32(110), false,
+				int32(drumPitchHat), float32(0.5), float32(0.1), int32(70), true,
+				int32(drumPitchKick), float32(4), float32(0.25), int32(110), false,
+			},
+			"/live/clip/get/length": {int32(0), int32(1), float32(4)},
+		},
+	}
+	p, err := captureDrumPattern(client, 0, 1, " mine ", "")
+	if err != nil {
+		t.Fatalf("captureDrumPattern() error = %v", err)
+	}
+	if p.Name != "mine" || p.LengthBeats != 4 || p.Version != drumPatternVersion {
+		t.Errorf("pattern = %+v", p)
+	}
+	// Muted hat and the note past the loop end are dropped; the rest is time-sorted.
+	if len(p.Notes) != 2 || p.Notes[0].Pitch != drumPitchKick || p.Notes[1].Pitch != drumPitchSnare {
+		t.Errorf("notes = %+v", p.Notes)
+	}
+}
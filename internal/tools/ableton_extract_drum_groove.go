@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+)
+
+const (
+	drumPitchOther        = 39 // D#1, clap on most Drum Rack kits
+	defaultDrumGrooveGrid = 0.25
+	maxDrumGrooveBeats    = 64.0
+)
+
+type ExtractDrumGrooveInput struct {
+	Path             string   `json:"path,omitempty" jsonschema:"description=Absolute local path to the drum loop (.wav, .aiff, .flac, .mp3); omit to use the audio clip at source_track_index/source_clip_index"`
+	SourceTrackIndex *int     `json:"source_track_index,omitempty" jsonschema:"description=Audio clip to read the file path from when path is omitted,minimum=0"`
+	SourceClipIndex  *int     `json:"source_clip_index,omitempty" jsonschema:"minimum=0"`
+	TrackIndex       int      `json:"track_index" jsonschema:"description=MIDI (Drum Rack) track for the extracted clip,minimum=0"`
+	ClipIndex        int      `json:"clip_index" jsonschema:"description=Must be an empty clip slot,minimum=0"`
+	SourceBPM        *float64 `json:"source_bpm,omitempty" jsonschema:"description=Tempo of the loop (default: detected),minimum=20,maximum=400"`
+	DownbeatSec      *float64 `json:"downbeat_sec,omitempty" jsonschema:"description=Where bar 1 starts in the file (default: beat-tracked first downbeat),minimum=0"`
+	Grid             *float64 `json:"grid,omitempty" jsonschema:"description=Quantize grid in beats (0.25 = 1/16 default; 0.5 = 1/8; 0.3333 = 1/8 triplet),minimum=0.0625,maximum=1"`
+	Quantize         bool     `json:"quantize,omitempty" jsonschema:"description=Snap notes onto the grid; by default notes keep the loop's groove timing offsets"`
+	LengthBeats      *float64 `json:"length_beats,omitempty" jsonschema:"description=Clip length (default: whole bars covering the hits; max 64),minimum=1,maximum=64"`
+	IncludeOther     bool     `json:"include_other,omitempty" jsonschema:"description=Also write hits classified as other on pitch 39 (default: skipped)"`
+	Fire             bool     `json:"fire,omitempty" jsonschema:"description=Fire the extracted clip after creating it"`
+}
+
+type DrumGrooveHit struct {
+	Beat        float64 `json:"beat" jsonschema:"description=Detected position in beats from the first downbeat"`
+	GridBeat    float64 `json:"grid_beat"`
+	OffsetBeats float64 `json:"offset_beats" jsonschema:"description=Beat minus grid_beat; positive = late (laid back)"`
+	Class       string  `json:"class"`
+	Pitch       int     `json:"pitch"`
+	Velocity    int     `json:"velocity"`
+}
+
+type ExtractDrumGrooveOutput struct {
+	TrackIndex       int             `json:"track_index"`
+	ClipIndex        int             `json:"clip_index"`
+	SourcePath       string          `json:"source_path"`
+	SourceBPM        float64         `json:"source_bpm"`
+	FirstDownbeatSec float64         `json:"first_downbeat_sec"`
+	Grid             float64         `json:"grid"`
+	Quantized        bool            `json:"quantized"`
+	LengthBeats      float64         `json:"length_beats"`
+	NotesAdded       int             `json:"notes_added"`
+	ClassCounts      map[string]int  `json:"class_counts"`
+	PickupHits       int             `json:"pickup_hits,omitempty" jsonschema:"description=Hits before the first downbeat (not written)"`
+	Hits             []DrumGrooveHit `json:"hits"`
+	Fired            bool            `json:"fired"`
+	Note             string          `json:"note"`
+}
+
+// drumHitExtractor lets tests bypass decoding a real file.
+type drumHitExtractor func(path string, sourceBPM float64, downbeatSec *float64) (audioanalyze.DrumHits, error)
+
+func NewAbletonExtractDrumGroove(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_extract_drum_groove",
+		"Ableton Live: turn a local drum loop (or an audio clip's file) into a MIDI drum clip in an empty slot — onsets are classified as kick/snare/hat/other from band energy and spectral shape and written on Drum Rack pitches 36/38/42 (other = 39), counted from the first downbeat. Notes keep the loop's timing offsets (groove) unless quantize is set.",
+		func(_ *ai.ToolContext, input ExtractDrumGrooveInput) (ExtractDrumGrooveOutput, error) {
+			return extractDrumGroove(client, input, audioanalyze.ExtractDrumHits)
+		},
+	)
+}
+
+func extractDrumGroove(client variationClient, input ExtractDrumGrooveInput, extract drumHitExtractor) (ExtractDrumGrooveOutput, error) {
+	if err := validateTrackClipIndices(input.TrackIndex, input.ClipIndex); err != nil {
+		return ExtractDrumGrooveOutput{}, err
+	}
+	grid := defaultDrumGrooveGrid
+	if input.Grid != nil {
+		grid = *input.Grid
+	}
+	if grid < 0.0625 || grid > 1 {
+		return ExtractDrumGrooveOutput{}, errors.New("grid must be between 0.0625 and 1")
+	}
+	sourceBPM := 0.0
+	if input.SourceBPM != nil {
+		sourceBPM = *input.SourceBPM
+		if sourceBPM < 20 || sourceBPM > 400 {
+			return ExtractDrumGrooveOutput{}, errors.New("source_bpm must be between 20 and 400")
+		}
+	}
+	if input.DownbeatSec != nil && *input.DownbeatSec < 0 {
+		return ExtractDrumGrooveOutput{}, errors.New("downbeat_sec must be >= 0")
+	}
+	length := 0.0
+	if input.LengthBeats != nil {
+		length = *input.LengthBeats
+		if length < 1 || length > maxDrumGrooveBeats {
+			return ExtractDrumGrooveOutput{}, fmt.Errorf("length_beats must be between 1 and %.0f", maxDrumGrooveBeats)
+		}
+	}
+
+	path := strings.TrimSpace(input.Path)
+	if path == "" {
+		if input.SourceTrackIndex == nil || input.SourceClipIndex == nil {
+			return ExtractDrumGrooveOutput{}, errors.New("pass path, or source_track_index and source_clip_index of an audio clip")
+		}
+		if err := validateTrackClipIndices(*input.SourceTrackIndex, *input.SourceClipIndex); err != nil {
+			return ExtractDrumGrooveOutput{}, err
+		}
+		p, err := queryClipFilePath(client, *input.SourceTrackIndex, *input.SourceClipIndex)
+		if err != nil {
+			return ExtractDrumGrooveOutput{}, wrapActionable(err, "no_source_file", "pass path to the drum loop on disk")
+		}
+		path = p
+	}
+
+	hasClip, err := queryClipHasClip(client, input.TrackIndex, input.ClipIndex)
+	if err != nil {
+		return ExtractDrumGrooveOutput{}, fmt.Errorf("check target slot: %w", err)
+	}
+	if hasClip {
+		return ExtractDrumGrooveOutput{}, errors.New("clip_index must be an empty slot to preserve A/B comparison")
+	}
+
+	drums, err := extract(path, sourceBPM, input.DownbeatSec)
+	if err != nil {
+		return ExtractDrumGrooveOutput{}, wrapActionable(fmt.Errorf("extract drum hits from %s: %w", path, err), "drum_extraction_failed",
+			"check the file is a drum loop; pass source_bpm and downbeat_sec if the tempo or bar 1 was misdetected")
+	}
+	if length == 0 {
+		length = drumGrooveLength(drums.Hits, grid)
+	}
+	notes, hits := drumGrooveNotes(drums.Hits, grid, length, input.Quantize, input.IncludeOther)
+	if len(notes) == 0 {
+		return ExtractDrumGrooveOutput{}, errors.New("no kick, snare or hat hits inside the clip length (try include_other)")
+	}
+
+	if err := client.Send("/live/clip_slot/create_clip",
+		int32(input.TrackIndex), int32(input.ClipIndex), float32(length),
+	); err != nil {
+		return ExtractDrumGrooveOutput{}, fmt.Errorf("create clip: %w", err)
+	}
+	created, err := queryClipHasClip(client, input.TrackIndex, input.ClipIndex)
+	if err != nil {
+		return ExtractDrumGrooveOutput{}, fmt.Errorf("verify clip: %w", err)
+	}
+	if !created {
+		return ExtractDrumGrooveOutput{}, errors.New("clip was not created (is the target track a MIDI track?)")
+	}
+	if err := client.Send("/live/clip/add/notes", addNotesArgs(input.TrackIndex, input.ClipIndex, notes)...); err != nil {
+		return ExtractDrumGrooveOutput{}, fmt.Errorf("add drum notes: %w", err)
+	}
+	name := "Drums: " + strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if err := client.Send("/live/clip/set/name", int32(input.TrackIndex), int32(input.ClipIndex), name); err != nil {
+		return ExtractDrumGrooveOutput{}, fmt.Errorf("set drum clip name: %w", err)
+	}
+
+	fired := false
+	if input.Fire {
+		if err := client.Send("/live/clip_slot/fire", int32(input.TrackIndex), int32(input.ClipIndex)); err != nil {
+			return ExtractDrumGrooveOutput{}, fmt.Errorf("fire drum clip: %w", err)
+		}
+		fired = true
+	}
+
+	counts := map[string]int{}
+	for _, h := range hits {
+		counts[h.Class]++
+	}
+	sourcePath := drums.Path
+	if sourcePath == "" {
+		sourcePath = path
+	}
+	return ExtractDrumGrooveOutput{
+		TrackIndex:       input.TrackIndex,
+		ClipIndex:        input.ClipIndex,
+		SourcePath:       sourcePath,
+		SourceBPM:        drums.BPM,
+		FirstDownbeatSec: drums.FirstDownbeatSec,
+		Grid:             grid,
+		Quantized:        input.Quantize,
+		LengthBeats:      length,
+		NotesAdded:       len(notes),
+		ClassCounts:      counts,
+		PickupHits:       drums.PickupHits,
+		Hits:             hits,
+		Fired:            fired,
+		Note:             "Beats are counted at the loop's own tempo, so the clip plays the groove at the project tempo. Check the classes against your kit: swap lanes in the Drum Rack or re-run with include_other for percussion.",
+	}, nil
+}
+
+// drumGrooveLength is the whole number of bars covering every hit's grid
+// position, capped at maxDrumGrooveBeats.
+func drumGrooveLength(hits []audioanalyze.Onset, grid float64) float64 {
+	last := 0.0
+	for _, h := range hits {
+		last = math.Max(last, snapToGrid(h.Beat, grid))
+	}
+	bars := math.Floor(last/4) + 1
+	return math.Min(bars*4, maxDrumGrooveBeats)
+}
+
+func snapToGrid(beat, grid float64) float64 {
+	return math.Round(math.Round(beat/grid)*grid*1000) / 1000
+}
+
+// drumGrooveNotes maps classified hits onto Drum Rack pitches. Each hit is
+// assigned a grid step; with quantize the note lands on it, otherwise it keeps
+// its detected position so the loop's push and drag survive. Two hits of the
+// same class on one step keep the stronger.
+func drumGrooveNotes(hits []audioanalyze.Onset, grid, length float64, quantize, includeOther bool) ([]MidiNote, []DrumGrooveHit) {
+	type key struct {
+		pitch int
+		step  float64
+	}
+	best := map[key]int{}
+	var out []DrumGrooveHit
+	for _, h := range hits {
+		pitch, _, ok := drumLane(h.Class, includeOther)
+		if !ok {
+			continue
+		}
+		gridBeat := snapToGrid(h.Beat, grid)
+		if gridBeat < 0 || gridBeat >= length {
+			continue
+		}
+		hit := DrumGrooveHit{
+			Beat:        h.Beat,
+			GridBeat:    gridBeat,
+			OffsetBeats: math.Round((h.Beat-gridBeat)*1000) / 1000,
+			Class:       h.Class,
+			Pitch:       pitch,
+			Velocity:    clampVelocity(int(math.Round(30 + h.Strength*97))),
+		}
+		k := key{pitch, gridBeat}
+		if i, seen := best[k]; seen {
+			if hit.Velocity > out[i].Velocity {
+				out[i] = hit
+			}
+			continue
+		}
+		best[k] = len(out)
+		out = append(out, hit)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].GridBeat < out[j].GridBeat })
+
+	notes := make([]MidiNote, 0, len(out))
+	for _, h := range out {
+		_, duration, _ := drumLane(h.Class, includeOther)
+		start := h.GridBeat
+		if !quantize {
+			start = math.Max(0, h.Beat)
+		}
+		notes = append(notes, MidiNote{Pitch: h.Pitch, StartTime: start, Duration: duration, Velocity: h.Velocity})
+	}
+	return notes, out
+}
+
+// drumLane returns the pitch and note length for a hit class, matching the
+// lanes buildDrumPattern writes.
+func drumLane(class string, includeOther bool) (int, float64, bool) {
+	switch class {
+	case audioanalyze.DrumKick:
+		return drumPitchKick, 0.25, true
+	case audioanalyze.DrumSnare:
+		return drumPitchSnare, 0.25, true
+	case audioanalyze.DrumHat:
+		return drumPitchHat, 0.125, true
+	default:
+		return drumPitchOther, 0.25, includeOther
+	}
+}
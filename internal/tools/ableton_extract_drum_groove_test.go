@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+)
+
+func grooveHits() []audioanalyze.Onset {
+	return []audioanalyze.Onset{
+		{Beat: 0.01, Strength: 1, Class: audioanalyze.DrumKick},
+		{Beat: 0.55, Strength: 0.3, Class: audioanalyze.DrumHat},
+		{Beat: 0.98, Strength: 0.7, Class: audioanalyze.DrumSnare},
+		{Beat: 1.03, Strength: 0.9, Class: audioanalyze.DrumSnare}, // flam: same step, stronger wins
+		{Beat: 1.5, Strength: 0.4, Class: audioanalyze.DrumOther},
+		{Beat: 5.02, Strength: 0.8, Class: audioanalyze.DrumKick},
+	}
+}
+
+func TestDrumGrooveNotesKeepsOffsets(t *testing.T) {
+	t.Parallel()
+
+	notes, hits := drumGrooveNotes(grooveHits(), 0.25, 8, false, false)
+	if len(notes) != 4 || len(hits) != 4 {
+		t.Fatalf("notes = %+v", notes)
+	}
+	want := []struct {
+		pitch int
+		start float64
+	}{{drumPitchKick, 0.01}, {drumPitchHat, 0.55}, {drumPitchSnare, 1.03}, {drumPitchKick, 5.02}}
+	for i, w := range want {
+		if notes[i].Pitch != w.pitch || notes[i].StartTime != w.start {
+			t.Errorf("note %d = %+v, want pitch %d at %v", i, notes[i], w.pitch, w.start)
+		}
+	}
+	if hits[1].GridBeat != 0.5 || hits[1].OffsetBeats != 0.05 || hits[2].Velocity != 117 {
+		t.Errorf("hits = %+v", hits)
+	}
+
+	quantized, _ := drumGrooveNotes(grooveHits(), 0.25, 4, true, true)
+	if len(quantized) != 4 || quantized[2].StartTime != 1 || quantized[3].Pitch != drumPitchOther || quantized[3].StartTime != 1.5 {
+		t.Errorf("quantized = %+v", quantized)
+	}
+}
+
+func TestDrumGrooveLengthWholeBars(t *testing.T) {
+	t.Parallel()
+
+	if got := drumGrooveLength(grooveHits(), 0.25); got != 8 {
+		t.Errorf("length = %v, want 8", got)
+	}
+	if got := drumGrooveLength([]audioanalyze.Onset{{Beat: 200}}, 0.25); got != maxDrumGrooveBeats {
+		t.Errorf("length = %v, want cap %v", got, maxDrumGrooveBeats)
+	}
+}
+
+func TestExtractDrumGrooveFromClipFile(t *testing.T) {
+	t.Parallel()
+
+	client := &recipeClientStub{
+		queries: map[string][]interface{}{
+			"/live/clip_slot/get/has_clip": {int32(1), int32(2), int32(0)},
+			"/live/clip/get/file_path":     {int32(0), int32(0), "/loops/break.wav"},
+		},
+	}
+	var gotPath string
+	extract := func(path string, bpm float64, downbeat *float64) (audioanalyze.DrumHits, error) {
+		gotPath = path
+		return audioanalyze.DrumHits{BPM: 96, FirstDownbeatSec: 0.12, Hits: grooveHits()}, nil
+	}
+	src, clip := 0, 0
+	out, err := extractDrumGroove(&hasClipToggleStub{recipeClientStub: client}, ExtractDrumGrooveInput{
+		SourceTrackIndex: &src,
+		SourceClipIndex:  &clip,
+		TrackIndex:       1,
+		ClipIndex:        2,
+	}, extract)
+	if err != nil {
+		t.Fatalf("extractDrumGroove() error = %v", err)
+	}
+	if gotPath != "/loops/break.wav" || out.SourcePath != "/loops/break.wav" {
+		t.Errorf("path = %q / %q", gotPath, out.SourcePath)
+	}
+	if out.NotesAdded != 4 || out.LengthBeats != 8 || out.SourceBPM != 96 || out.ClassCounts["kick"] != 2 {
+		t.Errorf("out = %+v", out)
+	}
+	assertSent(t, client, "/live/clip/add/notes")
+	for _, c := range client.calls {
+		if c.address == "/live/clip/set/name" && c.args[2] != "Drums: break" {
+			t.Errorf("clip name = %v", c.args[2])
+		}
+	}
+}
+
+func TestExtractDrumGrooveValidation(t *testing.T) {
+	t.Parallel()
+
+	noExtract := func(string, float64, *float64) (audioanalyze.DrumHits, error) {
+		t.Fatal("extract should not be called")
+		return audioanalyze.DrumHits{}, nil
+	}
+	if _, err := extractDrumGroove(&recipeClientStub{}, ExtractDrumGrooveInput{TrackIndex: 1}, noExtract); err == nil || !strings.Contains(err.Error(), "pass path") {
+		t.Errorf("error = %v, want missing source error", err)
+	}
+	grid := 3.0
+	if _, err := extractDrumGroove(&recipeClientStub{}, ExtractDrumGrooveInput{Path: "/a.wav", Grid: &grid}, noExtract); err == nil || !strings.Contains(err.Error(), "grid") {
+		t.Errorf("error = %v, want grid error", err)
+	}
+	client := &recipeClientStub{queries: map[string][]interface{}{
+		"/live/clip_slot/get/has_clip": {int32(0), int32(0), int32(1)},
+	}}
+	if _, err := extractDrumGroove(client, ExtractDrumGrooveInput{Path: "/a.wav"}, noExtract); err == nil || !strings.Contains(err.Error(), "empty slot") {
+		t.Errorf("error = %v, want empty slot error", err)
+	}
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/splice"
+)
+
+const (
+	defaultSimilarResults    = 10
+	maxSimilarResults        = 50
+	defaultSimilarCandidates = 200
+	maxSimilarCandidates     = 2000
+)
+
+type FindSimilarSamplesInput struct {
+	ReferencePath    string   `json:"reference_path,omitempty" jsonschema:"description=Absolute local path of the sound to match (.wav, .aiff, .flac, .mp3); omit to use the audio clip at source_track_index/source_clip_index"`
+	SourceTrackIndex *int     `json:"source_track_index,omitempty" jsonschema:"description=Audio clip to read the reference file path from when reference_path is omitted,minimum=0"`
+	SourceClipIndex  *int     `json:"source_clip_index,omitempty" jsonschema:"minimum=0"`
+	Library          string   `json:"library,omitempty" jsonschema:"description=Compare only against this library (splice or a name from the libraries file); default uses all"`
+	Query            string   `json:"query,omitempty" jsonschema:"description=Optional words that narrow the candidates by file and folder name (e.g. kick)"`
+	Kind             string   `json:"kind,omitempty" jsonschema:"description=loop or one_shot"`
+	Tags             []string `json:"tags,omitempty" jsonschema:"description=Instrument tags candidates must carry (kick, snare, bass...)"`
+	MaxCandidates    int      `json:"max_candidates,omitempty" jsonschema:"description=Library files to analyze after name filters (default 200; analyses are cached),minimum=1,maximum=2000"`
+	Workers          int      `json:"workers,omitempty" jsonschema:"description=Concurrent analyses (default min(CPUs, 4)),minimum=1,maximum=8"`
+	MaxResults       int      `json:"max_results,omitempty" jsonschema:"description=Neighbours to return (default 10, max 50),minimum=1,maximum=50"`
+}
+
+// SimilarSample is a library sample with its distance from the reference.
+type SimilarSample struct {
+	splice.Sample
+	Distance   float64               `json:"distance" jsonschema:"description=Weighted feature distance; 0 is identical"`
+	Similarity float64               `json:"similarity" jsonschema:"description=0..1, exp(-distance)"`
+	Features   audioanalyze.Features `json:"features"`
+}
+
+type FindSimilarSamplesOutput struct {
+	ReferencePath     string                `json:"reference_path"`
+	ReferenceFeatures audioanalyze.Features `json:"reference_features"`
+	Candidates        int                   `json:"candidates" jsonschema:"description=Library files that passed the name filters"`
+	Analyzed          int                   `json:"analyzed"`
+	Cached            int                   `json:"cached"`
+	Failed            int                   `json:"failed" jsonschema:"description=Candidates that could not be decoded (e.g. OGG)"`
+	Truncated         bool                  `json:"truncated,omitempty" jsonschema:"description=More candidates matched than max_candidates; narrow with query, kind or tags"`
+	Results           []SimilarSample       `json:"results"`
+	Warnings          []string              `json:"warnings,omitempty"`
+	Note              string                `json:"note"`
+}
+
+func NewAbletonFindSimilarSamples(g *genkit.Genkit, client *abletonosc.Client, settings SpliceLibrarySettings, cache *audioanalyze.Cache) ai.Tool {
+	return genkit.DefineTool(g, "ableton_find_similar_samples",
+		"Find library samples that sound like a reference file or audio clip (\"a kick like this one\"): compares brightness, crest factor, low/mid/high band balance, duration and onset density from local audio analysis across the indexed libraries and returns the nearest neighbours. Fully local and deterministic; analyses are cached. Narrow large libraries with query, kind or tags.",
+		func(_ *ai.ToolContext, input FindSimilarSamplesInput) (FindSimilarSamplesOutput, error) {
+			return findSimilarSamples(client, settings, cache, input)
+		},
+	)
+}
+
+func findSimilarSamples(client matchTempoClient, settings SpliceLibrarySettings, cache *audioanalyze.Cache, input FindSimilarSamplesInput) (FindSimilarSamplesOutput, error) {
+	maxResults := input.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultSimilarResults
+	}
+	if maxResults > maxSimilarResults {
+		return FindSimilarSamplesOutput{}, fmt.Errorf("max_results must be <= %d", maxSimilarResults)
+	}
+	maxCandidates := input.MaxCandidates
+	if maxCandidates <= 0 {
+		maxCandidates = defaultSimilarCandidates
+	}
+	if maxCandidates > maxSimilarCandidates {
+		return FindSimilarSamplesOutput{}, fmt.Errorf("max_candidates must be <= %d", maxSimilarCandidates)
+	}
+
+	refPath := strings.TrimSpace(input.ReferencePath)
+	if refPath == "" {
+		if input.SourceTrackIndex == nil || input.SourceClipIndex == nil {
+			return FindSimilarSamplesOutput{}, errors.New("pass reference_path, or source_track_index and source_clip_index of an audio clip")
+		}
+		if err := validateTrackClipIndices(*input.SourceTrackIndex, *input.SourceClipIndex); err != nil {
+			return FindSimilarSamplesOutput{}, err
+		}
+		p, err := queryClipFilePath(client, *input.SourceTrackIndex, *input.SourceClipIndex)
+		if err != nil {
+			return FindSimilarSamplesOutput{}, wrapActionable(err, "no_source_file", "pass reference_path to the sound on disk")
+		}
+		refPath = p
+	}
+	ref := audioanalyze.ExtractFeatures([]string{refPath}, 1, cache)[0]
+	if ref.Err != nil {
+		return FindSimilarSamplesOutput{}, fmt.Errorf("analyze reference %s: %w", refPath, ref.Err)
+	}
+	refPath = filepath.Clean(refPath)
+
+	catalog, _, warnings, err := loadCatalog(settings, input.Library, false)
+	if err != nil {
+		return FindSimilarSamplesOutput{}, err
+	}
+	matches, err := catalog.Filter(splice.Query{Text: input.Query, Kind: input.Kind, Tags: input.Tags})
+	if err != nil {
+		return FindSimilarSamplesOutput{}, err
+	}
+	candidates := make([]splice.Sample, 0, len(matches))
+	for _, s := range matches {
+		if filepath.Clean(s.AbsolutePath) != refPath {
+			candidates = append(candidates, s)
+		}
+	}
+	out := FindSimilarSamplesOutput{
+		ReferencePath:     refPath,
+		ReferenceFeatures: ref.Features,
+		Candidates:        len(candidates),
+		Warnings:          warnings,
+	}
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+		out.Truncated = true
+	}
+
+	paths := make([]string, len(candidates))
+	for i, s := range candidates {
+		paths[i] = s.AbsolutePath
+	}
+	results := make([]SimilarSample, 0, len(candidates))
+	for i, r := range audioanalyze.ExtractFeatures(paths, input.Workers, cache) {
+		if r.Err != nil {
+			out.Failed++
+			continue
+		}
+		out.Analyzed++
+		if r.Cached {
+			out.Cached++
+		}
+		d := audioanalyze.FeatureDistance(ref.Features, r.Features)
+		results = append(results, SimilarSample{
+			Sample:     candidates[i],
+			Distance:   round4(d),
+			Similarity: audioanalyze.Similarity(d),
+			Features:   r.Features,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.Library != b.Library {
+			return a.Library < b.Library
+		}
+		return a.RelativePath < b.RelativePath
+	})
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	out.Results = results
+	out.Note = "Nearest by timbre and rhythm, not by name. Candidates are name-filtered first and the first max_candidates (best name match, then library and path) are analyzed. Load one with ableton_load_splice_sample."
+	return out, nil
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+)
+
+// writeSoundWAV writes a 16-bit mono WAV at 44.1 kHz: short clicks every
+// clickEvery seconds when clickEvery > 0, otherwise a sustained sine at hz.
+func writeSoundWAV(t *testing.T, path string, seconds, clickEvery, hz float64) {
+	t.Helper()
+	const rate = 44100
+	n := int(seconds * rate)
+	pcm := make([]int16, n)
+	for i := range pcm {
+		sec := float64(i) / rate
+		if clickEvery > 0 {
+			if math.Mod(sec, clickEvery) < 0.005 {
+				pcm[i] = 20000
+			}
+			continue
+		}
+		pcm[i] = int16(12000 * math.Sin(2*math.Pi*hz*sec))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 0, 44+2*n)
+	le := binary.LittleEndian
+	buf = append(buf, "RIFF"...)
+	buf = le.AppendUint32(buf, uint32(36+2*n))
+	buf = append(buf, "WAVEfmt "...)
+	buf = le.AppendUint32(buf, 16)
+	buf = le.AppendUint16(buf, 1)
+	buf = le.AppendUint16(buf, 1)
+	buf = le.AppendUint32(buf, rate)
+	buf = le.AppendUint32(buf, rate*2)
+	buf = le.AppendUint16(buf, 2)
+	buf = le.AppendUint16(buf, 16)
+	buf = append(buf, "data"...)
+	buf = le.AppendUint32(buf, uint32(2*n))
+	for _, v := range pcm {
+		buf = le.AppendUint16(buf, uint16(v))
+	}
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindSimilarSamples(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	ref := filepath.Join(root, "Drums", "ref_clicks.wav")
+	writeSoundWAV(t, ref, 4, 0.5, 0)
+	writeSoundWAV(t, filepath.Join(root, "Drums", "clicks_fast.wav"), 4, 0.48, 0)
+	writeSoundWAV(t, filepath.Join(root, "Bass", "sub_drone.wav"), 4, 0, 55)
+	writeSoundWAV(t, filepath.Join(root, "Synth", "bright_drone.wav"), 4, 0, 3000)
+	if err := os.WriteFile(filepath.Join(root, "Synth", "broken.wav"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := audioanalyze.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := SpliceLibrarySettings{ConfiguredPath: root}
+
+	got, err := findSimilarSamples(nil, settings, cache, FindSimilarSamplesInput{ReferencePath: ref})
+	if err != nil {
+		t.Fatalf("findSimilarSamples() error = %v", err)
+	}
+	if got.Candidates != 4 || got.Analyzed != 3 || got.Failed != 1 {
+		t.Errorf("counts = candidates %d analyzed %d failed %d", got.Candidates, got.Analyzed, got.Failed)
+	}
+	if len(got.Results) != 3 || got.Results[0].Name != "clicks_fast.wav" {
+		t.Fatalf("results = %+v", got.Results)
+	}
+	if got.Results[0].Library != "splice" || got.Results[0].Similarity <= got.Results[1].Similarity {
+		t.Errorf("nearest = %+v", got.Results[0])
+	}
+
+	// Same answer from the clip's file path, served from the cache.
+	track, clip := 1, 0
+	client := &recipeClientStub{queries: map[string][]interface{}{
+		"/live/clip/get/file_path": {int32(1), int32(0), ref},
+	}}
+	again, err := findSimilarSamples(client, settings, cache, FindSimilarSamplesInput{SourceTrackIndex: &track, SourceClipIndex: &clip, MaxResults: 1})
+	if err != nil {
+		t.Fatalf("findSimilarSamples(clip) error = %v", err)
+	}
+	if len(again.Results) != 1 || again.Results[0].Distance != got.Results[0].Distance || again.Cached != 3 {
+		t.Errorf("clip reference = %+v", again)
+	}
+
+	if _, err := findSimilarSamples(nil, settings, cache, FindSimilarSamplesInput{}); err == nil {
+		t.Error("expected an error without a reference")
+	}
+}
@@ -0,0 +1,440 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+)
+
+const (
+	grooveVersion       = 1
+	grooveStepBeats     = 0.25 // groove templates are per 16th
+	grooveStepsPerBar   = 16
+	defaultGrooveBars   = 1
+	maxGrooveBars       = 4
+	defaultGrooveAmount = 1.0
+)
+
+// Groove is a per-16th feel template: how early/late each step lands and how
+// hard it is played relative to the loudest step. Steps with Counts of 0 had
+// no hits in the source and leave notes untouched.
+type Groove struct {
+	Version       int       `json:"version"`
+	Name          string    `json:"name"`
+	Source        string    `json:"source"`
+	SourceBPM     float64   `json:"source_bpm,omitempty"`
+	Bars          int       `json:"bars"`
+	StepBeats     float64   `json:"step_beats"`
+	TimingOffsets []float64 `json:"timing_offsets" jsonschema:"description=Beats per step; positive = late"`
+	Velocities    []float64 `json:"velocities" jsonschema:"description=0..1 per step relative to the loudest step"`
+	Counts        []int     `json:"counts" jsonschema:"description=Hits averaged into each step"`
+	SavedAt       time.Time `json:"saved_at"`
+}
+
+var grooveNameSanitize = regexp.MustCompile(`[^a-zA-Z0-9_.\- ]+`)
+
+func grooveDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ableton-osc-mcp", "grooves")
+}
+
+func groovePath(name string) (string, error) {
+	clean := strings.TrimSpace(name)
+	if clean == "" {
+		return "", errors.New("groove name is required")
+	}
+	clean = grooveNameSanitize.ReplaceAllString(clean, "_")
+	return filepath.Join(grooveDir(), clean+".json"), nil
+}
+
+func writeGroove(path string, groove Groove) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create groove dir: %w", err)
+	}
+	data, err := json.MarshalIndent(groove, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode groove: %w", err)
+	}
+	data = append(data, '\n')
+	return os.WriteFile(path, data, 0o600)
+}
+
+func readGroove(path string) (Groove, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Groove{}, fmt.Errorf("groove not found: %s", strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	if err != nil {
+		return Groove{}, fmt.Errorf("read groove: %w", err)
+	}
+	var g Groove
+	if err := json.Unmarshal(data, &g); err != nil {
+		return Groove{}, fmt.Errorf("parse groove: %w", err)
+	}
+	if g.Version != grooveVersion {
+		return Groove{}, fmt.Errorf("unsupported groove version: %d", g.Version)
+	}
+	steps := g.Bars * grooveStepsPerBar
+	if steps <= 0 || len(g.TimingOffsets) != steps || len(g.Velocities) != steps || len(g.Counts) != steps {
+		return Groove{}, errors.New("groove has inconsistent step data")
+	}
+	return g, nil
+}
+
+// grooveHit is one source event: position in beats from bar 1 and a 0..1
+// strength (onset strength or velocity/127).
+type grooveHit struct {
+	Beat     float64
+	Strength float64
+}
+
+// buildGroove folds hits onto bars×16 steps and averages each step's timing
+// offset from the 16th grid and its strength.
+func buildGroove(hits []grooveHit, bars int) Groove {
+	steps := bars * grooveStepsPerBar
+	offsets := make([]float64, steps)
+	strengths := make([]float64, steps)
+	counts := make([]int, steps)
+	for _, h := range hits {
+		if h.Beat < -grooveStepBeats/2 {
+			continue
+		}
+		step := int(math.Round(h.Beat / grooveStepBeats))
+		idx := ((step % steps) + steps) % steps
+		offsets[idx] += h.Beat - float64(step)*grooveStepBeats
+		strengths[idx] += h.Strength
+		counts[idx]++
+	}
+	loudest := 0.0
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		offsets[i] = math.Round(offsets[i]/float64(n)*1000) / 1000
+		strengths[i] /= float64(n)
+		loudest = math.Max(loudest, strengths[i])
+	}
+	if loudest > 0 {
+		for i := range strengths {
+			strengths[i] = math.Round(strengths[i]/loudest*100) / 100
+		}
+	}
+	return Groove{
+		Version:       grooveVersion,
+		Bars:          bars,
+		StepBeats:     grooveStepBeats,
+		TimingOffsets: offsets,
+		Velocities:    strengths,
+		Counts:        counts,
+	}
+}
+
+// applyGroove moves each note toward the template step nearest its start.
+// timingAmount scales the offset; velocityAmount blends each velocity toward
+// the step's level relative to the template average, so accents follow the
+// source without changing the clip's overall dynamics.
+func applyGroove(notes []MidiNote, g Groove, timingAmount, velocityAmount, clipLength float64) ([]MidiNote, int) {
+	steps := g.Bars * grooveStepsPerBar
+	var levelSum float64
+	levelCount := 0
+	for i, n := range g.Counts {
+		if n > 0 {
+			levelSum += g.Velocities[i]
+			levelCount++
+		}
+	}
+	avgLevel := 0.0
+	if levelCount > 0 {
+		avgLevel = levelSum / float64(levelCount)
+	}
+
+	out := make([]MidiNote, 0, len(notes))
+	moved := 0
+	for _, n := range notes {
+		note := n
+		if n.Mute != nil {
+			m := *n.Mute
+			note.Mute = &m
+		}
+		step := int(math.Round(n.StartTime / grooveStepBeats))
+		idx := ((step % steps) + steps) % steps
+		if g.Counts[idx] > 0 {
+			start := n.StartTime + g.TimingOffsets[idx]*timingAmount
+			start = math.Max(0, start)
+			if clipLength > 0 && start >= clipLength {
+				start = n.StartTime
+			}
+			note.StartTime = math.Round(start*10000) / 10000
+			if avgLevel > 0 {
+				factor := g.Velocities[idx] / avgLevel
+				scaled := float64(n.Velocity) * (1 + velocityAmount*(factor-1))
+				note.Velocity = clampVelocity(int(math.Round(scaled)))
+			}
+			if note.StartTime != n.StartTime || note.Velocity != n.Velocity {
+				moved++
+			}
+		}
+		out = append(out, note)
+	}
+	return out, moved
+}
+
+type ExtractGrooveInput struct {
+	Name        string   `json:"name" jsonschema:"description=Groove name (stored as JSON under the app config dir)"`
+	TrackIndex  *int     `json:"track_index,omitempty" jsonschema:"description=Source clip track (audio or MIDI); omit with path,minimum=0"`
+	ClipIndex   *int     `json:"clip_index,omitempty" jsonschema:"minimum=0"`
+	Path        string   `json:"path,omitempty" jsonschema:"description=Absolute local audio file to read onsets from instead of a clip"`
+	Bars        *int     `json:"bars,omitempty" jsonschema:"description=Template length in bars; longer sources are folded onto it (default 1),minimum=1,maximum=4"`
+	SourceBPM   *float64 `json:"source_bpm,omitempty" jsonschema:"description=Audio sources only: tempo of the loop (default: detected),minimum=20,maximum=400"`
+	DownbeatSec *float64 `json:"downbeat_sec,omitempty" jsonschema:"description=Audio sources only: where bar 1 starts (default: beat-tracked first downbeat),minimum=0"`
+}
+
+type ExtractGrooveOutput struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Source  string `json:"source"`
+	Hits    int    `json:"hits"`
+	Groove  Groove `json:"groove"`
+	Message string `json:"message"`
+}
+
+func NewAbletonExtractGroove(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_extract_groove",
+		"Ableton Live: capture a real feel as a named groove template — per-16th timing offsets and velocity profile from an audio clip's (or local file's) onsets or from a MIDI clip's notes. Saved as JSON under the app config dir for ableton_apply_groove.",
+		func(_ *ai.ToolContext, input ExtractGrooveInput) (ExtractGrooveOutput, error) {
+			return extractGroove(client, input, audioanalyze.ExtractDrumHits)
+		},
+	)
+}
+
+func extractGroove(client variationClient, input ExtractGrooveInput, extract drumHitExtractor) (ExtractGrooveOutput, error) {
+	path, err := groovePath(input.Name)
+	if err != nil {
+		return ExtractGrooveOutput{}, err
+	}
+	bars := defaultGrooveBars
+	if input.Bars != nil {
+		bars = *input.Bars
+	}
+	if bars < 1 || bars > maxGrooveBars {
+		return ExtractGrooveOutput{}, fmt.Errorf("bars must be between 1 and %d", maxGrooveBars)
+	}
+	sourceBPM := 0.0
+	if input.SourceBPM != nil {
+		sourceBPM = *input.SourceBPM
+		if sourceBPM < 20 || sourceBPM > 400 {
+			return ExtractGrooveOutput{}, errors.New("source_bpm must be between 20 and 400")
+		}
+	}
+
+	var hits []grooveHit
+	var source string
+	audioPath := strings.TrimSpace(input.Path)
+	if audioPath == "" {
+		if input.TrackIndex == nil || input.ClipIndex == nil {
+			return ExtractGrooveOutput{}, errors.New("pass track_index and clip_index of a clip, or path to a local audio file")
+		}
+		track, clip := *input.TrackIndex, *input.ClipIndex
+		if err := validateTrackClipIndices(track, clip); err != nil {
+			return ExtractGrooveOutput{}, err
+		}
+		isAudio, err := queryClipIsAudio(client, track, clip)
+		if err != nil {
+			return ExtractGrooveOutput{}, err
+		}
+		if isAudio {
+			p, err := queryClipFilePath(client, track, clip)
+			if err != nil {
+				return ExtractGrooveOutput{}, wrapActionable(err, "no_source_file", "pass path to the audio file on disk")
+			}
+			audioPath = p
+		} else {
+			res, err := client.Query("/live/clip/get/notes", int32(track), int32(clip))
+			if err != nil {
+				return ExtractGrooveOutput{}, fmt.Errorf("get notes: %w", err)
+			}
+			_, _, notes, err := parseClipNotesResponse(res)
+			if err != nil {
+				return ExtractGrooveOutput{}, fmt.Errorf("get notes: %w", err)
+			}
+			for _, n := range notes {
+				if n.Mute != nil && *n.Mute {
+					continue
+				}
+				hits = append(hits, grooveHit{Beat: n.StartTime, Strength: float64(n.Velocity) / 127})
+			}
+			source = fmt.Sprintf("midi clip %d/%d", track, clip)
+		}
+	}
+	if audioPath != "" {
+		drums, err := extract(audioPath, sourceBPM, input.DownbeatSec)
+		if err != nil {
+			return ExtractGrooveOutput{}, wrapActionable(fmt.Errorf("detect onsets in %s: %w", audioPath, err), "onset_detection_failed",
+				"pass source_bpm and downbeat_sec if the tempo or bar 1 was misdetected, or extract from a MIDI clip")
+		}
+		for _, h := range drums.Hits {
+			hits = append(hits, grooveHit{Beat: h.Beat, Strength: h.Strength})
+		}
+		sourceBPM = drums.BPM
+		source = audioPath
+		if drums.Path != "" {
+			source = drums.Path
+		}
+	}
+	if len(hits) == 0 {
+		return ExtractGrooveOutput{}, errors.New("source has no notes or onsets to extract a groove from")
+	}
+
+	groove := buildGroove(hits, bars)
+	groove.Name = strings.TrimSpace(input.Name)
+	groove.Source = source
+	groove.SourceBPM = sourceBPM
+	groove.SavedAt = time.Now().UTC()
+	if err := writeGroove(path, groove); err != nil {
+		return ExtractGrooveOutput{}, err
+	}
+	return ExtractGrooveOutput{
+		Name:    groove.Name,
+		Path:    path,
+		Source:  source,
+		Hits:    len(hits),
+		Groove:  groove,
+		Message: "Apply with ableton_apply_groove; start around amount 0.5 and raise it until the target locks in with the source.",
+	}, nil
+}
+
+type ApplyGrooveInput struct {
+	TrackIndex     int      `json:"track_index" jsonschema:"minimum=0"`
+	ClipIndex      int      `json:"clip_index" jsonschema:"minimum=0"`
+	Name           string   `json:"name" jsonschema:"description=Groove name from ableton_list_grooves"`
+	Amount         *float64 `json:"amount,omitempty" jsonschema:"description=How much of the groove's timing to impose 0-1 (default 1),minimum=0,maximum=1"`
+	VelocityAmount *float64 `json:"velocity_amount,omitempty" jsonschema:"description=How much of the groove's accents to impose 0-1 (default: amount),minimum=0,maximum=1"`
+}
+
+type ApplyGrooveOutput struct {
+	TrackIndex     int     `json:"track_index"`
+	ClipIndex      int     `json:"clip_index"`
+	Name           string  `json:"name"`
+	Amount         float64 `json:"amount"`
+	VelocityAmount float64 `json:"velocity_amount"`
+	NotesUpdated   int     `json:"notes_updated"`
+	NotesTotal     int     `json:"notes_total"`
+}
+
+func NewAbletonApplyGroove(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_apply_groove",
+		"Ableton Live: impose a saved groove template (from ableton_extract_groove) onto a MIDI clip — each note moves by its 16th step's timing offset and takes on the step's accent, scaled by amount",
+		func(_ *ai.ToolContext, input ApplyGrooveInput) (ApplyGrooveOutput, error) {
+			return applyGrooveToClip(client, input)
+		},
+	)
+}
+
+func applyGrooveToClip(client humanizeClient, input ApplyGrooveInput) (ApplyGrooveOutput, error) {
+	if err := validateTrackClipIndices(input.TrackIndex, input.ClipIndex); err != nil {
+		return ApplyGrooveOutput{}, err
+	}
+	amount := defaultGrooveAmount
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+	if amount < 0 || amount > 1 {
+		return ApplyGrooveOutput{}, errors.New("amount must be between 0 and 1")
+	}
+	velocityAmount := amount
+	if input.VelocityAmount != nil {
+		velocityAmount = *input.VelocityAmount
+	}
+	if velocityAmount < 0 || velocityAmount > 1 {
+		return ApplyGrooveOutput{}, errors.New("velocity_amount must be between 0 and 1")
+	}
+	path, err := groovePath(input.Name)
+	if err != nil {
+		return ApplyGrooveOutput{}, err
+	}
+	groove, err := readGroove(path)
+	if err != nil {
+		return ApplyGrooveOutput{}, err
+	}
+
+	res, err := client.Query("/live/clip/get/notes", int32(input.TrackIndex), int32(input.ClipIndex))
+	if err != nil {
+		return ApplyGrooveOutput{}, fmt.Errorf("get notes: %w", err)
+	}
+	_, _, notes, err := parseClipNotesResponse(res)
+	if err != nil {
+		return ApplyGrooveOutput{}, fmt.Errorf("get notes: %w", err)
+	}
+	if len(notes) == 0 {
+		return ApplyGrooveOutput{}, errors.New("clip has no notes to groove")
+	}
+	clipLength := queryClipLength(client, input.TrackIndex, input.ClipIndex)
+	grooved, moved := applyGroove(notes, groove, amount, velocityAmount, clipLength)
+
+	if err := client.Send("/live/clip/remove/notes", int32(input.TrackIndex), int32(input.ClipIndex)); err != nil {
+		return ApplyGrooveOutput{}, fmt.Errorf("clear notes: %w", err)
+	}
+	if err := client.Send("/live/clip/add/notes", addNotesArgs(input.TrackIndex, input.ClipIndex, grooved)...); err != nil {
+		// Adding failed after clearing; restore the original notes so the clip is not left empty.
+		if restoreErr := client.Send("/live/clip/add/notes", addNotesArgs(input.TrackIndex, input.ClipIndex, notes)...); restoreErr != nil {
+			return ApplyGrooveOutput{}, fmt.Errorf("add grooved notes: %w; restore original notes also failed: %v", err, restoreErr)
+		}
+		return ApplyGrooveOutput{}, fmt.Errorf("add grooved notes: %w (original notes restored)", err)
+	}
+
+	return ApplyGrooveOutput{
+		TrackIndex:     input.TrackIndex,
+		ClipIndex:      input.ClipIndex,
+		Name:           groove.Name,
+		Amount:         amount,
+		VelocityAmount: velocityAmount,
+		NotesUpdated:   moved,
+		NotesTotal:     len(notes),
+	}, nil
+}
+
+type ListGroovesOutput struct {
+	Dir     string   `json:"dir"`
+	Grooves []string `json:"grooves"`
+}
+
+func NewAbletonListGrooves(g *genkit.Genkit) ai.Tool {
+	return genkit.DefineTool(g, "ableton_list_grooves",
+		"Ableton Live: list saved groove templates (names) available for ableton_apply_groove.",
+		func(_ *ai.ToolContext, _ struct{}) (ListGroovesOutput, error) {
+			dir := grooveDir()
+			out := ListGroovesOutput{Dir: dir, Grooves: []string{}}
+			entries, err := os.ReadDir(dir)
+			if errors.Is(err, os.ErrNotExist) {
+				return out, nil
+			}
+			if err != nil {
+				return ListGroovesOutput{}, fmt.Errorf("read groove dir: %w", err)
+			}
+			for _, e := range entries {
+				if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+					continue
+				}
+				out.Grooves = append(out.Grooves, strings.TrimSuffix(e.Name(), ".json"))
+			}
+			sort.Strings(out.Grooves)
+			return out, nil
+		},
+	)
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
+)
+
+// laidBackHits is two bars of 16ths where every off-beat 16th drags by 0.03
+// beats and beats are accented.
+func laidBackHits() []grooveHit {
+	var hits []grooveHit
+	for step := 0; step < 32; step++ {
+		beat := float64(step) * grooveStepBeats
+		strength := 0.5
+		if step%4 == 0 {
+			strength = 1
+		}
+		if step%2 == 1 {
+			beat += 0.03
+		}
+		hits = append(hits, grooveHit{Beat: beat, Strength: strength})
+	}
+	return hits
+}
+
+func TestBuildGrooveFoldsBars(t *testing.T) {
+	t.Parallel()
+
+	g := buildGroove(laidBackHits(), 1)
+	if len(g.TimingOffsets) != 16 || g.Counts[0] != 2 {
+		t.Fatalf("groove = %+v", g)
+	}
+	if g.TimingOffsets[0] != 0 || g.TimingOffsets[1] != 0.03 {
+		t.Errorf("offsets = %v", g.TimingOffsets)
+	}
+	if g.Velocities[0] != 1 || g.Velocities[1] != 0.5 {
+		t.Errorf("velocities = %v", g.Velocities)
+	}
+}
+
+func TestApplyGrooveAmount(t *testing.T) {
+	t.Parallel()
+
+	g := buildGroove(laidBackHits(), 1)
+	notes := []MidiNote{
+		{Pitch: 42, StartTime: 0, Duration: 0.1, Velocity: 100},
+		{Pitch: 42, StartTime: 0.25, Duration: 0.1, Velocity: 100},
+		{Pitch: 42, StartTime: 4.25, Duration: 0.1, Velocity: 100},
+	}
+	full, moved := applyGroove(notes, g, 1, 1, 8)
+	if moved != 3 || full[1].StartTime != 0.28 || full[2].StartTime != 4.28 {
+		t.Errorf("full = %+v moved %d", full, moved)
+	}
+	// Average level is 0.625, so the accent lands at 160% and the weak step at 80%.
+	if full[0].Velocity != 127 || full[1].Velocity != 80 {
+		t.Errorf("velocities = %d, %d", full[0].Velocity, full[1].Velocity)
+	}
+	half, _ := applyGroove(notes, g, 0.5, 0, 8)
+	if half[1].StartTime != 0.265 || half[1].Velocity != 100 {
+		t.Errorf("half = %+v", half[1])
+	}
+	if notes[1].StartTime != 0.25 {
+		t.Error("applyGroove mutated its input")
+	}
+}
+
+func TestGrooveRoundTripAndValidation(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "feel.json")
+	in := buildGroove(laidBackHits(), 2)
+	in.Name = "feel"
+	if err := writeGroove(path, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := readGroove(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got.Bars != 2 || len(got.Counts) != 32 || got.TimingOffsets[17] != 0.03 {
+		t.Errorf("round trip = %+v", got)
+	}
+	in.Counts = in.Counts[:3]
+	if err := writeGroove(path, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := readGroove(path); err == nil {
+		t.Error("expected inconsistent step data error")
+	}
+	if _, err := readGroove(filepath.Join(t.TempDir(), "nope.json")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("error = %v, want not found", err)
+	}
+}
+
+func TestExtractAndApplyGrooveMIDIClip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+
+	source := []MidiNote{
+		{Pitch: 36, StartTime: 0, Duration: 0.25, Velocity: 120},
+		{Pitch: 42, StartTime: 0.27, Duration: 0.1, Velocity: 60},
+	}
+	payload := []interface{}{int32(0), int32(0)}
+	for _, n := range source {
+		payload = append(payload, int32(n.Pitch), float32(n.StartTime), float32(n.Duration), int32(n.Velocity), false)
+	}
+	client := &recipeClientStub{queries: map[string][]interface{}{
+		"/live/clip/get/is_audio_clip": {int32(0), int32(0), int32(0)},
+		"/live/clip/get/notes":         payload,
+		"/live/clip/get/length":        {int32(0), int32(0), float32(4)},
+	}}
+	track, clip := 0, 0
+	noExtract := func(string, float64, *float64) (audioanalyze.DrumHits, error) {
+		t.Fatal("MIDI source should not decode audio")
+		return audioanalyze.DrumHits{}, nil
+	}
+	out, err := extractGroove(client, ExtractGrooveInput{Name: "pocket", TrackIndex: &track, ClipIndex: &clip}, noExtract)
+	if err != nil {
+		t.Fatalf("extractGroove() error = %v", err)
+	}
+	if out.Hits != 2 || out.Groove.TimingOffsets[1] != 0.02 || !strings.HasPrefix(out.Path, dir) {
+		t.Errorf("out = %+v", out)
+	}
+
+	amount := 0.5
+	applied, err := applyGrooveToClip(client, ApplyGrooveInput{Name: "pocket", Amount: &amount})
+	if err != nil {
+		t.Fatalf("applyGrooveToClip() error = %v", err)
+	}
+	if applied.NotesTotal != 2 || applied.VelocityAmount != 0.5 {
+		t.Errorf("applied = %+v", applied)
+	}
+	assertSent(t, client, "/live/clip/remove/notes")
+	assertSent(t, client, "/live/clip/add/notes")
+}
+
+func TestExtractGrooveAudioClipUsesOnsets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+
+	client := &recipeClientStub{queries: map[string][]interface{}{
+		"/live/clip/get/is_audio_clip": {int32(2), int32(1), int32(1)},
+		"/live/clip/get/file_path":     {int32(2), int32(1), "/loops/break.wav"},
+	}}
+	extract := func(path string, _ float64, _ *float64) (audioanalyze.DrumHits, error) {
+		if path != "/loops/break.wav" {
+			t.Errorf("path = %q", path)
+		}
+		return audioanalyze.DrumHits{BPM: 90, Hits: []audioanalyze.Onset{{Beat: 0, Strength: 1}, {Beat: 0.55, Strength: 0.4}}}, nil
+	}
+	track, clip := 2, 1
+	out, err := extractGroove(client, ExtractGrooveInput{Name: "break", TrackIndex: &track, ClipIndex: &clip}, extract)
+	if err != nil {
+		t.Fatalf("extractGroove() error = %v", err)
+	}
+	if out.Groove.SourceBPM != 90 || out.Groove.Counts[2] != 1 || out.Groove.TimingOffsets[2] != 0.05 {
+		t.Errorf("groove = %+v", out.Groove)
+	}
+	if _, err := extractGroove(client, ExtractGrooveInput{Name: "x"}, extract); err == nil || !strings.Contains(err.Error(), "track_index") {
+		t.Errorf("error = %v, want missing source error", err)
+	}
+}
@@ -121,7 +121,7 @@ func resolveHumanizeOptions(input HumanizeClipInput) (humanizeOptions, error) {
 		TimingAmount:   defaultHumanizeTimingAmount,
 		VelocityAmount: defaultHumanizeVelocityAmount,
 		Strength:       defaultHumanizeStrength,
-		Seed:           time.Now().UnixNano(),
+		Seed:           resolveSeed(input.Seed),
 	}
 	if input.TimingAmount != nil {
 		opts.TimingAmount = *input.TimingAmount
@@ -135,9 +135,6 @@ func resolveHumanizeOptions(input HumanizeClipInput) (humanizeOptions, error) {
 	if input.Strength != nil {
 		opts.Strength = *input.Strength
 	}
-	if input.Seed != nil {
-		opts.Seed = *input.Seed
-	}
 
 	if opts.TimingAmount < 0 || opts.TimingAmount > maxHumanizeTimingAmount {
 		return humanizeOptions{}, fmt.Errorf("timing_amount must be between 0 and %.2f", maxHumanizeTimingAmount)
@@ -243,10 +240,24 @@ func queryClipLength(client humanizeClient, trackIndex, clipIndex int) float64 {
 	return length
 }
 
+// resolveSeed returns the caller's RNG seed, or a time-based one so every
+// call without a seed still reports the seed it used.
+func resolveSeed(seed *int64) int64 {
+	if seed != nil {
+		return *seed
+	}
+	return time.Now().UnixNano()
+}
+
 // applyEighthSwing delays offbeat eighth notes toward the next onbeat.
 // swing=0 keeps even 8ths; swing=1 delays by one third of an 8th (triplet-ish feel).
 func applyEighthSwing(start, swing float64) float64 {
-	const unit = 0.5
+	return applySwing(start, swing, 0.5)
+}
+
+// applySwing delays every second grid step of length unit by up to a third
+// of the step.
+func applySwing(start, swing, unit float64) float64 {
 	slot := math.Floor((start + 1e-9) / unit)
 	if int(slot)%2 == 0 {
 		return start
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/splice"
+)
+
+// LibraryInfo describes one configured sample library root.
+type LibraryInfo struct {
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Exists  bool     `json:"exists"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// LibraryIndexStats is the index state of one searched library.
+type LibraryIndexStats struct {
+	Name  string              `json:"name"`
+	Path  string              `json:"path"`
+	Index splice.RefreshStats `json:"index"`
+}
+
+// configuredLibraries lists the Splice folder (when it resolves) followed by
+// the roots from the libraries file, whether or not their folders exist.
+func configuredLibraries(settings SpliceLibrarySettings) ([]LibraryInfo, error) {
+	var libs []LibraryInfo
+	if lib, err := splice.Resolve(settings.ConfiguredPath); err == nil {
+		libs = append(libs, LibraryInfo{Name: splice.SpliceLibraryName, Path: lib.Path, Exists: true})
+	}
+	roots, err := splice.LoadRoots(settings.LibrariesFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roots {
+		info, statErr := os.Stat(r.Path)
+		libs = append(libs, LibraryInfo{
+			Name:    r.Name,
+			Path:    r.Path,
+			Exists:  statErr == nil && info.IsDir(),
+			Include: r.Include,
+			Exclude: r.Exclude,
+		})
+	}
+	return libs, nil
+}
+
+// resolveLibraries returns the searchable roots, narrowed to name when set.
+// Missing folders are skipped with a warning; having none left is an error.
+func resolveLibraries(settings SpliceLibrarySettings, name string) ([]splice.Root, []string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	var (
+		roots    []splice.Root
+		warnings []string
+	)
+	lib, spliceErr := splice.Resolve(settings.ConfiguredPath)
+	if spliceErr == nil {
+		roots = append(roots, splice.Root{Name: splice.SpliceLibraryName, Path: lib.Path})
+	}
+	extra, err := splice.LoadRoots(settings.LibrariesFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if spliceErr != nil && (len(extra) == 0 || name == splice.SpliceLibraryName) {
+		return nil, nil, spliceErr
+	}
+	if spliceErr != nil && name == "" {
+		warnings = append(warnings, "splice: "+spliceErr.Error())
+	}
+	for _, r := range extra {
+		if info, err := os.Stat(r.Path); err != nil || !info.IsDir() {
+			if name == r.Name {
+				return nil, nil, fmt.Errorf("library %q folder %s is not a directory", r.Name, r.Path)
+			}
+			if name == "" {
+				warnings = append(warnings, fmt.Sprintf("%s: folder %s not found, skipped", r.Name, r.Path))
+			}
+			continue
+		}
+		roots = append(roots, r)
+	}
+	if name == "" {
+		if len(roots) == 0 {
+			return nil, nil, fmt.Errorf("no sample library folders found; check ABLETON_OSC_SPLICE_PATH and %s", settings.LibrariesFile)
+		}
+		return roots, warnings, nil
+	}
+	for _, r := range roots {
+		if r.Name == name {
+			return []splice.Root{r}, nil, nil
+		}
+	}
+	names := make([]string, 0, len(extra)+1)
+	names = append(names, splice.SpliceLibraryName)
+	for _, r := range extra {
+		names = append(names, r.Name)
+	}
+	sort.Strings(names)
+	return nil, nil, fmt.Errorf("unknown library %q (configured: %s)", name, strings.Join(names, ", "))
+}
+
+// loadCatalog indexes every resolved root so one query spans all of them.
+func loadCatalog(settings SpliceLibrarySettings, name string, refresh bool) (splice.Catalog, []LibraryIndexStats, []string, error) {
+	roots, warnings, err := resolveLibraries(settings, name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	indexer := settings.Index
+	if indexer == nil {
+		indexer = splice.NewIndexer("")
+	}
+	catalog := make(splice.Catalog, 0, len(roots))
+	stats := make([]LibraryIndexStats, 0, len(roots))
+	for _, r := range roots {
+		idx, s, err := indexer.Load(r, refresh)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("library %q: %w", r.Name, err)
+		}
+		catalog = append(catalog, idx)
+		stats = append(stats, LibraryIndexStats{Name: r.Name, Path: idx.Root, Index: s})
+	}
+	return catalog, stats, warnings, nil
+}
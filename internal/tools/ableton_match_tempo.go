@@ -3,12 +3,14 @@ package tools
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
 )
 
 const (
@@ -21,18 +23,27 @@ type MatchClipTempoInput struct {
 	ClipIndex  int    `json:"clip_index" jsonschema:"minimum=0"`
 	WarpMode   string `json:"warp_mode,omitempty" jsonschema:"description=Warp algorithm: beats (default, good for drums/loops) or complex (longer tonal samples)"`
 	Fire       bool   `json:"fire,omitempty" jsonschema:"description=Fire the clip after enabling warp"`
+	// Downbeat alignment: Live's auto-warp assumes bar 1 at the file start,
+	// which is wrong for samples with a pickup or leading silence.
+	AlignDownbeat bool     `json:"align_downbeat,omitempty" jsonschema:"description=Move the clip start marker and loop start to the first downbeat (the loop end shifts with them) (beat-tracked from the clip's audio file unless downbeat_sec is given)"`
+	DownbeatSec   *float64 `json:"downbeat_sec,omitempty" jsonschema:"description=Known first downbeat in seconds from the file start (e.g. beat_grid.first_downbeat_sec from ableton_analyze_local_audio),minimum=0"`
+	SourceBPM     *float64 `json:"source_bpm,omitempty" jsonschema:"description=Tempo of the audio file, used to convert downbeat_sec to clip beats (default: tracked BPM),minimum=20,maximum=400"`
 }
 
 type MatchClipTempoOutput struct {
-	TrackIndex        int     `json:"track_index"`
-	ClipIndex         int     `json:"clip_index"`
-	TempoBPM          float64 `json:"tempo_bpm"`
-	Warping           bool    `json:"warping"`
-	WarpMode          string  `json:"warp_mode"`
-	LengthBeats       float64 `json:"length_beats"`
-	LengthBeatsBefore float64 `json:"length_beats_before"`
-	Fired             bool    `json:"fired"`
-	Note              string  `json:"note"`
+	TrackIndex        int      `json:"track_index"`
+	ClipIndex         int      `json:"clip_index"`
+	TempoBPM          float64  `json:"tempo_bpm"`
+	Warping           bool     `json:"warping"`
+	WarpMode          string   `json:"warp_mode"`
+	LengthBeats       float64  `json:"length_beats"`
+	LengthBeatsBefore float64  `json:"length_beats_before"`
+	DownbeatSec       *float64 `json:"downbeat_sec,omitempty"`
+	SourceBPM         float64  `json:"source_bpm,omitempty"`
+	StartMarkerBeats  *float64 `json:"start_marker_beats,omitempty"`
+	LoopEndBeats      *float64 `json:"loop_end_beats,omitempty"`
+	Fired             bool     `json:"fired"`
+	Note              string   `json:"note"`
 }
 
 type matchTempoClient interface {
@@ -42,7 +53,7 @@ type matchTempoClient interface {
 
 func NewAbletonMatchClipTempo(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
 	return genkit.DefineTool(g, "ableton_match_clip_tempo",
-		"Ableton Live: enable Warp on an audio clip so it follows the project tempo (useful after loading a Splice sample)",
+		"Ableton Live: enable Warp on an audio clip so it follows the project tempo (useful after loading a Splice sample). With align_downbeat, beat-tracks the clip's audio file and moves the start marker / loop start to the real first downbeat instead of the file start, keeping the loop length",
 		func(_ *ai.ToolContext, input MatchClipTempoInput) (MatchClipTempoOutput, error) {
 			return matchClipTempo(client, input)
 		},
@@ -94,15 +105,7 @@ func matchClipTempo(client matchTempoClient, input MatchClipTempoInput) (MatchCl
 	}
 	lengthAfter := queryMatchTempoClipLength(client, input.TrackIndex, input.ClipIndex)
 
-	fired := false
-	if input.Fire {
-		if err := client.Send("/live/clip_slot/fire", int32(input.TrackIndex), int32(input.ClipIndex)); err != nil {
-			return MatchClipTempoOutput{}, fmt.Errorf("fire clip: %w", err)
-		}
-		fired = true
-	}
-
-	return MatchClipTempoOutput{
+	out := MatchClipTempoOutput{
 		TrackIndex:        input.TrackIndex,
 		ClipIndex:         input.ClipIndex,
 		TempoBPM:          tempo,
@@ -110,9 +113,107 @@ func matchClipTempo(client matchTempoClient, input MatchClipTempoInput) (MatchCl
 		WarpMode:          warpModeName,
 		LengthBeats:       lengthAfter,
 		LengthBeatsBefore: lengthBefore,
-		Fired:             fired,
 		Note:              "Warp is on so the clip follows the project tempo. Adjust warp markers in Live if the transient alignment is off.",
-	}, nil
+	}
+	if input.AlignDownbeat || input.DownbeatSec != nil {
+		if err := alignClipDownbeat(client, input, &out); err != nil {
+			return MatchClipTempoOutput{}, err
+		}
+	}
+
+	if input.Fire {
+		if err := client.Send("/live/clip_slot/fire", int32(input.TrackIndex), int32(input.ClipIndex)); err != nil {
+			return MatchClipTempoOutput{}, fmt.Errorf("fire clip: %w", err)
+		}
+		out.Fired = true
+	}
+	return out, nil
+}
+
+// alignClipDownbeat moves the start marker and loop start onto the first
+// downbeat and shifts the loop end by the same amount. Seconds convert to clip
+// beats at the source tempo, which matches Live's auto-warp when it detects
+// the same tempo with its first marker at the file start.
+func alignClipDownbeat(client matchTempoClient, input MatchClipTempoInput, out *MatchClipTempoOutput) error {
+	var downbeat, sourceBPM float64
+	if input.SourceBPM != nil {
+		sourceBPM = *input.SourceBPM
+	}
+	if input.DownbeatSec != nil {
+		if *input.DownbeatSec < 0 {
+			return errors.New("downbeat_sec must be >= 0")
+		}
+		downbeat = *input.DownbeatSec
+		if sourceBPM <= 0 {
+			return errors.New("source_bpm is required with downbeat_sec")
+		}
+	} else {
+		path, err := queryClipFilePath(client, input.TrackIndex, input.ClipIndex)
+		if err != nil {
+			return err
+		}
+		grid, err := audioanalyze.AnalyzeBeatGrid(path)
+		if err != nil {
+			return wrapActionable(fmt.Errorf("beat-track %s: %w", path, err), "beat_tracking_failed",
+				"pass downbeat_sec and source_bpm explicitly, or set the start marker with ableton_set_clip_region")
+		}
+		downbeat = grid.FirstDownbeatSec
+		if sourceBPM <= 0 {
+			sourceBPM = grid.BPM
+		}
+	}
+	if sourceBPM < 20 || sourceBPM > 400 {
+		return errors.New("source_bpm must be between 20 and 400")
+	}
+
+	startBeats := math.Round(downbeat*sourceBPM/60*1000) / 1000
+	loopStart, err := queryMatchTempoClipFloat(client, input.TrackIndex, input.ClipIndex, "loop_start")
+	if err != nil {
+		return err
+	}
+	loopEnd, err := queryMatchTempoClipFloat(client, input.TrackIndex, input.ClipIndex, "loop_end")
+	if err != nil {
+		return err
+	}
+	// The loop keeps its length. Live rejects loop_start >= loop_end, so the
+	// edge in the direction of travel moves first.
+	endBeats := math.Round((loopEnd+startBeats-loopStart)*1000) / 1000
+	edges := []struct {
+		prop  string
+		beats float64
+	}{{"loop_start", startBeats}, {"loop_end", endBeats}}
+	if startBeats > loopStart {
+		edges[0], edges[1] = edges[1], edges[0]
+	}
+	for _, edge := range edges {
+		if err := client.Send("/live/clip/set/"+edge.prop, int32(input.TrackIndex), int32(input.ClipIndex), float32(edge.beats)); err != nil {
+			return fmt.Errorf("set %s: %w", edge.prop, err)
+		}
+	}
+	if err := client.Send("/live/clip/set/start_marker", int32(input.TrackIndex), int32(input.ClipIndex), float32(startBeats)); err != nil {
+		return fmt.Errorf("set start marker: %w", err)
+	}
+	out.DownbeatSec = &downbeat
+	out.SourceBPM = sourceBPM
+	out.StartMarkerBeats = &startBeats
+	out.LoopEndBeats = &endBeats
+	out.Note = fmt.Sprintf("Warp is on and the clip now starts on its first downbeat (%.3fs = beat %.3f at %.1f BPM). If Live's auto-warp picked a different tempo, check the first warp marker.", downbeat, startBeats, sourceBPM)
+	return nil
+}
+
+func queryClipFilePath(client matchTempoClient, trackIndex, clipIndex int) (string, error) {
+	res, err := client.Query("/live/clip/get/file_path", int32(trackIndex), int32(clipIndex))
+	if err != nil {
+		return "", fmt.Errorf("get file_path: %w", err)
+	}
+	if err := ensureResponseLen(res, 3); err != nil {
+		return "", fmt.Errorf("get file_path: %w", err)
+	}
+	path, ok := res[2].(string)
+	if !ok || strings.TrimSpace(path) == "" {
+		return "", errors.New("clip has no file path to beat-track; pass downbeat_sec and source_bpm")
+	}
+	return path, nil
 }
 
 func resolveWarpMode(raw string) (string, int, error) {
@@ -171,6 +272,21 @@ func queryClipWarping(client matchTempoClient, trackIndex, clipIndex int) (bool,
 	return warping, nil
 }
 
+func queryMatchTempoClipFloat(client matchTempoClient, trackIndex, clipIndex int, prop string) (float64, error) {
+	res, err := client.Query("/live/clip/get/"+prop, int32(trackIndex), int32(clipIndex))
+	if err != nil {
+		return 0, fmt.Errorf("get %s: %w", prop, err)
+	}
+	if err := ensureResponseLen(res, 3); err != nil {
+		return 0, fmt.Errorf("get %s: %w", prop, err)
+	}
+	value, err := abletonosc.AsFloat64(res[2])
+	if err != nil {
+		return 0, fmt.Errorf("get %s: %w", prop, err)
+	}
+	return value, nil
+}
+
 func queryMatchTempoClipLength(client matchTempoClient, trackIndex, clipIndex int) float64 {
 	res, err := client.Query("/live/clip/get/length", int32(trackIndex), int32(clipIndex))
 	if err != nil || len(res) == 0 {
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -11,7 +12,10 @@ type matchTempoStub struct {
 	warping  bool
 	tempo    float64
 	length   float64
+	filePath string
+	loop     [2]float64
 	calls    []string
+	sent     map[string]float64
 	sendErr  map[string]error
 	queryErr map[string]error
 }
@@ -31,6 +35,12 @@ func (s *matchTempoStub) Query(address string, _ ...interface{}) ([]interface{},
 		return []interface{}{int32(0), int32(0), float32(s.length)}, nil
 	case "/live/clip/get/warping":
 		return []interface{}{int32(0), int32(0), s.warping}, nil
+	case "/live/clip/get/file_path":
+		return []interface{}{int32(0), int32(0), s.filePath}, nil
+	case "/live/clip/get/loop_start":
+		return []interface{}{int32(0), int32(0), float32(s.loop[0])}, nil
+	case "/live/clip/get/loop_end":
+		return []interface{}{int32(0), int32(0), float32(s.loop[1])}, nil
 	default:
 		return nil, errors.New("unexpected query: " + address)
 	}
@@ -41,6 +51,28 @@ func (s *matchTempoStub) Send(address string, args ...interface{}) error {
 	if err := s.sendErr[address]; err != nil {
 		return err
 	}
+	value, isFloat := float32(0), false
+	if len(args) == 3 {
+		value, isFloat = args[2].(float32)
+	}
+	if isFloat {
+		if s.sent == nil {
+			s.sent = map[string]float64{}
+		}
+		s.sent[address] = float64(value)
+	}
+	switch address {
+	case "/live/clip/set/loop_start":
+		if float64(value) >= s.loop[1] {
+			return errors.New("loop_start must be before loop_end")
+		}
+		s.loop[0] = float64(value)
+	case "/live/clip/set/loop_end":
+		if float64(value) <= s.loop[0] {
+			return errors.New("loop_end must be after loop_start")
+		}
+		s.loop[1] = float64(value)
+	}
 	if address == "/live/clip/set/warping" {
 		s.warping = true
 		// Live often reports a different beat length once warp is enabled.
@@ -78,6 +110,10 @@ func TestMatchClipTempo(t *testing.T) {
 	if !containsCall(client.calls, "/live/clip/set/warping") || !containsCall(client.calls, "/live/clip/set/warp_mode") {
 		t.Errorf("calls = %v", client.calls)
 	}
+	// Without alignment the loop is left alone.
+	if containsCall(client.calls, "/live/clip/set/loop_start") || containsCall(client.calls, "/live/clip/set/loop_end") {
+		t.Errorf("calls = %v, want loop untouched", client.calls)
+	}
 }
 
 func TestMatchClipTempoRejectsMIDI(t *testing.T) {
@@ -104,6 +140,68 @@ func TestMatchClipTempoRejectsEmptySlot(t *testing.T) {
 	}
 }
 
+func TestMatchClipTempoAlignsDownbeat(t *testing.T) {
+	t.Parallel()
+
+	// Downbeat 0.75s at 120 BPM is beat 1.5; the one-beat loop keeps its
+	// length, and the stub rejects an edge order that would invert it.
+	for name, tc := range map[string]struct {
+		loop    [2]float64
+		wantEnd float64
+		first   string
+	}{
+		"moves right": {loop: [2]float64{0, 1}, wantEnd: 2.5, first: "/live/clip/set/loop_end"},
+		"moves left":  {loop: [2]float64{4, 5}, wantEnd: 2.5, first: "/live/clip/set/loop_start"},
+	} {
+		client := &matchTempoStub{hasClip: true, isAudio: true, tempo: 128, length: 16, loop: tc.loop}
+		downbeat, bpm := 0.75, 120.0
+		got, err := matchClipTempo(client, MatchClipTempoInput{
+			AlignDownbeat: true,
+			DownbeatSec:   &downbeat,
+			SourceBPM:     &bpm,
+		})
+		if err != nil {
+			t.Fatalf("%s: matchClipTempo() error = %v", name, err)
+		}
+		if got.StartMarkerBeats == nil || *got.StartMarkerBeats != 1.5 || got.SourceBPM != 120 {
+			t.Errorf("%s: got = %#v", name, got)
+		}
+		if got.LoopEndBeats == nil || *got.LoopEndBeats != tc.wantEnd {
+			t.Errorf("%s: loop_end_beats = %v, want %v", name, got.LoopEndBeats, tc.wantEnd)
+		}
+		if client.sent["/live/clip/set/start_marker"] != 1.5 || client.sent["/live/clip/set/loop_start"] != 1.5 || client.sent["/live/clip/set/loop_end"] != tc.wantEnd {
+			t.Errorf("%s: sent = %v", name, client.sent)
+		}
+		if first := firstLoopCall(client.calls); first != tc.first {
+			t.Errorf("%s: first loop edge = %s, want %s (calls %v)", name, first, tc.first, client.calls)
+		}
+	}
+}
+
+func firstLoopCall(calls []string) string {
+	for _, call := range calls {
+		if call == "/live/clip/set/loop_start" || call == "/live/clip/set/loop_end" {
+			return call
+		}
+	}
+	return ""
+}
+
+func TestMatchClipTempoAlignNeedsSource(t *testing.T) {
+	t.Parallel()
+
+	downbeat := 0.5
+	_, err := matchClipTempo(&matchTempoStub{hasClip: true, isAudio: true, tempo: 120}, MatchClipTempoInput{DownbeatSec: &downbeat})
+	if err == nil || !strings.Contains(err.Error(), "source_bpm") {
+		t.Errorf("error = %v, want source_bpm error", err)
+	}
+	// Beat tracking needs the clip's file; an empty path is reported, not guessed.
+	_, err = matchClipTempo(&matchTempoStub{hasClip: true, isAudio: true, tempo: 120}, MatchClipTempoInput{AlignDownbeat: true})
+	if err == nil || !strings.Contains(err.Error(), "file path") {
+		t.Errorf("error = %v, want file path error", err)
+	}
+}
+
 func TestResolveWarpMode(t *testing.T) {
 	t.Parallel()
 
@@ -9,6 +9,7 @@ import (
 	"github.com/firebase/genkit/go/genkit"
 
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
 )
 
 const maxMixVariationDelta = 0.2
@@ -32,13 +33,16 @@ type MixVolumeChange struct {
 }
 
 type ApplyMixVariationInput struct {
-	Changes []MixVolumeChange `json:"changes" jsonschema:"description=One or more small volume changes for the B version"`
+	Changes   []MixVolumeChange `json:"changes" jsonschema:"description=One or more small volume changes for the B version"`
+	UseTaste  bool              `json:"use_taste,omitempty" jsonschema:"description=Resize every change to the delta size preferred in past mix A/B choices, keeping each change's direction"`
+	TasteUser string            `json:"taste_user,omitempty" jsonschema:"description=Taste user whose choices use_taste reads (default: the session user)"`
 }
 
 type ApplyMixVariationOutput struct {
 	Before           MixSnapshotOutput `json:"before" jsonschema:"description=Use this snapshot with ableton_restore_mix_snapshot to return to A"`
 	After            MixSnapshotOutput `json:"after"`
 	PreferencePrompt string            `json:"preference_prompt"`
+	Taste            *TasteChoice      `json:"taste,omitempty" jsonschema:"description=Why use_taste picked the delta size"`
 }
 
 type RestoreMixSnapshotInput struct {
@@ -59,11 +63,11 @@ func NewAbletonCaptureMixSnapshot(g *genkit.Genkit, client *abletonosc.Client) a
 	)
 }
 
-func NewAbletonApplyMixVariation(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+func NewAbletonApplyMixVariation(g *genkit.Genkit, client *abletonosc.Client, users *taste.Users) ai.Tool {
 	return genkit.DefineTool(g, "ableton_apply_mix_variation",
 		"Ableton Live: mix A/B entry — apply small track-volume changes for B and return the A snapshot (not covered by ableton_compare_ab_variation; restore with ableton_restore_mix_snapshot)",
 		func(_ *ai.ToolContext, input ApplyMixVariationInput) (ApplyMixVariationOutput, error) {
-			return applyMixVariation(client, input)
+			return applyMixVariationWithTaste(client, tasteLoaderFor(users, input.TasteUser), input)
 		},
 	)
 }
@@ -0,0 +1,470 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+)
+
+var (
+	sharpNoteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNoteNames  = [12]string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+)
+
+// modeScale describes a seven-note mode by its semitone degrees and its
+// offset above the parent major scale's tonic (used for key-signature spelling).
+type modeScale struct {
+	name         string
+	degrees      [7]int
+	parentOffset int
+}
+
+var (
+	ionianMode     = modeScale{name: "major", degrees: [7]int{0, 2, 4, 5, 7, 9, 11}, parentOffset: 0}
+	dorianMode     = modeScale{name: "dorian", degrees: [7]int{0, 2, 3, 5, 7, 9, 10}, parentOffset: 2}
+	phrygianMode   = modeScale{name: "phrygian", degrees: [7]int{0, 1, 3, 5, 7, 8, 10}, parentOffset: 4}
+	lydianMode     = modeScale{name: "lydian", degrees: [7]int{0, 2, 4, 6, 7, 9, 11}, parentOffset: 5}
+	mixolydianMode = modeScale{name: "mixolydian", degrees: [7]int{0, 2, 4, 5, 7, 9, 10}, parentOffset: 7}
+	aeolianMode    = modeScale{name: "minor", degrees: [7]int{0, 2, 3, 5, 7, 8, 10}, parentOffset: 9}
+	locrianMode    = modeScale{name: "locrian", degrees: [7]int{0, 1, 3, 5, 6, 8, 10}, parentOffset: 11}
+	harmonicMode   = modeScale{name: "harmonic minor", degrees: [7]int{0, 2, 3, 5, 7, 8, 11}, parentOffset: 9}
+	melodicMode    = modeScale{name: "melodic minor", degrees: [7]int{0, 2, 3, 5, 7, 9, 11}, parentOffset: 9}
+)
+
+// lookupMode maps a mode or Live scale name to a seven-note mode. Live's
+// pentatonic/blues scales fall back to their major or minor parent.
+func lookupMode(name string) (modeScale, bool) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	switch n {
+	case "", "maj", "major", "ionian":
+		return ionianMode, true
+	case "m", "min", "minor", "aeolian":
+		return aeolianMode, true
+	case "dorian":
+		return dorianMode, true
+	case "phrygian":
+		return phrygianMode, true
+	case "lydian":
+		return lydianMode, true
+	case "mixolydian":
+		return mixolydianMode, true
+	case "locrian":
+		return locrianMode, true
+	case "harmonic minor":
+		return harmonicMode, true
+	case "melodic minor":
+		return melodicMode, true
+	}
+	switch {
+	case strings.Contains(n, "minor"):
+		return aeolianMode, true
+	case strings.Contains(n, "major"):
+		return ionianMode, true
+	}
+	return modeScale{}, false
+}
+
+// musicalKey is a tonic plus mode used to resolve Roman numerals.
+type musicalKey struct {
+	tonicPC int
+	mode    modeScale
+	flats   bool
+}
+
+func (k musicalKey) tonicName() string {
+	return k.noteName(k.tonicPC)
+}
+
+func (k musicalKey) String() string {
+	return k.tonicName() + " " + k.mode.name
+}
+
+func (k musicalKey) noteName(pc int) string {
+	if k.flats {
+		return flatNoteNames[pc]
+	}
+	return sharpNoteNames[pc]
+}
+
+func newMusicalKey(tonicPC int, tonicSpelling string, mode modeScale) musicalKey {
+	flats := strings.Contains(tonicSpelling, "b")
+	if !flats && !strings.Contains(tonicSpelling, "#") {
+		switch ((tonicPC-mode.parentOffset)%12 + 12) % 12 {
+		case 5, 10, 3, 8, 1: // F, Bb, Eb, Ab, Db parents read in flats
+			flats = true
+		}
+	}
+	return musicalKey{tonicPC: tonicPC, mode: mode, flats: flats}
+}
+
+// parseMusicalKey reads keys like "Bb", "Bbm", "A minor", or "F# dorian".
+func parseMusicalKey(s string) (musicalKey, error) {
+	trimmed := strings.TrimSpace(s)
+	pc, name, rest, err := parseNoteName(trimmed)
+	if err != nil {
+		return musicalKey{}, fmt.Errorf("invalid key %q (try e.g. Bb, A minor, F# dorian)", s)
+	}
+	mode, ok := lookupMode(rest)
+	if !ok {
+		return musicalKey{}, fmt.Errorf("unsupported mode %q in key %q (try major, minor, dorian, phrygian, lydian, mixolydian, locrian, harmonic minor, melodic minor)", strings.TrimSpace(rest), s)
+	}
+	return newMusicalKey(pc, name, mode), nil
+}
+
+// querySongKey reads the song root note and scale set with ableton_set_song_key.
+func querySongKey(client chordClipClient) (musicalKey, error) {
+	rootRes, err := client.Query("/live/song/get/root_note")
+	if err != nil {
+		return musicalKey{}, fmt.Errorf("get song root note: %w", err)
+	}
+	if err := ensureResponseLen(rootRes, 1); err != nil {
+		return musicalKey{}, fmt.Errorf("get song root note: %w", err)
+	}
+	root, err := abletonosc.AsInt(rootRes[0])
+	if err != nil {
+		return musicalKey{}, fmt.Errorf("get song root note: %w", err)
+	}
+	scaleRes, err := client.Query("/live/song/get/scale_name")
+	if err != nil {
+		return musicalKey{}, fmt.Errorf("get song scale name: %w", err)
+	}
+	if err := ensureResponseLen(scaleRes, 1); err != nil {
+		return musicalKey{}, fmt.Errorf("get song scale name: %w", err)
+	}
+	scaleName := fmt.Sprint(scaleRes[0])
+	mode, ok := lookupMode(scaleName)
+	if !ok {
+		return musicalKey{}, fmt.Errorf("song scale %q has no Roman-numeral mode; pass key explicitly (e.g. A minor)", scaleName)
+	}
+	return newMusicalKey(((root%12)+12)%12, "", mode), nil
+}
+
+var romanNumeralPattern = regexp.MustCompile(`^([b#]?)(VII|VI|IV|V|III|II|I|vii|vi|iv|v|iii|ii|i)(.*)$`)
+
+var romanDegrees = map[string]int{"i": 0, "ii": 1, "iii": 2, "iv": 3, "v": 4, "vi": 5, "vii": 6}
+
+func isRomanNumeral(token string) bool {
+	head := token
+	if i := strings.Index(token, "/"); i > 0 {
+		head = token[:i]
+	}
+	return romanNumeralPattern.MatchString(normalizeRomanAccidentals(head))
+}
+
+func normalizeRomanAccidentals(s string) string {
+	return strings.NewReplacer("♭", "b", "♯", "#").Replace(s)
+}
+
+// romanToChordSymbol resolves a Roman numeral (e.g. ii7, bVII, V7/V, viiø7)
+// to an absolute chord symbol in key. Plain numerals follow the key's mode;
+// numerals with b/# alter the parallel major degree, which is how borrowed
+// chords are usually written. A /X suffix tonicizes the target degree.
+func romanToChordSymbol(token string, key musicalKey) (string, error) {
+	token = normalizeRomanAccidentals(strings.TrimSpace(token))
+	tonic := key.tonicPC
+	mode := key.mode
+	spell := key
+	if i := strings.Index(token, "/"); i > 0 {
+		target := token[i+1:]
+		m := romanNumeralPattern.FindStringSubmatch(target)
+		if m == nil || m[3] != "" && !isRomanQualitySuffix(m[3]) {
+			return "", fmt.Errorf("invalid secondary target %q in %q", target, token)
+		}
+		tonic = romanRootPC(m[1], m[2], key.tonicPC, key.mode)
+		mode = ionianMode
+		token = token[:i]
+	}
+
+	m := romanNumeralPattern.FindStringSubmatch(token)
+	if m == nil {
+		return "", fmt.Errorf("invalid Roman numeral %q", token)
+	}
+	accidental, numeral, suffix := m[1], m[2], m[3]
+	root := romanRootPC(accidental, numeral, tonic, mode)
+	switch accidental {
+	case "b":
+		spell.flats = true
+	case "#":
+		spell.flats = false
+	}
+	quality, err := romanQuality(numeral == strings.ToUpper(numeral), suffix)
+	if err != nil {
+		return "", fmt.Errorf("%w in %q", err, token)
+	}
+	return spell.noteName(root) + quality, nil
+}
+
+func romanRootPC(accidental, numeral string, tonic int, mode modeScale) int {
+	degree := romanDegrees[strings.ToLower(numeral)]
+	offset := mode.degrees[degree]
+	switch accidental {
+	case "b":
+		offset = ionianMode.degrees[degree] - 1
+	case "#":
+		offset = ionianMode.degrees[degree] + 1
+	}
+	return ((tonic+offset)%12 + 12) % 12
+}
+
+func isRomanQualitySuffix(suffix string) bool {
+	_, err := romanQuality(true, suffix)
+	return err == nil
+}
+
+// romanQuality turns the numeral case plus suffix (°, ø, +, 7, maj7, 9, sus4,
+// ...) into a chord-symbol quality understood by parseChordSymbol.
+func romanQuality(upper bool, suffix string) (string, error) {
+	triad := "minor"
+	if upper {
+		triad = "major"
+	}
+	ext := suffix
+	switch {
+	case strings.HasPrefix(ext, "°"):
+		triad, ext = "dim", strings.TrimPrefix(ext, "°")
+	case strings.HasPrefix(ext, "dim"):
+		triad, ext = "dim", strings.TrimPrefix(ext, "dim")
+	case strings.HasPrefix(ext, "o"):
+		triad, ext = "dim", strings.TrimPrefix(ext, "o")
+	case strings.HasPrefix(ext, "ø"):
+		triad, ext = "half_dim", strings.TrimPrefix(ext, "ø")
+	case strings.HasPrefix(ext, "+"):
+		triad, ext = "aug", strings.TrimPrefix(ext, "+")
+	case strings.HasPrefix(ext, "aug"):
+		triad, ext = "aug", strings.TrimPrefix(ext, "aug")
+	}
+
+	var quality string
+	switch triad {
+	case "dim":
+		switch ext {
+		case "":
+			quality = "dim"
+		case "7":
+			quality = "dim7"
+		}
+	case "half_dim":
+		if ext == "" || ext == "7" {
+			quality = "m7b5"
+		}
+	case "aug":
+		switch ext {
+		case "":
+			quality = "aug"
+		case "7":
+			quality = "7#5"
+		}
+	case "minor":
+		switch {
+		case ext == "":
+			quality = "m"
+		case ext == "maj7":
+			quality = "mmaj7"
+		case strings.HasPrefix(ext, "sus") || ext == "5":
+			quality = ext
+		default:
+			quality = "m" + ext
+		}
+	default:
+		quality = ext
+	}
+	if _, ok := chordQualityIntervals[quality]; !ok || (quality == "" && ext != "") {
+		return "", fmt.Errorf("unsupported Roman numeral suffix %q", suffix)
+	}
+	return quality, nil
+}
+
+// progressionFields splits a progression on spaces, commas, pipes, and dashes
+// used between numerals (ii–V–I). An ASCII hyphen splits a token only when
+// every piece is a Roman numeral (ii-V-I): C- is a minor chord name and a lone
+// - is a rest.
+func progressionFields(s string) []string {
+	var fields []string
+	for _, f := range strings.FieldsFunc(s, isProgressionSeparator) {
+		fields = append(fields, splitRomanHyphens(f)...)
+	}
+	return fields
+}
+
+func splitRomanHyphens(token string) []string {
+	parts := strings.Split(token, "-")
+	if len(parts) < 2 {
+		return []string{token}
+	}
+	for _, p := range parts {
+		if !isRomanNumeral(p) {
+			return []string{token}
+		}
+	}
+	return parts
+}
+
+func isProgressionSeparator(r rune) bool {
+	return r == ' ' || r == ',' || r == '|' || r == '\t' || r == '\n' || r == '–' || r == '—'
+}
+
+func progressionHasRomanNumerals(s string) bool {
+	for _, f := range progressionFields(s) {
+		if isRomanNumeral(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveProgressionKey parses an explicit key, or reads the song key from
+// Live when the progression uses Roman numerals. Returns nil when no key is
+// needed.
+func resolveProgressionKey(client chordClipClient, progression, key string) (*musicalKey, error) {
+	if strings.TrimSpace(key) != "" {
+		k, err := parseMusicalKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return &k, nil
+	}
+	if !progressionHasRomanNumerals(progression) {
+		return nil, nil
+	}
+	k, err := querySongKey(client)
+	if err != nil {
+		return nil, wrapActionable(err, "song_key_unavailable",
+			"Pass key (e.g. Bb major) or set it first with ableton_set_song_key.")
+	}
+	return &k, nil
+}
+
+// commonProgression is a named progression template in Roman numerals.
+type commonProgression struct {
+	name     string
+	numerals string
+	feel     string
+}
+
+var commonProgressionsByMode = map[string][]commonProgression{
+	"major": {
+		{name: "pop axis", numerals: "I V vi IV", feel: "anthemic, familiar"},
+		{name: "doo-wop", numerals: "I vi IV V", feel: "nostalgic, classic"},
+		{name: "jazz ii-V-I", numerals: "ii7 V7 Imaj7", feel: "smooth resolution"},
+		{name: "blues-rock", numerals: "I IV V IV", feel: "driving, bright"},
+		{name: "sensitive", numerals: "vi IV I V", feel: "emotional, lifting"},
+		{name: "mixolydian borrow", numerals: "I bVII IV I", feel: "rock, open"},
+		{name: "minor plagal", numerals: "I IV iv I", feel: "bittersweet"},
+		{name: "secondary dominant", numerals: "I V7/vi vi IV", feel: "pull toward the relative minor"},
+	},
+	"minor": {
+		{name: "epic minor", numerals: "i VI III VII", feel: "cinematic, soaring"},
+		{name: "natural minor cadence", numerals: "i iv v i", feel: "dark, modal"},
+		{name: "aeolian vamp", numerals: "i VII VI VII", feel: "brooding, looping"},
+		{name: "andalusian", numerals: "i VII VI V", feel: "flamenco, descending"},
+		{name: "minor ii-V-i", numerals: "iiø7 V7 i", feel: "jazzy tension"},
+		{name: "trap minor", numerals: "i VI iv v", feel: "moody, modern"},
+	},
+	"dorian": {
+		{name: "dorian vamp", numerals: "i7 IV7", feel: "funky, soulful"},
+		{name: "dorian rise", numerals: "i ii III IV", feel: "hopeful minor"},
+		{name: "so what", numerals: "i VII i IV", feel: "cool, modal"},
+	},
+	"phrygian": {
+		{name: "phrygian vamp", numerals: "i bII i", feel: "tense, exotic"},
+		{name: "phrygian descent", numerals: "i bII bIII bII", feel: "dark metal / flamenco"},
+	},
+	"lydian": {
+		{name: "lydian lift", numerals: "I II I", feel: "dreamy, floating"},
+		{name: "lydian cycle", numerals: "I II vii iii", feel: "airy, cinematic"},
+	},
+	"mixolydian": {
+		{name: "mixolydian vamp", numerals: "I bVII IV I", feel: "rock, anthemic"},
+		{name: "mixolydian shuffle", numerals: "I v IV I", feel: "laid back"},
+	},
+	"locrian": {
+		{name: "locrian drone", numerals: "i° bII i°", feel: "unstable, menacing"},
+	},
+	"harmonic minor": {
+		{name: "harmonic cadence", numerals: "i iv V7 i", feel: "classical, dramatic"},
+		{name: "harmonic turn", numerals: "i VI V i", feel: "exotic tension"},
+	},
+	"melodic minor": {
+		{name: "melodic minor vamp", numerals: "i IV7", feel: "jazzy, bittersweet"},
+	},
+}
+
+type SuggestChordProgressionsInput struct {
+	Key string `json:"key,omitempty" jsonschema:"description=Key such as Bb major, A minor, or F# dorian (default: the song key set with ableton_set_song_key)"`
+}
+
+type SuggestedProgression struct {
+	Name        string   `json:"name"`
+	Numerals    string   `json:"numerals"`
+	Progression string   `json:"progression"`
+	Chords      []string `json:"chords"`
+	Feel        string   `json:"feel"`
+}
+
+type SuggestChordProgressionsOutput struct {
+	Key          string                 `json:"key"`
+	Mode         string                 `json:"mode"`
+	Progressions []SuggestedProgression `json:"progressions"`
+	Note         string                 `json:"note"`
+}
+
+func NewAbletonSuggestChordProgressions(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+	return genkit.DefineTool(g, "ableton_suggest_chord_progressions",
+		"Ableton Live: suggest common chord progressions for a key/mode (default: the song key) as Roman numerals and resolved chord names. Pass either form to ableton_build_chord_clip",
+		func(_ *ai.ToolContext, input SuggestChordProgressionsInput) (SuggestChordProgressionsOutput, error) {
+			return suggestChordProgressions(client, input)
+		},
+	)
+}
+
+func suggestChordProgressions(client chordClipClient, input SuggestChordProgressionsInput) (SuggestChordProgressionsOutput, error) {
+	var key musicalKey
+	if strings.TrimSpace(input.Key) != "" {
+		k, err := parseMusicalKey(input.Key)
+		if err != nil {
+			return SuggestChordProgressionsOutput{}, err
+		}
+		key = k
+	} else {
+		k, err := querySongKey(client)
+		if err != nil {
+			return SuggestChordProgressionsOutput{}, wrapActionable(err, "song_key_unavailable",
+				"Pass key (e.g. Bb major) or set it first with ableton_set_song_key.")
+		}
+		key = k
+	}
+	templates, ok := commonProgressionsByMode[key.mode.name]
+	if !ok {
+		return SuggestChordProgressionsOutput{}, errors.New("no progression templates for mode " + key.mode.name)
+	}
+	out := SuggestChordProgressionsOutput{
+		Key:          key.String(),
+		Mode:         key.mode.name,
+		Progressions: make([]SuggestedProgression, 0, len(templates)),
+		Note:         "Numerals follow the key's mode; b/# numerals borrow from the parallel major and X/Y tonicizes Y.",
+	}
+	for _, tpl := range templates {
+		chords := make([]string, 0, 4)
+		for _, numeral := range progressionFields(tpl.numerals) {
+			sym, err := romanToChordSymbol(numeral, key)
+			if err != nil {
+				return SuggestChordProgressionsOutput{}, err
+			}
+			chords = append(chords, sym)
+		}
+		out.Progressions = append(out.Progressions, SuggestedProgression{
+			Name:        tpl.name,
+			Numerals:    tpl.numerals,
+			Progression: strings.Join(chords, " "),
+			Chords:      chords,
+			Feel:        tpl.feel,
+		})
+	}
+	return out, nil
+}
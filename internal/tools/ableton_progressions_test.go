@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMusicalKey(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in       string
+		want     string
+		wantErr  bool
+		wantMode string
+	}{
+		{in: "Bb", want: "Bb major", wantMode: "major"},
+		{in: "A minor", want: "A minor", wantMode: "minor"},
+		{in: "Bbm", want: "Bb minor", wantMode: "minor"},
+		{in: "F# dorian", want: "F# dorian", wantMode: "dorian"},
+		{in: "D minor", want: "D minor", wantMode: "minor"}, // F parent reads in flats
+		{in: "E harmonic minor", want: "E harmonic minor", wantMode: "harmonic minor"},
+		{in: "C blues", wantErr: true},
+		{in: "H", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := parseMusicalKey(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseMusicalKey(%q) expected error", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parseMusicalKey(%q) error = %v", tc.in, err)
+		}
+		if got.String() != tc.want || got.mode.name != tc.wantMode {
+			t.Errorf("parseMusicalKey(%q) = %q (%s), want %q", tc.in, got.String(), got.mode.name, tc.want)
+		}
+	}
+}
+
+func TestRomanToChordSymbol(t *testing.T) {
+	t.Parallel()
+
+	bb, _ := parseMusicalKey("Bb major")
+	c, _ := parseMusicalKey("C")
+	am, _ := parseMusicalKey("A minor")
+	cases := []struct {
+		key  musicalKey
+		in   string
+		want string
+	}{
+		{bb, "ii", "Cm"},
+		{bb, "V", "F"},
+		{bb, "I", "Bb"},
+		{bb, "ii7", "Cm7"},
+		{bb, "V7", "F7"},
+		{bb, "Imaj7", "Bbmaj7"},
+		{c, "bVII", "Bb"},
+		{c, "iv", "Fm"},
+		{c, "bVI", "Ab"},
+		{c, "V/V", "D"},
+		{c, "V7/ii", "A7"},
+		{c, "vii°", "Bdim"},
+		{c, "viiø7", "Bm7b5"},
+		{c, "vii°7/V", "F#dim7"},
+		{am, "i", "Am"},
+		{am, "VI", "F"},
+		{am, "III", "C"},
+		{am, "VII", "G"},
+		{am, "V7", "E7"},
+		{am, "bVI", "F"},
+	}
+	for _, tc := range cases {
+		got, err := romanToChordSymbol(tc.in, tc.key)
+		if err != nil {
+			t.Errorf("romanToChordSymbol(%q, %s) error = %v", tc.in, tc.key, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("romanToChordSymbol(%q, %s) = %q, want %q", tc.in, tc.key, got, tc.want)
+		}
+	}
+	if _, err := romanToChordSymbol("Vxyz", c); err == nil {
+		t.Error("expected error for unsupported suffix")
+	}
+}
+
+func TestParseProgressionInKeyRomanDashes(t *testing.T) {
+	t.Parallel()
+
+	bb, _ := parseMusicalKey("Bb")
+	for _, progression := range []string{"ii–V–I", "ii—V—I", "ii-V-I"} {
+		chords, err := parseProgressionInKey(progression, &bb)
+		if err != nil {
+			t.Fatalf("parseProgressionInKey(%q) error = %v", progression, err)
+		}
+		if len(chords) != 3 || chords[0].name != "Cm" || chords[1].name != "F" || chords[2].name != "Bb" {
+			t.Errorf("%q chords = %+v", progression, chords)
+		}
+	}
+	c, _ := parseMusicalKey("C")
+	for _, tc := range []struct {
+		progression string
+		want        []string
+	}{
+		{"I - IV -", []string{"C", "N.C.", "F", "N.C."}},
+		{"Am - vi", []string{"Am", "N.C.", "Am"}},
+		{"C- ii-V", []string{"C-", "Dm", "G"}},
+	} {
+		chords, err := parseProgressionInKey(tc.progression, &c)
+		if err != nil {
+			t.Fatalf("parseProgressionInKey(%q) error = %v", tc.progression, err)
+		}
+		var names []string
+		for _, ch := range chords {
+			names = append(names, ch.name)
+		}
+		if !reflect.DeepEqual(names, tc.want) {
+			t.Errorf("%q chords = %v, want %v", tc.progression, names, tc.want)
+		}
+	}
+	if _, err := parseProgression("I IV V"); err == nil {
+		t.Error("expected error for Roman numerals without a key")
+	}
+}
+
+func TestBuildChordClipRomanUsesSongKey(t *testing.T) {
+	t.Parallel()
+
+	stub := &recipeClientStub{
+		queries: map[string][]interface{}{
+			"/live/song/get/root_note":     {int32(9)},
+			"/live/song/get/scale_name":    {"Minor"},
+			"/live/clip_slot/get/has_clip": {int32(0), int32(0), int32(1)},
+		},
+	}
+	out, err := buildChordClip(stub, BuildChordClipInput{Progression: "i VI III VII"})
+	if err != nil {
+		t.Fatalf("buildChordClip() error = %v", err)
+	}
+	if out.Key != "A minor" || strings.Join(out.Chords, " ") != "Am F C G" {
+		t.Errorf("key/chords = %q/%v", out.Key, out.Chords)
+	}
+}
+
+func TestBuildChordClipRomanNeedsKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := buildChordClip(&recipeClientStub{}, BuildChordClipInput{Progression: "I V vi IV"})
+	if err == nil || !strings.Contains(err.Error(), "song_key_unavailable") {
+		t.Errorf("error = %v, want song_key_unavailable", err)
+	}
+}
+
+func TestSuggestChordProgressions(t *testing.T) {
+	t.Parallel()
+
+	out, err := suggestChordProgressions(&recipeClientStub{}, SuggestChordProgressionsInput{Key: "D dorian"})
+	if err != nil {
+		t.Fatalf("suggestChordProgressions() error = %v", err)
+	}
+	if out.Mode != "dorian" || len(out.Progressions) == 0 {
+		t.Fatalf("out = %+v", out)
+	}
+	if out.Progressions[0].Progression != "Dm7 G7" {
+		t.Errorf("dorian vamp = %q, want Dm7 G7", out.Progressions[0].Progression)
+	}
+	for mode, templates := range commonProgressionsByMode {
+		key := musicalKey{tonicPC: 0}
+		for _, m := range []modeScale{ionianMode, aeolianMode, dorianMode, phrygianMode, lydianMode, mixolydianMode, locrianMode, harmonicMode, melodicMode} {
+			if m.name == mode {
+				key.mode = m
+			}
+		}
+		for _, tpl := range templates {
+			if _, err := parseProgressionInKey(tpl.numerals, &key); err != nil {
+				t.Errorf("%s %q: %v", mode, tpl.numerals, err)
+			}
+		}
+	}
+}
@@ -29,7 +29,7 @@ type SetupDrumTrackInput struct {
 	TrackName   string   `json:"track_name,omitempty" jsonschema:"description=Optional track name (defaults to loaded kit name)"`
 	ClipIndex   *int     `json:"clip_index,omitempty" jsonschema:"description=Clip slot index (default 0),minimum=0"`
 	LengthBeats float64  `json:"length_beats,omitempty" jsonschema:"description=Clip length in beats (default 16 = 4 bars),minimum=1,maximum=128"`
-	Pattern     string   `json:"pattern,omitempty" jsonschema:"description=Preset pattern: basic_backbeat, four_on_floor, or kick_only (default basic_backbeat)"`
+	Pattern     string   `json:"pattern,omitempty" jsonschema:"description=Pattern name: basic_backbeat, four_on_floor, kick_only, a genre library pattern (boom_bap, trap_hats, uk_garage_2step, dnb_break, house, reggaeton), or one saved with ableton_save_drum_pattern (default basic_backbeat)"`
 	Fire        bool     `json:"fire,omitempty" jsonschema:"description=Fire the clip after setup"`
 }
 
@@ -52,14 +52,15 @@ type drumSetupClient interface {
 
 func NewAbletonSetupDrumTrack(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
 	return genkit.DefineTool(g, "ableton_setup_drum_track",
-		"Ableton Live: create a MIDI drum track, load a kit, and fill a clip with a preset pattern (requires browser patch)",
+		"Ableton Live: create a MIDI drum track, load a kit, and fill a clip with a preset, genre library, or saved pattern (requires browser patch)",
 		func(_ *ai.ToolContext, input SetupDrumTrackInput) (SetupDrumTrackOutput, error) {
-			return setupDrumTrack(client, input)
+			return setupDrumTrack(client, drumPatternDir(), input)
 		},
 	)
 }
 
-func setupDrumTrack(client drumSetupClient, input SetupDrumTrackInput) (SetupDrumTrackOutput, error) {
+// setupDrumTrack looks up saved patterns in patternDir.
+func setupDrumTrack(client drumSetupClient, patternDir string, input SetupDrumTrackInput) (SetupDrumTrackOutput, error) {
 	kitName := strings.TrimSpace(input.KitName)
 	rootName := strings.TrimSpace(input.RootName)
 	itemName := strings.TrimSpace(input.ItemName)
@@ -101,7 +102,7 @@ func setupDrumTrack(client drumSetupClient, input SetupDrumTrackInput) (SetupDru
 	if pattern == "" {
 		pattern = defaultDrumPattern
 	}
-	notes, err := buildDrumPattern(pattern, lengthBeats)
+	notes, err := buildDrumPattern(patternDir, pattern, lengthBeats)
 	if err != nil {
 		return SetupDrumTrackOutput{}, err
 	}
@@ -223,7 +224,7 @@ func setupDrumTrack(client drumSetupClient, input SetupDrumTrackInput) (SetupDru
 	}, nil
 }
 
-func buildDrumPattern(pattern string, lengthBeats float64) ([]MidiNote, error) {
+func buildDrumPattern(patternDir, pattern string, lengthBeats float64) ([]MidiNote, error) {
 	bars := int(lengthBeats / 4)
 	if bars < 1 {
 		bars = 1
@@ -239,7 +240,11 @@ func buildDrumPattern(pattern string, lengthBeats float64) ([]MidiNote, error) {
 	case "kick_only":
 		return drumNotesForBars(bars, end, []float64{0, 1, 2, 3}, nil, 0), nil
 	default:
-		return nil, fmt.Errorf("unsupported pattern %q (use basic_backbeat, four_on_floor, or kick_only)", pattern)
+		p, err := findDrumPattern(patternDir, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported pattern %q (use basic_backbeat, four_on_floor, kick_only, or a name from ableton_list_drum_patterns): %w", pattern, err)
+		}
+		return tileDrumPattern(p, end), nil
 	}
 }
 
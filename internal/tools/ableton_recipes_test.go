@@ -50,7 +50,7 @@ func TestBuildDrumPattern(t *testing.T) {
 
 	t.Run("basic_backbeat_one_bar", func(t *testing.T) {
 		t.Parallel()
-		notes, err := buildDrumPattern("basic_backbeat", 4)
+		notes, err := buildDrumPattern(t.TempDir(), "basic_backbeat", 4)
 		if err != nil {
 			t.Fatalf("buildDrumPattern() error = %v", err)
 		}
@@ -67,7 +67,7 @@ func TestBuildDrumPattern(t *testing.T) {
 
 	t.Run("four_on_floor_two_bars", func(t *testing.T) {
 		t.Parallel()
-		notes, err := buildDrumPattern("four_on_floor", 8)
+		notes, err := buildDrumPattern(t.TempDir(), "four_on_floor", 8)
 		if err != nil {
 			t.Fatalf("buildDrumPattern() error = %v", err)
 		}
@@ -78,7 +78,7 @@ func TestBuildDrumPattern(t *testing.T) {
 
 	t.Run("kick_only", func(t *testing.T) {
 		t.Parallel()
-		notes, err := buildDrumPattern("kick_only", 4)
+		notes, err := buildDrumPattern(t.TempDir(), "kick_only", 4)
 		if err != nil {
 			t.Fatalf("buildDrumPattern() error = %v", err)
 		}
@@ -92,7 +92,7 @@ func TestBuildDrumPattern(t *testing.T) {
 
 	t.Run("unsupported", func(t *testing.T) {
 		t.Parallel()
-		_, err := buildDrumPattern("latin", 16)
+		_, err := buildDrumPattern(t.TempDir(), "latin", 16)
 		if err == nil {
 			t.Fatal("buildDrumPattern() error = nil, want error")
 		}
@@ -110,7 +110,7 @@ func TestSetupDrumTrackByKitName(t *testing.T) {
 		},
 	}
 
-	got, err := setupDrumTrack(client, SetupDrumTrackInput{
+	got, err := setupDrumTrack(client, t.TempDir(), SetupDrumTrackInput{
 		KitName: "Street Kit",
 		Pattern: "kick_only",
 		Fire:    true,
@@ -159,7 +159,7 @@ func TestSetupDrumTrackByPath(t *testing.T) {
 		},
 	}
 	clipIndex := 1
-	got, err := setupDrumTrack(client, SetupDrumTrackInput{
+	got, err := setupDrumTrack(client, t.TempDir(), SetupDrumTrackInput{
 		RootName:    "Drums",
 		PathParts:   []string{"Kits"},
 		ItemName:    "Core Kit",
@@ -188,12 +188,12 @@ func TestSetupDrumTrackByPath(t *testing.T) {
 func TestSetupDrumTrackValidation(t *testing.T) {
 	t.Parallel()
 
-	_, err := setupDrumTrack(&recipeClientStub{}, SetupDrumTrackInput{})
+	_, err := setupDrumTrack(&recipeClientStub{}, t.TempDir(), SetupDrumTrackInput{})
 	if err == nil || !strings.Contains(err.Error(), "either kit_name or root_name") {
 		t.Fatalf("setupDrumTrack() error = %v, want mutual exclusion error", err)
 	}
 
-	_, err = setupDrumTrack(&recipeClientStub{}, SetupDrumTrackInput{
+	_, err = setupDrumTrack(&recipeClientStub{}, t.TempDir(), SetupDrumTrackInput{
 		KitName:  "Street Kit",
 		RootName: "Drums",
 		ItemName: "Street Kit",
@@ -9,6 +9,7 @@ import (
 	"github.com/firebase/genkit/go/genkit"
 
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
 )
 
 const defaultSceneEnergyVelocityDelta = 12
@@ -16,21 +17,24 @@ const defaultSceneEnergyVelocityDelta = 12
 type CreateSceneEnergyVariationInput struct {
 	SourceSceneIndex int    `json:"source_scene_index" jsonschema:"minimum=0"`
 	TrackIndices     []int  `json:"track_indices" jsonschema:"description=MIDI tracks whose clips in the source scene will change, minItems=1"`
-	Variation        string `json:"variation" jsonschema:"description=One change only: lift or pullback"`
+	Variation        string `json:"variation,omitempty" jsonschema:"description=One change only: lift or pullback (may be omitted with use_taste)"`
 	VelocityDelta    *int   `json:"velocity_delta,omitempty" jsonschema:"description=Velocity change in MIDI units (default 12),minimum=1,maximum=30"`
 	Fire             bool   `json:"fire,omitempty" jsonschema:"description=Fire the duplicated B scene after creating it"`
+	UseTaste         bool   `json:"use_taste,omitempty" jsonschema:"description=Pick an omitted variation and velocity_delta from the recorded taste profile"`
+	TasteUser        string `json:"taste_user,omitempty" jsonschema:"description=Taste user whose choices use_taste reads (default: the session user)"`
 }
 
 type CreateSceneEnergyVariationOutput struct {
-	SourceSceneIndex int    `json:"source_scene_index"`
-	TargetSceneIndex int    `json:"target_scene_index" jsonschema:"description=Duplicated B scene, inserted directly after the source scene"`
-	Variation        string `json:"variation"`
-	VelocityDelta    int    `json:"velocity_delta"`
-	TracksChanged    []int  `json:"tracks_changed"`
-	TracksSkipped    []int  `json:"tracks_skipped,omitempty"`
-	NotesChanged     int    `json:"notes_changed"`
-	Fired            bool   `json:"fired"`
-	PreferencePrompt string `json:"preference_prompt"`
+	SourceSceneIndex int          `json:"source_scene_index"`
+	TargetSceneIndex int          `json:"target_scene_index" jsonschema:"description=Duplicated B scene, inserted directly after the source scene"`
+	Variation        string       `json:"variation"`
+	VelocityDelta    int          `json:"velocity_delta"`
+	TracksChanged    []int        `json:"tracks_changed"`
+	TracksSkipped    []int        `json:"tracks_skipped,omitempty"`
+	NotesChanged     int          `json:"notes_changed"`
+	Fired            bool         `json:"fired"`
+	PreferencePrompt string       `json:"preference_prompt"`
+	Taste            *TasteChoice `json:"taste,omitempty" jsonschema:"description=Why use_taste picked these parameters"`
 }
 
 type sceneVariationTrack struct {
@@ -38,11 +42,11 @@ type sceneVariationTrack struct {
 	Notes []MidiNote
 }
 
-func NewAbletonCreateSceneEnergyVariation(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+func NewAbletonCreateSceneEnergyVariation(g *genkit.Genkit, client *abletonosc.Client, users *taste.Users) ai.Tool {
 	return genkit.DefineTool(g, "ableton_create_scene_energy_variation",
-		"Ableton Live: create only a scene energy A/B variation (lift or pullback) — prefer ableton_compare_ab_variation when you also want to audition",
+		"Ableton Live: create only a scene energy A/B variation (lift or pullback); use_taste picks the variation and velocity change from past A/B choices — prefer ableton_compare_ab_variation when you also want to audition",
 		func(_ *ai.ToolContext, input CreateSceneEnergyVariationInput) (CreateSceneEnergyVariationOutput, error) {
-			return createSceneEnergyVariation(client, input)
+			return createSceneEnergyVariationWithTaste(client, tasteLoaderFor(users, input.TasteUser), input)
 		},
 	)
 }
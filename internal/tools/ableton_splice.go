@@ -15,31 +15,48 @@ import (
 
 type SpliceLibrarySettings struct {
 	ConfiguredPath string
+	// LibrariesFile lists extra named library roots searched with Splice.
+	LibrariesFile string
+	// Index keeps the library index between searches; nil indexes per call.
+	Index *splice.Indexer
 }
 
 type GetSpliceLibraryOutput struct {
-	Path   string `json:"path,omitempty"`
-	Source string `json:"source"`
-	Exists bool   `json:"exists"`
-	Hint   string `json:"hint,omitempty"`
-	Note   string `json:"note"`
+	Path          string        `json:"path,omitempty"`
+	Source        string        `json:"source"`
+	Exists        bool          `json:"exists"`
+	Hint          string        `json:"hint,omitempty"`
+	LibrariesFile string        `json:"libraries_file,omitempty"`
+	Libraries     []LibraryInfo `json:"libraries"`
+	Note          string        `json:"note"`
 }
 
 type SearchSpliceSamplesInput struct {
-	Query      string `json:"query,omitempty" jsonschema:"description=Substring match against sample name or relative path"`
-	MaxResults *int   `json:"max_results,omitempty" jsonschema:"description=Max matches to return (default 20, max 50),minimum=1,maximum=50"`
+	Library        string   `json:"library,omitempty" jsonschema:"description=Search only this library (splice or a name from the libraries file); default searches all"`
+	Query          string   `json:"query,omitempty" jsonschema:"description=Words matched against file and folder names (exact, prefix, or one typo for 4+ letters); all words must match"`
+	BPMMin         float64  `json:"bpm_min,omitempty" jsonschema:"description=Only samples whose name states a BPM >= this,minimum=0,maximum=400"`
+	BPMMax         float64  `json:"bpm_max,omitempty" jsonschema:"description=Only samples whose name states a BPM <= this,minimum=0,maximum=400"`
+	Key            string   `json:"key,omitempty" jsonschema:"description=Key from the name, e.g. Am, F#m, Bb or C major; a bare tonic matches major and minor"`
+	Kind           string   `json:"kind,omitempty" jsonschema:"description=loop or one_shot"`
+	Tags           []string `json:"tags,omitempty" jsonschema:"description=Instrument tags that must all be present (kick, snare, clap, hat, perc, drums, bass, synth, pad, keys, guitar, vocal, fx, chords, strings, brass)"`
+	MinDurationSec float64  `json:"min_duration_sec,omitempty" jsonschema:"minimum=0"`
+	MaxDurationSec float64  `json:"max_duration_sec,omitempty" jsonschema:"minimum=0"`
+	Refresh        bool     `json:"refresh,omitempty" jsonschema:"description=Re-scan the library now (otherwise the index refreshes when older than 10 minutes)"`
+	MaxResults     *int     `json:"max_results,omitempty" jsonschema:"description=Max matches to return (default 20, max 50),minimum=1,maximum=50"`
 }
 
 type SearchSpliceSamplesOutput struct {
-	LibraryPath string          `json:"library_path"`
-	Query       string          `json:"query,omitempty"`
-	Samples     []splice.Sample `json:"samples"`
-	Note        string          `json:"note"`
+	Libraries []LibraryIndexStats `json:"libraries"`
+	Query     string              `json:"query,omitempty"`
+	Samples   []splice.Sample     `json:"samples"`
+	Warnings  []string            `json:"warnings,omitempty"`
+	Note      string              `json:"note"`
 }
 
 type LoadSpliceSampleInput struct {
 	AbsolutePath string `json:"absolute_path,omitempty" jsonschema:"description=Absolute path from ableton_search_splice_samples"`
-	RelativePath string `json:"relative_path,omitempty" jsonschema:"description=Path relative to the resolved Splice library root"`
+	RelativePath string `json:"relative_path,omitempty" jsonschema:"description=Path relative to the library root"`
+	Library      string `json:"library,omitempty" jsonschema:"description=Library that relative_path belongs to (default splice)"`
 	TrackIndex   int    `json:"track_index" jsonschema:"description=Destination audio track index,minimum=0"`
 	ClipIndex    int    `json:"clip_index" jsonschema:"description=Session clip slot index,minimum=0"`
 	Fire         bool   `json:"fire,omitempty" jsonschema:"description=Fire the clip after loading"`
@@ -60,18 +77,18 @@ type spliceLoadClient interface {
 
 func NewAbletonGetSpliceLibrary(g *genkit.Genkit, settings SpliceLibrarySettings) ai.Tool {
 	return genkit.DefineTool(g, "ableton_get_splice_library",
-		"Locate the local Splice content folder (synced downloads only; does not call the Splice cloud API)",
+		"Locate the local Splice content folder (synced downloads only; does not call the Splice cloud API) and list the other sample libraries configured in the libraries file",
 		func(_ *ai.ToolContext, _ EmptyInput) (GetSpliceLibraryOutput, error) {
-			return getSpliceLibrary(settings.ConfiguredPath), nil
+			return getSpliceLibrary(settings), nil
 		},
 	)
 }
 
 func NewAbletonSearchSpliceSamples(g *genkit.Genkit, settings SpliceLibrarySettings) ai.Tool {
 	return genkit.DefineTool(g, "ableton_search_splice_samples",
-		"Search audio files under the local Splice library folder (already downloaded/synced samples only)",
+		"Search audio files under the local Splice library folder (already downloaded/synced samples only) and any other configured library roots through a persistent index. Fuzzy word query plus filters for BPM range, key, loop/one_shot, instrument tags and duration, all parsed from file and folder names (e.g. _120_Am_). Each result names its library.",
 		func(_ *ai.ToolContext, input SearchSpliceSamplesInput) (SearchSpliceSamplesOutput, error) {
-			return searchSpliceSamples(settings.ConfiguredPath, input)
+			return searchSpliceSamples(settings, input)
 		},
 	)
 }
@@ -80,32 +97,41 @@ func NewAbletonLoadSpliceSample(g *genkit.Genkit, client *abletonosc.Client, set
 	return genkit.DefineTool(g, "ableton_load_splice_sample",
 		"Load a local Splice audio file into an empty session clip slot on an audio track (requires Live 12.0.5+ and the AbletonOSC browser patch)",
 		func(_ *ai.ToolContext, input LoadSpliceSampleInput) (LoadSpliceSampleOutput, error) {
-			return loadSpliceSample(client, settings.ConfiguredPath, input)
+			return loadSpliceSample(client, settings, input)
 		},
 	)
 }
 
-func getSpliceLibrary(configured string) GetSpliceLibraryOutput {
-	lib, err := splice.Resolve(configured)
+func getSpliceLibrary(settings SpliceLibrarySettings) GetSpliceLibraryOutput {
+	out := GetSpliceLibraryOutput{LibrariesFile: settings.LibrariesFile}
+	libs, libsErr := configuredLibraries(settings)
+	out.Libraries = libs
+	if out.Libraries == nil {
+		out.Libraries = []LibraryInfo{}
+	}
+	lib, err := splice.Resolve(settings.ConfiguredPath)
+	out.Path, out.Source = lib.Path, lib.Source
 	if err != nil {
-		return GetSpliceLibraryOutput{
-			Path:   lib.Path,
-			Source: lib.Source,
-			Exists: false,
-			Hint:   err.Error(),
-			Note:   "Cloud Splice search/download is not supported. Sync samples in the Splice desktop app, then point ABLETON_OSC_SPLICE_PATH at that folder if auto-detect fails.",
-		}
+		out.Hint = err.Error()
+		out.Note = "Cloud Splice search/download is not supported. Sync samples in the Splice desktop app, then point ABLETON_OSC_SPLICE_PATH at that folder if auto-detect fails."
+	} else {
+		out.Exists = true
+		out.Note = "Local synced Splice library only. Use ableton_search_splice_samples, then ableton_load_splice_sample on an audio track."
 	}
-	return GetSpliceLibraryOutput{
-		Path:   lib.Path,
-		Source: lib.Source,
-		Exists: true,
-		Note:   "Local synced Splice library only. Use ableton_search_splice_samples, then ableton_load_splice_sample on an audio track.",
+	if libsErr != nil {
+		out.Hint = strings.TrimPrefix(out.Hint+"; "+libsErr.Error(), "; ")
 	}
+	return out
 }
 
-func searchSpliceSamples(configured string, input SearchSpliceSamplesInput) (SearchSpliceSamplesOutput, error) {
-	lib, err := splice.Resolve(configured)
+func searchSpliceSamples(settings SpliceLibrarySettings, input SearchSpliceSamplesInput) (SearchSpliceSamplesOutput, error) {
+	if input.BPMMin > 0 && input.BPMMax > 0 && input.BPMMin > input.BPMMax {
+		return SearchSpliceSamplesOutput{}, errors.New("bpm_min must be <= bpm_max")
+	}
+	if input.MinDurationSec > 0 && input.MaxDurationSec > 0 && input.MinDurationSec > input.MaxDurationSec {
+		return SearchSpliceSamplesOutput{}, errors.New("min_duration_sec must be <= max_duration_sec")
+	}
+	catalog, stats, warnings, err := loadCatalog(settings, input.Library, input.Refresh)
 	if err != nil {
 		return SearchSpliceSamplesOutput{}, err
 	}
@@ -113,26 +139,37 @@ func searchSpliceSamples(configured string, input SearchSpliceSamplesInput) (Sea
 	if input.MaxResults != nil {
 		maxResults = *input.MaxResults
 	}
-	samples, err := splice.Search(lib.Path, input.Query, maxResults)
+	samples, err := catalog.Search(splice.Query{
+		Text:           input.Query,
+		BPMMin:         input.BPMMin,
+		BPMMax:         input.BPMMax,
+		Key:            input.Key,
+		Kind:           input.Kind,
+		Tags:           input.Tags,
+		MinDurationSec: input.MinDurationSec,
+		MaxDurationSec: input.MaxDurationSec,
+		MaxResults:     maxResults,
+	})
 	if err != nil {
 		return SearchSpliceSamplesOutput{}, err
 	}
 	return SearchSpliceSamplesOutput{
-		LibraryPath: lib.Path,
-		Query:       strings.TrimSpace(input.Query),
-		Samples:     samples,
-		Note:        "These files are already on disk. Load one with ableton_load_splice_sample (audio track, Live 12.0.5+).",
+		Libraries: stats,
+		Query:     strings.TrimSpace(input.Query),
+		Samples:   samples,
+		Warnings:  warnings,
+		Note:      "These files are already on disk. BPM/key/kind/tags come from file and folder names; confirm with ableton_analyze_local_audio if needed. Load one with ableton_load_splice_sample (audio track, Live 12.0.5+).",
 	}, nil
 }
 
-func loadSpliceSample(client spliceLoadClient, configured string, input LoadSpliceSampleInput) (LoadSpliceSampleOutput, error) {
+func loadSpliceSample(client spliceLoadClient, settings SpliceLibrarySettings, input LoadSpliceSampleInput) (LoadSpliceSampleOutput, error) {
 	if input.TrackIndex < 0 {
 		return LoadSpliceSampleOutput{}, errors.New("track_index must be >= 0")
 	}
 	if input.ClipIndex < 0 {
 		return LoadSpliceSampleOutput{}, errors.New("clip_index must be >= 0")
 	}
-	abs, err := resolveSpliceSamplePath(configured, input.AbsolutePath, input.RelativePath)
+	abs, err := resolveSpliceSamplePath(settings, input.Library, input.AbsolutePath, input.RelativePath)
 	if err != nil {
 		return LoadSpliceSampleOutput{}, err
 	}
@@ -185,7 +222,9 @@ func querySpliceSlotHasClip(client spliceLoadClient, trackIndex, clipIndex int)
 	return abletonosc.AsBool(res[2])
 }
 
-func resolveSpliceSamplePath(configured, absolutePath, relativePath string) (string, error) {
+// resolveSpliceSamplePath returns absolutePath, or relativePath joined onto
+// the named library root (Splice when library is empty).
+func resolveSpliceSamplePath(settings SpliceLibrarySettings, library, absolutePath, relativePath string) (string, error) {
 	absolutePath = strings.TrimSpace(absolutePath)
 	relativePath = strings.TrimSpace(relativePath)
 	if absolutePath == "" && relativePath == "" {
@@ -197,17 +236,21 @@ func resolveSpliceSamplePath(configured, absolutePath, relativePath string) (str
 		}
 		return filepath.Clean(absolutePath), nil
 	}
-	lib, err := splice.Resolve(configured)
+	if relativePath == "." {
+		return "", errors.New("relative_path is empty")
+	}
+	if strings.TrimSpace(library) == "" {
+		library = splice.SpliceLibraryName
+	}
+	roots, _, err := resolveLibraries(settings, library)
 	if err != nil {
 		return "", err
 	}
-	if relativePath == "" || relativePath == "." {
-		return "", errors.New("relative_path is empty")
-	}
-	abs := filepath.Clean(filepath.Join(lib.Path, filepath.FromSlash(relativePath)))
-	rel, err := filepath.Rel(lib.Path, abs)
+	root := roots[0].Path
+	abs := filepath.Clean(filepath.Join(root, filepath.FromSlash(relativePath)))
+	rel, err := filepath.Rel(root, abs)
 	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
-		return "", errors.New("relative_path must stay inside the Splice library")
+		return "", fmt.Errorf("relative_path must stay inside the %s library", roots[0].Name)
 	}
 	return abs, nil
 }
@@ -39,7 +39,7 @@ func (s *spliceStub) Send(address string, _ ...interface{}) error {
 func TestGetSpliceLibraryMissing(t *testing.T) {
 	t.Parallel()
 
-	got := getSpliceLibrary(filepath.Join(t.TempDir(), "nope"))
+	got := getSpliceLibrary(SpliceLibrarySettings{ConfiguredPath: filepath.Join(t.TempDir(), "nope")})
 	if got.Exists || got.Source != "env" {
 		t.Errorf("got = %#v", got)
 	}
@@ -54,7 +54,7 @@ func TestSearchSpliceSamples(t *testing.T) {
 		t.Fatal(err)
 	}
 	max := 5
-	got, err := searchSpliceSamples(root, SearchSpliceSamplesInput{Query: "kick", MaxResults: &max})
+	got, err := searchSpliceSamples(SpliceLibrarySettings{ConfiguredPath: root}, SearchSpliceSamplesInput{Query: "kick", MaxResults: &max})
 	if err != nil {
 		t.Fatalf("searchSpliceSamples() error = %v", err)
 	}
@@ -72,7 +72,7 @@ func TestLoadSpliceSample(t *testing.T) {
 		t.Fatal(err)
 	}
 	client := &spliceStub{}
-	got, err := loadSpliceSample(client, root, LoadSpliceSampleInput{
+	got, err := loadSpliceSample(client, SpliceLibrarySettings{ConfiguredPath: root}, LoadSpliceSampleInput{
 		AbsolutePath: abs,
 		TrackIndex:   0,
 		ClipIndex:    0,
@@ -90,7 +90,7 @@ func TestLoadSpliceSampleRejectsOccupiedSlot(t *testing.T) {
 	t.Parallel()
 
 	client := &spliceStub{hasClip: true}
-	_, err := loadSpliceSample(client, t.TempDir(), LoadSpliceSampleInput{
+	_, err := loadSpliceSample(client, SpliceLibrarySettings{ConfiguredPath: t.TempDir()}, LoadSpliceSampleInput{
 		AbsolutePath: "/tmp/x.wav",
 		TrackIndex:   0,
 		ClipIndex:    1,
@@ -106,7 +106,7 @@ func TestLoadSpliceSampleUnsupportedLive(t *testing.T) {
 	client := &spliceStub{
 		reply: []interface{}{int32(0), int32(0), "unsupported", "create_audio_clip requires Ableton Live 12.0.5+"},
 	}
-	_, err := loadSpliceSample(client, t.TempDir(), LoadSpliceSampleInput{
+	_, err := loadSpliceSample(client, SpliceLibrarySettings{ConfiguredPath: t.TempDir()}, LoadSpliceSampleInput{
 		AbsolutePath: "/tmp/x.wav",
 		TrackIndex:   0,
 		ClipIndex:    0,
@@ -120,7 +120,7 @@ func TestResolveSpliceSampleRelativePath(t *testing.T) {
 	t.Parallel()
 
 	root := t.TempDir()
-	got, err := resolveSpliceSamplePath(root, "", "sounds/kick.wav")
+	got, err := resolveSpliceSamplePath(SpliceLibrarySettings{ConfiguredPath: root}, "", "", "sounds/kick.wav")
 	if err != nil {
 		t.Fatalf("resolveSpliceSamplePath() error = %v", err)
 	}
@@ -128,12 +128,67 @@ func TestResolveSpliceSampleRelativePath(t *testing.T) {
 	if got != want {
 		t.Errorf("got %q want %q", got, want)
 	}
-	_, err = resolveSpliceSamplePath(root, "", "../escape.wav")
+	_, err = resolveSpliceSamplePath(SpliceLibrarySettings{ConfiguredPath: root}, "", "", "../escape.wav")
 	if err == nil {
 		t.Fatal("expected path escape error")
 	}
 }
 
+func TestSearchSpliceSamplesAcrossLibraries(t *testing.T) {
+	t.Parallel()
+
+	spliceDir, packsDir, cfgDir := t.TempDir(), t.TempDir(), t.TempDir()
+	for _, p := range []string{
+		filepath.Join(spliceDir, "Loops", "bass_loop_124_Am.wav"),
+		filepath.Join(packsDir, "Deep", "bass_loop_122_Am.wav"),
+		filepath.Join(packsDir, "_old", "bass_loop_90_Am.wav"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	librariesFile := filepath.Join(cfgDir, "libraries.json")
+	body := `{"libraries":[` +
+		`{"name":"packs","path":"` + filepath.ToSlash(packsDir) + `","exclude":["_old"]},` +
+		`{"name":"usb","path":"` + filepath.ToSlash(filepath.Join(cfgDir, "unplugged")) + `"}]}`
+	if err := os.WriteFile(librariesFile, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	settings := SpliceLibrarySettings{ConfiguredPath: spliceDir, LibrariesFile: librariesFile}
+
+	got, err := searchSpliceSamples(settings, SearchSpliceSamplesInput{Query: "bass"})
+	if err != nil {
+		t.Fatalf("searchSpliceSamples() error = %v", err)
+	}
+	if len(got.Samples) != 2 || got.Samples[0].Library != "packs" || got.Samples[1].Library != "splice" {
+		t.Errorf("samples = %#v", got.Samples)
+	}
+	if len(got.Libraries) != 2 || len(got.Warnings) != 1 {
+		t.Errorf("libraries = %#v, warnings = %v", got.Libraries, got.Warnings)
+	}
+
+	got, err = searchSpliceSamples(settings, SearchSpliceSamplesInput{Query: "bass", Library: "packs"})
+	if err != nil || len(got.Samples) != 1 || got.Samples[0].RelativePath != "Deep/bass_loop_122_Am.wav" {
+		t.Errorf("library filter: got = %#v, err = %v", got.Samples, err)
+	}
+	if _, err := searchSpliceSamples(settings, SearchSpliceSamplesInput{Library: "nope"}); err == nil {
+		t.Error("expected unknown library error")
+	}
+
+	abs, err := resolveSpliceSamplePath(settings, "packs", "", "Deep/bass_loop_122_Am.wav")
+	if err != nil || abs != filepath.Join(packsDir, "Deep", "bass_loop_122_Am.wav") {
+		t.Errorf("resolve in packs = %q, %v", abs, err)
+	}
+
+	info := getSpliceLibrary(settings)
+	if !info.Exists || len(info.Libraries) != 3 || info.Libraries[2].Exists {
+		t.Errorf("getSpliceLibrary() = %#v", info)
+	}
+}
+
 func TestParseCreateAudioClipResponse(t *testing.T) {
 	t.Parallel()
 
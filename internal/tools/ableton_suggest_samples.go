@@ -0,0 +1,309 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/splice"
+)
+
+const (
+	defaultSuggestResults   = 10
+	maxSuggestResults       = 50
+	defaultMaxStretchPct    = 10.0
+	defaultMaxTranspose     = 6
+	suggestKeyWeight        = 0.6
+	suggestTempoWeight      = 0.4
+	keylessKeyScore         = 0.7  // drums and FX without a key fit any key
+	relativeKeyScore        = 0.95 // relative keys share notes but shift the tonal centre
+	unknownTempoScore       = 0.5  // loops whose name states no BPM
+	transposePenaltyPerSemi = 0.06
+)
+
+type SuggestSamplesInput struct {
+	Query         string   `json:"query,omitempty" jsonschema:"description=Optional words matched against sample file and folder names (e.g. bass, vocal chop)"`
+	Library       string   `json:"library,omitempty" jsonschema:"description=Suggest only from this library (splice or a name from the libraries file); default uses all"`
+	Kind          string   `json:"kind,omitempty" jsonschema:"description=loop or one_shot"`
+	Tags          []string `json:"tags,omitempty" jsonschema:"description=Instrument tags that must all be present (kick, bass, pad, vocal...)"`
+	Key           string   `json:"key,omitempty" jsonschema:"description=Override the project key (e.g. A minor); default reads the song root note and scale from Live"`
+	Tempo         *float64 `json:"tempo,omitempty" jsonschema:"description=Override the project tempo; default reads it from Live,minimum=20,maximum=400"`
+	MaxStretchPct float64  `json:"max_stretch_pct,omitempty" jsonschema:"description=Largest warp stretch allowed for loops in percent (default 10),minimum=1,maximum=50"`
+	MaxTranspose  *int     `json:"max_transpose,omitempty" jsonschema:"description=Largest transpose in semitones a suggestion may need (default 6),minimum=0,maximum=6"`
+	RequireKey    bool     `json:"require_key,omitempty" jsonschema:"description=Skip samples whose names state no key (drop drums/FX)"`
+	MaxResults    int      `json:"max_results,omitempty" jsonschema:"description=Suggestions to return (default 10, max 50),minimum=1,maximum=50"`
+}
+
+// SampleSuggestion is a library sample ranked for the current project.
+type SampleSuggestion struct {
+	splice.Sample
+	Score              float64 `json:"score" jsonschema:"description=0..1; 60% key fit, 40% tempo fit"`
+	KeyRelation        string  `json:"key_relation" jsonschema:"description=same, relative, fifths_N (N steps on the circle of fifths before transposing) or keyless"`
+	FifthsDistance     int     `json:"fifths_distance,omitempty"`
+	TransposeSemitones int     `json:"transpose_semitones" jsonschema:"description=Coarse pitch for ableton_set_clip_pitch that lands the sample on the project key or its relative"`
+	TempoRelation      string  `json:"tempo_relation" jsonschema:"description=same, half_time, double_time, unknown or none (one-shot)"`
+	StretchPct         float64 `json:"stretch_pct,omitempty" jsonschema:"description=Warp stretch needed at the chosen tempo relation, in percent"`
+}
+
+type SuggestSamplesOutput struct {
+	Libraries   []string           `json:"libraries"`
+	Warnings    []string           `json:"warnings,omitempty"`
+	ProjectKey  string             `json:"project_key"`
+	Tempo       float64            `json:"tempo"`
+	Considered  int                `json:"considered"`
+	Suggestions []SampleSuggestion `json:"suggestions"`
+	NextStep    string             `json:"next_step"`
+}
+
+func NewAbletonSuggestSamples(g *genkit.Genkit, client *abletonosc.Client, settings SpliceLibrarySettings) ai.Tool {
+	return genkit.DefineTool(g, "ableton_suggest_samples",
+		"Rank indexed library samples (Splice plus configured library roots) for the current project: reads song tempo and root/scale from Live, scores each sample by key fit (same or relative key, circle-of-fifths distance, transpose needed) and tempo fit (within warp range, or half/double time), and returns the transpose in semitones for ableton_set_clip_pitch. Key and BPM come from sample file names.",
+		func(_ *ai.ToolContext, input SuggestSamplesInput) (SuggestSamplesOutput, error) {
+			return suggestSamples(client, settings, input)
+		},
+	)
+}
+
+func suggestSamples(client chordClipClient, settings SpliceLibrarySettings, input SuggestSamplesInput) (SuggestSamplesOutput, error) {
+	maxResults := input.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultSuggestResults
+	}
+	if maxResults > maxSuggestResults {
+		return SuggestSamplesOutput{}, fmt.Errorf("max_results must be <= %d", maxSuggestResults)
+	}
+	maxStretch := input.MaxStretchPct
+	if maxStretch <= 0 {
+		maxStretch = defaultMaxStretchPct
+	}
+	if maxStretch > 50 {
+		return SuggestSamplesOutput{}, errors.New("max_stretch_pct must be <= 50")
+	}
+	maxTranspose := defaultMaxTranspose
+	if input.MaxTranspose != nil {
+		maxTranspose = *input.MaxTranspose
+	}
+	if maxTranspose < 0 || maxTranspose > 6 {
+		return SuggestSamplesOutput{}, errors.New("max_transpose must be 0-6")
+	}
+
+	var key musicalKey
+	var err error
+	if strings.TrimSpace(input.Key) != "" {
+		key, err = parseMusicalKey(input.Key)
+	} else {
+		key, err = querySongKey(client)
+	}
+	if err != nil {
+		return SuggestSamplesOutput{}, err
+	}
+	tempo := 0.0
+	if input.Tempo != nil {
+		tempo = *input.Tempo
+	} else {
+		tempo, err = querySongTempo(client)
+		if err != nil {
+			return SuggestSamplesOutput{}, err
+		}
+	}
+	if tempo <= 0 {
+		return SuggestSamplesOutput{}, errors.New("tempo must be > 0")
+	}
+
+	catalog, stats, warnings, err := loadCatalog(settings, input.Library, false)
+	if err != nil {
+		return SuggestSamplesOutput{}, err
+	}
+	candidates, err := catalog.Filter(splice.Query{Text: input.Query, Kind: input.Kind, Tags: input.Tags})
+	if err != nil {
+		return SuggestSamplesOutput{}, err
+	}
+
+	projectMinor := modeIsMinor(key.mode)
+	var suggestions []SampleSuggestion
+	for _, sample := range candidates {
+		if input.RequireKey && sample.Key == "" {
+			continue
+		}
+		s, ok := rankSample(sample, key.tonicPC, projectMinor, tempo, maxStretch, maxTranspose)
+		if ok {
+			suggestions = append(suggestions, s)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if suggestions[i].Library != suggestions[j].Library {
+			return suggestions[i].Library < suggestions[j].Library
+		}
+		return suggestions[i].RelativePath < suggestions[j].RelativePath
+	})
+	considered := len(candidates)
+	if len(suggestions) > maxResults {
+		suggestions = suggestions[:maxResults]
+	}
+	if suggestions == nil {
+		suggestions = []SampleSuggestion{}
+	}
+	scaleName := "major"
+	if projectMinor {
+		scaleName = "minor"
+	}
+	libraries := make([]string, len(stats))
+	for i, st := range stats {
+		libraries[i] = st.Name
+	}
+	return SuggestSamplesOutput{
+		Libraries:   libraries,
+		Warnings:    warnings,
+		ProjectKey:  key.tonicName() + " " + scaleName,
+		Tempo:       tempo,
+		Considered:  considered,
+		Suggestions: suggestions,
+		NextStep:    "Load a suggestion with ableton_load_splice_sample, then call ableton_set_clip_pitch with coarse=transpose_semitones and ableton_match_clip_tempo to warp it.",
+	}, nil
+}
+
+// rankSample scores sample against the project key and tempo. It rejects
+// loops that need more than maxStretch percent of warping and keyed samples
+// that need more than maxTranspose semitones.
+func rankSample(sample splice.Sample, projectPC int, projectMinor bool, tempo, maxStretch float64, maxTranspose int) (SampleSuggestion, bool) {
+	out := SampleSuggestion{Sample: sample}
+
+	keyScore := keylessKeyScore
+	out.KeyRelation = "keyless"
+	if sample.Key != "" {
+		samplePC := pitchClassIndex(sample.Key)
+		if samplePC < 0 {
+			return SampleSuggestion{}, false
+		}
+		sampleMinor := sample.Scale == "minor"
+		transpose, fifths := keyFit(samplePC, sampleMinor, projectPC, projectMinor)
+		semis := math.Abs(float64(transpose))
+		if semis > float64(maxTranspose) {
+			return SampleSuggestion{}, false
+		}
+		out.TransposeSemitones = transpose
+		out.FifthsDistance = fifths
+		switch {
+		case fifths == 0 && sampleMinor == projectMinor:
+			out.KeyRelation = "same"
+		case fifths == 0:
+			out.KeyRelation = "relative"
+		default:
+			out.KeyRelation = fmt.Sprintf("fifths_%d", fifths)
+		}
+		// Untransposed neighbours on the circle blend; otherwise pay per
+		// semitone of transposition artefacts.
+		keyScore = math.Max(1-0.25*float64(fifths), 1-transposePenaltyPerSemi*semis)
+		if out.KeyRelation == "relative" {
+			keyScore = relativeKeyScore
+		}
+	}
+
+	tempoScore := 1.0
+	switch {
+	case sample.Kind == splice.KindOneShot && sample.BPM == 0:
+		out.TempoRelation = "none"
+	case sample.BPM == 0:
+		out.TempoRelation = "unknown"
+		tempoScore = unknownTempoScore
+	default:
+		relation, stretch := tempoFit(sample.BPM, tempo)
+		if stretch > maxStretch {
+			return SampleSuggestion{}, false
+		}
+		out.TempoRelation = relation
+		out.StretchPct = math.Round(stretch*10) / 10
+		tempoScore = 1 - 0.5*stretch/maxStretch
+		if relation != "same" {
+			tempoScore -= 0.1 // half/double time changes the feel
+		}
+	}
+
+	out.Score = math.Round((suggestKeyWeight*keyScore+suggestTempoWeight*tempoScore)*1000) / 1000
+	return out, true
+}
+
+// keyFit returns the transpose (-6..5 semitones) that moves a sample onto the
+// project key, or onto its relative key when the scales differ, and the
+// circle-of-fifths distance between the untransposed keys' parent majors.
+func keyFit(samplePC int, sampleMinor bool, projectPC int, projectMinor bool) (int, int) {
+	target := projectPC
+	switch {
+	case sampleMinor && !projectMinor:
+		target = projectPC + 9 // relative minor
+	case !sampleMinor && projectMinor:
+		target = projectPC + 3 // relative major
+	}
+	transpose := ((target-samplePC)%12 + 12) % 12
+	if transpose > 5 {
+		transpose -= 12
+	}
+	parent := func(pc int, minor bool) int {
+		if minor {
+			return (pc + 3) % 12
+		}
+		return pc
+	}
+	steps := ((parent(samplePC, sampleMinor)-parent(projectPC, projectMinor))*7%12 + 12) % 12
+	return transpose, min(steps, 12-steps)
+}
+
+// tempoFit picks the closest of same, half or double time and returns the
+// stretch needed in percent of the project tempo.
+func tempoFit(sampleBPM, tempo float64) (string, float64) {
+	best, bestStretch := "", math.Inf(1)
+	for _, c := range []struct {
+		relation string
+		ratio    float64
+	}{{"same", 1}, {"half_time", 2}, {"double_time", 0.5}} {
+		stretch := math.Abs(sampleBPM*c.ratio-tempo) / tempo * 100
+		if stretch < bestStretch {
+			best, bestStretch = c.relation, stretch
+		}
+	}
+	return best, bestStretch
+}
+
+// modeIsMinor groups modes by their third: minor-third modes pair with minor
+// samples, the rest with major.
+func modeIsMinor(m modeScale) bool {
+	return m.degrees[2] == 3
+}
+
+func pitchClassIndex(name string) int {
+	for i, n := range sharpNoteNames {
+		if n == name {
+			return i
+		}
+	}
+	for i, n := range flatNoteNames {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func querySongTempo(client chordClipClient) (float64, error) {
+	res, err := client.Query("/live/song/get/tempo")
+	if err != nil {
+		return 0, fmt.Errorf("get tempo: %w", err)
+	}
+	if err := ensureResponseLen(res, 1); err != nil {
+		return 0, fmt.Errorf("get tempo: %w", err)
+	}
+	tempo, err := abletonosc.AsFloat64(res[0])
+	if err != nil || tempo <= 0 {
+		return 0, fmt.Errorf("unexpected tempo: %v", res)
+	}
+	return tempo, nil
+}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuggestSamplesRanksByKeyAndTempo(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, rel := range []string{
+		"Loops/bass_loop_124_Am.wav",    // relative minor, on tempo
+		"Loops/keys_loop_120_Em.wav",    // one fifth away, 3% stretch
+		"Loops/pad_loop_62_C.wav",       // same key, half time
+		"Loops/lead_loop_124_F#.wav",    // tritone: needs 6 semitones
+		"Loops/drum_loop_150.wav",       // too far to warp
+		"One Shots/kick_punchy.wav",     // keyless one-shot
+		"Loops/vocal_loop_124_Cmaj.wav", // exact match
+	} {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := &recipeClientStub{queries: map[string][]interface{}{
+		"/live/song/get/tempo":      {float32(124)},
+		"/live/song/get/root_note":  {int32(0)},
+		"/live/song/get/scale_name": {"Major"},
+	}}
+	five := 5
+
+	got, err := suggestSamples(client, SpliceLibrarySettings{ConfiguredPath: root}, SuggestSamplesInput{MaxTranspose: &five})
+	if err != nil {
+		t.Fatalf("suggestSamples() error = %v", err)
+	}
+	if got.ProjectKey != "C major" || got.Tempo != 124 || got.Considered != 7 {
+		t.Errorf("header = %+v", got)
+	}
+	byName := map[string]SampleSuggestion{}
+	for _, s := range got.Suggestions {
+		byName[s.Name] = s
+	}
+	if len(got.Suggestions) != 5 {
+		t.Fatalf("suggestions = %+v", got.Suggestions)
+	}
+	if got.Suggestions[0].Name != "vocal_loop_124_Cmaj.wav" || got.Suggestions[0].KeyRelation != "same" {
+		t.Errorf("top = %+v", got.Suggestions[0])
+	}
+	if s := byName["bass_loop_124_Am.wav"]; s.KeyRelation != "relative" || s.TransposeSemitones != 0 {
+		t.Errorf("relative = %+v", s)
+	}
+	if s := byName["keys_loop_120_Em.wav"]; s.KeyRelation != "fifths_1" || s.TransposeSemitones != 5 || s.StretchPct != 3.2 {
+		t.Errorf("E minor = %+v", s)
+	}
+	if s := byName["pad_loop_62_C.wav"]; s.TempoRelation != "half_time" || s.StretchPct != 0 {
+		t.Errorf("half time = %+v", s)
+	}
+	if s := byName["kick_punchy.wav"]; s.KeyRelation != "keyless" || s.TempoRelation != "none" {
+		t.Errorf("one-shot = %+v", s)
+	}
+	if _, ok := byName["lead_loop_124_F#.wav"]; ok {
+		t.Error("tritone sample should exceed max_transpose 5")
+	}
+	if _, ok := byName["drum_loop_150.wav"]; ok {
+		t.Error("150 BPM loop should be outside the warp range")
+	}
+}
+
+func TestSuggestSamplesKeyOverrideSkipsLive(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "bass_loop_100_Dm.wav"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tempo := 100.0
+	client := &recipeClientStub{}
+	got, err := suggestSamples(client, SpliceLibrarySettings{ConfiguredPath: root}, SuggestSamplesInput{Key: "A minor", Tempo: &tempo, RequireKey: true})
+	if err != nil {
+		t.Fatalf("suggestSamples() error = %v", err)
+	}
+	if len(client.calls) != 0 {
+		t.Errorf("unexpected Live queries: %+v", client.calls)
+	}
+	if len(got.Suggestions) != 1 || got.Suggestions[0].TransposeSemitones != -5 || got.Suggestions[0].KeyRelation != "fifths_1" {
+		t.Errorf("suggestions = %+v", got.Suggestions)
+	}
+}
+
+func TestKeyFit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		samplePC          int
+		sampleMinor       bool
+		projectPC         int
+		projectMinor      bool
+		transpose, fifths int
+	}{
+		{0, false, 0, false, 0, 0},
+		{9, true, 0, false, 0, 0},  // A minor in C major
+		{0, true, 0, false, -3, 3}, // C minor in C major
+		{7, false, 0, false, 5, 1}, // G major in C major
+		{10, false, 0, true, 5, 1}, // Bb major in C minor (relative Eb)
+	}
+	for _, tt := range tests {
+		transpose, fifths := keyFit(tt.samplePC, tt.sampleMinor, tt.projectPC, tt.projectMinor)
+		if transpose != tt.transpose || fifths != tt.fifths {
+			t.Errorf("keyFit(%+v) = %d, %d", tt, transpose, fifths)
+		}
+	}
+}
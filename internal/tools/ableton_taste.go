@@ -3,12 +3,13 @@ package tools
 import (
 	"errors"
 	"fmt"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
 )
 
@@ -17,23 +18,71 @@ type RecordVariationPreferenceInput struct {
 	Variation  string `json:"variation" jsonschema:"description=Variation that was compared (e.g. groove, lift, volume, bypass)"`
 	Preferred  string `json:"preferred" jsonschema:"description=Which version you preferred: source or variation"`
 	Note       string `json:"note,omitempty" jsonschema:"description=Optional short reason for the choice (max 500 characters)"`
+	// Parameters that produced B, copied from the compare call.
+	Strength      *float64          `json:"strength,omitempty" jsonschema:"description=Drum/bass strength used for B,minimum=0,maximum=1"`
+	Seed          *int64            `json:"seed,omitempty" jsonschema:"description=Seed used (or returned) for B"`
+	VelocityDelta *int              `json:"velocity_delta,omitempty" jsonschema:"description=Scene velocity change used for B,minimum=1,maximum=30"`
+	MixDeltas     []MixVolumeChange `json:"mix_deltas,omitempty" jsonschema:"description=Volume changes applied by ableton_apply_mix_variation"`
+	DeviceIndices []int             `json:"device_indices,omitempty" jsonschema:"description=FX devices toggled by ableton_compare_fx_bypass"`
+	// Context; read from Live when omitted.
+	TrackIndex *int     `json:"track_index,omitempty" jsonschema:"description=Track the variation was on; its name is stored with the choice,minimum=0"`
+	Tempo      *float64 `json:"tempo,omitempty" jsonschema:"description=Project tempo (default: read from Live),minimum=20,maximum=400"`
+	Key        string   `json:"key,omitempty" jsonschema:"description=Project key such as A minor (default: read from Live)"`
+	User       string   `json:"user,omitempty" jsonschema:"description=Taste user to record for (default: the session user from ableton_set_taste_user)"`
+}
+
+type GetTasteProfileInput struct {
+	User string `json:"user,omitempty" jsonschema:"description=Taste user to summarize (default: the session user)"`
 }
 
 type TastePreferenceOutput struct {
-	Instrument string `json:"instrument"`
-	Variation  string `json:"variation"`
-	Preferred  string `json:"preferred"`
-	Note       string `json:"note,omitempty"`
+	Instrument string                 `json:"instrument"`
+	Variation  string                 `json:"variation"`
+	Preferred  string                 `json:"preferred"`
+	Note       string                 `json:"note,omitempty"`
+	Params     *taste.VariationParams `json:"params,omitempty"`
+	Context    *taste.Context         `json:"context,omitempty"`
+}
+
+type GetTasteReportInput struct {
+	User       string `json:"user,omitempty" jsonschema:"description=Taste user to report on (default: the session user)"`
+	WindowDays int    `json:"window_days,omitempty" jsonschema:"description=Recent window compared against earlier choices (default 7),minimum=1,maximum=365"`
+}
+
+type TasteReportOutput struct {
+	User        string       `json:"user"`
+	ProfilePath string       `json:"profile_path"`
+	WindowDays  int          `json:"window_days"`
+	Report      taste.Report `json:"report"`
+}
+
+type ListTastePreferencesInput struct {
+	User       string `json:"user,omitempty" jsonschema:"description=Taste user to query (default: the session user)"`
+	Instrument string `json:"instrument,omitempty" jsonschema:"description=drum, bass, scene, mix, or fx"`
+	Variation  string `json:"variation,omitempty"`
+	Preferred  string `json:"preferred,omitempty" jsonschema:"description=source or variation"`
+	FromUser   string `json:"from_user,omitempty" jsonschema:"description=Only choices merged from this user (team profiles)"`
+	SinceDays  int    `json:"since_days,omitempty" jsonschema:"description=Only choices from the last N days,minimum=1,maximum=3650"`
+	Limit      int    `json:"limit,omitempty" jsonschema:"description=Most recent matches to return (default 50, max 500),minimum=1,maximum=500"`
+}
+
+type ListTastePreferencesOutput struct {
+	User        string             `json:"user"`
+	ProfilePath string             `json:"profile_path"`
+	Preferences []taste.Preference `json:"preferences" jsonschema:"description=Matching choices, oldest first"`
 }
 
 type TasteSummary struct {
-	Instrument string `json:"instrument"`
-	Variation  string `json:"variation"`
-	Accepted   int    `json:"accepted"`
-	Rejected   int    `json:"rejected"`
+	Instrument   string `json:"instrument"`
+	Variation    string `json:"variation"`
+	Accepted     int    `json:"accepted" jsonschema:"description=Choices that preferred the variation over the source"`
+	Rejected     int    `json:"rejected"`
+	VersusWins   int    `json:"versus_wins,omitempty" jsonschema:"description=Ranking pairs this variation won against another variation"`
+	VersusLosses int    `json:"versus_losses,omitempty" jsonschema:"description=Ranking pairs this variation lost to another variation"`
 }
 
 type TasteProfileOutput struct {
+	User                string                 `json:"user"`
 	ProfilePath         string                 `json:"profile_path"`
 	PreferencesRecorded int                    `json:"preferences_recorded"`
 	Summaries           []TasteSummary         `json:"summaries"`
@@ -42,50 +91,190 @@ type TasteProfileOutput struct {
 }
 
 type tasteStore interface {
-	Record(preference taste.Preference) (taste.Profile, error)
+	Record(preference taste.Preference) (taste.Summary, error)
 	Load() (taste.Profile, error)
 	Path() string
 }
 
+const (
+	defaultTasteReportWindowDays = 7
+	defaultTastePreferenceLimit  = 50
+	maxTastePreferenceLimit      = 500
+)
+
 var tasteInstrumentOrder = []string{"bass", "drum", "fx", "mix", "scene"}
 
-func NewAbletonRecordVariationPreference(g *genkit.Genkit, store tasteStore) ai.Tool {
+func NewAbletonRecordVariationPreference(g *genkit.Genkit, client *abletonosc.Client, users *taste.Users) ai.Tool {
 	return genkit.DefineTool(g, "ableton_record_variation_preference",
-		"Ableton Live: after an A/B listen, record whether source or variation matched your taste (drum, bass, scene, mix, or fx) — usually follows ableton_compare_ab_variation, mix compare, or ableton_compare_fx_bypass",
+		"Ableton Live: after an A/B listen, record whether source or variation matched your taste (drum, bass, scene, mix, or fx) — usually follows ableton_compare_ab_variation, mix compare, or ableton_compare_fx_bypass. Pass the parameters used for B (strength, seed, velocity_delta, mix_deltas, device_indices) and track_index; project tempo, key and track name are stored with the choice",
 		func(_ *ai.ToolContext, input RecordVariationPreferenceInput) (TasteProfileOutput, error) {
-			preference, err := validateTastePreference(input)
+			user, store, err := users.Lookup(input.User)
 			if err != nil {
 				return TasteProfileOutput{}, err
 			}
-			profile, err := store.Record(preference)
+			out, err := recordVariationPreference(client, store, input)
 			if err != nil {
 				return TasteProfileOutput{}, err
 			}
-			out := tasteProfileOutput(profile, store.Path())
-			out.RecordedPreference = &TastePreferenceOutput{
-				Instrument: preference.Instrument,
-				Variation:  preference.Variation,
-				Preferred:  preference.Preferred,
-				Note:       preference.Note,
-			}
+			out.User = user
 			return out, nil
 		},
 	)
 }
 
-func NewAbletonGetTasteProfile(g *genkit.Genkit, store tasteStore) ai.Tool {
+func recordVariationPreference(client chordClipClient, store tasteStore, input RecordVariationPreferenceInput) (TasteProfileOutput, error) {
+	preference, err := validateTastePreference(input)
+	if err != nil {
+		return TasteProfileOutput{}, err
+	}
+	preference.Context = tastePreferenceContext(client, input)
+	summary, err := store.Record(preference)
+	if err != nil {
+		return TasteProfileOutput{}, err
+	}
+	out := tasteProfileOutput(summary, store.Path())
+	out.RecordedPreference = &TastePreferenceOutput{
+		Instrument: preference.Instrument,
+		Variation:  preference.Variation,
+		Preferred:  preference.Preferred,
+		Note:       preference.Note,
+		Params:     preference.Params,
+		Context:    preference.Context,
+	}
+	return out, nil
+}
+
+// tastePreferenceContext fills tempo, key and track name from the input,
+// asking Live for whatever was omitted. Live being unreachable only leaves
+// those fields empty; the choice itself is still recorded.
+func tastePreferenceContext(client chordClipClient, input RecordVariationPreferenceInput) *taste.Context {
+	ctx := taste.Context{TrackIndex: input.TrackIndex, Key: strings.TrimSpace(input.Key)}
+	if input.Tempo != nil {
+		ctx.TempoBPM = *input.Tempo
+	}
+	if client != nil {
+		if ctx.TempoBPM == 0 {
+			if tempo, err := querySongTempo(client); err == nil {
+				ctx.TempoBPM = tempo
+			}
+		}
+		if ctx.Key == "" {
+			if key, err := querySongKey(client); err == nil {
+				ctx.Key = key.String()
+			}
+		}
+		if input.TrackIndex != nil {
+			if name, err := queryTrackName(client, *input.TrackIndex); err == nil {
+				ctx.TrackName = name
+			}
+		}
+	}
+	if ctx == (taste.Context{}) {
+		return nil
+	}
+	return &ctx
+}
+
+func NewAbletonGetTasteProfile(g *genkit.Genkit, users *taste.Users) ai.Tool {
 	return genkit.DefineTool(g, "ableton_get_taste_profile",
 		"Ableton Live: summarize saved A/B preferences and suggest the next comparison — use before choosing what to run with ableton_compare_ab_variation",
-		func(_ *ai.ToolContext, _ EmptyInput) (TasteProfileOutput, error) {
-			profile, err := store.Load()
+		func(_ *ai.ToolContext, input GetTasteProfileInput) (TasteProfileOutput, error) {
+			user, store, err := users.Lookup(input.User)
+			if err != nil {
+				return TasteProfileOutput{}, err
+			}
+			summary, err := store.Summary()
 			if err != nil {
 				return TasteProfileOutput{}, err
 			}
-			return tasteProfileOutput(profile, store.Path()), nil
+			out := tasteProfileOutput(summary, store.Path())
+			out.User = user
+			return out, nil
+		},
+	)
+}
+
+func NewAbletonGetTasteReport(g *genkit.Genkit, users *taste.Users) ai.Tool {
+	return genkit.DefineTool(g, "ableton_get_taste_report",
+		"Report how A/B preferences are drifting: acceptance rates with 95% confidence intervals, the last window_days against earlier choices, breakdowns by tempo range and key, and note keywords grouped into clusters",
+		func(_ *ai.ToolContext, input GetTasteReportInput) (TasteReportOutput, error) {
+			user, store, err := users.Lookup(input.User)
+			if err != nil {
+				return TasteReportOutput{}, err
+			}
+			return tasteReport(user, store, input, time.Now().UTC())
 		},
 	)
 }
 
+func tasteReport(user string, store tasteStore, input GetTasteReportInput, now time.Time) (TasteReportOutput, error) {
+	days := input.WindowDays
+	if days == 0 {
+		days = defaultTasteReportWindowDays
+	}
+	if days < 1 || days > 365 {
+		return TasteReportOutput{}, errors.New("window_days must be between 1 and 365")
+	}
+	profile, err := store.Load()
+	if err != nil {
+		return TasteReportOutput{}, err
+	}
+	return TasteReportOutput{
+		User:        user,
+		ProfilePath: store.Path(),
+		WindowDays:  days,
+		Report:      profile.Report(taste.ReportOptions{Now: now, Window: time.Duration(days) * 24 * time.Hour}),
+	}, nil
+}
+
+func NewAbletonListTastePreferences(g *genkit.Genkit, users *taste.Users) ai.Tool {
+	return genkit.DefineTool(g, "ableton_list_taste_preferences",
+		"List recorded A/B choices filtered by instrument, variation, answer, merged user and age, with their parameters, project context and notes",
+		func(_ *ai.ToolContext, input ListTastePreferencesInput) (ListTastePreferencesOutput, error) {
+			user, store, err := users.Lookup(input.User)
+			if err != nil {
+				return ListTastePreferencesOutput{}, err
+			}
+			return listTastePreferences(user, store, input, time.Now().UTC())
+		},
+	)
+}
+
+func listTastePreferences(user string, store taste.Backend, input ListTastePreferencesInput, now time.Time) (ListTastePreferencesOutput, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = defaultTastePreferenceLimit
+	}
+	if limit < 1 || limit > maxTastePreferenceLimit {
+		return ListTastePreferencesOutput{}, fmt.Errorf("limit must be between 1 and %d", maxTastePreferenceLimit)
+	}
+	if input.SinceDays < 0 || input.SinceDays > 3650 {
+		return ListTastePreferencesOutput{}, errors.New("since_days must be between 1 and 3650")
+	}
+	filter := taste.Filter{
+		Instrument: strings.ToLower(strings.TrimSpace(input.Instrument)),
+		Variation:  strings.ToLower(strings.TrimSpace(input.Variation)),
+		Preferred:  strings.ToLower(strings.TrimSpace(input.Preferred)),
+		Limit:      limit,
+	}
+	if filter.Preferred != "" && filter.Preferred != "source" && filter.Preferred != "variation" {
+		return ListTastePreferencesOutput{}, errors.New("preferred must be source or variation")
+	}
+	fromUser, err := taste.NormalizeUser(input.FromUser)
+	if err != nil {
+		return ListTastePreferencesOutput{}, err
+	}
+	filter.User = fromUser
+	if input.SinceDays > 0 {
+		filter.Since = now.AddDate(0, 0, -input.SinceDays)
+	}
+	prefs, err := store.Preferences(filter)
+	if err != nil {
+		return ListTastePreferencesOutput{}, err
+	}
+	return ListTastePreferencesOutput{User: user, ProfilePath: store.Path(), Preferences: prefs}, nil
+}
+
 func validateTastePreference(input RecordVariationPreferenceInput) (taste.Preference, error) {
 	instrument := strings.ToLower(strings.TrimSpace(input.Instrument))
 	variation := strings.ToLower(strings.TrimSpace(input.Variation))
@@ -101,14 +290,59 @@ func validateTastePreference(input RecordVariationPreferenceInput) (taste.Prefer
 	if len(note) > 500 {
 		return taste.Preference{}, errors.New("note must be 500 characters or fewer")
 	}
+	params, err := tastePreferenceParams(input)
+	if err != nil {
+		return taste.Preference{}, err
+	}
+	if input.Tempo != nil && (*input.Tempo < 20 || *input.Tempo > 400) {
+		return taste.Preference{}, errors.New("tempo must be between 20 and 400")
+	}
+	if input.TrackIndex != nil && *input.TrackIndex < 0 {
+		return taste.Preference{}, errors.New("track_index must be >= 0")
+	}
 	return taste.Preference{
 		Instrument: instrument,
 		Variation:  variation,
 		Preferred:  preferred,
 		Note:       note,
+		Params:     params,
 	}, nil
 }
 
+// tastePreferenceParams validates the B parameters; nil when none were given.
+func tastePreferenceParams(input RecordVariationPreferenceInput) (*taste.VariationParams, error) {
+	if input.Strength != nil && (*input.Strength < 0 || *input.Strength > 1) {
+		return nil, errors.New("strength must be between 0 and 1")
+	}
+	if input.VelocityDelta != nil && (*input.VelocityDelta < 1 || *input.VelocityDelta > 30) {
+		return nil, errors.New("velocity_delta must be between 1 and 30")
+	}
+	params := taste.VariationParams{
+		Strength:      input.Strength,
+		Seed:          input.Seed,
+		VelocityDelta: input.VelocityDelta,
+		DeviceIndices: input.DeviceIndices,
+	}
+	for _, change := range input.MixDeltas {
+		if change.TrackIndex < 0 {
+			return nil, errors.New("mix_deltas track_index must be >= 0")
+		}
+		if change.Delta < -0.2 || change.Delta > 0.2 {
+			return nil, errors.New("mix_deltas delta must be between -0.2 and 0.2")
+		}
+		params.MixDeltas = append(params.MixDeltas, taste.MixDelta{TrackIndex: change.TrackIndex, Delta: change.Delta})
+	}
+	for _, device := range input.DeviceIndices {
+		if device < 0 {
+			return nil, errors.New("device_indices must be >= 0")
+		}
+	}
+	if params.Strength == nil && params.Seed == nil && params.VelocityDelta == nil && len(params.MixDeltas) == 0 && len(params.DeviceIndices) == 0 {
+		return nil, nil
+	}
+	return &params, nil
+}
+
 func validateTasteInstrumentVariation(instrument, variation string) error {
 	switch instrument {
 	case "drum":
@@ -149,48 +383,21 @@ func isFXVariation(variation string) bool {
 	return variation == "bypass"
 }
 
-func tasteProfileOutput(profile taste.Profile, path string) TasteProfileOutput {
-	type counter struct {
-		instrument string
-		variation  string
-		accepted   int
-		rejected   int
-	}
-
-	counts := make(map[string]*counter)
-	for _, preference := range profile.Preferences {
-		key := preference.Instrument + "\x00" + preference.Variation
-		item, ok := counts[key]
-		if !ok {
-			item = &counter{instrument: preference.Instrument, variation: preference.Variation}
-			counts[key] = item
-		}
-		if preference.Preferred == "variation" {
-			item.accepted++
-		} else {
-			item.rejected++
-		}
-	}
-
-	summaries := make([]TasteSummary, 0, len(counts))
-	for _, item := range counts {
+func tasteProfileOutput(summary taste.Summary, path string) TasteProfileOutput {
+	summaries := make([]TasteSummary, 0, len(summary.Tallies))
+	for _, tally := range summary.Tallies {
 		summaries = append(summaries, TasteSummary{
-			Instrument: item.instrument,
-			Variation:  item.variation,
-			Accepted:   item.accepted,
-			Rejected:   item.rejected,
+			Instrument:   tally.Instrument,
+			Variation:    tally.Variation,
+			Accepted:     tally.Accepted,
+			Rejected:     tally.Rejected,
+			VersusWins:   tally.VersusWins,
+			VersusLosses: tally.VersusLosses,
 		})
 	}
-	sort.Slice(summaries, func(i, j int) bool {
-		if summaries[i].Instrument == summaries[j].Instrument {
-			return summaries[i].Variation < summaries[j].Variation
-		}
-		return summaries[i].Instrument < summaries[j].Instrument
-	})
-
 	return TasteProfileOutput{
 		ProfilePath:         path,
-		PreferencesRecorded: len(profile.Preferences),
+		PreferencesRecorded: summary.Preferences,
 		Summaries:           summaries,
 		NextSuggestions:     nextTasteSuggestions(summaries),
 	}
@@ -203,7 +410,7 @@ func nextTasteSuggestions(summaries []TasteSummary) []string {
 	}
 	for _, summary := range summaries {
 		if _, ok := byInstrument[summary.Instrument]; ok {
-			byInstrument[summary.Instrument][summary.Variation] = summary.Accepted + summary.Rejected
+			byInstrument[summary.Instrument][summary.Variation] = summary.Accepted + summary.Rejected + summary.VersusWins + summary.VersusLosses
 		}
 	}
 
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
+)
+
+// Parameter levels the taste profile chooses between; recorded values count
+// toward the nearest level.
+var (
+	tasteStrengthLevels      = []float64{0.3, 0.45, 0.6, 0.75, 0.9}
+	tasteVelocityDeltaLevels = []float64{6, 12, 18, 24}
+	tasteMixDeltaLevels      = []float64{0.03, 0.05, 0.08, 0.12, 0.2}
+)
+
+// TasteChoice explains parameters picked with use_taste.
+type TasteChoice struct {
+	Variation     string           `json:"variation,omitempty"`
+	Strength      *float64         `json:"strength,omitempty"`
+	VelocityDelta *int             `json:"velocity_delta,omitempty"`
+	MixDelta      *float64         `json:"mix_delta,omitempty" jsonschema:"description=Size applied to every change, keeping each change's sign"`
+	Reasons       []string         `json:"reasons"`
+	Variations    []taste.Estimate `json:"variations,omitempty" jsonschema:"description=Acceptance posterior per variation, best first"`
+	Levels        []taste.Estimate `json:"levels,omitempty" jsonschema:"description=Acceptance posterior per parameter level"`
+}
+
+type tasteProfileLoader interface {
+	Load() (taste.Profile, error)
+}
+
+// tasteLoaderFor resolves the store use_taste reads; a bad user name only
+// matters when use_taste is set.
+func tasteLoaderFor(users *taste.Users, user string) tasteProfileLoader {
+	_, store, err := users.Lookup(user)
+	if err != nil {
+		return tasteLoadError{err}
+	}
+	return store
+}
+
+type tasteLoadError struct{ err error }
+
+func (e tasteLoadError) Load() (taste.Profile, error) {
+	return taste.Profile{}, e.err
+}
+
+func loadTasteProfile(store tasteProfileLoader) (taste.Profile, error) {
+	if store == nil {
+		return taste.Profile{}, errors.New("use_taste needs a taste profile; none is configured")
+	}
+	return store.Load()
+}
+
+// pickTasteVariation keeps a requested variation and otherwise takes the
+// best-ranked one for instrument.
+func pickTasteVariation(profile taste.Profile, instrument, requested string, choice *TasteChoice) string {
+	requested = strings.ToLower(strings.TrimSpace(requested))
+	if requested != "" {
+		choice.Reasons = append(choice.Reasons, fmt.Sprintf("variation %s was given; taste only picked parameters", requested))
+		return requested
+	}
+	ranked := profile.RankVariations(instrument, tasteVariationsFor(instrument))
+	best := ranked[0]
+	choice.Variation = best.Name
+	choice.Variations = ranked
+	if best.Accepted+best.Rejected == 0 {
+		choice.Reasons = append(choice.Reasons, fmt.Sprintf("variation %s: not compared yet, so it is explored first (upper bound %.2f)", best.Name, best.Upper))
+	} else {
+		choice.Reasons = append(choice.Reasons, fmt.Sprintf("variation %s: preferred %g of %g times (acceptance %.2f, upper bound %.2f is the best of %d)",
+			best.Name, best.Accepted, best.Accepted+best.Rejected, best.Mean, best.Upper, len(ranked)))
+	}
+	return best.Name
+}
+
+// pickTasteLevel explains a PickLevel result for the named parameter.
+func pickTasteLevel(profile taste.Profile, instrument, variation, param string, levels []float64, def float64, value func(taste.Preference) (float64, bool), choice *TasteChoice) float64 {
+	picked := profile.PickLevel(instrument, variation, levels, def, value)
+	choice.Levels = picked.Estimates
+	if picked.Observed == 0 {
+		choice.Reasons = append(choice.Reasons, fmt.Sprintf("%s %g: no recorded %s %s choices carry %s, so the default is used", param, picked.Value, instrument, variation, param))
+		return picked.Value
+	}
+	choice.Reasons = append(choice.Reasons, fmt.Sprintf("%s %g: best acceptance among %d recorded %s %s choices with %s", param, picked.Value, picked.Observed, instrument, variation, param))
+	return picked.Value
+}
+
+func recordedStrength(p taste.Preference) (float64, bool) {
+	if p.Params == nil || p.Params.Strength == nil {
+		return 0, false
+	}
+	return *p.Params.Strength, true
+}
+
+func recordedVelocityDelta(p taste.Preference) (float64, bool) {
+	if p.Params == nil || p.Params.VelocityDelta == nil {
+		return 0, false
+	}
+	return float64(*p.Params.VelocityDelta), true
+}
+
+// recordedMixDelta is the mean change size of a mix choice.
+func recordedMixDelta(p taste.Preference) (float64, bool) {
+	if p.Params == nil || len(p.Params.MixDeltas) == 0 {
+		return 0, false
+	}
+	sum := 0.0
+	for _, d := range p.Params.MixDeltas {
+		sum += math.Abs(d.Delta)
+	}
+	return sum / float64(len(p.Params.MixDeltas)), true
+}
+
+func applyDrumTaste(profile taste.Profile, input CreateDrumVariationInput) (CreateDrumVariationInput, *TasteChoice) {
+	choice := &TasteChoice{}
+	input.Variation = pickTasteVariation(profile, "drum", input.Variation, choice)
+	if input.Strength == nil {
+		s := pickTasteLevel(profile, "drum", input.Variation, "strength", tasteStrengthLevels, defaultVariationStrength, recordedStrength, choice)
+		input.Strength = &s
+		choice.Strength = &s
+	}
+	return input, choice
+}
+
+func applyBassTaste(profile taste.Profile, input CreateBassVariationInput) (CreateBassVariationInput, *TasteChoice) {
+	choice := &TasteChoice{}
+	input.Variation = pickTasteVariation(profile, "bass", input.Variation, choice)
+	if input.Strength == nil {
+		s := pickTasteLevel(profile, "bass", input.Variation, "strength", tasteStrengthLevels, defaultBassVariationStrength, recordedStrength, choice)
+		input.Strength = &s
+		choice.Strength = &s
+	}
+	return input, choice
+}
+
+func applySceneTaste(profile taste.Profile, input CreateSceneEnergyVariationInput) (CreateSceneEnergyVariationInput, *TasteChoice) {
+	choice := &TasteChoice{}
+	input.Variation = pickTasteVariation(profile, "scene", input.Variation, choice)
+	if input.VelocityDelta == nil {
+		v := int(pickTasteLevel(profile, "scene", input.Variation, "velocity_delta", tasteVelocityDeltaLevels, defaultSceneEnergyVelocityDelta, recordedVelocityDelta, choice))
+		input.VelocityDelta = &v
+		choice.VelocityDelta = &v
+	}
+	return input, choice
+}
+
+// applyMixTaste resizes every change to the learned delta size, keeping its
+// sign. With nothing learned the given deltas stand.
+func applyMixTaste(profile taste.Profile, input ApplyMixVariationInput) (ApplyMixVariationInput, *TasteChoice, error) {
+	choice := &TasteChoice{Variation: "volume"}
+	picked := profile.PickLevel("mix", "volume", tasteMixDeltaLevels, 0, recordedMixDelta)
+	if picked.Observed == 0 {
+		choice.Reasons = append(choice.Reasons, "no recorded mix choices carry deltas, so the given deltas are used")
+		return input, choice, nil
+	}
+	size := picked.Value
+	changes := make([]MixVolumeChange, len(input.Changes))
+	for i, change := range input.Changes {
+		if change.Delta == 0 {
+			return ApplyMixVariationInput{}, nil, fmt.Errorf("changes delta for track %d must be non-zero with use_taste; its sign sets the direction", change.TrackIndex)
+		}
+		changes[i] = MixVolumeChange{TrackIndex: change.TrackIndex, Delta: math.Copysign(size, change.Delta)}
+	}
+	input.Changes = changes
+	choice.MixDelta = &size
+	choice.Levels = picked.Estimates
+	choice.Reasons = append(choice.Reasons, fmt.Sprintf("delta size %g: best acceptance among %d recorded mix choices; each change keeps its direction", size, picked.Observed))
+	return input, choice, nil
+}
+
+func createDrumVariationWithTaste(client variationClient, store tasteProfileLoader, input CreateDrumVariationInput) (CreateDrumVariationOutput, error) {
+	var choice *TasteChoice
+	if input.UseTaste {
+		profile, err := loadTasteProfile(store)
+		if err != nil {
+			return CreateDrumVariationOutput{}, err
+		}
+		input, choice = applyDrumTaste(profile, input)
+	}
+	out, err := createDrumVariation(client, input)
+	if err != nil {
+		return CreateDrumVariationOutput{}, err
+	}
+	out.Taste = choice
+	return out, nil
+}
+
+func createBassVariationWithTaste(client variationClient, store tasteProfileLoader, input CreateBassVariationInput) (CreateBassVariationOutput, error) {
+	var choice *TasteChoice
+	if input.UseTaste {
+		profile, err := loadTasteProfile(store)
+		if err != nil {
+			return CreateBassVariationOutput{}, err
+		}
+		input, choice = applyBassTaste(profile, input)
+	}
+	out, err := createBassVariation(client, input)
+	if err != nil {
+		return CreateBassVariationOutput{}, err
+	}
+	out.Taste = choice
+	return out, nil
+}
+
+func createSceneEnergyVariationWithTaste(client variationClient, store tasteProfileLoader, input CreateSceneEnergyVariationInput) (CreateSceneEnergyVariationOutput, error) {
+	var choice *TasteChoice
+	if input.UseTaste {
+		profile, err := loadTasteProfile(store)
+		if err != nil {
+			return CreateSceneEnergyVariationOutput{}, err
+		}
+		input, choice = applySceneTaste(profile, input)
+	}
+	out, err := createSceneEnergyVariation(client, input)
+	if err != nil {
+		return CreateSceneEnergyVariationOutput{}, err
+	}
+	out.Taste = choice
+	return out, nil
+}
+
+func applyMixVariationWithTaste(client mixABClient, store tasteProfileLoader, input ApplyMixVariationInput) (ApplyMixVariationOutput, error) {
+	var choice *TasteChoice
+	if input.UseTaste {
+		profile, err := loadTasteProfile(store)
+		if err != nil {
+			return ApplyMixVariationOutput{}, err
+		}
+		if input, choice, err = applyMixTaste(profile, input); err != nil {
+			return ApplyMixVariationOutput{}, err
+		}
+	}
+	out, err := applyMixVariation(client, input)
+	if err != nil {
+		return ApplyMixVariationOutput{}, err
+	}
+	out.Taste = choice
+	return out, nil
+}
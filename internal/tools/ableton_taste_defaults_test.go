@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
+)
+
+type tasteProfileStub struct {
+	profile taste.Profile
+}
+
+func (s tasteProfileStub) Load() (taste.Profile, error) {
+	return s.profile, nil
+}
+
+func tastePref(instrument, variation, preferred string, params *taste.VariationParams) taste.Preference {
+	return taste.Preference{Instrument: instrument, Variation: variation, Preferred: preferred, Params: params}
+}
+
+func strengthParams(v float64) *taste.VariationParams {
+	return &taste.VariationParams{Strength: &v}
+}
+
+func TestApplyDrumTastePicksWinnerAndStrength(t *testing.T) {
+	t.Parallel()
+
+	profile := taste.Profile{Preferences: []taste.Preference{
+		tastePref("drum", "groove", "variation", strengthParams(0.3)),
+		tastePref("drum", "groove", "variation", strengthParams(0.3)),
+		tastePref("drum", "groove", "variation", strengthParams(0.3)),
+		tastePref("drum", "groove", "source", strengthParams(0.9)),
+		tastePref("drum", "fill", "source", nil),
+		tastePref("drum", "fill", "source", nil),
+		tastePref("drum", "density", "source", nil),
+		tastePref("drum", "density", "source", nil),
+	}}
+	got, choice := applyDrumTaste(profile, CreateDrumVariationInput{})
+	if got.Variation != "groove" || got.Strength == nil || *got.Strength != 0.3 {
+		t.Fatalf("input = %+v strength %v", got, got.Strength)
+	}
+	if choice.Variation != "groove" || len(choice.Variations) != 3 || len(choice.Levels) != len(tasteStrengthLevels) || len(choice.Reasons) != 2 {
+		t.Errorf("choice = %+v", choice)
+	}
+
+	// Explicit values are kept; only the missing ones are picked.
+	fixed := 0.8
+	got, choice = applyDrumTaste(profile, CreateDrumVariationInput{Variation: " Fill ", Strength: &fixed})
+	if got.Variation != "fill" || *got.Strength != 0.8 || choice.Variation != "" || choice.Strength != nil {
+		t.Errorf("explicit = %+v, choice %+v", got, choice)
+	}
+}
+
+func TestApplySceneTasteColdStart(t *testing.T) {
+	t.Parallel()
+
+	got, choice := applySceneTaste(taste.Profile{}, CreateSceneEnergyVariationInput{})
+	if got.Variation != "lift" || got.VelocityDelta == nil || *got.VelocityDelta != defaultSceneEnergyVelocityDelta {
+		t.Fatalf("cold start = %+v", got)
+	}
+	if len(choice.Reasons) != 2 || choice.Levels != nil {
+		t.Errorf("choice = %+v", choice)
+	}
+}
+
+func TestApplyMixVariationWithTaste(t *testing.T) {
+	t.Parallel()
+
+	store := tasteProfileStub{profile: taste.Profile{Preferences: []taste.Preference{
+		tastePref("mix", "volume", "variation", &taste.VariationParams{MixDeltas: []taste.MixDelta{{TrackIndex: 0, Delta: 0.05}, {TrackIndex: 1, Delta: -0.05}}}),
+		tastePref("mix", "volume", "source", &taste.VariationParams{MixDeltas: []taste.MixDelta{{TrackIndex: 0, Delta: 0.2}}}),
+	}}}
+	client := &mixABStub{volumes: map[int]float64{0: 0.5, 1: 0.7}}
+	got, err := applyMixVariationWithTaste(client, store, ApplyMixVariationInput{
+		UseTaste: true,
+		Changes:  []MixVolumeChange{{TrackIndex: 0, Delta: 0.2}, {TrackIndex: 1, Delta: -0.01}},
+	})
+	if err != nil {
+		t.Fatalf("applyMixVariationWithTaste() error = %v", err)
+	}
+	if got.Taste == nil || got.Taste.MixDelta == nil || *got.Taste.MixDelta != 0.05 {
+		t.Fatalf("taste = %+v", got.Taste)
+	}
+	if math.Abs(got.After.Tracks[0].Volume-0.55) > 1e-6 || math.Abs(got.After.Tracks[1].Volume-0.65) > 1e-6 {
+		t.Errorf("after = %+v", got.After.Tracks)
+	}
+
+	if _, err := applyMixVariationWithTaste(client, store, ApplyMixVariationInput{
+		UseTaste: true,
+		Changes:  []MixVolumeChange{{TrackIndex: 0, Delta: 0}},
+	}); err == nil {
+		t.Error("expected an error for a zero delta under use_taste")
+	}
+
+	// Without use_taste the deltas are applied as given and no choice is reported.
+	plain, err := applyMixVariationWithTaste(client, store, ApplyMixVariationInput{Changes: []MixVolumeChange{{TrackIndex: 0, Delta: 0.1}}})
+	if err != nil || plain.Taste != nil {
+		t.Errorf("plain = %+v, %v", plain, err)
+	}
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
+)
+
+type RankedVersionInput struct {
+	Label     string `json:"label" jsonschema:"description=Label from ableton_audition_multi (A, B, C...)"`
+	Variation string `json:"variation" jsonschema:"description=Variation that produced this version, or source for the original"`
+	// Parameters that produced the version, copied from the variation call.
+	Strength      *float64 `json:"strength,omitempty" jsonschema:"description=Drum/bass strength used,minimum=0,maximum=1"`
+	Seed          *int64   `json:"seed,omitempty" jsonschema:"description=Seed used (or returned)"`
+	VelocityDelta *int     `json:"velocity_delta,omitempty" jsonschema:"description=Scene velocity change used,minimum=1,maximum=30"`
+}
+
+type RecordRankingPreferenceInput struct {
+	Instrument string               `json:"instrument" jsonschema:"description=Audition family: drum, bass, or scene"`
+	Versions   []RankedVersionInput `json:"versions" jsonschema:"description=What each auditioned label was; at most one source"`
+	Ranking    []string             `json:"ranking" jsonschema:"description=Every label once, best first (e.g. C, A, B)"`
+	Note       string               `json:"note,omitempty" jsonschema:"description=Optional short reason for the ranking (max 500 characters)"`
+	// Context; read from Live when omitted.
+	TrackIndex *int     `json:"track_index,omitempty" jsonschema:"description=Track the clips were on; its name is stored with the choices,minimum=0"`
+	Tempo      *float64 `json:"tempo,omitempty" jsonschema:"description=Project tempo (default: read from Live),minimum=20,maximum=400"`
+	Key        string   `json:"key,omitempty" jsonschema:"description=Project key such as A minor (default: read from Live)"`
+	User       string   `json:"user,omitempty" jsonschema:"description=Taste user to record for (default: the session user from ableton_set_taste_user)"`
+}
+
+type RecordRankingPreferenceOutput struct {
+	User                string             `json:"user"`
+	ProfilePath         string             `json:"profile_path"`
+	Ranking             []string           `json:"ranking"`
+	Pairs               []taste.Preference `json:"pairs" jsonschema:"description=Pairwise choices recorded for the ranking"`
+	PreferencesRecorded int                `json:"preferences_recorded"`
+	Summaries           []TasteSummary     `json:"summaries"`
+}
+
+func NewAbletonRecordRankingPreference(g *genkit.Genkit, client *abletonosc.Client, users *taste.Users) ai.Tool {
+	return genkit.DefineTool(g, "ableton_record_ranking_preference",
+		"Ableton Live: after ableton_audition_multi, record a best-first ranking of the auditioned versions. Each pair becomes an A/B choice in the taste profile: variation against source counts once, two variations count half each way",
+		func(_ *ai.ToolContext, input RecordRankingPreferenceInput) (RecordRankingPreferenceOutput, error) {
+			user, store, err := users.Lookup(input.User)
+			if err != nil {
+				return RecordRankingPreferenceOutput{}, err
+			}
+			out, err := recordRankingPreference(client, store, input)
+			if err != nil {
+				return RecordRankingPreferenceOutput{}, err
+			}
+			out.User = user
+			return out, nil
+		},
+	)
+}
+
+func recordRankingPreference(client chordClipClient, store taste.Backend, input RecordRankingPreferenceInput) (RecordRankingPreferenceOutput, error) {
+	ranking, labels, err := validateRankingPreference(input)
+	if err != nil {
+		return RecordRankingPreferenceOutput{}, err
+	}
+	ranking.Context = tastePreferenceContext(client, RecordVariationPreferenceInput{
+		TrackIndex: input.TrackIndex,
+		Tempo:      input.Tempo,
+		Key:        input.Key,
+	})
+	pairs, err := ranking.Pairwise()
+	if err != nil {
+		return RecordRankingPreferenceOutput{}, err
+	}
+	recorded, err := store.RecordAll(pairs)
+	if err != nil {
+		return RecordRankingPreferenceOutput{}, err
+	}
+	summary := tasteProfileOutput(recorded, store.Path())
+	return RecordRankingPreferenceOutput{
+		ProfilePath:         store.Path(),
+		Ranking:             labels,
+		Pairs:               pairs,
+		PreferencesRecorded: summary.PreferencesRecorded,
+		Summaries:           summary.Summaries,
+	}, nil
+}
+
+// validateRankingPreference orders the versions by the ranking and returns
+// the normalized labels.
+func validateRankingPreference(input RecordRankingPreferenceInput) (taste.Ranking, []string, error) {
+	instrument := strings.ToLower(strings.TrimSpace(input.Instrument))
+	if instrument != "drum" && instrument != "bass" && instrument != "scene" {
+		return taste.Ranking{}, nil, errors.New("instrument must be drum, bass, or scene")
+	}
+	note := strings.TrimSpace(input.Note)
+	if len(note) > 500 {
+		return taste.Ranking{}, nil, errors.New("note must be 500 characters or fewer")
+	}
+	if input.Tempo != nil && (*input.Tempo < 20 || *input.Tempo > 400) {
+		return taste.Ranking{}, nil, errors.New("tempo must be between 20 and 400")
+	}
+	if input.TrackIndex != nil && *input.TrackIndex < 0 {
+		return taste.Ranking{}, nil, errors.New("track_index must be >= 0")
+	}
+	if len(input.Versions) < minAuditionVersions || len(input.Versions) > maxAuditionVersions {
+		return taste.Ranking{}, nil, fmt.Errorf("versions must list between %d and %d versions", minAuditionVersions, maxAuditionVersions)
+	}
+
+	byLabel := map[string]taste.RankedVersion{}
+	for _, v := range input.Versions {
+		label := strings.ToUpper(strings.TrimSpace(v.Label))
+		if label == "" {
+			return taste.Ranking{}, nil, errors.New("every version needs a label")
+		}
+		if _, dup := byLabel[label]; dup {
+			return taste.Ranking{}, nil, fmt.Errorf("versions lists label %s twice", label)
+		}
+		variation := strings.ToLower(strings.TrimSpace(v.Variation))
+		var params *taste.VariationParams
+		if variation == taste.SourceVersion {
+			if v.Strength != nil || v.Seed != nil || v.VelocityDelta != nil {
+				return taste.Ranking{}, nil, fmt.Errorf("version %s is the source and takes no parameters", label)
+			}
+		} else {
+			if err := validateTasteInstrumentVariation(instrument, variation); err != nil {
+				return taste.Ranking{}, nil, fmt.Errorf("version %s: %w", label, err)
+			}
+			var err error
+			params, err = tastePreferenceParams(RecordVariationPreferenceInput{
+				Strength:      v.Strength,
+				Seed:          v.Seed,
+				VelocityDelta: v.VelocityDelta,
+			})
+			if err != nil {
+				return taste.Ranking{}, nil, fmt.Errorf("version %s: %w", label, err)
+			}
+		}
+		byLabel[label] = taste.RankedVersion{Variation: variation, Params: params}
+	}
+
+	if len(input.Ranking) != len(byLabel) {
+		return taste.Ranking{}, nil, errors.New("ranking must list every version label exactly once")
+	}
+	ranking := taste.Ranking{Instrument: instrument, Note: note}
+	labels := make([]string, 0, len(input.Ranking))
+	ranked := map[string]bool{}
+	for _, l := range input.Ranking {
+		label := strings.ToUpper(strings.TrimSpace(l))
+		version, ok := byLabel[label]
+		if !ok {
+			return taste.Ranking{}, nil, fmt.Errorf("ranking label %s is not in versions", label)
+		}
+		if ranked[label] {
+			return taste.Ranking{}, nil, fmt.Errorf("ranking lists %s twice", label)
+		}
+		ranked[label] = true
+		labels = append(labels, label)
+		ranking.Versions = append(ranking.Versions, version)
+	}
+	return ranking, labels, nil
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
+)
+
+func TestRecordRankingPreference(t *testing.T) {
+	t.Parallel()
+
+	store, err := taste.NewStore(filepath.Join(t.TempDir(), "profile.json"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	client := &recipeClientStub{queries: map[string][]interface{}{
+		"/live/song/get/tempo": {float32(128)},
+	}}
+	soft, hard := 0.3, 0.8
+	out, err := recordRankingPreference(client, store, RecordRankingPreferenceInput{
+		Instrument: "Drum",
+		Versions: []RankedVersionInput{
+			{Label: "a", Variation: "source"},
+			{Label: "B", Variation: "groove", Strength: &soft},
+			{Label: "C", Variation: "groove", Strength: &hard},
+		},
+		Ranking: []string{"B", "a", "C"},
+		Note:    "subtle swing",
+		Key:     "D minor",
+	})
+	if err != nil {
+		t.Fatalf("recordRankingPreference() error = %v", err)
+	}
+	if len(out.Pairs) != 4 || out.PreferencesRecorded != 4 {
+		t.Fatalf("pairs = %#v", out.Pairs)
+	}
+	if got := out.Ranking; len(got) != 3 || got[1] != "A" {
+		t.Errorf("ranking = %v", got)
+	}
+	for _, p := range out.Pairs {
+		if p.Context == nil || p.Context.TempoBPM != 128 || p.Context.Key != "D minor" || p.Note != "subtle swing" {
+			t.Errorf("pair lost context: %#v", p)
+		}
+	}
+	// B beat the source and the source beat C; B beating C is a pair between
+	// variations and is counted apart from the choices against the source.
+	want := TasteSummary{Instrument: "drum", Variation: "groove", Accepted: 1, Rejected: 1, VersusWins: 1, VersusLosses: 1}
+	if len(out.Summaries) != 1 || out.Summaries[0] != want {
+		t.Errorf("summaries = %#v, want %#v", out.Summaries, want)
+	}
+	report, err := tasteReport("default", store, GetTasteReportInput{}, time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overall := report.Report.Overall; len(overall) != 1 || overall[0].Accepted != 1 || overall[0].Rejected != 1 ||
+		overall[0].VersusWins != 1 || overall[0].VersusLosses != 1 || overall[0].Rate.Rate != 0.5 {
+		t.Errorf("report overall = %+v", overall)
+	}
+	if b := report.Report.ByKey; len(b) != 1 || b[0].Overall.Accepted+b[0].Overall.Rejected != 2 {
+		t.Errorf("report by key = %+v", b)
+	}
+	if c := report.Report.NoteClusters; len(c) != 2 || c[0].Notes != 2 {
+		t.Errorf("report note clusters = %+v, want the note once per choice against the source", c)
+	}
+	profile, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	est := profile.RankVariations("drum", []string{"groove"})[0]
+	if est.Accepted != 1.5 || est.Rejected != 1.5 {
+		t.Errorf("weighted estimate = %+v, want 1.5/1.5", est)
+	}
+}
+
+func TestValidateRankingPreference(t *testing.T) {
+	t.Parallel()
+
+	versions := []RankedVersionInput{{Label: "A", Variation: "source"}, {Label: "B", Variation: "lift"}}
+	for name, input := range map[string]RecordRankingPreferenceInput{
+		"mix instrument":  {Instrument: "mix", Versions: versions, Ranking: []string{"A", "B"}},
+		"missing label":   {Instrument: "scene", Versions: versions, Ranking: []string{"A"}},
+		"unknown label":   {Instrument: "scene", Versions: versions, Ranking: []string{"A", "C"}},
+		"repeated label":  {Instrument: "scene", Versions: versions, Ranking: []string{"A", "A"}},
+		"wrong variation": {Instrument: "drum", Versions: versions, Ranking: []string{"B", "A"}},
+		"source params": {Instrument: "scene", Ranking: []string{"A", "B"}, Versions: []RankedVersionInput{
+			{Label: "A", Variation: "source", VelocityDelta: new(int)}, {Label: "B", Variation: "lift"},
+		}},
+	} {
+		if _, _, err := validateRankingPreference(input); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
 )
@@ -70,7 +71,7 @@ func TestTasteProfileOutput(t *testing.T) {
 			{Instrument: "scene", Variation: "lift", Preferred: "variation"},
 		},
 	}
-	got := tasteProfileOutput(profile, "/tmp/taste-profile.json")
+	got := tasteProfileOutput(profile.Summary(), "/tmp/taste-profile.json")
 	if got.PreferencesRecorded != 4 {
 		t.Errorf("PreferencesRecorded = %d, want 4", got.PreferencesRecorded)
 	}
@@ -95,7 +96,7 @@ func TestTasteProfileOutput(t *testing.T) {
 func TestTasteProfileColdStartSuggestions(t *testing.T) {
 	t.Parallel()
 
-	got := tasteProfileOutput(taste.Profile{Version: 1}, "/tmp/taste-profile.json")
+	got := tasteProfileOutput(taste.Profile{Version: 1}.Summary(), "/tmp/taste-profile.json")
 	if got.PreferencesRecorded != 0 {
 		t.Fatalf("PreferencesRecorded = %d", got.PreferencesRecorded)
 	}
@@ -125,12 +126,155 @@ func TestTasteStoreRoundTripForToolProfile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("validateTastePreference() error = %v", err)
 	}
-	profile, err := store.Record(preference)
+	summary, err := store.Record(preference)
 	if err != nil {
 		t.Fatalf("Record() error = %v", err)
 	}
-	got := tasteProfileOutput(profile, store.Path())
+	got := tasteProfileOutput(summary, store.Path())
 	if got.ProfilePath != store.Path() || got.PreferencesRecorded != 1 {
 		t.Errorf("profile output = %#v", got)
 	}
 }
+
+func TestRecordVariationPreferenceStoresParamsAndContext(t *testing.T) {
+	t.Parallel()
+
+	store, err := taste.NewStore(filepath.Join(t.TempDir(), "profile.json"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	client := &recipeClientStub{queries: map[string][]interface{}{
+		"/live/song/get/tempo":      {float32(124)},
+		"/live/song/get/root_note":  {int32(9)},
+		"/live/song/get/scale_name": {"Minor"},
+		"/live/track/get/name":      {int32(2), "Drums"},
+	}}
+	strength := 0.35
+	seed := int64(7)
+	track := 2
+	out, err := recordVariationPreference(client, store, RecordVariationPreferenceInput{
+		Instrument: "drum",
+		Variation:  "groove",
+		Preferred:  "variation",
+		Strength:   &strength,
+		Seed:       &seed,
+		TrackIndex: &track,
+	})
+	if err != nil {
+		t.Fatalf("recordVariationPreference() error = %v", err)
+	}
+	got := out.RecordedPreference
+	if got == nil || got.Params == nil || *got.Params.Strength != 0.35 || *got.Params.Seed != 7 {
+		t.Fatalf("recorded params = %#v", got)
+	}
+	if got.Context == nil || got.Context.TempoBPM != 124 || got.Context.Key != "A minor" || got.Context.TrackName != "Drums" {
+		t.Errorf("recorded context = %#v", got.Context)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := loaded.Preferences[0]; p.Params == nil || p.Context == nil || p.Context.TrackName != "Drums" {
+		t.Errorf("stored preference = %#v", p)
+	}
+}
+
+func TestRecordVariationPreferenceWithoutLive(t *testing.T) {
+	t.Parallel()
+
+	store, err := taste.NewStore(filepath.Join(t.TempDir(), "profile.json"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	client := &recipeClientStub{queries: map[string][]interface{}{}}
+	out, err := recordVariationPreference(client, store, RecordVariationPreferenceInput{
+		Instrument: "mix",
+		Variation:  "volume",
+		Preferred:  "source",
+		MixDeltas:  []MixVolumeChange{{TrackIndex: 1, Delta: -0.1}},
+	})
+	if err != nil {
+		t.Fatalf("recordVariationPreference() error = %v", err)
+	}
+	if got := out.RecordedPreference; got.Context != nil || len(got.Params.MixDeltas) != 1 {
+		t.Errorf("recorded = %#v", got)
+	}
+
+	_, err = recordVariationPreference(client, store, RecordVariationPreferenceInput{
+		Instrument: "mix",
+		Variation:  "volume",
+		Preferred:  "variation",
+		MixDeltas:  []MixVolumeChange{{TrackIndex: 1, Delta: 0.5}},
+	})
+	if err == nil {
+		t.Fatal("expected out-of-range mix delta error")
+	}
+}
+
+func TestTasteReport(t *testing.T) {
+	t.Parallel()
+
+	store, err := taste.NewStore(filepath.Join(t.TempDir(), "profile.json"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	for _, p := range []taste.Preference{
+		{Instrument: "bass", Variation: "staccato", Preferred: "source", RecordedAt: now.AddDate(0, 0, -20)},
+		{Instrument: "bass", Variation: "staccato", Preferred: "variation", RecordedAt: now.AddDate(0, 0, -3)},
+	} {
+		if _, err := store.Record(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := tasteReport("default", store, GetTasteReportInput{}, now)
+	if err != nil {
+		t.Fatalf("tasteReport() error = %v", err)
+	}
+	if got.WindowDays != 7 || got.Report.Preferences != 2 || len(got.Report.Trends) != 1 || got.Report.Trends[0].Direction != "up" {
+		t.Errorf("report = %+v", got)
+	}
+	// A 30-day window has nothing earlier to compare against.
+	got, err = tasteReport("default", store, GetTasteReportInput{WindowDays: 30}, now)
+	if err != nil || got.Report.Trends[0].Direction != "new" {
+		t.Errorf("30-day report = %+v, %v", got.Report.Trends, err)
+	}
+	if _, err := tasteReport("default", store, GetTasteReportInput{WindowDays: 400}, now); err == nil {
+		t.Error("expected an error for window_days > 365")
+	}
+}
+
+func TestListTastePreferences(t *testing.T) {
+	t.Parallel()
+
+	db, err := taste.OpenSQLite(filepath.Join(t.TempDir(), "taste.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store := db.Profile("default")
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	for i, p := range []taste.Preference{
+		{Instrument: "drum", Variation: "fill", Preferred: "source"},
+		{Instrument: "drum", Variation: "fill", Preferred: "variation"},
+		{Instrument: "bass", Variation: "groove", Preferred: "variation"},
+	} {
+		p.RecordedAt = now.AddDate(0, 0, -10+4*i)
+		if _, err := store.Record(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := listTastePreferences("default", store, ListTastePreferencesInput{Instrument: " Drum ", SinceDays: 7}, now)
+	if err != nil {
+		t.Fatalf("listTastePreferences() error = %v", err)
+	}
+	if len(got.Preferences) != 1 || got.Preferences[0].Preferred != "variation" {
+		t.Errorf("preferences = %+v", got.Preferences)
+	}
+	if _, err := listTastePreferences("default", store, ListTastePreferencesInput{Preferred: "both"}, now); err == nil {
+		t.Error("expected an error for an unknown preferred value")
+	}
+}
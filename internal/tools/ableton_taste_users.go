@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
+)
+
+const (
+	defaultTasteMergeTarget = "team"
+	maxTasteMergeWeight     = 10
+)
+
+type SetTasteUserInput struct {
+	User string `json:"user,omitempty" jsonschema:"description=User whose profile later taste calls use by default (e.g. mika); omit to only list users"`
+}
+
+type TasteUsersOutput struct {
+	ActiveUser  string   `json:"active_user"`
+	ProfilePath string   `json:"profile_path"`
+	Users       []string `json:"users" jsonschema:"description=default plus every user with a saved profile"`
+}
+
+type ExportTasteProfileInput struct {
+	User      string `json:"user,omitempty" jsonschema:"description=Taste user to export (default: the session user)"`
+	Path      string `json:"path" jsonschema:"description=Absolute destination path for the profile JSON"`
+	Overwrite bool   `json:"overwrite,omitempty" jsonschema:"description=Replace an existing file at path"`
+}
+
+type ExportTasteProfileOutput struct {
+	User        string `json:"user"`
+	Path        string `json:"path"`
+	Preferences int    `json:"preferences"`
+}
+
+type ImportTasteProfileInput struct {
+	Path       string  `json:"path" jsonschema:"description=Absolute path of a profile exported with ableton_export_taste_profile (or any taste profile file)"`
+	User       string  `json:"user,omitempty" jsonschema:"description=Taste user to import into (default: the session user)"`
+	Mode       string  `json:"mode,omitempty" jsonschema:"description=merge (default) adds new choices and skips duplicates; replace overwrites the user's profile"`
+	SourceUser string  `json:"source_user,omitempty" jsonschema:"description=Label stored on imported choices that carry no user yet (merge only)"`
+	Weight     float64 `json:"weight,omitempty" jsonschema:"description=How much imported choices count in merge mode (default 1),minimum=0,maximum=10"`
+}
+
+type ImportTasteProfileOutput struct {
+	User        string             `json:"user"`
+	ProfilePath string             `json:"profile_path"`
+	Mode        string             `json:"mode"`
+	Imported    int                `json:"imported" jsonschema:"description=Choices read from path"`
+	Preferences int                `json:"preferences" jsonschema:"description=Choices in the user's profile afterwards"`
+	Merge       *taste.MergeReport `json:"merge,omitempty"`
+}
+
+type TasteMergeSource struct {
+	User   string  `json:"user"`
+	Weight float64 `json:"weight,omitempty" jsonschema:"description=How much this user's choices count (default 1),minimum=0,maximum=10"`
+}
+
+type MergeTasteProfilesInput struct {
+	Sources []TasteMergeSource `json:"sources" jsonschema:"description=Users to combine, each with an optional weight,minItems=1"`
+	Target  string             `json:"target,omitempty" jsonschema:"description=User that receives the merged profile, replacing its choices (default team)"`
+}
+
+type MergeTasteProfilesOutput struct {
+	Target      string             `json:"target"`
+	ProfilePath string             `json:"profile_path"`
+	Sources     []TasteMergeSource `json:"sources"`
+	Report      taste.MergeReport  `json:"report"`
+	Note        string             `json:"note"`
+}
+
+func NewAbletonSetTasteUser(g *genkit.Genkit, users *taste.Users) ai.Tool {
+	return genkit.DefineTool(g, "ableton_set_taste_user",
+		"Select whose taste profile this session records to and reads from (per-call user inputs still override), and list known users. Omit user to only list.",
+		func(_ *ai.ToolContext, input SetTasteUserInput) (TasteUsersOutput, error) {
+			return setTasteUser(users, input)
+		},
+	)
+}
+
+func NewAbletonExportTasteProfile(g *genkit.Genkit, users *taste.Users) ai.Tool {
+	return genkit.DefineTool(g, "ableton_export_taste_profile",
+		"Write a user's taste profile to a JSON file to share or back up; load it elsewhere with ableton_import_taste_profile",
+		func(_ *ai.ToolContext, input ExportTasteProfileInput) (ExportTasteProfileOutput, error) {
+			return exportTasteProfile(users, input)
+		},
+	)
+}
+
+func NewAbletonImportTasteProfile(g *genkit.Genkit, users *taste.Users) ai.Tool {
+	return genkit.DefineTool(g, "ableton_import_taste_profile",
+		"Load an exported taste profile into a user: merge (default) adds choices not already present by timestamp, replace overwrites",
+		func(_ *ai.ToolContext, input ImportTasteProfileInput) (ImportTasteProfileOutput, error) {
+			return importTasteProfile(users, input)
+		},
+	)
+}
+
+func NewAbletonMergeTasteProfiles(g *genkit.Genkit, users *taste.Users) ai.Tool {
+	return genkit.DefineTool(g, "ableton_merge_taste_profiles",
+		"Combine several users' taste profiles into a team profile with per-user weights; duplicates are dropped by timestamp and conflicting answers keep the heavier user. Use the result with user or taste_user=team",
+		func(_ *ai.ToolContext, input MergeTasteProfilesInput) (MergeTasteProfilesOutput, error) {
+			return mergeTasteProfiles(users, input)
+		},
+	)
+}
+
+func setTasteUser(users *taste.Users, input SetTasteUserInput) (TasteUsersOutput, error) {
+	var store taste.Backend
+	var err error
+	if strings.TrimSpace(input.User) != "" {
+		store, err = users.SetActive(input.User)
+	} else {
+		_, store, err = users.Lookup("")
+	}
+	if err != nil {
+		return TasteUsersOutput{}, err
+	}
+	names, err := users.List()
+	if err != nil {
+		return TasteUsersOutput{}, err
+	}
+	return TasteUsersOutput{ActiveUser: users.Active(), ProfilePath: store.Path(), Users: names}, nil
+}
+
+func exportTasteProfile(users *taste.Users, input ExportTasteProfileInput) (ExportTasteProfileOutput, error) {
+	path, err := tasteFilePath(input.Path)
+	if err != nil {
+		return ExportTasteProfileOutput{}, err
+	}
+	user, store, err := users.Lookup(input.User)
+	if err != nil {
+		return ExportTasteProfileOutput{}, err
+	}
+	if filepath.Clean(store.Path()) == path {
+		return ExportTasteProfileOutput{}, errors.New("path is the profile itself; choose another file")
+	}
+	if _, err := os.Stat(path); err == nil && !input.Overwrite {
+		return ExportTasteProfileOutput{}, fmt.Errorf("%s already exists; pass overwrite=true to replace it", path)
+	}
+	profile, err := store.Load()
+	if err != nil {
+		return ExportTasteProfileOutput{}, err
+	}
+	dest, err := taste.NewStore(path)
+	if err != nil {
+		return ExportTasteProfileOutput{}, err
+	}
+	if err := dest.Replace(profile); err != nil {
+		return ExportTasteProfileOutput{}, err
+	}
+	return ExportTasteProfileOutput{User: user, Path: path, Preferences: len(profile.Preferences)}, nil
+}
+
+func importTasteProfile(users *taste.Users, input ImportTasteProfileInput) (ImportTasteProfileOutput, error) {
+	path, err := tasteFilePath(input.Path)
+	if err != nil {
+		return ImportTasteProfileOutput{}, err
+	}
+	mode := strings.ToLower(strings.TrimSpace(input.Mode))
+	if mode == "" {
+		mode = "merge"
+	}
+	if mode != "merge" && mode != "replace" {
+		return ImportTasteProfileOutput{}, errors.New("mode must be merge or replace")
+	}
+	if err := validateTasteWeight(input.Weight); err != nil {
+		return ImportTasteProfileOutput{}, err
+	}
+	sourceUser, err := taste.NormalizeUser(input.SourceUser)
+	if err != nil {
+		return ImportTasteProfileOutput{}, err
+	}
+	user, store, err := users.Lookup(input.User)
+	if err != nil {
+		return ImportTasteProfileOutput{}, err
+	}
+	imported, err := taste.ReadProfile(path)
+	if err != nil {
+		return ImportTasteProfileOutput{}, err
+	}
+
+	out := ImportTasteProfileOutput{User: user, ProfilePath: store.Path(), Mode: mode, Imported: len(imported.Preferences)}
+	result := imported
+	if mode == "merge" {
+		existing, err := store.Load()
+		if err != nil {
+			return ImportTasteProfileOutput{}, err
+		}
+		merged, report, err := taste.Merge([]taste.MergeSource{
+			{Profile: existing},
+			{User: sourceUser, Weight: input.Weight, Profile: imported},
+		})
+		if err != nil {
+			return ImportTasteProfileOutput{}, err
+		}
+		result = merged
+		out.Merge = &report
+	}
+	if err := store.Replace(result); err != nil {
+		return ImportTasteProfileOutput{}, err
+	}
+	out.Preferences = len(result.Preferences)
+	return out, nil
+}
+
+func mergeTasteProfiles(users *taste.Users, input MergeTasteProfilesInput) (MergeTasteProfilesOutput, error) {
+	if len(input.Sources) == 0 {
+		return MergeTasteProfilesOutput{}, errors.New("sources must name at least one user")
+	}
+	target := input.Target
+	if strings.TrimSpace(target) == "" {
+		target = defaultTasteMergeTarget
+	}
+	target, targetStore, err := users.Lookup(target)
+	if err != nil {
+		return MergeTasteProfilesOutput{}, err
+	}
+
+	sources := make([]taste.MergeSource, 0, len(input.Sources))
+	used := make([]TasteMergeSource, 0, len(input.Sources))
+	seen := map[string]bool{}
+	for _, source := range input.Sources {
+		if strings.TrimSpace(source.User) == "" {
+			return MergeTasteProfilesOutput{}, errors.New("sources user must not be empty")
+		}
+		if err := validateTasteWeight(source.Weight); err != nil {
+			return MergeTasteProfilesOutput{}, err
+		}
+		name, store, err := users.Lookup(source.User)
+		if err != nil {
+			return MergeTasteProfilesOutput{}, err
+		}
+		if seen[name] {
+			return MergeTasteProfilesOutput{}, fmt.Errorf("duplicate source user: %s", name)
+		}
+		seen[name] = true
+		profile, err := store.Load()
+		if err != nil {
+			return MergeTasteProfilesOutput{}, fmt.Errorf("load %s: %w", name, err)
+		}
+		weight := source.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		sources = append(sources, taste.MergeSource{User: name, Weight: weight, Profile: profile})
+		used = append(used, TasteMergeSource{User: name, Weight: weight})
+	}
+
+	merged, report, err := taste.Merge(sources)
+	if err != nil {
+		return MergeTasteProfilesOutput{}, err
+	}
+	if err := targetStore.Replace(merged); err != nil {
+		return MergeTasteProfilesOutput{}, err
+	}
+	return MergeTasteProfilesOutput{
+		Target:      target,
+		ProfilePath: targetStore.Path(),
+		Sources:     used,
+		Report:      report,
+		Note:        "The target's previous choices were replaced. Re-run the merge after members record more choices; use_taste counts each choice by its user's weight.",
+	}, nil
+}
+
+func tasteFilePath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", errors.New("path is required")
+	}
+	if !filepath.IsAbs(path) {
+		return "", errors.New("path must be absolute")
+	}
+	return filepath.Clean(path), nil
+}
+
+func validateTasteWeight(weight float64) error {
+	if weight < 0 || weight > maxTasteMergeWeight {
+		return fmt.Errorf("weight must be between 0 and %d", maxTasteMergeWeight)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
+)
+
+func newTestTasteUsers(t *testing.T) *taste.Users {
+	t.Helper()
+	dir := t.TempDir()
+	users, err := taste.NewUsers(filepath.Join(dir, "taste-profile.json"), filepath.Join(dir, "users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return users
+}
+
+func recordTaste(t *testing.T, users *taste.Users, user string, prefs ...taste.Preference) {
+	t.Helper()
+	_, store, err := users.Lookup(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range prefs {
+		if _, err := store.Record(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSetTasteUser(t *testing.T) {
+	t.Parallel()
+
+	users := newTestTasteUsers(t)
+	recordTaste(t, users, "ren", taste.Preference{Instrument: "drum", Variation: "fill", Preferred: "source"})
+
+	got, err := setTasteUser(users, SetTasteUserInput{User: "Mika"})
+	if err != nil {
+		t.Fatalf("setTasteUser() error = %v", err)
+	}
+	if got.ActiveUser != "mika" || filepath.Base(got.ProfilePath) != "mika.json" {
+		t.Errorf("active = %+v", got)
+	}
+	// mika has no saved choices yet, so only default and ren are listed.
+	if want := []string{"default", "ren"}; !reflect.DeepEqual(got.Users, want) {
+		t.Errorf("users = %v, want %v", got.Users, want)
+	}
+	if _, err := setTasteUser(users, SetTasteUserInput{User: "a/b"}); err == nil {
+		t.Error("expected an error for an invalid user name")
+	}
+}
+
+func TestExportImportAndMergeTasteProfiles(t *testing.T) {
+	t.Parallel()
+
+	users := newTestTasteUsers(t)
+	at := func(min int) time.Time { return time.Date(2026, time.October, 2, 9, min, 0, 0, time.UTC) }
+	recordTaste(t, users, "mika",
+		taste.Preference{Instrument: "drum", Variation: "groove", Preferred: "variation", RecordedAt: at(1)},
+		taste.Preference{Instrument: "scene", Variation: "lift", Preferred: "source", RecordedAt: at(2)},
+	)
+	recordTaste(t, users, "ren",
+		taste.Preference{Instrument: "scene", Variation: "lift", Preferred: "variation", RecordedAt: at(2)},
+		taste.Preference{Instrument: "bass", Variation: "staccato", Preferred: "variation", RecordedAt: at(3)},
+	)
+
+	exportPath := filepath.Join(t.TempDir(), "mika-export.json")
+	exported, err := exportTasteProfile(users, ExportTasteProfileInput{User: "mika", Path: exportPath})
+	if err != nil || exported.Preferences != 2 {
+		t.Fatalf("exportTasteProfile() = %+v, %v", exported, err)
+	}
+	if _, err := exportTasteProfile(users, ExportTasteProfileInput{User: "mika", Path: exportPath}); err == nil {
+		t.Error("expected an error exporting over an existing file")
+	}
+
+	// Importing twice merges once: the second pass only finds duplicates.
+	for i, wantMerged := range []int{2, 2} {
+		got, err := importTasteProfile(users, ImportTasteProfileInput{Path: exportPath, User: "guest", SourceUser: "mika"})
+		if err != nil {
+			t.Fatalf("importTasteProfile() #%d error = %v", i, err)
+		}
+		if got.Preferences != wantMerged || got.Merge == nil || got.Merge.Duplicates != 2*i {
+			t.Errorf("import #%d = %+v", i, got.Merge)
+		}
+	}
+
+	merged, err := mergeTasteProfiles(users, MergeTasteProfilesInput{Sources: []TasteMergeSource{{User: "mika"}, {User: "ren", Weight: 3}}})
+	if err != nil {
+		t.Fatalf("mergeTasteProfiles() error = %v", err)
+	}
+	if merged.Target != "team" || merged.Report.Merged != 3 || len(merged.Report.Conflicts) != 1 || merged.Report.Conflicts[0].KeptUser != "ren" {
+		t.Fatalf("merged = %+v", merged)
+	}
+	_, team, _ := users.Lookup("team")
+	profile, err := team.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.Preferences) != 3 || profile.Preferences[1].User != "ren" || profile.Preferences[1].Weight != 3 {
+		t.Errorf("team profile = %+v", profile.Preferences)
+	}
+
+	if _, err := mergeTasteProfiles(users, MergeTasteProfilesInput{Sources: []TasteMergeSource{{User: "mika"}, {User: "MIKA"}}}); err == nil {
+		t.Error("expected an error for a duplicate source")
+	}
+	if _, err := importTasteProfile(users, ImportTasteProfileInput{Path: exportPath, Mode: "append"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/firebase/genkit/go/genkit"
 
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
 )
 
 const (
@@ -24,24 +25,27 @@ type CreateDrumVariationInput struct {
 	TrackIndex      int      `json:"track_index" jsonschema:"minimum=0"`
 	SourceClipIndex int      `json:"source_clip_index" jsonschema:"minimum=0"`
 	TargetClipIndex int      `json:"target_clip_index" jsonschema:"description=Must be an empty clip slot for the A/B variation,minimum=0"`
-	Variation       string   `json:"variation" jsonschema:"description=One change only: groove, density, or fill"`
+	Variation       string   `json:"variation,omitempty" jsonschema:"description=One change only: groove, density, or fill (may be omitted with use_taste)"`
 	Strength        *float64 `json:"strength,omitempty" jsonschema:"description=Variation intensity 0-1 (default 0.6),minimum=0,maximum=1"`
 	HatPitch        *int     `json:"hat_pitch,omitempty" jsonschema:"description=Closed hat MIDI pitch for density variation (default 42),minimum=0,maximum=127"`
 	SnarePitch      *int     `json:"snare_pitch,omitempty" jsonschema:"description=Snare MIDI pitch for fill variation (default 38),minimum=0,maximum=127"`
 	Seed            *int64   `json:"seed,omitempty" jsonschema:"description=Optional RNG seed for reproducible groove or density variations"`
 	Fire            bool     `json:"fire,omitempty" jsonschema:"description=Fire the target clip after creating it"`
+	UseTaste        bool     `json:"use_taste,omitempty" jsonschema:"description=Pick an omitted variation and strength from the recorded taste profile"`
+	TasteUser       string   `json:"taste_user,omitempty" jsonschema:"description=Taste user whose choices use_taste reads (default: the session user)"`
 }
 
 type CreateDrumVariationOutput struct {
-	TrackIndex      int     `json:"track_index"`
-	SourceClipIndex int     `json:"source_clip_index"`
-	TargetClipIndex int     `json:"target_clip_index"`
-	Variation       string  `json:"variation"`
-	Strength        float64 `json:"strength"`
-	NotesChanged    int     `json:"notes_changed"`
-	NotesAdded      int     `json:"notes_added"`
-	Seed            int64   `json:"seed"`
-	Fired           bool    `json:"fired"`
+	TrackIndex      int          `json:"track_index"`
+	SourceClipIndex int          `json:"source_clip_index"`
+	TargetClipIndex int          `json:"target_clip_index"`
+	Variation       string       `json:"variation"`
+	Strength        float64      `json:"strength"`
+	NotesChanged    int          `json:"notes_changed"`
+	NotesAdded      int          `json:"notes_added"`
+	Seed            int64        `json:"seed"`
+	Fired           bool         `json:"fired"`
+	Taste           *TasteChoice `json:"taste,omitempty" jsonschema:"description=Why use_taste picked these parameters"`
 }
 
 type variationOptions struct {
@@ -56,11 +60,11 @@ type variationClient interface {
 	Query(address string, args ...interface{}) ([]interface{}, error)
 }
 
-func NewAbletonCreateDrumVariation(g *genkit.Genkit, client *abletonosc.Client) ai.Tool {
+func NewAbletonCreateDrumVariation(g *genkit.Genkit, client *abletonosc.Client, users *taste.Users) ai.Tool {
 	return genkit.DefineTool(g, "ableton_create_drum_variation",
-		"Ableton Live: create only a drum A/B variation (groove, density, or fill) in an empty slot — prefer ableton_compare_ab_variation when you also want to audition",
+		"Ableton Live: create only a drum A/B variation (groove, density, or fill) in an empty slot; use_taste picks the variation and strength from past A/B choices — prefer ableton_compare_ab_variation when you also want to audition",
 		func(_ *ai.ToolContext, input CreateDrumVariationInput) (CreateDrumVariationOutput, error) {
-			return createDrumVariation(client, input)
+			return createDrumVariationWithTaste(client, tasteLoaderFor(users, input.TasteUser), input)
 		},
 	)
 }
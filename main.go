@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 
@@ -9,8 +10,10 @@ import (
 	"github.com/firebase/genkit/go/genkit"
 
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/abletonosc"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/audioanalyze"
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/config"
 	mcpinternal "github.com/nozomi-koborinai/ableton-osc-mcp/internal/mcp"
+	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/splice"
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/taste"
 	"github.com/nozomi-koborinai/ableton-osc-mcp/internal/tools"
 )
@@ -41,10 +44,23 @@ func main() {
 	defer func() {
 		_ = ableton.Close()
 	}()
-	tasteStore, err := taste.NewStore(cfg.TasteProfilePath)
+	tasteUsers, closeTaste, err := openTasteUsers(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer closeTaste()
+	if _, err := tasteUsers.SetActive(cfg.TasteUser); err != nil {
+		log.Fatal(err)
+	}
+	analysisCache, err := audioanalyze.NewCache(cfg.AnalysisCacheDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	spliceSettings := tools.SpliceLibrarySettings{
+		ConfiguredPath: cfg.SplicePath,
+		LibrariesFile:  cfg.LibrariesFile,
+		Index:          splice.NewIndexer(cfg.SpliceIndexDir),
+	}
 
 	toolList := []ai.Tool{
 		// Song / Transport
@@ -96,6 +112,10 @@ func main() {
 		tools.NewAbletonClearClipNotes(g, ableton),
 		tools.NewAbletonAddMidiNotes(g, ableton),
 		tools.NewAbletonHumanizeClip(g, ableton),
+		tools.NewAbletonArpeggiateClip(g, ableton),
+		tools.NewAbletonExtractGroove(g, ableton),
+		tools.NewAbletonApplyGroove(g, ableton),
+		tools.NewAbletonListGrooves(g),
 		tools.NewAbletonDuplicateClipTo(g, ableton),
 		tools.NewAbletonDeleteClip(g, ableton),
 		tools.NewAbletonSetClipName(g, ableton),
@@ -108,12 +128,16 @@ func main() {
 		tools.NewAbletonSetClipEnvelopeSteps(g, ableton),
 		tools.NewAbletonClearClipEnvelope(g, ableton),
 		tools.NewAbletonMatchClipTempo(g, ableton),
-		tools.NewAbletonAnalyzeLocalAudio(g),
+		tools.NewAbletonAnalyzeLocalAudio(g, analysisCache),
+		tools.NewAbletonAnalyzeFolder(g, analysisCache),
 		tools.NewAbletonAnalyzeAudioURL(g),
+		tools.NewAbletonCompareAudioFiles(g),
+		tools.NewAbletonExtractDrumGroove(g, ableton),
 		tools.NewAbletonChopDraft(g),
-		tools.NewAbletonCreateDrumVariation(g, ableton),
-		tools.NewAbletonCreateBassVariation(g, ableton),
+		tools.NewAbletonCreateDrumVariation(g, ableton, tasteUsers),
+		tools.NewAbletonCreateBassVariation(g, ableton, tasteUsers),
 		tools.NewAbletonAuditionAB(g, ableton),
+		tools.NewAbletonAuditionMulti(g, ableton),
 
 		// Scenes
 		tools.NewAbletonFireScene(g, ableton),
@@ -121,7 +145,7 @@ func main() {
 		tools.NewAbletonSetSceneName(g, ableton),
 		tools.NewAbletonCreateNamedScenes(g, ableton),
 		tools.NewAbletonSetSceneClipPresence(g, ableton),
-		tools.NewAbletonCreateSceneEnergyVariation(g, ableton),
+		tools.NewAbletonCreateSceneEnergyVariation(g, ableton, tasteUsers),
 		tools.NewAbletonGetSoundingSnapshot(g, ableton),
 
 		// Devices / Browser
@@ -143,9 +167,11 @@ func main() {
 		tools.NewAbletonLoadBrowserItem(g, ableton),
 		tools.NewAbletonLoadBrowserPath(g, ableton),
 		tools.NewAbletonLoadDevicePreset(g, ableton),
-		tools.NewAbletonGetSpliceLibrary(g, tools.SpliceLibrarySettings{ConfiguredPath: cfg.SplicePath}),
-		tools.NewAbletonSearchSpliceSamples(g, tools.SpliceLibrarySettings{ConfiguredPath: cfg.SplicePath}),
-		tools.NewAbletonLoadSpliceSample(g, ableton, tools.SpliceLibrarySettings{ConfiguredPath: cfg.SplicePath}),
+		tools.NewAbletonGetSpliceLibrary(g, spliceSettings),
+		tools.NewAbletonSearchSpliceSamples(g, spliceSettings),
+		tools.NewAbletonLoadSpliceSample(g, ableton, spliceSettings),
+		tools.NewAbletonSuggestSamples(g, ableton, spliceSettings),
+		tools.NewAbletonFindSimilarSamples(g, ableton, spliceSettings, analysisCache),
 
 		// Mix bus / Master
 		tools.NewAbletonGetTrackMeter(g, ableton),
@@ -158,7 +184,7 @@ func main() {
 		tools.NewAbletonLoadOnMaster(g, ableton),
 		tools.NewAbletonAutogainTracks(g, ableton),
 		tools.NewAbletonCaptureMixSnapshot(g, ableton),
-		tools.NewAbletonApplyMixVariation(g, ableton),
+		tools.NewAbletonApplyMixVariation(g, ableton, tasteUsers),
 		tools.NewAbletonRestoreMixSnapshot(g, ableton),
 
 		// Bounce / Session Record
@@ -168,13 +194,24 @@ func main() {
 
 		// Recipes
 		tools.NewAbletonSetupDrumTrack(g, ableton),
+		tools.NewAbletonSaveDrumPattern(g, ableton),
+		tools.NewAbletonListDrumPatterns(g),
 		tools.NewAbletonCompareABVariation(g, ableton),
 		tools.NewAbletonCompareFXBypass(g, ableton),
 		tools.NewAbletonBuildChordClip(g, ableton),
+		tools.NewAbletonSuggestChordProgressions(g, ableton),
+		tools.NewAbletonGenerateBassline(g, ableton),
 
 		// A/B comparison feedback
-		tools.NewAbletonRecordVariationPreference(g, tasteStore),
-		tools.NewAbletonGetTasteProfile(g, tasteStore),
+		tools.NewAbletonRecordVariationPreference(g, ableton, tasteUsers),
+		tools.NewAbletonRecordRankingPreference(g, ableton, tasteUsers),
+		tools.NewAbletonGetTasteProfile(g, tasteUsers),
+		tools.NewAbletonGetTasteReport(g, tasteUsers),
+		tools.NewAbletonListTastePreferences(g, tasteUsers),
+		tools.NewAbletonSetTasteUser(g, tasteUsers),
+		tools.NewAbletonExportTasteProfile(g, tasteUsers),
+		tools.NewAbletonImportTasteProfile(g, tasteUsers),
+		tools.NewAbletonMergeTasteProfiles(g, tasteUsers),
 
 		// Raw OSC
 		tools.NewAbletonOscSend(g, ableton),
@@ -186,3 +223,29 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// openTasteUsers opens the configured taste backend. The first start on
+// SQLite copies existing JSON profiles into the database.
+func openTasteUsers(cfg config.Config) (*taste.Users, func(), error) {
+	switch cfg.TasteBackend {
+	case "json":
+		users, err := taste.NewUsers(cfg.TasteProfilePath, cfg.TasteUsersDir)
+		return users, func() {}, err
+	case "sqlite":
+		db, err := taste.OpenSQLite(cfg.TasteDBPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		imported, err := db.ImportJSON(cfg.TasteProfilePath, cfg.TasteUsersDir)
+		if err != nil {
+			_ = db.Close()
+			return nil, nil, err
+		}
+		if !imported.Skipped {
+			log.Printf("Imported %d taste preferences from JSON profiles into %s", imported.Preferences, cfg.TasteDBPath)
+		}
+		return taste.NewSQLiteUsers(db), func() { _ = db.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("ABLETON_OSC_TASTE_BACKEND must be json or sqlite, got %q", cfg.TasteBackend)
+	}
+}